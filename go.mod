@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/AlessandroSechi/zammad-go v0.0.0-20241027101934-e9e7d13e8bd5
 	github.com/mark3labs/mcp-go v0.23.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,79 @@
+// Package server builds an MCP server offering the Zammad toolset, for Go
+// programs that embed the tools instead of running the zammad-go-mcp
+// command.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"github.com/arush15june/zammad-go-mcp/zammadmcp/tools"
+)
+
+// Config configures a Zammad MCP server.
+type Config struct {
+	// URL is the base URL of the Zammad instance, e.g.
+	// https://support.example.com.
+	URL string
+	// Token is the API token of the Zammad user the tools act as.
+	Token string
+	// HTTPClient sends the requests to Zammad; nil uses a client bounded by
+	// the settings' http_timeout.
+	HTTPClient zammad.Doer
+	// Settings are the toolset settings, e.g. from tools.LoadConfig; nil
+	// uses tools.DefaultConfig.
+	Settings *tools.Config
+	// Middleware is the chain wrapping the tool handlers, e.g.
+	// tools.DefaultMiddleware with middleware of your own inserted with
+	// tools.InsertMiddleware; nil uses tools.DefaultMiddleware.
+	Middleware []tools.Middleware
+}
+
+// NewServer connects to Zammad and returns an MCP server offering the Zammad
+// resources and tools, ready to be served with mcp-go's ServeStdio or
+// NewSSEServer. To add the toolset to an existing server instead, create it
+// with tools.ServerOptions and call tools.Setup and tools.Register.
+//
+// The toolset keeps its client, settings and session state in package
+// variables, so a process can serve one Zammad instance; calling NewServer
+// again replaces the instance of servers created before, unless it fails to
+// connect or the settings are invalid. Background jobs of
+// the command, such as scheduled reports, file intake and the offline write
+// queue, are not started.
+func NewServer(cfg Config) (*mcpserver.MCPServer, error) {
+	if cfg.URL == "" || cfg.Token == "" {
+		return nil, errors.New("the Zammad URL and token must be set")
+	}
+	settings := tools.DefaultConfig()
+	if cfg.Settings != nil {
+		settings = *cfg.Settings
+	}
+	// The connection is checked before Setup replaces the state of servers
+	// created before, so a failed call leaves them working.
+	probe := &zammad.Client{Url: cfg.URL, Token: cfg.Token, Client: cfg.HTTPClient}
+	if probe.Client == nil {
+		probe.Client = &http.Client{Timeout: time.Duration(settings.HTTPTimeout)}
+	}
+	if _, err := probe.UserMe(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Zammad API: %w", err)
+	}
+	client := &zammad.Client{Url: cfg.URL, Token: cfg.Token, Client: cfg.HTTPClient}
+	if err := tools.Setup(client, settings); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	chain := cfg.Middleware
+	if chain == nil {
+		chain = tools.DefaultMiddleware()
+	}
+	s := mcpserver.NewMCPServer("Zammad MCP Server", "1.0.0", tools.ServerOptions(chain)...)
+	if err := tools.Register(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
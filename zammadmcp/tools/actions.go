@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxSessionActions caps the actions kept per session for the transcript;
+// older ones are dropped and counted.
+const maxSessionActions = 1000
+
+// sessionAction is a write performed through the server in a client session.
+type sessionAction struct {
+	ID       int            `json:"id"`
+	Tool     string         `json:"tool"`
+	At       time.Time      `json:"at"`
+	Summary  string         `json:"summary"`
+	Objects  []actionObject `json:"objects"`
+	UndoneAt *time.Time     `json:"undone_at,omitempty"`
+
+	undo *undoStep // nil if the action cannot be undone
+}
+
+// actionObject is a Zammad object an action created or changed, with a link
+// into the web UI for reviewing it.
+type actionObject struct {
+	Type   string `json:"type"` // ticket, article, user or organization
+	ID     int    `json:"id"`
+	WebURL string `json:"web_url"`
+}
+
+func ticketObject(ticketID int) actionObject {
+	return actionObject{Type: "ticket", ID: ticketID, WebURL: ticketWebURL(ticketID)}
+}
+
+// articleObject links an article within its ticket's view.
+func articleObject(ticketID, articleID int) actionObject {
+	return actionObject{Type: "article", ID: articleID, WebURL: zammadWebURL(fmt.Sprintf("ticket/zoom/%d/%d", ticketID, articleID))}
+}
+
+func userObject(userID int) actionObject {
+	return actionObject{Type: "user", ID: userID, WebURL: userWebURL(userID)}
+}
+
+func organizationObject(organizationID int) actionObject {
+	return actionObject{Type: "organization", ID: organizationID, WebURL: organizationWebURL(organizationID)}
+}
+
+// undoStep is how to reverse an action on a ticket.
+type undoStep struct {
+	TicketID int
+	// UpdatedAt is the ticket's updated_at after the action. If the ticket
+	// changed since, undoing needs force.
+	UpdatedAt time.Time
+	// Restore sets the changed ticket attributes back to their previous
+	// values.
+	Restore ticketUpdate
+	// RemoveTag is a tag the action added.
+	RemoveTag string
+	// ReactivateUserID is a user the action deactivated.
+	ReactivateUserID int
+	// Article is an article whose visibility the action changed, with its
+	// previous internal flag.
+	Article *articleVisibility
+	// Remains describes what the undo cannot reverse, e.g. a posted note.
+	Remains string
+}
+
+// actionLog is the transcript of one session.
+type actionLog struct {
+	actions []*sessionAction
+	dropped int // actions beyond maxSessionActions
+}
+
+// actionLogs holds the writes of each session, for the zammad://session/actions
+// transcript and for undo_last_action, which only reaches back
+// config.UndoHistory actions.
+type actionLogs struct {
+	mu        sync.Mutex
+	nextID    int
+	bySession map[string]*actionLog
+}
+
+var sessionActions = &actionLogs{bySession: make(map[string]*actionLog)}
+
+// record adds an action to the log of the call's session.
+func (l *actionLogs) record(ctx context.Context, tool, summary string, undo *undoStep, objects ...actionObject) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	session := sessionID(ctx)
+	history, ok := l.bySession[session]
+	if !ok {
+		history = &actionLog{}
+		l.bySession[session] = history
+	}
+	if objects == nil {
+		objects = []actionObject{}
+	}
+	history.actions = append(history.actions, &sessionAction{ID: l.nextID, Tool: tool, At: time.Now().UTC(), Summary: summary, Objects: objects, undo: undo})
+	if len(history.actions) > maxSessionActions {
+		history.dropped += len(history.actions) - maxSessionActions
+		history.actions = slices.Clone(history.actions[len(history.actions)-maxSessionActions:])
+	}
+}
+
+// last returns the most recent action of the call's session that was not
+// undone yet, among the last config.UndoHistory actions, or nil.
+func (l *actionLogs) last(ctx context.Context) *sessionAction {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	history, ok := l.bySession[sessionID(ctx)]
+	if !ok {
+		return nil
+	}
+	for i := len(history.actions) - 1; i >= max(0, len(history.actions)-config.UndoHistory); i-- {
+		if history.actions[i].UndoneAt == nil {
+			return history.actions[i]
+		}
+	}
+	return nil
+}
+
+func (l *actionLogs) markUndone(action *sessionAction) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	action.UndoneAt = &now
+}
+
+// transcript returns copies of the actions of the call's session, oldest
+// first, and how many older ones were dropped.
+func (l *actionLogs) transcript(ctx context.Context) ([]sessionAction, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	history, ok := l.bySession[sessionID(ctx)]
+	if !ok {
+		return []sessionAction{}, 0
+	}
+	actions := make([]sessionAction, len(history.actions))
+	for i, action := range history.actions {
+		actions[i] = *action
+	}
+	return actions, history.dropped
+}
+
+// forget drops the log of a session that ended.
+func (l *actionLogs) forget(ctx context.Context, session server.ClientSession) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.bySession, session.SessionID())
+}
+
+// sessionTranscript is the content of the zammad://session/actions resource.
+type sessionTranscript struct {
+	Actions []sessionAction `json:"actions"`
+	Dropped int             `json:"dropped,omitempty"` // older actions no longer kept
+}
+
+// handleSessionActions lists the writes performed in the reading client's
+// session, so a human can review them before ending the conversation.
+func handleSessionActions(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+
+	actions, dropped := sessionActions.transcript(ctx)
+	jsonData, err := json.MarshalIndent(sessionTranscript{Actions: actions, Dropped: dropped}, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling session actions to JSON: %v", err)
+		return nil, fmt.Errorf("failed to marshal session actions: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
+
+// ticketUndo returns the undo step restoring the ticket attributes an update
+// set to their values before it, which left the ticket's updated_at at after.
+// Custom fields are not in ticketRecord; callers changing them add their
+// previous values to the step's Restore.
+func ticketUndo(before ticketRecord, after time.Time, u ticketUpdate) *undoStep {
+	undo := &undoStep{TicketID: before.ID, UpdatedAt: after}
+	if u.OwnerID.set {
+		undo.Restore.OwnerID = setTo(before.OwnerID)
+	}
+	if u.GroupID.set {
+		undo.Restore.GroupID = setTo(before.GroupID)
+	}
+	if u.StateID.set {
+		undo.Restore.StateID = setTo(before.StateID)
+	}
+	if u.PriorityID.set {
+		undo.Restore.PriorityID = setTo(before.PriorityID)
+	}
+	if u.Title.set {
+		undo.Restore.Title = setTo(before.Title)
+	}
+	if u.CustomerID.set {
+		undo.Restore.CustomerID = setTo(before.CustomerID)
+	}
+	if u.OrganizationID.set {
+		undo.Restore.OrganizationID = cleared[int]()
+		if before.OrganizationID != 0 {
+			undo.Restore.OrganizationID = setTo(before.OrganizationID)
+		}
+	}
+	if u.PendingTime.set {
+		undo.Restore.PendingTime = cleared[time.Time]()
+		if before.PendingTime != nil {
+			undo.Restore.PendingTime = pendingAt(*before.PendingTime)
+		}
+	}
+	return undo
+}
+
+// ticketUpdatedAt returns a ticket's current updated_at, for actions that
+// change a ticket in several requests, or fallback if it cannot be read.
+func ticketUpdatedAt(ticketID int, fallback time.Time) time.Time {
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d for the undo history: %v", ticketID, err)
+		return fallback
+	}
+	return ticket.UpdatedAt
+}
+
+// undoResult is the outcome of undo_last_action.
+type undoResult struct {
+	Action   *sessionAction  `json:"action"`
+	Restored []string        `json:"restored"`
+	Remains  string          `json:"remains,omitempty"`
+	Ticket   json.RawMessage `json:"ticket"` // ticketRecord in the requested output profile
+}
+
+// handleUndoLastAction reverses the most recent write of the session, if it
+// is reversible and the ticket was not changed since, unless force is set.
+func handleUndoLastAction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	force := mcp.ParseBoolean(request, "force", false)
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	action := sessionActions.last(ctx)
+	if action == nil {
+		return mcp.NewToolResultError("There is no action of this session left to undo."), nil
+	}
+	undo := action.undo
+	if undo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("The last action (%s: %s) cannot be undone automatically; reverse it by hand.", action.Tool, action.Summary)), nil
+	}
+
+	ticket, err := fetchTicket(undo.TicketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", undo.TicketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", undo.TicketID), err), nil
+	}
+	if !ticket.UpdatedAt.Equal(undo.UpdatedAt) && !force {
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"Conflict: ticket %d was modified at %s (by user %d), after the action (%s: %s). Nothing was undone. Check the ticket, and call again with force set to true to restore the previous values anyway.",
+			undo.TicketID, ticket.UpdatedAt.Format(time.RFC3339), ticket.UpdatedByID, action.Tool, action.Summary)), nil
+	}
+
+	var restored []string
+	if !undo.Restore.empty() {
+		if ticket, err = updateTicket(undo.TicketID, undo.Restore); err != nil {
+			log.Printf("Error restoring ticket %d: %v", undo.TicketID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to restore ticket %d; nothing was undone", undo.TicketID), err), nil
+		}
+		restored = undo.Restore.describe()
+	}
+	if undo.Article != nil {
+		if _, err := setArticleInternal(undo.Article.ID, undo.Article.Internal); err != nil {
+			log.Printf("Error restoring the visibility of article %d: %v", undo.Article.ID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to make article %d %s again; nothing was undone", undo.Article.ID, visibilityName(undo.Article.Internal)), err), nil
+		}
+		restored = append(restored, fmt.Sprintf("made article %d %s", undo.Article.ID, visibilityName(undo.Article.Internal)))
+	}
+	var failures []string
+	if undo.RemoveTag != "" {
+		if err := removeTicketTag(undo.TicketID, undo.RemoveTag); err != nil {
+			log.Printf("Error removing tag %q from ticket %d: %v", undo.RemoveTag, undo.TicketID, err)
+			failures = append(failures, fmt.Sprintf("removing tag %q failed: %v", undo.RemoveTag, err))
+		} else {
+			restored = append(restored, fmt.Sprintf("removed tag %q", undo.RemoveTag))
+		}
+	}
+	if undo.ReactivateUserID != 0 {
+		if err := updateUser(undo.ReactivateUserID, userUpdate{Active: setTo(true)}); err != nil {
+			log.Printf("Error reactivating user %d: %v", undo.ReactivateUserID, err)
+			failures = append(failures, fmt.Sprintf("reactivating user %d failed: %v", undo.ReactivateUserID, err))
+		} else {
+			restored = append(restored, fmt.Sprintf("reactivated user %d", undo.ReactivateUserID))
+		}
+	}
+	sessionActions.markUndone(action)
+	log.Printf("Undid action %d (%s) on ticket %d", action.ID, action.Tool, undo.TicketID)
+
+	ticketData, err := profile.project(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", undo.TicketID, err) // Internal server error
+	}
+	jsonData, err := json.MarshalIndent(undoResult{Action: action, Restored: restored, Remains: undo.Remains, Ticket: ticketData}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal undo result: %w", err) // Internal server error
+	}
+	if len(failures) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: undid %s only in part (%s):\n%s", action.Tool, strings.Join(failures, "; "), string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Undid %s: %s:\n%s", action.Tool, action.Summary, string(jsonData))), nil
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// agingBuckets counts backlog tickets by time since creation.
+type agingBuckets struct {
+	UnderOneDay  int    `json:"under_1d"`
+	OneToThree   int    `json:"1d_to_3d"`
+	ThreeToSeven int    `json:"3d_to_7d"`
+	OverSeven    int    `json:"over_7d"`
+	Total        int    `json:"total"`
+	OldestAge    string `json:"oldest_age,omitempty"`
+	oldest       time.Duration
+}
+
+func (b *agingBuckets) add(age time.Duration) {
+	const day = 24 * time.Hour
+	switch {
+	case age < day:
+		b.UnderOneDay++
+	case age < 3*day:
+		b.OneToThree++
+	case age < 7*day:
+		b.ThreeToSeven++
+	default:
+		b.OverSeven++
+	}
+	b.Total++
+	if age > b.oldest {
+		b.oldest = age
+		b.OldestAge = formatDuration(age)
+	}
+}
+
+// groupAging is the aging of one group's backlog.
+type groupAging struct {
+	GroupID int    `json:"group_id"`
+	Group   string `json:"group,omitempty"`
+	agingBuckets
+}
+
+// ticketAgingReport is the result of report_ticket_aging.
+type ticketAgingReport struct {
+	AsOf   time.Time    `json:"as_of"`
+	Groups []groupAging `json:"groups"`
+	Total  agingBuckets `json:"total"`
+}
+
+// handleReportTicketAging buckets the backlog of new, open and pending
+// tickets by age per group.
+func handleReportTicketAging(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	group := mcp.ParseString(request, "group", "")
+	scope, err := reportScope(group)
+	if err != nil {
+		log.Printf("Error resolving group %q: %v", group, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+	}
+	windows, err := requestDateWindows(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument", err), nil
+	}
+	if windows != "" {
+		scope += " AND " + windows
+	}
+	openQuery, err := openStateQuery()
+	if err != nil {
+		log.Printf("Error fetching ticket states: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to fetch ticket states", err), nil
+	}
+
+	// Whole minutes keep repeated calls identical for the response cache.
+	now := time.Now().UTC().Truncate(time.Minute)
+	report := ticketAgingReport{AsOf: now, Groups: []groupAging{}}
+	byGroup := make(map[int]*agingBuckets)
+	pager := newTicketPager(openQuery+scope, 100, continuation{Page: 1})
+	for {
+		if err := yieldTurn(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Ticket aging report cancelled", err), nil
+		}
+		tickets, err := pager.next()
+		if err != nil {
+			log.Printf("Error searching open tickets: %v", err)
+			return mcp.NewToolResultErrorFromErr("Failed to search open tickets", err), nil
+		}
+		if len(tickets) == 0 {
+			break
+		}
+		for _, ticket := range tickets {
+			buckets, ok := byGroup[ticket.GroupID]
+			if !ok {
+				buckets = &agingBuckets{}
+				byGroup[ticket.GroupID] = buckets
+			}
+			age := max(now.Sub(ticket.CreatedAt), 0)
+			buckets.add(age)
+			report.Total.add(age)
+		}
+		sendProgress(ctx, request, 0, 0, fmt.Sprintf("%d open tickets read", report.Total.Total))
+	}
+	groupNames := make(map[int]string)
+	if groups, err := zammadClient.GroupList(); err != nil {
+		log.Printf("Could not list groups for the aging report: %v", err)
+	} else {
+		for _, g := range groups {
+			groupNames[g.ID] = g.Name
+		}
+	}
+	for id, buckets := range byGroup {
+		report.Groups = append(report.Groups, groupAging{GroupID: id, Group: groupNames[id], agingBuckets: *buckets})
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].GroupID < report.Groups[j].GroupID })
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket aging: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Age of %d open tickets by group:\n%s", report.Total.Total, string(jsonData))), nil
+}
@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// approveToolName is the tool that executes staged writes.
+const approveToolName = "approve_pending_action"
+
+// stagedTools are the tools that change Zammad data, with a test whether a
+// call writes at all: dry runs pass through even in approval mode. The tests
+// parse arguments like the handlers do, so "true" or 1 count as confirm too.
+var stagedTools = map[string]func(request mcp.CallToolRequest) bool{
+	"create_ticket":                      alwaysWrites,
+	"create_ticket_from_email_text":      alwaysWrites,
+	"split_ticket":                       alwaysWrites,
+	"update_ticket":                      alwaysWrites,
+	"bulk_update_tickets":                confirmed,
+	"change_ticket_state":                alwaysWrites,
+	"set_ticket_priority":                alwaysWrites,
+	"add_note_to_ticket":                 alwaysWrites,
+	"set_article_visibility":             alwaysWrites,
+	"reply_to_ticket":                    alwaysWrites,
+	"summarize_and_note":                 alwaysWrites,
+	"undo_last_action":                   alwaysWrites,
+	"import_tickets":                     alwaysWrites,
+	"import_users":                       func(request mcp.CallToolRequest) bool { return !mcp.ParseBoolean(request, "dry_run", false) },
+	"handover_ticket":                    alwaysWrites,
+	"auto_assign_ticket":                 alwaysWrites,
+	"assign_ticket":                      alwaysWrites,
+	"move_ticket_to_group":               alwaysWrites,
+	"change_ticket_customer":             alwaysWrites,
+	"mark_ticket_seen":                   alwaysWrites,
+	"snooze_ticket":                      alwaysWrites,
+	"set_pending_time":                   alwaysWrites,
+	"mark_as_spam":                       alwaysWrites,
+	"delete_ticket":                      confirmed,
+	"update_organization":                alwaysWrites,
+	"reassign_users_to_organization":     confirmed,
+	"broadcast_update_to_linked_tickets": confirmed,
+}
+
+func alwaysWrites(mcp.CallToolRequest) bool { return true }
+
+// confirmed is the test of tools that only preview their changes without
+// confirm.
+func confirmed(request mcp.CallToolRequest) bool { return mcp.ParseBoolean(request, "confirm", false) }
+
+// pendingAction is a write staged in approval mode, waiting for a human to
+// approve or reject it.
+type pendingAction struct {
+	ID        int            `json:"id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	StagedAt  time.Time      `json:"staged_at"`
+}
+
+// pendingActions holds the staged writes of each session.
+type pendingActions struct {
+	mu        sync.Mutex
+	nextID    int
+	bySession map[string][]pendingAction
+}
+
+var sessionPending = &pendingActions{bySession: make(map[string][]pendingAction)}
+
+func (p *pendingActions) stage(ctx context.Context, tool string, args map[string]any) pendingAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	action := pendingAction{ID: p.nextID, Tool: tool, Arguments: args, StagedAt: time.Now().UTC()}
+	session := sessionID(ctx)
+	p.bySession[session] = append(p.bySession[session], action)
+	return action
+}
+
+// list returns the staged writes of the call's session, oldest first.
+func (p *pendingActions) list(ctx context.Context) []pendingAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]pendingAction{}, p.bySession[sessionID(ctx)]...)
+}
+
+// take removes a staged write of the call's session and returns it, so it
+// runs at most once.
+func (p *pendingActions) take(ctx context.Context, id int) (pendingAction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session := sessionID(ctx)
+	for i, action := range p.bySession[session] {
+		if action.ID == id {
+			p.bySession[session] = append(p.bySession[session][:i:i], p.bySession[session][i+1:]...)
+			return action, true
+		}
+	}
+	return pendingAction{}, false
+}
+
+// forget drops the staged writes of a session that ended.
+func (p *pendingActions) forget(ctx context.Context, session server.ClientSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.bySession, session.SessionID())
+}
+
+// approvedCallKey marks the context of a call executed by
+// approve_pending_action, which withApproval lets through.
+type approvedCallKey struct{}
+
+// withApproval stages calls of write tools instead of running them when
+// approval_mode is on. It runs after the argument repair and the current
+// ticket resolution, so the staged arguments are exactly those that will be
+// executed.
+func withApproval(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !config.ApprovalMode || !isWriteCall(request) || ctx.Value(approvedCallKey{}) != nil {
+			return next(ctx, request)
+		}
+		action := sessionPending.stage(ctx, request.Params.Name, request.Params.Arguments)
+		log.Printf("Staged %s as pending action %d", request.Params.Name, action.ID)
+
+		jsonData, err := json.MarshalIndent(action, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal pending action: %w", err) // Internal server error
+		}
+		return mcp.NewToolResultText(fmt.Sprintf(localized(msgStaged),
+			request.Params.Name, action.ID, action.ID, string(jsonData))), nil
+	}
+}
+
+// handleApprovePendingAction returns the handler of approve_pending_action,
+// which executes or rejects a staged write, or lists the staged writes if no
+// id is given. The write runs as a regular call of its tool on s, with its own
+// timeout.
+func handleApprovePendingAction(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := mcp.ParseInt(request, "id", 0)
+		reject := mcp.ParseBoolean(request, "reject", false)
+		if id <= 0 {
+			pending := sessionPending.list(ctx)
+			jsonData, err := json.MarshalIndent(pending, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal pending actions: %w", err) // Internal server error
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Pending actions of this session (%d):\n%s", len(pending), string(jsonData))), nil
+		}
+		action, ok := sessionPending.take(ctx, id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("No pending action %d in this session; it was already approved or rejected, or staged in another session. Call %s without id to list the pending actions.", id, approveToolName)), nil
+		}
+		if reject {
+			log.Printf("Rejected pending action %d (%s)", action.ID, action.Tool)
+			return mcp.NewToolResultText(fmt.Sprintf("Rejected pending action %d (%s); nothing was changed.", action.ID, action.Tool)), nil
+		}
+
+		log.Printf("Executing approved action %d (%s)", action.ID, action.Tool)
+		params := map[string]any{"name": action.Tool, "arguments": action.Arguments}
+		if request.Params.Meta != nil {
+			params["_meta"] = request.Params.Meta // progress notifications go to the approving call
+		}
+		message, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": fmt.Sprintf("approved-%d", action.ID), "method": string(mcp.MethodToolsCall), "params": params})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal approved call: %w", err) // Internal server error
+		}
+		switch response := s.HandleMessage(context.WithValue(ctx, approvedCallKey{}, action.ID), message).(type) {
+		case mcp.JSONRPCResponse:
+			result, ok := response.Result.(mcp.CallToolResult)
+			if !ok {
+				return nil, fmt.Errorf("unexpected result of approved call: %T", response.Result) // Internal server error
+			}
+			return &result, nil
+		case mcp.JSONRPCError:
+			return mcp.NewToolResultError(fmt.Sprintf("Approved action %d (%s) failed: %s", action.ID, action.Tool, response.Error.Message)), nil
+		default:
+			return nil, fmt.Errorf("unexpected response to approved call: %T", response) // Internal server error
+		}
+	}
+}
+
+// stagedToolNames returns the names of the tools staged in approval mode,
+// sorted.
+func stagedToolNames() []string {
+	names := make([]string, 0, len(stagedTools))
+	for name := range stagedTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// articlePassage is a match of a query within a ticket article. Offset and
+// Length are in characters of the article's plain text body.
+type articlePassage struct {
+	ArticleID int    `json:"article_id"`
+	From      string `json:"from,omitempty"`
+	CreatedAt string `json:"created_at"`
+	Offset    int    `json:"offset"`
+	Length    int    `json:"length"`
+	Passage   string `json:"passage"`
+}
+
+var (
+	htmlBreakPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// articlePlainText returns the body of an article as plain text, stripping
+// markup from HTML articles.
+func articlePlainText(article zammad.TicketArticle) string {
+	if !strings.Contains(article.ContentType, "html") {
+		return article.Body
+	}
+	return htmlToPlainText(article.Body)
+}
+
+// htmlToPlainText strips markup from HTML, turning line-breaking elements
+// into newlines.
+func htmlToPlainText(s string) string {
+	text := htmlBreakPattern.ReplaceAllString(s, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
+
+// findPassages returns the passages of text matching query, case-insensitively,
+// with up to contextChars characters of surrounding text on each side.
+func findPassages(text, query string, contextChars int) []articlePassage {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	needle := []rune(strings.ToLower(query))
+	if len(lower) != len(runes) {
+		// Case folding changed the length (rare scripts); match case-sensitively
+		// so offsets stay valid.
+		lower, needle = runes, []rune(query)
+	}
+
+	var passages []articlePassage
+	for i := 0; i+len(needle) <= len(lower); i++ {
+		if !runesHavePrefix(lower[i:], needle) {
+			continue
+		}
+		start := max(0, i-contextChars)
+		end := min(len(runes), i+len(needle)+contextChars)
+		passage := strings.Join(strings.Fields(string(runes[start:end])), " ")
+		if start > 0 {
+			passage = "…" + passage
+		}
+		if end < len(runes) {
+			passage += "…"
+		}
+		passages = append(passages, articlePassage{Offset: i, Length: len(needle), Passage: passage})
+		i += len(needle) - 1
+	}
+	return passages
+}
+
+func runesHavePrefix(s, prefix []rune) bool {
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSearchInTicket searches the articles of a ticket server-side and
+// returns only the matching passages.
+func handleSearchInTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	query := strings.TrimSpace(mcp.ParseString(request, "query", ""))
+	contextChars := mcp.ParseInt(request, "context_chars", 150)
+	maxMatches := mcp.ParseInt(request, "max_matches", 50)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if query == "" {
+		return mcp.NewToolResultError("Missing or invalid required argument: query"), nil
+	}
+	if contextChars < 0 {
+		contextChars = 0
+	}
+	if maxMatches <= 0 {
+		maxMatches = 50
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+
+	articles, err := zammadClient.TicketArticleByTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching articles for ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get articles for ticket %d", ticketID), err), nil
+	}
+
+	var matches []articlePassage
+	for _, article := range articles {
+		fence := config.FenceCustomerContent && isCustomerArticle(article)
+		if config.FenceCustomerContent {
+			article.Body = stripHiddenContent(article.Body)
+		}
+		for _, p := range findPassages(articlePlainText(article), query, contextChars) {
+			p.ArticleID = article.ID
+			p.From = article.From
+			p.CreatedAt = article.CreatedAt.Format(time.RFC3339)
+			if fence {
+				p.Passage = fenceUntrusted(fmt.Sprintf("article %d", article.ID), p.Passage)
+			}
+			matches = append(matches, p)
+		}
+	}
+	total := len(matches)
+	matches, next := pageOf(matches, offset, maxMatches)
+
+	if total == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No matches for %q in the %d articles of ticket %d.", query, len(articles), ticketID)), nil
+	}
+
+	jsonData, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal matches for ticket %d: %w", ticketID, err) // Internal server error
+	}
+	log.Printf("Found %d matches for %q in %d articles of ticket %d", total, query, len(articles), ticketID)
+	header := fmt.Sprintf("Matches for %q in ticket %d (%d found in %d articles)", query, ticketID, total, len(articles))
+	if total > len(matches) {
+		header += fmt.Sprintf(", showing %d-%d", offset+1, offset+len(matches))
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s%s", header, string(jsonData), moreResultsNote(request, next))), nil
+}
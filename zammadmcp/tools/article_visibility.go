@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// articleVisibility is the internal flag of an article, for undoing a
+// visibility change.
+type articleVisibility struct {
+	ID       int
+	Internal bool
+}
+
+// visibilityName describes an article's internal flag.
+func visibilityName(internal bool) string {
+	if internal {
+		return "internal"
+	}
+	return "public"
+}
+
+// handleSetArticleVisibility makes an article internal or public, e.g. to
+// share a note with the customer after posting it internally. Without
+// internal, the visibility is flipped. Making an article public shows it to
+// the customer in the customer portal, but sends nothing.
+func handleSetArticleVisibility(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	articleID := mcp.ParseInt(request, "article_id", 0)
+	if articleID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: article_id (must be a positive number)"), nil
+	}
+	article, err := fetchArticle(articleID)
+	if err != nil {
+		log.Printf("Error fetching article %d from Zammad: %v", articleID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get article %d", articleID), err), nil
+	}
+	ticketID := article.TicketID
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+	internal := mcp.ParseBoolean(request, "internal", !article.Internal)
+	if article.Internal == internal {
+		return mcp.NewToolResultText(fmt.Sprintf("Article %d of ticket %d is already %s; nothing changed.", articleID, ticketID, visibilityName(internal))), nil
+	}
+
+	updated, err := setArticleInternal(articleID, internal)
+	if err != nil {
+		log.Printf("Error changing the visibility of article %d in Zammad: %v", articleID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to make article %d %s", articleID, visibilityName(internal)), err), nil
+	}
+	undo := &undoStep{
+		TicketID:  ticketID,
+		UpdatedAt: ticketUpdatedAt(ticketID, updated.UpdatedAt),
+		Article:   &articleVisibility{ID: articleID, Internal: article.Internal},
+	}
+	summary := fmt.Sprintf("made article %d of ticket %d %s", articleID, ticketID, visibilityName(internal))
+	sessionActions.record(ctx, request.Params.Name, summary, undo, ticketObject(ticketID), articleObject(ticketID, articleID))
+
+	log.Printf("Made article %d of ticket %d %s", articleID, ticketID, visibilityName(internal))
+	jsonData, err := json.MarshalIndent(fenceArticle(updated.TicketArticle), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal article %d: %w", articleID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Article %d of ticket %d is now %s:\n%s", articleID, ticketID, visibilityName(internal), string(jsonData))), nil
+}
@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Assignment strategies of auto_assign_ticket.
+const (
+	assignLeastOpen  = "least_open"
+	assignRoundRobin = "round_robin"
+)
+
+// autoAssignSettings configures auto_assign_ticket.
+type autoAssignSettings struct {
+	// Strategy is used when the tool is not given one.
+	Strategy string `yaml:"strategy"`
+}
+
+// groupAgent is a user with access to a group, as far as needed to pick an
+// assignee.
+type groupAgent struct {
+	ID                 int                 `json:"id"`
+	Login              string              `json:"login"`
+	Firstname          string              `json:"firstname"`
+	Lastname           string              `json:"lastname"`
+	Active             bool                `json:"active"`
+	GroupIDs           map[string][]string `json:"group_ids"`
+	OutOfOffice        bool                `json:"out_of_office"`
+	OutOfOfficeStartAt string              `json:"out_of_office_start_at"`
+	OutOfOfficeEndAt   string              `json:"out_of_office_end_at"`
+	openTickets        int
+}
+
+func (a groupAgent) displayName() string {
+	if name := strings.TrimSpace(a.Firstname + " " + a.Lastname); name != "" {
+		return name
+	}
+	return a.Login
+}
+
+// canOwn reports whether the agent may own tickets of the group: Zammad
+// requires full access to the group.
+func (a groupAgent) canOwn(groupID int) bool {
+	return slices.Contains(a.GroupIDs[fmt.Sprint(groupID)], "full")
+}
+
+// absent reports whether the agent is out of office on the given day
+// (YYYY-MM-DD, as Zammad stores the period).
+func (a groupAgent) absent(day string) bool {
+	return a.OutOfOffice && a.OutOfOfficeStartAt != "" && a.OutOfOfficeEndAt != "" &&
+		a.OutOfOfficeStartAt <= day && day <= a.OutOfOfficeEndAt
+}
+
+// roundRobin remembers the last agent auto_assign_ticket picked per group, so
+// round_robin continues with the next one. It is kept in memory only.
+var roundRobin = struct {
+	sync.Mutex
+	last map[int]int
+}{last: make(map[int]int)}
+
+// groupAgents returns the active agents that can own tickets of the group and
+// are not out of office, ordered by ID.
+func groupAgents(groupID int) ([]groupAgent, error) {
+	var users []groupAgent
+	path := fmt.Sprintf("/api/v1/users/search?query=*&limit=500&%s=full", url.QueryEscape(fmt.Sprintf("group_ids[%d]", groupID)))
+	if err := zammadRequest(http.MethodGet, path, nil, &users); err != nil {
+		return nil, err
+	}
+	today := time.Now().In(displayLocation()).Format(time.DateOnly)
+	agents := make([]groupAgent, 0, len(users))
+	for _, u := range users {
+		if u.Active && u.ID != unassignedOwnerID && u.canOwn(groupID) && !u.absent(today) {
+			agents = append(agents, u)
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents, nil
+}
+
+// countOpenTickets sets the number of backlog tickets (see openStateTypes)
+// each agent owns, in any group.
+func countOpenTickets(agents []groupAgent) error {
+	openQuery, err := openStateQuery()
+	if err != nil {
+		return fmt.Errorf("failed to fetch ticket states: %w", err)
+	}
+	for i := range agents {
+		query := fmt.Sprintf("owner_id:%d AND %s", agents[i].ID, openQuery)
+		if agents[i].openTickets, err = countTickets(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pickAgent chooses the assignee among agents (ordered by ID) with the given
+// strategy.
+func pickAgent(agents []groupAgent, strategy string, groupID int) groupAgent {
+	if strategy == assignRoundRobin {
+		roundRobin.Lock()
+		defer roundRobin.Unlock()
+		next := agents[0]
+		for _, a := range agents {
+			if a.ID > roundRobin.last[groupID] {
+				next = a
+				break
+			}
+		}
+		roundRobin.last[groupID] = next.ID
+		return next
+	}
+	best := agents[0]
+	for _, a := range agents[1:] {
+		if a.openTickets < best.openTickets {
+			best = a
+		}
+	}
+	return best
+}
+
+// assignCandidate is an agent considered by auto_assign_ticket.
+type assignCandidate struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Login       string `json:"login"`
+	OpenTickets *int   `json:"open_tickets,omitempty"` // only counted by least_open
+}
+
+// autoAssignResult is the outcome of auto_assign_ticket.
+type autoAssignResult struct {
+	Ticket     json.RawMessage   `json:"ticket"` // ticketRecord in the requested output profile
+	Strategy   string            `json:"strategy"`
+	AssignedTo assignCandidate   `json:"assigned_to"`
+	Candidates []assignCandidate `json:"candidates"`
+}
+
+// handleAutoAssignTicket assigns a ticket to an agent of its group, chosen by
+// the fewest open tickets or round-robin.
+func handleAutoAssignTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	strategy := mcp.ParseString(request, "strategy", config.AutoAssign.Strategy)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if strategy != assignLeastOpen && strategy != assignRoundRobin {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument strategy: %q (one of: %s, %s)", strategy, assignLeastOpen, assignRoundRobin)), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	agents, err := groupAgents(ticket.GroupID)
+	if err != nil {
+		log.Printf("Error listing agents of group %d: %v", ticket.GroupID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to list the agents of group %d", ticket.GroupID), err), nil
+	}
+	if len(agents) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Group %d of ticket %d has no active agent with full access who is not out of office", ticket.GroupID, ticketID)), nil
+	}
+	if strategy == assignLeastOpen {
+		if err := countOpenTickets(agents); err != nil {
+			log.Printf("Error counting open tickets of the agents of group %d: %v", ticket.GroupID, err)
+			return mcp.NewToolResultErrorFromErr("Failed to count the agents' open tickets", err), nil
+		}
+	}
+	agent := pickAgent(agents, strategy, ticket.GroupID)
+
+	update := ticketUpdate{OwnerID: setTo(agent.ID)}
+	updated, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error assigning ticket %d to user %d: %v", ticketID, agent.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to assign ticket %d to %s", ticketID, agent.displayName()), err), nil
+	}
+	log.Printf("Auto-assigned ticket %d to user %d (%s)", ticketID, agent.ID, strategy)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("assigned ticket %d to %s", ticketID, agent.displayName()), ticketUndo(ticket, updated.UpdatedAt, update), ticketObject(ticketID), userObject(agent.ID))
+
+	ticketData, err := profile.project(updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	result := autoAssignResult{Ticket: ticketData, Strategy: strategy}
+	for _, a := range agents {
+		candidate := assignCandidate{ID: a.ID, Name: a.displayName(), Login: a.Login}
+		if strategy == assignLeastOpen {
+			candidate.OpenTickets = &a.openTickets
+		}
+		if a.ID == agent.ID {
+			result.AssignedTo = candidate
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal assignment result: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d assigned to %s (%s):\n%s", ticketID, agent.displayName(), strategy, string(jsonData))), nil
+}
+
+// handleAssignTicket assigns a ticket to a given agent. Zammad only lets
+// agents with full access to the ticket's group own it, so others are refused
+// before the update.
+func handleAssignTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	ownerRef := strings.TrimSpace(mcp.ParseString(request, "owner", ""))
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if ownerRef == "" {
+		return mcp.NewToolResultError("Missing or invalid required argument: owner (user ID, login or email address)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	user, err := resolveUser(ownerRef)
+	if err != nil {
+		log.Printf("Error resolving owner %q: %v", ownerRef, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the owner %q", ownerRef), err), nil
+	}
+	// The zammad-go user lacks the group permissions and out-of-office period.
+	var agent groupAgent
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", user.ID), nil, &agent); err != nil {
+		log.Printf("Error fetching user %d from Zammad: %v", user.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get user %d", user.ID), err), nil
+	}
+	if !agent.Active || agent.ID == unassignedOwnerID {
+		return mcp.NewToolResultError(fmt.Sprintf("%s (user %d) is not an active user and cannot own tickets", agent.displayName(), agent.ID)), nil
+	}
+	if !agent.canOwn(ticket.GroupID) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s (user %d) has no full access to group %d of ticket %d and cannot own it; use auto_assign_ticket or pick an agent of the group", agent.displayName(), agent.ID, ticket.GroupID, ticketID)), nil
+	}
+	if ticket.OwnerID == agent.ID {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d is already assigned to %s; nothing changed.", ticketID, agent.displayName())), nil
+	}
+
+	update := ticketUpdate{OwnerID: setTo(agent.ID)}
+	updated, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error assigning ticket %d to user %d: %v", ticketID, agent.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to assign ticket %d to %s", ticketID, agent.displayName()), err), nil
+	}
+	log.Printf("Assigned ticket %d to user %d", ticketID, agent.ID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("assigned ticket %d to %s", ticketID, agent.displayName()), ticketUndo(ticket, updated.UpdatedAt, update), ticketObject(ticketID), userObject(agent.ID))
+
+	jsonData, err := profile.marshalIndent(updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	header := fmt.Sprintf("Ticket %d assigned to %s", ticketID, agent.displayName())
+	if agent.absent(time.Now().In(displayLocation()).Format(time.DateOnly)) {
+		header += fmt.Sprintf(" (out of office until %s)", agent.OutOfOfficeEndAt)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
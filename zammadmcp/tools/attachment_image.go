@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // decoder for GIF attachments
+	"image/jpeg"
+	"image/png"
+	"log"
+	"mime"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxImagePixels bounds the size of images the server decodes, against
+// decompression bombs: a small file can declare a huge canvas.
+const maxImagePixels = 50_000_000
+
+// downscaleImage returns src scaled down to width x height by averaging the
+// source pixels that fall into each target pixel, which keeps text in
+// screenshots legible better than sampling.
+func downscaleImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	}
+	origin := rgba.Bounds().Min
+	srcWidth, srcHeight := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				i := rgba.PixOffset(origin.X+x0, origin.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := range sum {
+						sum[c] += int(rgba.Pix[i+c])
+					}
+					i += 4
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// scaledSize returns the size of an image of width x height fitted into
+// maxDimension pixels on its longer side, keeping the aspect ratio.
+func scaledSize(width, height, maxDimension int) (int, int) {
+	if width >= height {
+		return maxDimension, max(height*maxDimension/width, 1)
+	}
+	return max(width*maxDimension/height, 1), maxDimension
+}
+
+// handleGetAttachmentImage returns an image attachment of a ticket article as
+// image content, downscaled and re-encoded if it is larger than the maximum
+// dimension, so it fits the vision input of the model.
+func handleGetAttachmentImage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	articleID := mcp.ParseInt(request, "article_id", 0)
+	attachmentID := mcp.ParseInt(request, "attachment_id", 0)
+	maxDimension := mcp.ParseInt(request, "max_dimension", config.Attachments.ImageMaxDimension)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if articleID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: article_id (must be a positive number)"), nil
+	}
+	if attachmentID < 0 {
+		return mcp.NewToolResultError("Invalid argument attachment_id: must be a positive number"), nil
+	}
+	if maxDimension < 0 {
+		return mcp.NewToolResultError("Invalid argument max_dimension: must not be negative"), nil
+	}
+
+	_, attachment, failure, err := selectAttachment(ticketID, articleID, attachmentID)
+	if failure != nil || err != nil {
+		return failure, err
+	}
+	mediaType, _, err := mime.ParseMediaType(attachment.contentType())
+	if err != nil {
+		mediaType = strings.ToLower(attachment.contentType())
+	}
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Attachment %q is of type %s; only JPEG, PNG, GIF and WebP images can be returned. Use get_attachment_text for documents.", attachment.Filename, mediaType)), nil
+	}
+	data, failure := downloadAttachment(ticketID, articleID, attachment)
+	if failure != nil {
+		return failure, nil
+	}
+
+	if mediaType == "image/webp" {
+		// The standard library has no WebP codec.
+		description := fmt.Sprintf("Image attachment %q of article %d (%s, %s), not downscaled: the server cannot decode WebP", attachment.Filename, articleID, mediaType, byteSize(len(data)))
+		return mcp.NewToolResultImage(description, base64.StdEncoding.EncodeToString(data), mediaType), nil
+	}
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Error reading image attachment %d: %v", attachment.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to read image %q", attachment.Filename), err), nil
+	}
+	if header.Width*header.Height > maxImagePixels {
+		return mcp.NewToolResultError(fmt.Sprintf("Image %q is %dx%d pixels, more than the server decodes (%d megapixels)", attachment.Filename, header.Width, header.Height, maxImagePixels/1_000_000)), nil
+	}
+	if maxDimension == 0 || max(header.Width, header.Height) <= maxDimension {
+		description := fmt.Sprintf("Image attachment %q of article %d (%dx%d, %s, %s)", attachment.Filename, articleID, header.Width, header.Height, mediaType, byteSize(len(data)))
+		return mcp.NewToolResultImage(description, base64.StdEncoding.EncodeToString(data), mediaType), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Error decoding image attachment %d: %v", attachment.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to decode image %q", attachment.Filename), err), nil
+	}
+	width, height := scaledSize(header.Width, header.Height, maxDimension)
+	scaled := downscaleImage(img, width, height)
+	// Transparent images stay PNG, as JPEG has no alpha channel.
+	var encoded bytes.Buffer
+	encodedType := "image/jpeg"
+	if scaled.Opaque() {
+		err = jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: config.Attachments.ImageQuality})
+	} else {
+		encodedType = "image/png"
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&encoded, scaled)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image %q: %w", attachment.Filename, err) // Internal server error
+	}
+	log.Printf("Downscaled image attachment %d from %dx%d (%s) to %dx%d (%s)", attachment.ID, header.Width, header.Height, byteSize(len(data)), width, height, byteSize(encoded.Len()))
+	description := fmt.Sprintf("Image attachment %q of article %d, downscaled from %dx%d (%s, %s) to %dx%d (%s, %s)",
+		attachment.Filename, articleID, header.Width, header.Height, mediaType, byteSize(len(data)), width, height, encodedType, byteSize(encoded.Len()))
+	if mediaType == "image/gif" {
+		description += "; only the first frame of an animation is kept"
+	}
+	return mcp.NewToolResultImage(description, base64.StdEncoding.EncodeToString(encoded.Bytes()), encodedType), nil
+}
@@ -0,0 +1,737 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxExtractedBytes bounds what the extractors decompress from a single
+// archive member or PDF stream, against zip and deflate bombs.
+const maxExtractedBytes = 32 << 20
+
+// defaultAttachmentTextChars is how many characters get_attachment_text
+// returns unless asked for fewer or more.
+const defaultAttachmentTextChars = 20000
+
+// attachmentText is the result of get_attachment_text.
+type attachmentText struct {
+	AttachmentID int    `json:"attachment_id"`
+	Filename     string `json:"filename"`
+	ContentType  string `json:"content_type"`
+	Size         string `json:"size"`
+	Format       string `json:"format"`
+	Characters   int    `json:"characters"`
+	Truncated    bool   `json:"truncated,omitempty"`
+	Text         string `json:"text"`
+}
+
+// errNoText reports an attachment of a supported format without extractable
+// text, e.g. a scanned PDF.
+var errNoText = errors.New("the attachment contains no extractable text")
+
+// unsupportedFormatError reports an attachment whose format has no extractor.
+type unsupportedFormatError struct {
+	mediaType string
+}
+
+func (e *unsupportedFormatError) Error() string {
+	return fmt.Sprintf("text cannot be extracted from attachments of type %s; supported are plain text, HTML, PDF, Word, Excel and PowerPoint (OOXML) and OpenDocument files", e.mediaType)
+}
+
+// attachmentFormat determines the extractor for an attachment from its MIME
+// type, falling back to its file name extension for generic types.
+func attachmentFormat(filename, mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	switch mediaType {
+	case "application/pdf":
+		return "pdf"
+	case "text/html", "application/xhtml+xml":
+		return "html"
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return "docx"
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return "xlsx"
+	case "application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		return "pptx"
+	case "application/vnd.oasis.opendocument.text", "application/vnd.oasis.opendocument.spreadsheet", "application/vnd.oasis.opendocument.presentation":
+		return "odf"
+	case "application/json", "application/xml", "application/csv", "application/x-yaml", "message/rfc822":
+		return "text"
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return "text"
+	}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".pdf":
+		return "pdf"
+	case ".htm", ".html":
+		return "html"
+	case ".docx":
+		return "docx"
+	case ".xlsx":
+		return "xlsx"
+	case ".pptx":
+		return "pptx"
+	case ".odt", ".ods", ".odp":
+		return "odf"
+	case ".txt", ".log", ".csv", ".tsv", ".md", ".json", ".xml", ".yaml", ".yml", ".eml", ".ini", ".conf":
+		return "text"
+	}
+	return ""
+}
+
+// extractAttachmentText returns the plain text of an attachment of the given
+// format, see attachmentFormat.
+func extractAttachmentText(ctx context.Context, format, mimeType string, data []byte) (string, error) {
+	var text string
+	var err error
+	switch format {
+	case "text":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("the attachment is not valid UTF-8 text")
+		}
+		text = string(data)
+	case "html":
+		text = htmlToPlainText(string(data))
+	case "pdf":
+		text, err = extractPDFText(ctx, data)
+	case "docx", "xlsx", "pptx", "odf":
+		text, err = extractOfficeText(format, data)
+	default:
+		return "", &unsupportedFormatError{mimeType}
+	}
+	if err != nil {
+		return "", err
+	}
+	text = tidyExtractedText(text)
+	if text == "" {
+		return "", errNoText
+	}
+	return text, nil
+}
+
+// tidyExtractedText trims trailing spaces from lines and collapses runs of
+// blank lines.
+func tidyExtractedText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	var b strings.Builder
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			blank++
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(strings.Repeat("\n", min(blank, 1)+1))
+		}
+		blank = 0
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// zipMember returns the decompressed content of a member of an archive, or
+// nil if there is no such member.
+func zipMember(archive *zip.Reader, name string) ([]byte, error) {
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(io.LimitReader(r, maxExtractedBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxExtractedBytes {
+			return nil, fmt.Errorf("%s decompresses to more than %s", name, byteSize(maxExtractedBytes))
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// numberedMembers returns the archive members named prefix<n>.xml, such as
+// ppt/slides/slide1.xml, ordered by n.
+func numberedMembers(archive *zip.Reader, prefix string) []string {
+	type member struct {
+		name string
+		n    int
+	}
+	var members []member
+	for _, f := range archive.File {
+		rest, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(rest, ".xml")); err == nil && strings.HasSuffix(rest, ".xml") {
+			members = append(members, member{f.Name, n})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].n < members[j].n })
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.name
+	}
+	return names
+}
+
+// xmlTextRules tell xmlText which elements of a document format carry text
+// and which break lines. Elements are matched by local name.
+type xmlTextRules struct {
+	text   map[string]bool // elements whose character data is text; nil means all
+	lines  map[string]bool // elements that end a line
+	tabs   map[string]bool // elements that end with a tab
+	breaks map[string]bool // empty elements that are a line break
+}
+
+var (
+	wordTextRules  = xmlTextRules{text: map[string]bool{"t": true}, lines: map[string]bool{"p": true, "tr": true}, tabs: map[string]bool{"tab": true, "tc": true}, breaks: map[string]bool{"br": true, "cr": true}}
+	slideTextRules = xmlTextRules{text: map[string]bool{"t": true}, lines: map[string]bool{"p": true}, tabs: map[string]bool{"tc": true}, breaks: map[string]bool{"br": true}}
+	odfTextRules   = xmlTextRules{lines: map[string]bool{"p": true, "h": true, "table-row": true}, tabs: map[string]bool{"tab": true, "table-cell": true}, breaks: map[string]bool{"line-break": true}}
+)
+
+// xmlText returns the text of an XML document according to rules.
+func xmlText(data []byte, rules xmlTextRules) (string, error) {
+	var b strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inText := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch name := t.Name.Local; {
+			case rules.text[name]:
+				inText++
+			case rules.breaks[name]:
+				b.WriteByte('\n')
+			case name == "s" && rules.text == nil:
+				// OpenDocument <text:s text:c="n"/> stands for n spaces.
+				n := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						n, _ = strconv.Atoi(attr.Value)
+					}
+				}
+				b.WriteString(strings.Repeat(" ", max(n, 1)))
+			}
+		case xml.EndElement:
+			switch name := t.Name.Local; {
+			case rules.text[name]:
+				inText--
+			case rules.lines[name]:
+				b.WriteByte('\n')
+			case rules.tabs[name]:
+				b.WriteByte('\t')
+			}
+		case xml.CharData:
+			if rules.text == nil || inText > 0 {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// extractOfficeText extracts the text of an OOXML (docx, xlsx, pptx) or
+// OpenDocument file.
+func extractOfficeText(format string, data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("the attachment is not a valid %s file: %w", format, err)
+	}
+	switch format {
+	case "docx":
+		document, err := zipMember(archive, "word/document.xml")
+		if err != nil {
+			return "", err
+		}
+		if document == nil {
+			return "", fmt.Errorf("the attachment is not a valid docx file: word/document.xml is missing")
+		}
+		return xmlText(document, wordTextRules)
+	case "pptx":
+		var b strings.Builder
+		for i, name := range numberedMembers(archive, "ppt/slides/slide") {
+			slide, err := zipMember(archive, name)
+			if err != nil {
+				return "", err
+			}
+			text, err := xmlText(slide, slideTextRules)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+			fmt.Fprintf(&b, "--- Slide %d ---\n%s\n\n", i+1, text)
+		}
+		return b.String(), nil
+	case "xlsx":
+		return extractSpreadsheetText(archive)
+	case "odf":
+		content, err := zipMember(archive, "content.xml")
+		if err != nil {
+			return "", err
+		}
+		if content == nil {
+			return "", fmt.Errorf("the attachment is not a valid OpenDocument file: content.xml is missing")
+		}
+		return xmlText(content, odfTextRules)
+	}
+	return "", &unsupportedFormatError{format}
+}
+
+// xlsxSheet is the part of an xlsx worksheet holding the cell values.
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// extractSpreadsheetText returns the worksheets of an xlsx file as lines of
+// tab-separated cell values.
+func extractSpreadsheetText(archive *zip.Reader) (string, error) {
+	var shared []string
+	if data, err := zipMember(archive, "xl/sharedStrings.xml"); err != nil {
+		return "", err
+	} else if data != nil {
+		var sst struct {
+			Items []struct {
+				Text string `xml:",innerxml"`
+			} `xml:"si"`
+		}
+		if err := xml.Unmarshal(data, &sst); err != nil {
+			return "", fmt.Errorf("xl/sharedStrings.xml: %w", err)
+		}
+		for _, item := range sst.Items {
+			// Rich text items hold runs of <t> elements.
+			text, err := xmlText([]byte("<si>"+item.Text+"</si>"), xmlTextRules{text: map[string]bool{"t": true}})
+			if err != nil {
+				return "", fmt.Errorf("xl/sharedStrings.xml: %w", err)
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range numberedMembers(archive, "xl/worksheets/sheet") {
+		data, err := zipMember(archive, name)
+		if err != nil {
+			return "", err
+		}
+		var sheet xlsxSheet
+		if err := xml.Unmarshal(data, &sheet); err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(&b, "--- %s ---\n", strings.TrimSuffix(path.Base(name), ".xml"))
+		for _, row := range sheet.Rows {
+			values := make([]string, len(row.Cells))
+			for j, cell := range row.Cells {
+				switch cell.Type {
+				case "s":
+					if k, err := strconv.Atoi(cell.Value); err == nil && k >= 0 && k < len(shared) {
+						values[j] = shared[k]
+					}
+				case "inlineStr":
+					values[j] = cell.Inline
+				default:
+					values[j] = cell.Value
+				}
+			}
+			b.WriteString(strings.Join(values, "\t"))
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// extractPDFText extracts the text of a PDF, with the configured command if
+// there is one and the built-in extractor otherwise.
+func extractPDFText(ctx context.Context, data []byte) (string, error) {
+	command := config.Attachments.PDFTextCommand
+	if len(command) == 0 {
+		return pdfText(data)
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s: %w: %s", command[0], err, message)
+		}
+		return "", fmt.Errorf("%s: %w", command[0], err)
+	}
+	if !utf8.Valid(output) {
+		output = bytes.ToValidUTF8(output, []byte("�"))
+	}
+	return string(output), nil
+}
+
+var (
+	pdfStreamPattern = regexp.MustCompile(`>>\s*stream\r?\n`)
+	pdfLengthPattern = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// pdfText is a basic PDF text extractor: it decodes the uncompressed and
+// Flate-compressed streams of the file and collects the strings shown by the
+// text operators of content streams. Text in fonts with custom encodings,
+// which most PDFs from office suites use for non-Latin scripts, comes out
+// garbled or not at all; pdf_text_command handles those.
+func pdfText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", fmt.Errorf("the attachment is not a PDF file")
+	}
+	var b strings.Builder
+	for pos := 0; ; {
+		loc := pdfStreamPattern.FindIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		// The stream dictionary follows the "obj" of the object header.
+		dictEnd, start := pos+loc[0], pos+loc[1]
+		dictStart := pos + max(bytes.LastIndex(data[pos:dictEnd], []byte("obj")), 0)
+		dict := data[dictStart:dictEnd]
+		end := -1
+		if m := pdfLengthPattern.FindSubmatch(dict); m != nil && m[2] == nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && start+n <= len(data) {
+				end = start + n
+			}
+		}
+		if end < 0 {
+			// The length is missing or an indirect object.
+			i := bytes.Index(data[start:], []byte("endstream"))
+			if i < 0 {
+				break
+			}
+			end = start + i
+		}
+		pos = end
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/XRef")) || bytes.Contains(dict, []byte("/ObjStm")) {
+			continue
+		}
+		stream := data[start:end]
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Count(dict, []byte("Decode")) > 1 {
+				continue // other or chained filters, or predictors
+			}
+			r, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			// Streams are often followed by garbage that makes the reader fail
+			// at the end; keep what was decoded.
+			stream, _ = io.ReadAll(io.LimitReader(r, maxExtractedBytes))
+		}
+		pdfContentText(&b, stream)
+	}
+	return b.String(), nil
+}
+
+// pdfContentText writes the strings shown by the text operators of a content
+// stream to b.
+func pdfContentText(b *strings.Builder, content []byte) {
+	var operands [][]byte // strings since the last operator
+	inArray := false
+	var array [][]byte
+	lineHasText := false
+	newline := func() {
+		if lineHasText {
+			b.WriteByte('\n')
+			lineHasText = false
+		}
+	}
+	show := func(s []byte) {
+		b.WriteString(pdfStringText(s))
+		lineHasText = true
+	}
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, n := pdfLiteralString(content[i:])
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			s := pdfHexString(content[i+1 : i+end])
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+			i += end + 1
+		case c == '[':
+			inArray, array = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case inArray && (c == '-' || c >= '0' && c <= '9' || c == '.'):
+			// Kerning inside TJ arrays; a large negative adjustment is a
+			// word gap.
+			j := i + 1
+			for j < len(content) && (content[j] == '.' || content[j] >= '0' && content[j] <= '9') {
+				j++
+			}
+			if n, err := strconv.ParseFloat(string(content[i:j]), 64); err == nil && n < -200 {
+				array = append(array, []byte(" "))
+			}
+			i = j
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '\'' || c == '"' || c == '*':
+			j := i + 1
+			for j < len(content) && (content[j] >= 'A' && content[j] <= 'Z' || content[j] >= 'a' && content[j] <= 'z' || content[j] == '*') {
+				j++
+			}
+			switch string(content[i:j]) {
+			case "Tj":
+				for _, s := range operands {
+					show(s)
+				}
+			case "'", "\"":
+				newline()
+				for _, s := range operands {
+					show(s)
+				}
+			case "TJ":
+				for _, s := range array {
+					if string(s) == " " {
+						b.WriteByte(' ')
+					} else {
+						show(s)
+					}
+				}
+				array = nil
+			case "T*", "Td", "TD", "ET":
+				newline()
+			case "Tm":
+				// A new text matrix usually starts a new line.
+				newline()
+			case "BT":
+				if lineHasText {
+					b.WriteByte(' ')
+				}
+			}
+			operands = nil
+			i = j
+		default:
+			i++
+		}
+	}
+	newline()
+}
+
+// pdfLiteralString decodes the literal string at the start of s and returns
+// it with the number of bytes it takes up.
+func pdfLiteralString(s []byte) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+			out = append(out, c)
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+				if e == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					n, j := 0, i
+					for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+						n = n*8 + int(s[j]-'0')
+					}
+					out = append(out, byte(n))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, len(s)
+}
+
+// pdfHexString decodes the content of a hexadecimal string.
+func pdfHexString(s []byte) []byte {
+	var digits []byte
+	for _, c := range s {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		n, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(n)
+	}
+	return out
+}
+
+// pdfStringText converts a PDF string to text: UTF-16 if it has a byte order
+// mark, and otherwise single-byte, read as Latin-1 as a fair approximation of
+// the standard encodings. Control characters are dropped.
+func pdfStringText(s []byte) string {
+	var runes []rune
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		for i := 2; i+1 < len(s); i += 2 {
+			runes = append(runes, rune(s[i])<<8|rune(s[i+1]))
+		}
+	} else {
+		for _, c := range s {
+			runes = append(runes, rune(c))
+		}
+	}
+	var b strings.Builder
+	for _, r := range runes {
+		if r == '\t' || !unicode.IsControl(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// handleGetAttachmentText downloads an attachment of a ticket article and
+// returns its text, so documents can be referenced without passing binary
+// content to the model.
+func handleGetAttachmentText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	articleID := mcp.ParseInt(request, "article_id", 0)
+	attachmentID := mcp.ParseInt(request, "attachment_id", 0)
+	maxChars := mcp.ParseInt(request, "max_chars", defaultAttachmentTextChars)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if articleID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: article_id (must be a positive number)"), nil
+	}
+	if attachmentID < 0 {
+		return mcp.NewToolResultError("Invalid argument attachment_id: must be a positive number"), nil
+	}
+	if maxChars <= 0 {
+		maxChars = defaultAttachmentTextChars
+	}
+
+	article, attachment, failure, err := selectAttachment(ticketID, articleID, attachmentID)
+	if failure != nil || err != nil {
+		return failure, err
+	}
+	contentType := attachment.contentType()
+	format := attachmentFormat(attachment.Filename, contentType)
+	if format == "" {
+		message := (&unsupportedFormatError{contentType}).Error()
+		if strings.HasPrefix(contentType, "image/") {
+			message += ". Use get_attachment_image for images."
+		}
+		return mcp.NewToolResultError(message), nil
+	}
+	data, failure := downloadAttachment(ticketID, articleID, attachment)
+	if failure != nil {
+		return failure, nil
+	}
+
+	text, err := extractAttachmentText(ctx, format, contentType, data)
+	if err != nil {
+		log.Printf("Error extracting the text of attachment %q of article %d: %v", attachment.Filename, articleID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to extract the text of attachment %q", attachment.Filename), err), nil
+	}
+	result := attachmentText{
+		AttachmentID: attachment.ID,
+		Filename:     attachment.Filename,
+		ContentType:  contentType,
+		Size:         byteSize(len(data)).String(),
+		Format:       format,
+		Characters:   utf8.RuneCountInString(text),
+	}
+	if result.Characters > maxChars {
+		text = string([]rune(text)[:maxChars])
+		result.Truncated = true
+	}
+	if config.FenceCustomerContent && isCustomerArticle(article.TicketArticle) {
+		text = fenceUntrusted(fmt.Sprintf("attachment %q of article %d", attachment.Filename, articleID), text)
+	}
+	result.Text = text
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling the text of attachment %d to JSON: %v", attachment.ID, err)
+		return nil, fmt.Errorf("failed to marshal the text of attachment %d: %w", attachment.ID, err) // Internal server error
+	}
+	header := fmt.Sprintf("Text of attachment %q of article %d (%d characters", attachment.Filename, articleID, result.Characters)
+	if result.Truncated {
+		header += fmt.Sprintf(", showing the first %d", maxChars)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s):\n%s", header, string(jsonData))), nil
+}
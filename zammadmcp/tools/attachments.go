@@ -0,0 +1,301 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// attachmentPolicy limits which attachments can be uploaded to or downloaded
+// from Zammad through the server.
+type attachmentPolicy struct {
+	// MaxSize is the largest attachment passed through; zero means
+	// unlimited.
+	MaxSize byteSize `yaml:"max_size"`
+	// AllowedTypes, if not empty, lists the only MIME types passed through.
+	// Entries may end in /* to match a whole category, e.g. image/*.
+	AllowedTypes []string `yaml:"allowed_types"`
+	// BlockedTypes lists MIME types that are never passed through.
+	BlockedTypes []string `yaml:"blocked_types"`
+	// BlockedExtensions lists file name extensions that are never passed
+	// through, whatever MIME type the file claims to have.
+	BlockedExtensions []string `yaml:"blocked_extensions"`
+	// Scan is the virus scanner attachments that pass the policy are
+	// checked with.
+	Scan attachmentScanner `yaml:"scan"`
+	// PDFTextCommand extracts the text of PDF attachments for
+	// get_attachment_text, e.g. [pdftotext, -layout, -, -]. It reads the PDF
+	// on stdin and writes the text to stdout. Without it, a built-in
+	// extractor handles PDFs with simply encoded fonts.
+	PDFTextCommand []string `yaml:"pdf_text_command"`
+	// ImageMaxDimension is the longest side, in pixels, that images returned
+	// by get_attachment_image are downscaled to; zero returns them as they
+	// are.
+	ImageMaxDimension int `yaml:"image_max_dimension"`
+	// ImageQuality is the JPEG quality (1-100) downscaled images are encoded
+	// with.
+	ImageQuality int `yaml:"image_quality"`
+}
+
+// defaultAttachmentPolicy caps attachments at 10 MiB and blocks executables
+// and scripts.
+var defaultAttachmentPolicy = attachmentPolicy{
+	MaxSize: 10 << 20,
+	BlockedTypes: []string{
+		"application/x-msdownload", "application/x-msdos-program", "application/x-msi",
+		"application/x-executable", "application/x-sharedlib", "application/x-mach-binary",
+		"application/vnd.microsoft.portable-executable", "application/x-sh", "application/x-bat",
+		"application/java-archive", "application/x-ms-shortcut",
+	},
+	BlockedExtensions: []string{
+		".exe", ".dll", ".com", ".scr", ".msi", ".bat", ".cmd", ".ps1", ".vbs", ".vbe",
+		".js", ".jse", ".wsf", ".hta", ".jar", ".lnk", ".sh", ".app", ".dmg",
+	},
+	Scan:              attachmentScanner{Timeout: duration(60 * time.Second)},
+	ImageMaxDimension: 1568,
+	ImageQuality:      85,
+}
+
+// attachmentPolicyError reports an attachment rejected by the policy.
+type attachmentPolicyError struct {
+	filename string
+	reason   string
+}
+
+func (e *attachmentPolicyError) Error() string {
+	return fmt.Sprintf("attachment %q is not allowed by the server's attachment policy: %s", e.filename, e.reason)
+}
+
+// check returns an *attachmentPolicyError if an attachment of the given name,
+// MIME type and size in bytes may not be passed through.
+func (p attachmentPolicy) check(filename, mimeType string, size int) error {
+	if p.MaxSize > 0 && byteSize(size) > p.MaxSize {
+		return &attachmentPolicyError{filename, fmt.Sprintf("its size of %s exceeds the limit of %s", byteSize(size), p.MaxSize)}
+	}
+	ext := strings.ToLower(path.Ext(filename))
+	for _, blocked := range p.BlockedExtensions {
+		if ext != "" && ext == strings.ToLower(blocked) {
+			return &attachmentPolicyError{filename, fmt.Sprintf("files with the extension %s are blocked", ext)}
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	for _, blocked := range p.BlockedTypes {
+		if mimeTypeMatches(mediaType, blocked) {
+			return &attachmentPolicyError{filename, fmt.Sprintf("the type %s is blocked", mediaType)}
+		}
+	}
+	if len(p.AllowedTypes) == 0 {
+		return nil
+	}
+	for _, allowed := range p.AllowedTypes {
+		if mimeTypeMatches(mediaType, allowed) {
+			return nil
+		}
+	}
+	return &attachmentPolicyError{filename, fmt.Sprintf("the type %q is not one of the allowed types %s", mediaType, strings.Join(p.AllowedTypes, ", "))}
+}
+
+// articleAttachment is an entry of the attachments list of a Zammad article.
+type articleAttachment struct {
+	ID          int            `json:"id"`
+	Filename    string         `json:"filename"`
+	Size        json.Number    `json:"size"` // Zammad sends a string
+	Preferences map[string]any `json:"preferences,omitempty"`
+}
+
+// contentType returns the MIME type Zammad recorded for the attachment.
+func (a articleAttachment) contentType() string {
+	for _, key := range []string{"Content-Type", "Mime-Type"} {
+		if s, ok := a.Preferences[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return "application/octet-stream"
+}
+
+// articleWithAttachments is a ticket article with its attachments, which the
+// zammad-go client does not decode.
+type articleWithAttachments struct {
+	zammad.TicketArticle
+	Attachments []articleAttachment `json:"attachments"`
+}
+
+// selectAttachment fetches an article of a ticket and picks the attachment
+// with the given ID, or its only attachment if attachmentID is 0. If there is
+// no such attachment, it returns a result for the tool to return instead,
+// which lists the article's attachments.
+func selectAttachment(ticketID, articleID, attachmentID int) (articleWithAttachments, articleAttachment, *mcp.CallToolResult, error) {
+	article, err := fetchArticle(articleID)
+	if err != nil {
+		log.Printf("Error fetching article %d from Zammad: %v", articleID, err)
+		return article, articleAttachment{}, mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get article %d", articleID), err), nil
+	}
+	if article.TicketID != ticketID {
+		return article, articleAttachment{}, mcp.NewToolResultError(fmt.Sprintf("Article %d does not belong to ticket %d", articleID, ticketID)), nil
+	}
+	if len(article.Attachments) == 0 {
+		return article, articleAttachment{}, mcp.NewToolResultError(fmt.Sprintf("Article %d has no attachments", articleID)), nil
+	}
+	for _, attachment := range article.Attachments {
+		if attachment.ID == attachmentID || attachmentID == 0 && len(article.Attachments) == 1 {
+			return article, attachment, nil, nil
+		}
+	}
+	listing, err := json.MarshalIndent(article.Attachments, "", "  ")
+	if err != nil {
+		return article, articleAttachment{}, nil, fmt.Errorf("failed to marshal attachments of article %d: %w", articleID, err) // Internal server error
+	}
+	if attachmentID == 0 {
+		return article, articleAttachment{}, mcp.NewToolResultText(fmt.Sprintf("Article %d has %d attachments; call again with one of their IDs as attachment_id:\n%s", articleID, len(article.Attachments), string(listing))), nil
+	}
+	return article, articleAttachment{}, mcp.NewToolResultError(fmt.Sprintf("Article %d has no attachment %d. Its attachments are:\n%s", articleID, attachmentID, string(listing))), nil
+}
+
+// downloadAttachment downloads an attachment of an article and applies the
+// attachment policy and the virus scan to it. The size Zammad recorded is
+// checked before downloading. If the attachment cannot be passed through, it
+// returns the error result for the tool to return.
+func downloadAttachment(ticketID, articleID int, attachment articleAttachment) ([]byte, *mcp.CallToolResult) {
+	contentType := attachment.contentType()
+	if size, err := attachment.Size.Int64(); err == nil {
+		if err := config.Attachments.check(attachment.Filename, contentType, int(size)); err != nil {
+			return nil, mcp.NewToolResultError(err.Error())
+		}
+	}
+	data, err := fetchAttachmentData(ticketID, articleID, attachment.ID)
+	if err != nil {
+		log.Printf("Error downloading attachment %d of article %d from Zammad: %v", attachment.ID, articleID, err)
+		return nil, mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to download attachment %q", attachment.Filename), err)
+	}
+	if err := checkAttachment(attachment.Filename, contentType, data); err != nil {
+		return nil, mcp.NewToolResultError(err.Error())
+	}
+	return data, nil
+}
+
+// attachmentsDescription describes the attachments argument of tools that
+// create articles.
+const attachmentsDescription = "Files to attach to the article, such as a log file or a screenshot. Each is an object with filename, mime_type (default: derived from the file name) and data_base64, the file's content encoded in base64. The server's attachment policy applies."
+
+// attachmentsOption is the attachments argument of tools that create
+// articles.
+func attachmentsOption() mcp.ToolOption {
+	return mcp.WithArray("attachments", mcp.Description(attachmentsDescription), mcp.Items(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"filename":    map[string]any{"type": "string", "description": "The file name, e.g. 'error.log'."},
+			"mime_type":   map[string]any{"type": "string", "description": "The MIME type, e.g. 'text/plain'."},
+			"data_base64": map[string]any{"type": "string", "description": "The content of the file, encoded in base64."},
+		},
+		"required": []string{"filename", "data_base64"},
+	}))
+}
+
+// articleUpload is an attachment of a new article as the Zammad API takes
+// it.
+type articleUpload struct {
+	Filename string `json:"filename"`
+	Data     string `json:"data"` // base64
+	MimeType string `json:"mime-type"`
+}
+
+// requestAttachments decodes the attachments argument of a call and applies
+// the attachment policy and the virus scan to each file. If an attachment is
+// invalid or cannot be passed through, it returns the error result for the
+// tool to return.
+func requestAttachments(request mcp.CallToolRequest) ([]articleUpload, *mcp.CallToolResult) {
+	raw, ok := request.Params.Arguments["attachments"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, mcp.NewToolResultError("Invalid argument attachments: expected an array of objects with filename, mime_type and data_base64")
+	}
+	var uploads []articleUpload
+	for i, item := range items {
+		attachment, _ := item.(map[string]any)
+		filename, _ := attachment["filename"].(string)
+		encoded, _ := attachment["data_base64"].(string)
+		mimeType, _ := attachment["mime_type"].(string)
+		filename = strings.TrimSpace(filename)
+		if filename == "" || encoded == "" {
+			return nil, mcp.NewToolResultError(fmt.Sprintf("Invalid argument attachments: attachment %d needs a filename and data_base64", i+1))
+		}
+		data, err := decodeBase64(encoded)
+		if err != nil {
+			return nil, mcp.NewToolResultError(fmt.Sprintf("Invalid argument attachments: data_base64 of %q is not valid base64: %v", filename, err))
+		}
+		if mimeType = strings.TrimSpace(mimeType); mimeType == "" {
+			if mimeType = mime.TypeByExtension(path.Ext(filename)); mimeType == "" {
+				mimeType = http.DetectContentType(data)
+			}
+		}
+		if err := checkAttachment(filename, mimeType, data); err != nil {
+			return nil, mcp.NewToolResultError(err.Error())
+		}
+		uploads = append(uploads, articleUpload{Filename: filename, Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType})
+	}
+	return uploads, nil
+}
+
+// decodeBase64 decodes base64 data as models tend to send it: wrapped into
+// lines, without padding, or as a data URL.
+func decodeBase64(encoded string) ([]byte, error) {
+	if strings.HasPrefix(encoded, "data:") {
+		if _, data, ok := strings.Cut(encoded, ","); ok {
+			encoded = data
+		}
+	}
+	encoded = strings.Join(strings.Fields(encoded), "")
+	return base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(encoded, "="))
+}
+
+// uploadFilenames returns the file names of uploads.
+func uploadFilenames(uploads []articleUpload) []string {
+	var names []string
+	for _, upload := range uploads {
+		names = append(names, upload.Filename)
+	}
+	return names
+}
+
+// createArticleWithAttachments creates an article like zammad-go's
+// TicketArticleCreate, with attachments, which zammad.TicketArticle cannot
+// carry.
+func createArticleWithAttachments(article zammad.TicketArticle, uploads []articleUpload) (articleWithAttachments, error) {
+	var created articleWithAttachments
+	data, err := json.Marshal(article)
+	if err != nil {
+		return created, err
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return created, err
+	}
+	payload["attachments"] = uploads
+	err = zammadRequest(http.MethodPost, "/api/v1/ticket_articles", payload, &created)
+	return created, err
+}
+
+// mimeTypeMatches reports whether a media type matches a pattern such as
+// "application/pdf" or "image/*".
+func mimeTypeMatches(mediaType, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if category, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, category+"/")
+	}
+	return mediaType == pattern
+}
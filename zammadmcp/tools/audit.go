@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time       time.Time      `json:"time"`
+	Session    string         `json:"session,omitempty"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments"`
+	Outcome    string         `json:"outcome"` // ok, error (an error result) or failed
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// auditWriter appends audit entries to a file as JSON Lines.
+type auditWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// auditLog is the audit log opened by Setup, or nil if audit_log is unset.
+var auditLog *auditWriter
+
+func openAuditLog(path string) (*auditWriter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditWriter{file: file}, nil
+}
+
+func (a *auditWriter) write(entry auditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Error marshalling audit entry for %s: %v", entry.Tool, err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		log.Printf("Error writing the audit log: %v", err)
+	}
+}
+
+// withAuditLog appends every tool call to the audit log with its outcome.
+// Arguments are sanitized like in error reports, so the log holds no
+// secrets and no message texts.
+func withAuditLog(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if auditLog == nil {
+			return next(ctx, request)
+		}
+		start := time.Now()
+		result, err := next(ctx, request)
+		entry := auditEntry{
+			Time:       start.UTC(),
+			Session:    sessionID(ctx),
+			Tool:       request.Params.Name,
+			Arguments:  sanitizeArguments(request.Params.Arguments),
+			Outcome:    "ok",
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		switch {
+		case err != nil:
+			entry.Outcome, entry.Error = "failed", err.Error()
+		case result != nil && result.IsError:
+			entry.Outcome = "error"
+			if len(result.Content) > 0 {
+				if text, ok := result.Content[0].(mcp.TextContent); ok {
+					entry.Error = truncateString(text.Text, 500)
+				}
+			}
+		}
+		auditLog.write(entry)
+		return result, err
+	}
+}
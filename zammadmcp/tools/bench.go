@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// benchCall is one tool call of the bench workload.
+type benchCall struct {
+	Tool      string         `yaml:"tool"`
+	Arguments map[string]any `yaml:"arguments"`
+}
+
+// defaultBenchCalls is the workload used when the configuration file has no
+// bench_calls: read-only calls that work on any instance.
+var defaultBenchCalls = []benchCall{
+	{Tool: "search_tickets", Arguments: map[string]any{"query": "*", "limit": 10, "profile": "minimal"}},
+	{Tool: "list_unassigned_tickets", Arguments: map[string]any{"limit": 10, "profile": "minimal"}},
+	{Tool: "search_users", Arguments: map[string]any{"query": "*", "limit": 10}},
+}
+
+// benchSample is the outcome of one call.
+type benchSample struct {
+	tool    string
+	latency time.Duration
+	failed  bool
+}
+
+// RunBench implements the `bench` subcommand: it sends the configured tool
+// calls through the server, including its middleware such as the response
+// cache and tool timeouts, with the given concurrency and prints latency
+// percentiles per tool. It returns the process exit code.
+func RunBench(s *server.MCPServer, args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	concurrency := flags.Int("concurrency", 4, "number of calls in flight at a time")
+	requests := flags.Int("requests", 100, "total number of calls, cycling through the workload")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *concurrency <= 0 || *requests <= 0 {
+		fmt.Println("bench: -concurrency and -requests must be positive")
+		return 2
+	}
+	calls := config.BenchCalls
+	if len(calls) == 0 {
+		calls = defaultBenchCalls
+	}
+	messages := make([]json.RawMessage, len(calls))
+	for i, call := range calls {
+		data, err := json.Marshal(map[string]any{
+			"jsonrpc": mcp.JSONRPC_VERSION,
+			"id":      i + 1,
+			"method":  string(mcp.MethodToolsCall),
+			"params":  map[string]any{"name": call.Tool, "arguments": call.Arguments},
+		})
+		if err != nil {
+			fmt.Printf("bench: invalid arguments of %s: %v\n", call.Tool, err)
+			return 2
+		}
+		messages[i] = data
+	}
+
+	fmt.Printf("Zammad MCP bench: %d calls to %s with concurrency %d\n\n", *requests, zammadClient.Url, *concurrency)
+	next := make(chan int)
+	samples := make([]benchSample, *requests)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				call := calls[i%len(calls)]
+				callStart := time.Now()
+				response := s.HandleMessage(context.Background(), messages[i%len(calls)])
+				samples[i] = benchSample{tool: call.Tool, latency: time.Since(callStart), failed: benchCallFailed(response)}
+			}
+		}()
+	}
+	for i := 0; i < *requests; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	byTool := make(map[string][]benchSample)
+	var tools []string
+	for _, sample := range samples {
+		if _, ok := byTool[sample.tool]; !ok {
+			tools = append(tools, sample.tool)
+		}
+		byTool[sample.tool] = append(byTool[sample.tool], sample)
+	}
+	fmt.Printf("%-30s %6s %6s %9s %9s %9s %9s\n", "Tool", "Calls", "Errors", "p50", "p90", "p99", "Max")
+	failed := 0
+	for _, tool := range tools {
+		latencies := make([]time.Duration, 0, len(byTool[tool]))
+		errors := 0
+		for _, sample := range byTool[tool] {
+			latencies = append(latencies, sample.latency)
+			if sample.failed {
+				errors++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Printf("%-30s %6d %6d %9s %9s %9s %9s\n", tool, len(latencies), errors,
+			benchRound(percentile(latencies, 50)), benchRound(percentile(latencies, 90)),
+			benchRound(percentile(latencies, 99)), benchRound(latencies[len(latencies)-1]))
+		failed += errors
+	}
+	fmt.Printf("\n%d calls in %s (%.1f calls/s), %d failed.\n", *requests, benchRound(elapsed), float64(*requests)/elapsed.Seconds(), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// benchCallFailed reports whether a tools/call response is a protocol error
+// or an error result.
+func benchCallFailed(response mcp.JSONRPCMessage) bool {
+	result, ok := response.(mcp.JSONRPCResponse)
+	if !ok {
+		return true
+	}
+	toolResult, ok := result.Result.(mcp.CallToolResult)
+	return !ok || toolResult.IsError
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// benchRound rounds a latency for display.
+func benchRound(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// broadcastDelivery is the outcome of posting the update to one linked ticket.
+type broadcastDelivery struct {
+	TicketID  int    `json:"ticket_id"`
+	Number    string `json:"number"`
+	Title     string `json:"title"`
+	To        string `json:"to,omitempty"` // recipient of an email update
+	Status    string `json:"status"`       // posted, would_post, skipped or failed
+	Reason    string `json:"reason,omitempty"`
+	ArticleID int    `json:"article_id,omitempty"`
+}
+
+// broadcastResult is the outcome of broadcast_update_to_linked_tickets.
+type broadcastResult struct {
+	MasterTicketID int                 `json:"master_ticket_id"`
+	Type           string              `json:"type"`
+	Posted         int                 `json:"posted"`
+	Skipped        int                 `json:"skipped"`
+	Failed         int                 `json:"failed"`
+	Tickets        []broadcastDelivery `json:"tickets"`
+}
+
+// handleBroadcastUpdateToLinkedTickets posts the same public article to every
+// ticket linked to a master ticket as its child, to keep all customers
+// affected by an outage informed. Without confirm it only lists the tickets it
+// would post to. Failures on some tickets do not stop the others.
+func handleBroadcastUpdateToLinkedTickets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	body := mcp.ParseString(request, "body", "")
+	subject := mcp.ParseString(request, "subject", "")
+	articleType := mcp.ParseString(request, "type", "email")
+	includeRelated := mcp.ParseBoolean(request, "include_related", false)
+	includeClosed := mcp.ParseBoolean(request, "include_closed", false)
+	skipSignature := mcp.ParseBoolean(request, "skip_signature", false)
+	confirm := mcp.ParseBoolean(request, "confirm", false)
+	if ticketID <= 0 || strings.TrimSpace(body) == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, body"), nil
+	}
+	if articleType != "email" && articleType != "note" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument type: %q (one of: email, note)", articleType)), nil
+	}
+
+	links, err := fetchTicketLinks(ticketID)
+	if err != nil {
+		log.Printf("Error fetching links of ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get links of ticket %d", ticketID), err), nil
+	}
+	tickets := links.linkedTickets(includeRelated)
+	if len(tickets) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Ticket %d has no linked child tickets; link the affected tickets to it as children first", ticketID)), nil
+	}
+	states, err := fetchTicketStates()
+	if err != nil {
+		log.Printf("Error fetching ticket states from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get ticket states", err), nil
+	}
+	stateTypes := make(map[int]string, len(states))
+	for _, s := range states {
+		stateTypes[s.ID] = s.StateType
+	}
+	groupNames := make(map[int]string)
+	signatures := make(map[int]string) // by group ID, fetched on first use
+	if articleType == "email" && !skipSignature {
+		groups, err := zammadClient.GroupList()
+		if err != nil {
+			log.Printf("Error listing groups from Zammad: %v", err)
+			return mcp.NewToolResultErrorFromErr("Failed to list groups for the signatures (set skip_signature to send without them)", err), nil
+		}
+		for _, g := range groups {
+			groupNames[g.ID] = g.Name
+		}
+	}
+
+	// deliver posts the update to one ticket, or checks that it could.
+	deliver := func(ticket linkedTicket) broadcastDelivery {
+		entry := broadcastDelivery{TicketID: ticket.ID, Number: ticket.Number, Title: ticket.Title, Status: "would_post"}
+		fail := func(reason string, err error) broadcastDelivery {
+			log.Printf("Error posting update to ticket %d: %s: %v", ticket.ID, reason, err)
+			entry.Status, entry.Reason = "failed", fmt.Sprintf("%s: %v", reason, err)
+			return entry
+		}
+		if !includeClosed && !slices.Contains(openStateTypes, stateTypes[ticket.StateID]) {
+			entry.Status, entry.Reason = "skipped", "ticket is closed"
+			return entry
+		}
+		article := zammad.TicketArticle{TicketID: ticket.ID, Subject: subject, ContentType: "text/plain", Type: articleType, Internal: false}
+		if articleType == "email" {
+			customer, err := zammadClient.UserShow(ticket.CustomerID)
+			if err != nil {
+				return fail(fmt.Sprintf("failed to get customer %d", ticket.CustomerID), err)
+			}
+			if customer.Email == "" {
+				entry.Status, entry.Reason = "skipped", "customer has no email address"
+				return entry
+			}
+			article.To, entry.To = customer.Email, customer.Email
+			if article.Subject == "" {
+				article.Subject = ticket.Title
+			}
+		}
+		// Variables such as #{customer.firstname} differ per ticket.
+		var err error
+		if article.Body, err = expandVariables(body, ticket.ID); err != nil {
+			return fail("failed to expand the variables in body", err)
+		}
+		if !confirm {
+			return entry
+		}
+		if articleType == "email" && !skipSignature {
+			signature, ok := signatures[ticket.GroupID]
+			if !ok {
+				if signature, err = groupSignature(groupNames[ticket.GroupID]); err != nil {
+					return fail(fmt.Sprintf("failed to load the signature of group %d", ticket.GroupID), err)
+				}
+				signatures[ticket.GroupID] = signature
+			}
+			article.Body = appendSignature(article.Body, signature)
+		}
+		created, err := zammadClient.TicketArticleCreate(article)
+		if err != nil {
+			return fail("failed to post the article", err)
+		}
+		entry.Status, entry.ArticleID = "posted", created.ID
+		return entry
+	}
+
+	result := broadcastResult{MasterTicketID: ticketID, Type: articleType, Tickets: []broadcastDelivery{}}
+	objects := []actionObject{ticketObject(ticketID)}
+	wouldPost := 0
+	for i, ticket := range tickets {
+		if err := yieldTurn(ctx); err != nil {
+			log.Printf("Broadcast to the tickets linked to ticket %d cancelled: %v", ticketID, err)
+			break
+		}
+		entry := deliver(ticket)
+		switch entry.Status {
+		case "posted":
+			result.Posted++
+			objects = append(objects, ticketObject(ticket.ID), articleObject(ticket.ID, entry.ArticleID))
+		case "would_post":
+			wouldPost++
+		case "skipped":
+			result.Skipped++
+		case "failed":
+			result.Failed++
+		}
+		result.Tickets = append(result.Tickets, entry)
+		sendProgress(ctx, request, float64(i+1), float64(len(tickets)), fmt.Sprintf("%d of %d tickets processed", i+1, len(tickets)))
+	}
+
+	if result.Posted > 0 {
+		sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("posted an update to %d tickets linked to ticket %d", result.Posted, ticketID), nil, objects...)
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal broadcast result: %w", err) // Internal server error
+	}
+	if !confirm {
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: the update would be posted to %d of %d tickets linked to ticket %d. Nothing was posted; call again with confirm set to true to post it.\n%s",
+			wouldPost, len(tickets), ticketID, string(jsonData))), nil
+	}
+	log.Printf("Posted an update to %d tickets linked to ticket %d (%d skipped, %d failed)", result.Posted, ticketID, result.Skipped, result.Failed)
+	summary := fmt.Sprintf("Posted the update to %d of %d tickets linked to ticket %d (%d skipped).", result.Posted, len(tickets), ticketID, result.Skipped)
+	if result.Failed > 0 || len(result.Tickets) < len(tickets) {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: %s\n%s", summary, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(jsonData))), nil
+}
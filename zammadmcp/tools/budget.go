@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// byteSize is a size in bytes read from strings such as "512KiB", "64MB" or
+// "1048576".
+type byteSize int64
+
+var byteSizePattern = regexp.MustCompile(`^(\d+)\s*([kmg]i?b?|b)?$`)
+
+func (s *byteSize) UnmarshalYAML(value *yaml.Node) error {
+	m := byteSizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value.Value)))
+	if m == nil {
+		return fmt.Errorf("line %d: invalid size %q: expected e.g. 512KiB, 64MiB or 1GB", value.Line, value.Value)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid size %q: %v", value.Line, value.Value, err)
+	}
+	unit := strings.TrimSuffix(m[2], "b")
+	base := int64(1000)
+	if strings.HasSuffix(unit, "i") {
+		base = 1024
+		unit = strings.TrimSuffix(unit, "i")
+	}
+	switch unit {
+	case "k":
+		n *= base
+	case "m":
+		n *= base * base
+	case "g":
+		n *= base * base * base
+	}
+	*s = byteSize(n)
+	return nil
+}
+
+func (s byteSize) String() string {
+	switch {
+	case s >= 1<<30 && s%(1<<30) == 0:
+		return fmt.Sprintf("%dGiB", s>>30)
+	case s >= 1<<20 && s%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", s>>20)
+	case s >= 1<<10 && s%(1<<10) == 0:
+		return fmt.Sprintf("%dKiB", s>>10)
+	}
+	return fmt.Sprintf("%d bytes", int64(s))
+}
+
+// memoryBudget tracks the estimated size of what a request has gathered, so
+// that reading a huge instance stops before it exhausts the process's memory.
+type memoryBudget struct {
+	limit byteSize // zero means unlimited
+	used  byteSize
+}
+
+// newMemoryBudget returns a budget of the configured memory_budget.
+func newMemoryBudget() *memoryBudget {
+	return &memoryBudget{limit: config.MemoryBudget}
+}
+
+// charge adds n bytes and reports whether the budget still holds.
+func (b *memoryBudget) charge(n int) bool {
+	b.used += byteSize(n)
+	return b.limit <= 0 || b.used <= b.limit
+}
+
+// exhausted reports whether more than the budget has been charged.
+func (b *memoryBudget) exhausted() bool {
+	return b.limit > 0 && b.used > b.limit
+}
+
+// chargeJSON charges the encoded size of v.
+func (b *memoryBudget) chargeJSON(v any) bool {
+	if b.limit <= 0 {
+		return true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return true // the caller's own encoding will report the error
+	}
+	return b.charge(len(data))
+}
+
+// continuation marks where a listing that stopped at the memory budget
+// resumes: the page to fetch and how many of its entries were returned
+// already. It is passed to clients as an opaque token.
+type continuation struct {
+	Page int `json:"page"`
+	Skip int `json:"skip,omitempty"`
+	// After is the ID of the last entry returned, if known, to resume after
+	// it if entries moved within the page since.
+	After int `json:"after,omitempty"`
+}
+
+func (c continuation) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseContinuation decodes a token produced by continuation.String. An
+// empty token starts at the beginning.
+func parseContinuation(token string) (continuation, error) {
+	c := continuation{Page: 1}
+	if token == "" {
+		return c, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Page < 1 || c.Skip < 0 {
+		return continuation{}, fmt.Errorf("invalid continuation token %q", token)
+	}
+	return c, nil
+}
+
+// listPaged pages through a list endpoint such as /api/v1/users, starting at
+// from. Once the budget is exhausted it stops fetching and returns where to
+// continue; at least one entry is returned per call so listings progress.
+func listPaged[T any](path string, from continuation, budget *memoryBudget) ([]T, *continuation, error) {
+	items := make([]T, 0)
+	skip := from.Skip
+	for page := from.Page; ; page++ {
+		var batch []T
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("%s?page=%d&per_page=100", path, page), nil, &batch); err != nil {
+			return items, nil, err
+		}
+		if len(batch) == 0 {
+			return items, nil, nil
+		}
+		for i := skip; i < len(batch); i++ {
+			if !budget.chargeJSON(batch[i]) && len(items) > 0 {
+				return items, &continuation{Page: page, Skip: i}, nil
+			}
+			items = append(items, batch[i])
+		}
+		skip = 0
+	}
+}
+
+// resourceContinuation returns the position a list resource read starts at,
+// from the continuation argument of its URI template.
+func resourceContinuation(request mcp.ReadResourceRequest) (continuation, error) {
+	var token string
+	switch v := request.Params.Arguments["continuation"].(type) {
+	case string:
+		token = v
+	case []string: // as matched by mcp-go's URI templates
+		if len(v) > 0 {
+			token = v[0]
+		}
+	}
+	from, err := parseContinuation(token)
+	if err != nil {
+		return continuation{}, fmt.Errorf("%w: %w", ErrResourceNotFound, err)
+	}
+	return from, nil
+}
+
+// appendContinuation adds a note with the URI to read next if a list
+// resource stopped at the memory budget.
+func appendContinuation(contents []mcp.ResourceContents, baseURI, what string, count int, next *continuation) []mcp.ResourceContents {
+	if next == nil {
+		return contents
+	}
+	nextURI := fmt.Sprintf("%s?continuation=%s", baseURI, next)
+	log.Printf("Warning: %s stopped after %d %s at the memory budget of %s; continue with %s", baseURI, count, what, config.MemoryBudget, nextURI)
+	return append(contents, mcp.TextResourceContents{
+		URI:      nextURI,
+		MIMEType: "text/plain",
+		Text:     fmt.Sprintf("%d %s are listed; the memory budget of %s was reached. Read %s for the rest.", count, what, config.MemoryBudget, nextURI),
+	})
+}
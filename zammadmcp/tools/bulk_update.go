@@ -0,0 +1,263 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// bulkUpdateMaxTickets bounds the tickets a single bulk_update_tickets call
+// changes.
+const bulkUpdateMaxTickets = 500
+
+// bulkUpdateEntry is the outcome of bulk_update_tickets for one ticket.
+type bulkUpdateEntry struct {
+	TicketID  int      `json:"ticket_id"`
+	Number    string   `json:"number,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Status    string   `json:"status"` // updated, would_update, partial or failed
+	Error     string   `json:"error,omitempty"`
+	TagErrors []string `json:"tag_errors,omitempty"`
+	// QueuedTags are tag changes stored in the offline write queue while
+	// Zammad was unreachable, e.g. "add urgent".
+	QueuedTags []string `json:"queued_tags,omitempty"`
+}
+
+// bulkUpdateResult is the outcome of bulk_update_tickets.
+type bulkUpdateResult struct {
+	Changes []string          `json:"changes"`
+	Updated int               `json:"updated"`
+	Partial int               `json:"partial"` // updated, but some tag changes failed
+	Failed  int               `json:"failed"`
+	Tickets []bulkUpdateEntry `json:"tickets"`
+}
+
+// listArgument returns the values of an array argument as strings. A
+// comma-separated string is accepted too, as some clients flatten arrays.
+func listArgument(request mcp.CallToolRequest, name string) []string {
+	var values []string
+	switch v := request.Params.Arguments[name].(type) {
+	case []any:
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+	case string:
+		values = strings.Split(v, ",")
+	}
+	var list []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// handleBulkUpdateTickets applies the same changes to many tickets, given by
+// ID or by a search query. Names are resolved once up front, so nothing is
+// changed if any of them is unknown. Without confirm it only lists the
+// tickets it would change. Failures on some tickets do not stop the others.
+func handleBulkUpdateTickets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idArgs := listArgument(request, "ticket_ids")
+	query := strings.TrimSpace(mcp.ParseString(request, "query", ""))
+	limit := mcp.ParseInt(request, "limit", 100)
+	stateName := mcp.ParseString(request, "state", "")
+	pendingUntil := mcp.ParseString(request, "pending_until", "")
+	priorityName := mcp.ParseString(request, "priority", "")
+	ownerRef := mcp.ParseString(request, "owner", "")
+	group := mcp.ParseString(request, "group", "")
+	addTags := listArgument(request, "add_tags")
+	removeTags := listArgument(request, "remove_tags")
+	confirm := mcp.ParseBoolean(request, "confirm", false)
+	if (len(idArgs) == 0) == (query == "") {
+		return mcp.NewToolResultError("Missing or invalid required arguments: pass exactly one of ticket_ids and query"), nil
+	}
+	if stateName == "" && priorityName == "" && ownerRef == "" && group == "" && len(addTags) == 0 && len(removeTags) == 0 {
+		return mcp.NewToolResultError("Nothing to update: pass at least one of state, priority, owner, group, add_tags, remove_tags"), nil
+	}
+	if pendingUntil != "" && stateName == "" {
+		return mcp.NewToolResultError("Invalid argument pending_until: only used together with a pending state"), nil
+	}
+	if limit <= 0 || limit > bulkUpdateMaxTickets {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument limit: must be between 1 and %d", bulkUpdateMaxTickets)), nil
+	}
+	var ticketIDs []int
+	for _, arg := range idArgs {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid argument ticket_ids: %q is not a ticket ID", arg)), nil
+		}
+		if !slices.Contains(ticketIDs, id) {
+			ticketIDs = append(ticketIDs, id)
+		}
+	}
+	if len(ticketIDs) > bulkUpdateMaxTickets {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument ticket_ids: at most %d tickets per call", bulkUpdateMaxTickets)), nil
+	}
+
+	var update ticketUpdate
+	var changes []string
+	if stateName != "" {
+		_, change, failure := stateChange(stateName, pendingUntil, &update)
+		if failure != nil {
+			return failure, nil
+		}
+		changes = append(changes, change)
+	}
+	if priorityName != "" {
+		priority, err := resolveTicketPriority(priorityName)
+		if err != nil {
+			log.Printf("Error resolving priority %q: %v", priorityName, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find priority %q", priorityName), err), nil
+		}
+		update.PriorityID = setTo(priority.ID)
+		changes = append(changes, fmt.Sprintf("priority %q", priority.Name))
+	}
+	if ownerRef != "" {
+		owner, err := resolveUser(ownerRef)
+		if err != nil {
+			log.Printf("Error resolving owner %q: %v", ownerRef, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the owner %q", ownerRef), err), nil
+		}
+		update.OwnerID = setTo(owner.ID)
+		changes = append(changes, fmt.Sprintf("owner %s", userDisplayName(owner)))
+	}
+	if group != "" {
+		groupID, err := groupIDByName(group)
+		if err != nil {
+			log.Printf("Error resolving group %q: %v", group, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+		}
+		update.GroupID = setTo(groupID)
+		changes = append(changes, fmt.Sprintf("group %q", group))
+	}
+	for _, tag := range addTags {
+		changes = append(changes, fmt.Sprintf("add tag %q", tag))
+	}
+	for _, tag := range removeTags {
+		changes = append(changes, fmt.Sprintf("remove tag %q", tag))
+	}
+	summary := strings.Join(changes, ", ")
+
+	found := make(map[int]ticketRecord)
+	if query != "" {
+		tickets, err := searchTicketRecords(query, limit+1)
+		if err != nil {
+			log.Printf("Error searching tickets in Zammad: %v", err)
+			return mcp.NewToolResultErrorFromErr("Failed to search tickets", err), nil
+		}
+		if len(tickets) > limit {
+			return mcp.NewToolResultError(fmt.Sprintf("The query matches more than %d tickets; narrow it down or raise limit (at most %d). Nothing was changed.", limit, bulkUpdateMaxTickets)), nil
+		}
+		if len(tickets) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("No tickets match the query %q; nothing was changed.", query)), nil
+		}
+		for _, t := range tickets {
+			ticketIDs = append(ticketIDs, t.ID)
+			found[t.ID] = t
+		}
+	}
+
+	// apply changes a ticket, or checks that it exists.
+	apply := func(ticketID int) bulkUpdateEntry {
+		entry := bulkUpdateEntry{TicketID: ticketID, Status: "would_update"}
+		ticket, ok := found[ticketID]
+		if !ok {
+			var err error
+			if ticket, err = fetchTicket(ticketID); err != nil {
+				log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+				entry.Status, entry.Error = "failed", fmt.Sprintf("failed to get the ticket: %v", err)
+				return entry
+			}
+		}
+		entry.Number, entry.Title = ticket.Number, ticket.Title
+		if !confirm {
+			return entry
+		}
+		if !update.empty() {
+			if _, err := updateTicket(ticketID, update); err != nil {
+				log.Printf("Error updating ticket %d in Zammad: %v", ticketID, err)
+				entry.Status, entry.Error = "failed", err.Error()
+				return entry
+			}
+		}
+		for _, tag := range addTags {
+			if err := addTicketTag(ticketID, tag); err != nil {
+				if queueWriteOnOutage(queuedWrite{Kind: queuedTagAdd, TicketID: ticketID, Tag: tag}, err) != "" {
+					entry.QueuedTags = append(entry.QueuedTags, "add "+tag)
+					continue
+				}
+				log.Printf("Error tagging ticket %d with %q: %v", ticketID, tag, err)
+				entry.TagErrors = append(entry.TagErrors, fmt.Sprintf("add %s: %v", tag, err))
+			}
+		}
+		for _, tag := range removeTags {
+			if err := removeTicketTag(ticketID, tag); err != nil {
+				if queueWriteOnOutage(queuedWrite{Kind: queuedTagRemove, TicketID: ticketID, Tag: tag}, err) != "" {
+					entry.QueuedTags = append(entry.QueuedTags, "remove "+tag)
+					continue
+				}
+				log.Printf("Error removing tag %q from ticket %d: %v", tag, ticketID, err)
+				entry.TagErrors = append(entry.TagErrors, fmt.Sprintf("remove %s: %v", tag, err))
+			}
+		}
+		entry.Status = "updated"
+		if len(entry.TagErrors) > 0 {
+			entry.Status = "partial"
+		}
+		return entry
+	}
+
+	result := bulkUpdateResult{Changes: changes, Tickets: []bulkUpdateEntry{}}
+	var objects []actionObject
+	wouldUpdate := 0
+	for i, ticketID := range ticketIDs {
+		if err := yieldTurn(ctx); err != nil {
+			log.Printf("Bulk update cancelled after %d of %d tickets: %v", i, len(ticketIDs), err)
+			break
+		}
+		entry := apply(ticketID)
+		switch entry.Status {
+		case "updated":
+			result.Updated++
+		case "would_update":
+			wouldUpdate++
+		case "partial":
+			result.Partial++
+		case "failed":
+			result.Failed++
+		}
+		if entry.Status == "updated" || entry.Status == "partial" {
+			objects = append(objects, ticketObject(ticketID))
+		}
+		result.Tickets = append(result.Tickets, entry)
+		sendProgress(ctx, request, float64(i+1), float64(len(ticketIDs)), fmt.Sprintf("%d of %d tickets processed", i+1, len(ticketIDs)))
+	}
+
+	if len(objects) > 0 {
+		sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("bulk-updated %d tickets: %s", len(objects), summary), nil, objects...)
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bulk update result: %w", err) // Internal server error
+	}
+	if !confirm {
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: %d of %d tickets would be updated (%s). Nothing was changed; call again with confirm set to true to apply the changes.\n%s",
+			wouldUpdate, len(ticketIDs), summary, string(jsonData))), nil
+	}
+	log.Printf("Bulk-updated %d of %d tickets (%d partially, %d failed): %s", result.Updated, len(ticketIDs), result.Partial, result.Failed, summary)
+	message := fmt.Sprintf("Updated %d of %d tickets (%s).", result.Updated, len(ticketIDs), summary)
+	if result.Partial > 0 {
+		message += fmt.Sprintf(" %d more were updated, but some of their tag changes failed.", result.Partial)
+	}
+	if result.Failed > 0 || result.Partial > 0 || len(result.Tickets) < len(ticketIDs) {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: %s\n%s", message, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", message, string(jsonData))), nil
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// cachedTools are the read-only tools whose results may be served from the
+// response cache. Calling any other tool may change Zammad data and clears the
+// cache.
+var cachedTools = map[string]bool{
+	"get_ticket":                   true,
+	"search_tickets":               true,
+	"diff_ticket_changes":          true,
+	"who_touched_ticket":           true,
+	"get_ticket_timeline":          true,
+	"get_allowed_transitions":      true,
+	"list_ticket_templates":        true,
+	"list_unassigned_tickets":      true,
+	"list_awaiting_first_response": true,
+	"list_waiting_on_agent":        true,
+	"get_user":                     true,
+	"search_users":                 true,
+	"get_ticket_articles":          true,
+	"get_ticket_seen_state":        true,
+	"search_in_ticket":             true,
+	"get_attachment_text":          true,
+	"get_attachment_image":         true,
+	"export_ticket_document":       true,
+	"get_organization":             true,
+	"find_duplicate_organizations": true,
+	"report_ticket_trends":         true,
+	"report_tag_usage":             true,
+	"report_csat":                  true,
+	"report_linked_incidents":      true,
+	"report_channel_health":        true,
+	"report_ticket_aging":          true,
+}
+
+// cachedResponse is a tool result and the time it stops being served.
+type cachedResponse struct {
+	result  *mcp.CallToolResult
+	expires time.Time
+}
+
+// responseCache holds recent results of read-only tools by tool and
+// arguments, so an agent that repeats the same lookup in quick succession does
+// not hit Zammad every time.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	// generation counts the clears, so a result read before a write is
+	// not stored after it.
+	generation uint64
+}
+
+var toolResponses = &responseCache{entries: make(map[string]cachedResponse)}
+
+// noCacheOption is the no_cache argument of cached tools.
+func noCacheOption() mcp.ToolOption {
+	return mcp.WithBoolean("no_cache", mcp.Description("Read fresh data from Zammad instead of a result cached from an identical call in the last few seconds. Default: false."))
+}
+
+// responseCacheKey identifies a call by tool name and arguments, except
+// no_cache.
+func responseCacheKey(request mcp.CallToolRequest) string {
+	args := make(map[string]any, len(request.Params.Arguments))
+	for name, value := range request.Params.Arguments {
+		if name != "no_cache" {
+			args[name] = value
+		}
+	}
+	// Map keys are marshalled in sorted order, so equal arguments give equal
+	// keys.
+	data, _ := json.Marshal(map[string]any{"tool": request.Params.Name, "args": args})
+	return string(data)
+}
+
+func (c *responseCache) get(key string) *mcp.CallToolResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.result
+}
+
+// current returns the generation to pass to put for a result about to be
+// read.
+func (c *responseCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores result unless the cache was cleared since generation, as the
+// result may then predate a write.
+func (c *responseCache) put(key string, result *mcp.CallToolResult, ttl time.Duration, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedResponse{result: result, expires: now.Add(ttl)}
+}
+
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.generation++
+}
+
+// withResponseCache serves repeated calls of read-only tools with identical
+// arguments from a cache for cache_ttl. Error results are not cached, and a
+// call with no_cache set reads fresh data and refreshes the cached result.
+// Any other tool call clears the cache before and after it runs, so the model
+// sees its own changes and reads running alongside it do not cache data from
+// before them.
+func withResponseCache(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ttl := time.Duration(config.CacheTTL)
+		if ttl <= 0 {
+			return next(ctx, request)
+		}
+		if !cachedTools[request.Params.Name] {
+			toolResponses.clear()
+			defer toolResponses.clear()
+			return next(ctx, request)
+		}
+
+		key := responseCacheKey(request)
+		if !mcp.ParseBoolean(request, "no_cache", false) {
+			if result := toolResponses.get(key); result != nil {
+				log.Printf("Serving tool call %s from the response cache", request.Params.Name)
+				return result, nil
+			}
+		}
+		generation := toolResponses.current()
+		result, err := next(ctx, request)
+		if err == nil && result != nil && !result.IsError {
+			toolResponses.put(key, result, ttl, generation)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// changeCustomerResult is the outcome of a change_ticket_customer call.
+type changeCustomerResult struct {
+	Ticket   json.RawMessage `json:"ticket"` // ticketRecord in the requested output profile
+	Customer userRecord      `json:"customer"`
+	// Created is set when the customer was created for the call.
+	Created bool `json:"customer_created,omitempty"`
+}
+
+// resolveTicketCustomer finds the user given as a user ID or email address,
+// and with create set creates a customer for an unknown address. It reports
+// whether the customer was created.
+func resolveTicketCustomer(ref string, create bool) (userRecord, bool, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.Atoi(ref); err == nil {
+		user, err := fetchUserRecord(id)
+		return user, false, err
+	}
+	address, err := mail.ParseAddress(ref)
+	if err != nil {
+		return userRecord{}, false, fmt.Errorf("%q is neither a user ID nor an email address", ref)
+	}
+	id, created, err := findOrCreateCustomer(address, create)
+	if err != nil {
+		return userRecord{}, false, err
+	}
+	user, err := fetchUserRecord(id)
+	return user, created, err
+}
+
+// handleChangeTicketCustomer moves a ticket to another customer, e.g. one
+// that was filed under the wrong requester. The ticket's organization follows
+// the customer's, and is cleared for customers without one.
+func handleChangeTicketCustomer(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	customerRef := mcp.ParseString(request, "customer", "")
+	create := mcp.ParseBoolean(request, "create_customer", false)
+	if ticketID <= 0 || strings.TrimSpace(customerRef) == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, customer"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	customer, created, err := resolveTicketCustomer(customerRef, create)
+	if err != nil {
+		log.Printf("Error resolving customer %q: %v", customerRef, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the customer %q", customerRef), err), nil
+	}
+	name := userDisplayName(customer.User)
+	if before.CustomerID == customer.ID {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d already belongs to %s; nothing changed.", ticketID, name)), nil
+	}
+
+	update := ticketUpdate{CustomerID: setTo(customer.ID), OrganizationID: cleared[int]()}
+	if customer.OrganizationID != 0 {
+		update.OrganizationID = setTo(customer.OrganizationID)
+	}
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error changing the customer of ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to change the customer of ticket %d", ticketID), err), nil
+	}
+	summary := fmt.Sprintf("changed the customer of ticket %d from user %d to %s", ticketID, before.CustomerID, name)
+	sessionActions.record(ctx, request.Params.Name, summary, ticketUndo(before, ticket.UpdatedAt, update), ticketObject(ticketID), userObject(customer.ID))
+
+	log.Printf("Changed the customer of ticket %d from user %d to %d", ticketID, before.CustomerID, customer.ID)
+	ticketData, err := profile.project(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	jsonData, err := json.MarshalIndent(changeCustomerResult{Ticket: ticketData, Customer: customer, Created: created}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the customer change of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	header := fmt.Sprintf("Ticket %d now belongs to %s", ticketID, name)
+	if created {
+		header += " (new customer)"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
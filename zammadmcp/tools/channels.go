@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// channelFetchStaleAfter is how long an active email channel may go without
+// fetching mail before it is reported. Zammad fetches about every minute.
+const channelFetchStaleAfter = 15 * time.Minute
+
+// emailChannel is an email channel as listed by /api/v1/channels_email. Its
+// options also hold the account passwords, so only the fields needed for the
+// report are decoded.
+type emailChannel struct {
+	ID      int    `json:"id"`
+	Area    string `json:"area"`
+	GroupID int    `json:"group_id"`
+	Active  bool   `json:"active"`
+	Options struct {
+		Inbound  channelAdapter `json:"inbound"`
+		Outbound channelAdapter `json:"outbound"`
+	} `json:"options"`
+	Preferences struct {
+		LastFetch *time.Time `json:"last_fetch"`
+	} `json:"preferences"`
+	StatusIn   string `json:"status_in"`
+	StatusOut  string `json:"status_out"`
+	LastLogIn  string `json:"last_log_in"`
+	LastLogOut string `json:"last_log_out"`
+}
+
+// channelAdapter is the inbound or outbound side of an email channel.
+type channelAdapter struct {
+	Adapter string `json:"adapter"`
+	Options struct {
+		Host string `json:"host"`
+		User string `json:"user"`
+	} `json:"options"`
+}
+
+// channelDirectionHealth is the state of one side of a channel.
+type channelDirectionHealth struct {
+	Adapter string `json:"adapter,omitempty"`
+	Host    string `json:"host,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// channelHealth is the report entry of one email channel.
+type channelHealth struct {
+	ID           int                    `json:"id"`
+	Addresses    []string               `json:"addresses,omitempty"`
+	Group        string                 `json:"group,omitempty"`
+	Active       bool                   `json:"active"`
+	Inbound      channelDirectionHealth `json:"inbound"`
+	Outbound     channelDirectionHealth `json:"outbound"`
+	LastFetch    *time.Time             `json:"last_fetch,omitempty"`
+	LastFetchAgo string                 `json:"last_fetch_ago,omitempty"`
+	Problems     []string               `json:"problems,omitempty"`
+}
+
+// channelHealthReport is the result of report_channel_health.
+type channelHealthReport struct {
+	Status   string          `json:"status"` // "ok", or "problems" if any active channel has one
+	Channels []channelHealth `json:"channels"`
+}
+
+// fetchEmailChannels returns the email channels of the instance, with the
+// addresses sending through each of them.
+func fetchEmailChannels() ([]emailChannel, map[int][]string, error) {
+	var result struct {
+		ChannelIDs []int `json:"channel_ids"`
+		Assets     struct {
+			Channel      map[string]emailChannel `json:"Channel"`
+			EmailAddress map[string]struct {
+				ChannelID int    `json:"channel_id"`
+				Email     string `json:"email"`
+			} `json:"EmailAddress"`
+		} `json:"assets"`
+	}
+	if err := zammadRequest(http.MethodGet, "/api/v1/channels_email", nil, &result); err != nil {
+		return nil, nil, err
+	}
+	channels := make([]emailChannel, 0, len(result.ChannelIDs))
+	for _, id := range result.ChannelIDs {
+		if channel, ok := result.Assets.Channel[fmt.Sprint(id)]; ok {
+			channels = append(channels, channel)
+		}
+	}
+	addresses := make(map[int][]string)
+	for _, address := range result.Assets.EmailAddress {
+		addresses[address.ChannelID] = append(addresses[address.ChannelID], address.Email)
+	}
+	for _, list := range addresses {
+		sort.Strings(list)
+	}
+	return channels, addresses, nil
+}
+
+// assessChannel builds the report entry of a channel.
+func assessChannel(channel emailChannel, addresses []string, groupNames map[int]string, now time.Time) channelHealth {
+	health := channelHealth{
+		ID:        channel.ID,
+		Addresses: addresses,
+		Group:     groupNames[channel.GroupID],
+		Active:    channel.Active,
+		Inbound: channelDirectionHealth{
+			Adapter: channel.Options.Inbound.Adapter,
+			Host:    channel.Options.Inbound.Options.Host,
+			Status:  channelStatus(channel.StatusIn),
+			Message: channel.LastLogIn,
+		},
+		Outbound: channelDirectionHealth{
+			Adapter: channel.Options.Outbound.Adapter,
+			Host:    channel.Options.Outbound.Options.Host,
+			Status:  channelStatus(channel.StatusOut),
+			Message: channel.LastLogOut,
+		},
+		LastFetch: channel.Preferences.LastFetch,
+	}
+	if !channel.Active {
+		return health
+	}
+	if health.Inbound.Status == "error" {
+		health.Problems = append(health.Problems, "fetching mail fails: "+orUnknown(channel.LastLogIn))
+	}
+	if health.Outbound.Status == "error" {
+		health.Problems = append(health.Problems, "sending mail fails: "+orUnknown(channel.LastLogOut))
+	}
+	// Send-only channels (adapter "null" or none) never fetch.
+	if inbound := channel.Options.Inbound.Adapter; inbound != "" && inbound != "null" {
+		switch {
+		case health.LastFetch == nil:
+			health.Problems = append(health.Problems, "mail has never been fetched")
+		case now.Sub(*health.LastFetch) > channelFetchStaleAfter:
+			health.Problems = append(health.Problems, fmt.Sprintf("no mail fetched for %s; is the scheduler running?", formatDuration(now.Sub(*health.LastFetch))))
+		}
+	}
+	if health.LastFetch != nil {
+		health.LastFetchAgo = formatDuration(now.Sub(*health.LastFetch))
+	}
+	return health
+}
+
+// channelStatus normalizes a channel status; Zammad leaves it empty until the
+// first fetch or delivery.
+func channelStatus(status string) string {
+	if status == "" {
+		return "unknown"
+	}
+	return status
+}
+
+func orUnknown(message string) string {
+	if strings.TrimSpace(message) == "" {
+		return "no details logged"
+	}
+	return message
+}
+
+// handleReportChannelHealth reports the state of the email channels: fetch
+// and delivery errors and when mail was last fetched.
+func handleReportChannelHealth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channels, addresses, err := fetchEmailChannels()
+	if err != nil {
+		var apiErr *zammadAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+			return mcp.NewToolResultError("Reading email channels requires the admin.channel_email permission, which the API token does not have."), nil
+		}
+		log.Printf("Error fetching email channels from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get email channels", err), nil
+	}
+	groupNames := make(map[int]string)
+	if groups, err := zammadClient.GroupList(); err != nil {
+		log.Printf("Could not list groups for the channel report: %v", err)
+	} else {
+		for _, g := range groups {
+			groupNames[g.ID] = g.Name
+		}
+	}
+
+	report := channelHealthReport{Status: "ok", Channels: make([]channelHealth, 0, len(channels))}
+	now := time.Now()
+	unhealthy := 0
+	for _, channel := range channels {
+		health := assessChannel(channel, addresses[channel.ID], groupNames, now)
+		if len(health.Problems) > 0 {
+			report.Status = "problems"
+			unhealthy++
+		}
+		report.Channels = append(report.Channels, health)
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal channel health: %w", err) // Internal server error
+	}
+	var summary string
+	switch {
+	case len(channels) == 0:
+		summary = "No email channels are configured."
+	case unhealthy == 0:
+		summary = fmt.Sprintf("All %d email channels are healthy.", len(channels))
+	default:
+		summary = fmt.Sprintf("%d of %d email channels have problems.", unhealthy, len(channels))
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(jsonData))), nil
+}
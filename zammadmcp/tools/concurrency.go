@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// expectedUpdatedAtDescription documents the optimistic concurrency argument
+// shared by the tools that modify tickets.
+const expectedUpdatedAtDescription = "Optional updated_at value (RFC 3339) of the ticket as last read. If the ticket changed since then, the call is aborted with a conflict error containing the current values."
+
+// checkTicketUnchanged implements optimistic concurrency for ticket writes.
+// If expected is empty it does nothing. Otherwise it compares expected with
+// the ticket's current updated_at and returns a conflict result (including the
+// current ticket) when they differ; a nil result means the write may proceed.
+// The check narrows but cannot fully close the window for concurrent edits,
+// as Zammad offers no conditional update.
+func checkTicketUnchanged(ticketID int, expected string) (*mcp.CallToolResult, error) {
+	if expected == "" {
+		return nil, nil
+	}
+	expectedAt, err := time.Parse(time.RFC3339Nano, expected)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid expected_updated_at %q: must be an RFC 3339 timestamp such as 2024-05-01T14:02:00Z", expected)), nil
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d for concurrency check: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if ticket.UpdatedAt.Equal(expectedAt) {
+		return nil, nil
+	}
+
+	log.Printf("Conflict on ticket %d: expected updated_at %s, current %s", ticketID, expectedAt.Format(time.RFC3339Nano), ticket.UpdatedAt.Format(time.RFC3339Nano))
+	jsonData, err := json.MarshalIndent(ticket, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultError(fmt.Sprintf(localized(msgConflict),
+		ticketID, ticket.UpdatedAt.Format(time.RFC3339), ticket.UpdatedByID, expectedAt.Format(time.RFC3339), string(jsonData))), nil
+}
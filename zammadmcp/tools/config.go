@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings of the toolset that are too structured for
+// environment variables. The command reads it from the YAML file named by
+// ZAMMAD_MCP_CONFIG; programs embedding the toolset start from DefaultConfig
+// or LoadConfig.
+type Config struct {
+	// ToolTimeout bounds every tool call that has no entry in ToolTimeouts.
+	ToolTimeout duration `yaml:"tool_timeout"`
+	// ToolTimeouts overrides ToolTimeout per tool name.
+	ToolTimeouts map[string]duration `yaml:"tool_timeouts"`
+	// HTTPTimeout bounds each request to the Zammad API, including reading
+	// the response body.
+	HTTPTimeout duration `yaml:"http_timeout"`
+	// MemoryBudget bounds the estimated size of what a single resource read
+	// or export gathers; zero disables the limit.
+	MemoryBudget byteSize `yaml:"memory_budget"`
+	// CacheTTL is how long results of read-only tools are reused for
+	// identical calls; zero disables the response cache.
+	CacheTTL duration `yaml:"cache_ttl"`
+	// TokenBudget is the estimated size, in tokens, from which tool results
+	// are condensed; zero disables the guard.
+	TokenBudget int `yaml:"token_budget"`
+	// ResponseLanguage is the ISO 639-1 code of the language of the prose the
+	// server writes itself, such as notices and note subjects.
+	ResponseLanguage string `yaml:"response_language"`
+	// Spam is the workflow applied by mark_as_spam.
+	Spam spamWorkflow `yaml:"spam"`
+	// PriorityMatrix is the impact/urgency matrix of suggest_priority.
+	PriorityMatrix priorityMatrix `yaml:"priority_matrix"`
+	// OutputProfile names the profile used by ticket tools that are not
+	// given one; OutputProfiles defines the profiles by name.
+	OutputProfile  string                   `yaml:"output_profile"`
+	OutputProfiles map[string]outputProfile `yaml:"output_profiles"`
+	// AutoAssign configures auto_assign_ticket.
+	AutoAssign autoAssignSettings `yaml:"auto_assign"`
+	// CSAT tells report_csat where satisfaction ratings are stored.
+	CSAT csatSettings `yaml:"csat"`
+	// Attachments limits the attachments passed through the server.
+	Attachments attachmentPolicy `yaml:"attachments"`
+	// FenceCustomerContent strips hidden content from article bodies and
+	// wraps customer-authored ones in labeled delimiters before they are
+	// shown to the model.
+	FenceCustomerContent bool `yaml:"fence_customer_content"`
+	// LenientArguments repairs tool arguments sent under camelCase or
+	// alias names, or as strings instead of numbers and booleans; see
+	// withLenientArguments.
+	LenientArguments bool `yaml:"lenient_arguments"`
+	// UndoHistory is how many recent writes of each session are kept for
+	// undo_last_action; zero disables the history.
+	UndoHistory int `yaml:"undo_history"`
+	// ApprovalMode stages calls of write tools for human review instead of
+	// running them; see withApproval.
+	ApprovalMode bool `yaml:"approval_mode"`
+	// AllowDelete enables the delete_ticket tool. It is not read from the
+	// file but from ZAMMAD_MCP_ALLOW_DELETE, so deletion is a deliberate
+	// choice of whoever runs the server.
+	AllowDelete bool `yaml:"-"`
+	// Access restricts which tools may be called; see withToolAccess.
+	Access accessSettings `yaml:"access"`
+	// RateLimit bounds the tool calls of each session; see withRateLimit.
+	RateLimit rateLimitSettings `yaml:"rate_limit"`
+	// AuditLog is the file every tool call is appended to; empty disables
+	// the audit log.
+	AuditLog string `yaml:"audit_log"`
+	// DryRun answers calls that change Zammad data with the call that would
+	// be made instead of running them.
+	DryRun bool `yaml:"dry_run"`
+	// Translation is the endpoint get_ticket_articles translates bodies
+	// with.
+	Translation translationSettings `yaml:"translation"`
+	// SlowCallThreshold is the duration from which a Zammad request is
+	// logged as slow; zero disables the warning.
+	SlowCallThreshold duration `yaml:"slow_call_threshold"`
+	// Fairness schedules the tool calls of concurrent sessions on the sse
+	// transport.
+	Fairness fairnessSettings `yaml:"fair_scheduling"`
+	// BenchCalls is the workload of the bench subcommand; empty uses
+	// defaultBenchCalls.
+	BenchCalls []benchCall `yaml:"bench_calls"`
+	// Schedules are read-only tools run on a cron schedule, with their
+	// output posted to a webhook or a ticket.
+	Schedules []scheduledReport `yaml:"schedules"`
+	// Intake creates tickets from files dropped into a directory.
+	Intake intakeSettings `yaml:"intake"`
+	// Idempotency recognizes retried create_ticket calls by their
+	// request_id.
+	Idempotency idempotencySettings `yaml:"idempotency"`
+}
+
+// config holds the settings in effect, installed by Setup.
+var config = DefaultConfig()
+
+// DefaultConfig returns the settings used where the configuration file has
+// none. They give long-running tools more time.
+func DefaultConfig() Config {
+	return Config{
+		ToolTimeout: duration(60 * time.Second),
+		ToolTimeouts: map[string]duration{
+			"import_tickets":              duration(10 * time.Minute),
+			"import_users":                duration(10 * time.Minute),
+			"bulk_update_tickets":         duration(10 * time.Minute),
+			"export_organization_history": duration(10 * time.Minute),
+			"summarize_and_note":          duration(summarySamplingTimeout + time.Minute),
+			"report_tag_usage":            duration(5 * time.Minute),
+			"report_csat":                 duration(5 * time.Minute),
+		},
+		HTTPTimeout:      duration(30 * time.Second),
+		MemoryBudget:     64 << 20,
+		CacheTTL:         duration(5 * time.Second),
+		TokenBudget:      25000,
+		ResponseLanguage: "en",
+		Spam:             spamWorkflow{Tag: "spam", State: "closed"},
+		PriorityMatrix:   defaultPriorityMatrix,
+		OutputProfile:    "full",
+		OutputProfiles:   defaultOutputProfiles,
+		AutoAssign:       autoAssignSettings{Strategy: assignLeastOpen},
+		CSAT:             defaultCSATSettings,
+		Attachments:      defaultAttachmentPolicy,
+
+		LenientArguments:  true,
+		UndoHistory:       20,
+		Fairness:          fairnessSettings{MaxConcurrent: 8, BulkWeight: 4},
+		SlowCallThreshold: duration(2 * time.Second),
+		Translation:       translationSettings{TargetLanguage: "en"},
+		Intake:            intakeSettings{CreateCustomers: true, PollInterval: duration(30 * time.Second)},
+		RateLimit:         rateLimitSettings{Burst: 10},
+		Idempotency:       idempotencySettings{TTL: duration(24 * time.Hour)},
+	}
+}
+
+// duration is a time.Duration read from strings such as "30s" or "5m".
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q: expected e.g. 30s or 5m", value.Line, value.Value)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("line %d: duration %q must not be negative", value.Line, value.Value)
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// LoadConfig reads the configuration file at path on top of the defaults.
+func LoadConfig(path string) (Config, error) {
+	settings := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return settings, err
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := settings.validate(); err != nil {
+		return settings, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// validate checks the settings and normalizes response_language.
+func (c *Config) validate() error {
+	if err := c.PriorityMatrix.validate(); err != nil {
+		return err
+	}
+	if _, ok := c.OutputProfiles[c.OutputProfile]; !ok {
+		return fmt.Errorf("output_profile %q is not defined", c.OutputProfile)
+	}
+	if s := c.AutoAssign.Strategy; s != assignLeastOpen && s != assignRoundRobin {
+		return fmt.Errorf("auto_assign.strategy %q must be %s or %s", s, assignLeastOpen, assignRoundRobin)
+	}
+	if c.Fairness.MaxConcurrent < 0 || c.Fairness.BulkWeight < 0 {
+		return errors.New("fair_scheduling.max_concurrent and bulk_weight must not be negative")
+	}
+	if err := c.Translation.validate(); err != nil {
+		return err
+	}
+	if err := c.CSAT.validate(); err != nil {
+		return err
+	}
+	if c.Attachments.ImageMaxDimension < 0 || c.Attachments.ImageQuality < 1 || c.Attachments.ImageQuality > 100 {
+		return errors.New("attachments.image_max_dimension must not be negative and attachments.image_quality must be between 1 and 100")
+	}
+	if c.UndoHistory < 0 {
+		return errors.New("undo_history must not be negative")
+	}
+	if c.TokenBudget < 0 {
+		return errors.New("token_budget must not be negative")
+	}
+	c.ResponseLanguage = strings.ToLower(strings.TrimSpace(c.ResponseLanguage))
+	if !languageCodePattern.MatchString(c.ResponseLanguage) {
+		return errors.New("response_language must be a two-letter ISO 639-1 code such as en or de")
+	}
+	for i := range c.Schedules {
+		if err := c.Schedules[i].validate(i); err != nil {
+			return err
+		}
+	}
+	if err := c.Intake.validate(); err != nil {
+		return err
+	}
+	if err := c.Access.validate(); err != nil {
+		return err
+	}
+	if err := c.RateLimit.validate(); err != nil {
+		return err
+	}
+	if err := c.Idempotency.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// toolTimeout returns the timeout for calls of the named tool; zero means no
+// timeout.
+func toolTimeout(name string) time.Duration {
+	if d, ok := config.ToolTimeouts[name]; ok {
+		return time.Duration(d)
+	}
+	return time.Duration(config.ToolTimeout)
+}
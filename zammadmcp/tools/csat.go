@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// csatReportTicketLimit caps the rated tickets per period report_csat reads.
+const csatReportTicketLimit = 2000
+
+// csatSettings describes where an instance stores customer satisfaction
+// ratings, configured in the csat section of the configuration file: in a
+// ticket attribute (such as a custom field filled by a survey add-on) or as
+// tags.
+type csatSettings struct {
+	// Field is the ticket attribute holding the rating.
+	Field string `yaml:"field"`
+	// Values maps values of Field to ratings, for fields that store labels
+	// such as "good". Without it, the values must be numbers.
+	Values map[string]float64 `yaml:"values"`
+	// Tags maps tags to ratings, for instances that tag rated tickets
+	// instead. Exclusive with Field.
+	Tags map[string]float64 `yaml:"tags"`
+	// Scale is the best rating; ratings from SatisfiedFrom count as
+	// satisfied.
+	Scale         float64 `yaml:"scale"`
+	SatisfiedFrom float64 `yaml:"satisfied_from"`
+	// DateField is the ticket timestamp that places a rating in a period.
+	DateField string `yaml:"date_field"`
+}
+
+var defaultCSATSettings = csatSettings{Scale: 5, SatisfiedFrom: 4, DateField: "close_at"}
+
+func (c csatSettings) validate() error {
+	if c.Field != "" && len(c.Tags) > 0 {
+		return fmt.Errorf("csat.field and csat.tags are exclusive")
+	}
+	if c.Scale <= 0 || c.SatisfiedFrom <= 0 || c.SatisfiedFrom > c.Scale {
+		return fmt.Errorf("csat.satisfied_from must be between 0 and csat.scale")
+	}
+	for name, ratings := range map[string]map[string]float64{"values": c.Values, "tags": c.Tags} {
+		for key, rating := range ratings {
+			if rating < 0 || rating > c.Scale {
+				return fmt.Errorf("csat.%s: rating %v of %q is outside the scale of %v", name, rating, key, c.Scale)
+			}
+		}
+	}
+	if c.DateField == "" {
+		return fmt.Errorf("csat.date_field must not be empty")
+	}
+	return nil
+}
+
+// configured reports whether a rating source is set.
+func (c csatSettings) configured() bool {
+	return c.Field != "" || len(c.Tags) > 0
+}
+
+// query returns the search clause matching rated tickets.
+func (c csatSettings) query() string {
+	if c.Field != "" {
+		return " AND _exists_:" + c.Field
+	}
+	tags := make([]string, 0, len(c.Tags))
+	for tag := range c.Tags {
+		tags = append(tags, strconv.Quote(tag))
+	}
+	sort.Strings(tags)
+	return fmt.Sprintf(" AND tags:(%s)", strings.Join(tags, " OR "))
+}
+
+// fieldRating converts a value of the rating field to a rating. ok is false
+// for unrated tickets; err is set for values that cannot be mapped.
+func (c csatSettings) fieldRating(value any) (rating float64, ok bool, err error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		if c.Values != nil {
+			return c.fieldRating(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return v, true, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return 0, false, nil
+		}
+		for label, rating := range c.Values {
+			if strings.EqualFold(label, v) {
+				return rating, true, nil
+			}
+		}
+		if c.Values == nil {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return n, true, nil
+			}
+		}
+		return 0, false, fmt.Errorf("value %q is not mapped to a rating", v)
+	default:
+		return 0, false, fmt.Errorf("value %v is not a rating", v)
+	}
+}
+
+// tagRating returns the rating of the first rating tag among tags.
+func (c csatSettings) tagRating(tags []string) (float64, bool) {
+	for _, tag := range tags {
+		if rating, ok := c.Tags[tag]; ok {
+			return rating, true
+		}
+	}
+	return 0, false
+}
+
+// csatSummary aggregates the ratings of a period.
+type csatSummary struct {
+	Responses    int            `json:"responses"`
+	Average      float64        `json:"average"`
+	Satisfied    int            `json:"satisfied"`
+	CSAT         string         `json:"csat"` // share of satisfied responses
+	Distribution map[string]int `json:"distribution"`
+	Unmapped     int            `json:"unmapped,omitempty"` // ratings that could not be read
+	sum          float64
+}
+
+func newCSATSummary() *csatSummary {
+	return &csatSummary{Distribution: make(map[string]int), CSAT: "n/a"}
+}
+
+func (s *csatSummary) add(rating float64) {
+	s.Responses++
+	s.sum += rating
+	if rating >= config.CSAT.SatisfiedFrom {
+		s.Satisfied++
+	}
+	s.Distribution[strconv.FormatFloat(rating, 'f', -1, 64)]++
+	s.Average = math.Round(s.sum/float64(s.Responses)*100) / 100
+	s.CSAT = fmt.Sprintf("%.0f%%", float64(s.Satisfied)*100/float64(s.Responses))
+}
+
+// groupCSAT is the summary of the current period for one group.
+type groupCSAT struct {
+	Group string `json:"group"`
+	*csatSummary
+}
+
+// csatReport is the result of report_csat.
+type csatReport struct {
+	Period         string       `json:"period"`
+	Group          string       `json:"group,omitempty"`
+	Scale          float64      `json:"scale"`
+	SatisfiedFrom  float64      `json:"satisfied_from"`
+	CurrentPeriod  reportWindow `json:"current_period"`
+	PreviousPeriod reportWindow `json:"previous_period"`
+	Current        *csatSummary `json:"current"`
+	Previous       *csatSummary `json:"previous"`
+	AverageChange  string       `json:"average_change,omitempty"`
+	Groups         []groupCSAT  `json:"groups,omitempty"` // current period, by responses
+	Truncated      bool         `json:"truncated,omitempty"`
+}
+
+// periodCSAT collects the ratings of the tickets in a window, overall and by
+// group ID, up to csatReportTicketLimit rated tickets.
+type periodCSAT struct {
+	total     *csatSummary
+	byGroup   map[int]*csatSummary
+	truncated bool
+}
+
+func collectPeriodCSAT(ctx context.Context, request mcp.CallToolRequest, window reportWindow, scope, label string) (periodCSAT, error) {
+	result := periodCSAT{total: newCSATSummary(), byGroup: make(map[int]*csatSummary)}
+	query := window.rangeQuery(config.CSAT.DateField) + scope + config.CSAT.query()
+	seen := make(map[int]bool)
+	read := 0
+	for page := 1; ; page++ {
+		// The search assets carry every ticket attribute, including custom
+		// fields the ticketRecord does not model.
+		var found struct {
+			Tickets []int `json:"tickets"`
+			Assets  struct {
+				Ticket map[string]map[string]any `json:"Ticket"`
+			} `json:"assets"`
+		}
+		params := fmt.Sprintf("query=%s&page=%d&per_page=100&limit=100", url.QueryEscape(query), page)
+		if err := zammadRequest(http.MethodGet, "/api/v1/tickets/search?"+params, nil, &found); err != nil {
+			return result, err
+		}
+		fresh := 0
+		for _, id := range found.Tickets {
+			ticket, ok := found.Assets.Ticket[strconv.Itoa(id)]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			fresh++
+			if read == csatReportTicketLimit {
+				result.truncated = true
+				return result, nil
+			}
+			if err := yieldTurn(ctx); err != nil {
+				return result, err
+			}
+			read++
+			groupID := 0
+			if n, ok := ticket["group_id"].(float64); ok {
+				groupID = int(n)
+			}
+			if result.byGroup[groupID] == nil {
+				result.byGroup[groupID] = newCSATSummary()
+			}
+			var rating float64
+			var rated bool
+			if config.CSAT.Field != "" {
+				var err error
+				rating, rated, err = config.CSAT.fieldRating(ticket[config.CSAT.Field])
+				if err != nil {
+					log.Printf("Rating of ticket %d not counted: %v", id, err)
+					result.total.Unmapped++
+					result.byGroup[groupID].Unmapped++
+					continue
+				}
+			} else {
+				tags, err := fetchTicketTags(id)
+				if err != nil {
+					return result, fmt.Errorf("failed to get tags of ticket %d: %w", id, err)
+				}
+				rating, rated = config.CSAT.tagRating(tags)
+			}
+			if rated {
+				result.total.add(rating)
+				result.byGroup[groupID].add(rating)
+			}
+		}
+		sendProgress(ctx, request, 0, 0, fmt.Sprintf("%d rated tickets of the %s period read", read, label))
+		// Guard against instances that ignore the page parameter.
+		if len(found.Tickets) < 100 || fresh == 0 {
+			return result, nil
+		}
+	}
+}
+
+// handleReportCSAT aggregates customer satisfaction ratings of the current
+// period, overall and per group, compared with the period before. Where the
+// ratings are stored is configured in the csat section.
+func handleReportCSAT(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !config.CSAT.configured() {
+		return mcp.NewToolResultError("No satisfaction ratings are configured: set csat.field or csat.tags in the configuration file (see ZAMMAD_MCP_CONFIG)"), nil
+	}
+	period := mcp.ParseString(request, "period", "month")
+	group := mcp.ParseString(request, "group", "")
+	byGroup := mcp.ParseBoolean(request, "by_group", true)
+	current, previous, err := reportWindows(period, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument period: %v", err)), nil
+	}
+	scope, err := reportScope(group)
+	if err != nil {
+		log.Printf("Error resolving group %q: %v", group, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+	}
+
+	now, err := collectPeriodCSAT(ctx, request, current, scope, "current")
+	if err != nil {
+		log.Printf("Error reading ratings of the current period: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to read the ratings of the current period", err), nil
+	}
+	before, err := collectPeriodCSAT(ctx, request, previous, scope, "previous")
+	if err != nil {
+		log.Printf("Error reading ratings of the previous period: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to read the ratings of the previous period", err), nil
+	}
+
+	report := csatReport{
+		Period:         period,
+		Group:          group,
+		Scale:          config.CSAT.Scale,
+		SatisfiedFrom:  config.CSAT.SatisfiedFrom,
+		CurrentPeriod:  current,
+		PreviousPeriod: previous,
+		Current:        now.total,
+		Previous:       before.total,
+		Truncated:      now.truncated || before.truncated,
+	}
+	if now.total.Responses > 0 && before.total.Responses > 0 {
+		report.AverageChange = fmt.Sprintf("%+.2f", now.total.Average-before.total.Average)
+	}
+	if byGroup && group == "" && len(now.byGroup) > 0 {
+		names := make(map[int]string)
+		if groups, err := zammadClient.GroupList(); err != nil {
+			log.Printf("Error listing groups, reporting group IDs: %v", err)
+		} else {
+			for _, g := range groups {
+				names[g.ID] = g.Name
+			}
+		}
+		for id, summary := range now.byGroup {
+			if summary.Responses == 0 && summary.Unmapped == 0 {
+				continue
+			}
+			name := names[id]
+			if name == "" {
+				name = fmt.Sprintf("group %d", id)
+			}
+			report.Groups = append(report.Groups, groupCSAT{Group: name, csatSummary: summary})
+		}
+		sort.Slice(report.Groups, func(i, j int) bool {
+			a, b := report.Groups[i], report.Groups[j]
+			if a.Responses != b.Responses {
+				return a.Responses > b.Responses
+			}
+			return a.Group < b.Group
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal satisfaction report: %w", err) // Internal server error
+	}
+	header := fmt.Sprintf("Customer satisfaction of the last %s vs the %s before", period, period)
+	if report.Truncated {
+		header += fmt.Sprintf(" (only the first %d rated tickets of a period were read)", csatReportTicketLimit)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
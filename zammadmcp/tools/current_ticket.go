@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// currentTicketArgument is the ticket_id value that stands for the session's
+// current ticket.
+const currentTicketArgument = "current"
+
+// currentTicketHint is appended to the description of ticket_id arguments.
+const currentTicketHint = ` Pass "current" for the current ticket of the session (see set_current_ticket).`
+
+// currentTickets holds the focus ticket of each client session, so a
+// multi-step conversation can refer to it as "current" instead of repeating
+// its ID.
+type currentTickets struct {
+	mu        sync.Mutex
+	bySession map[string]int
+}
+
+var sessionTickets = &currentTickets{bySession: make(map[string]int)}
+
+// sessionID returns the ID of the client session of a tool call; the stdio
+// transport has a single session.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+func (c *currentTickets) get(ctx context.Context) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, ok := c.bySession[sessionID(ctx)]
+	return id, ok
+}
+
+func (c *currentTickets) set(ctx context.Context, ticketID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bySession[sessionID(ctx)] = ticketID
+}
+
+// forget drops the current ticket of a session that ended.
+func (c *currentTickets) forget(ctx context.Context, session server.ClientSession) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.bySession, session.SessionID())
+}
+
+// withCurrentTicket replaces a ticket_id of "current" with the session's
+// current ticket. It runs before the response cache, so cached results are
+// keyed by the actual ticket.
+func withCurrentTicket(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		value, ok := request.Params.Arguments["ticket_id"].(string)
+		if !ok || !strings.EqualFold(strings.TrimSpace(value), currentTicketArgument) {
+			return next(ctx, request)
+		}
+		ticketID, ok := sessionTickets.get(ctx)
+		if !ok {
+			return mcp.NewToolResultError(`ticket_id is "current", but no current ticket is set in this session. Call set_current_ticket first or pass the ticket's ID.`), nil
+		}
+		args := maps.Clone(request.Params.Arguments)
+		args["ticket_id"] = float64(ticketID)
+		request.Params.Arguments = args
+		log.Printf("Resolved current ticket of %s to ticket %d", request.Params.Name, ticketID)
+		return next(ctx, request)
+	}
+}
+
+// handleSetCurrentTicket makes a ticket the session's current ticket.
+func handleSetCurrentTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	lookupTicketVIP(&ticket)
+	sessionTickets.set(ctx, ticketID)
+
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Current ticket set to #%s (ID %d). Pass ticket_id \"current\" to other tools to work on it:\n%s", ticket.Number, ticketID, string(jsonData))), nil
+}
+
+// handleGetCurrentTicket returns the session's current ticket as it is now
+// in Zammad.
+func handleGetCurrentTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	ticketID, ok := sessionTickets.get(ctx)
+	if !ok {
+		return mcp.NewToolResultText("No current ticket is set in this session. Use set_current_ticket to set one."), nil
+	}
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get current ticket %d", ticketID), err), nil
+	}
+	lookupTicketVIP(&ticket)
+
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Current ticket is #%s (ID %d):\n%s", ticket.Number, ticketID, string(jsonData))), nil
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listCursor is the position of the next chunk of a list, search or article
+// tool result, handed to the model as an opaque cursor argument. It is bound
+// to the call's other arguments, so a cursor cannot be applied to a
+// different query by mistake.
+type listCursor struct {
+	Offset int    `json:"offset"`
+	Args   string `json:"args"`
+}
+
+// cursorIgnoredArguments do not change which items a tool lists, only how
+// many or how they are rendered, so they may differ between chunks.
+var cursorIgnoredArguments = map[string]bool{"cursor": true, "limit": true, "max_matches": true, "profile": true}
+
+// cursorDescription documents the cursor argument of every paginated tool.
+const cursorDescription = "Cursor from the previous call's result to get the next chunk of results. Other arguments except limit must be unchanged."
+
+// argumentsFingerprint identifies the tool and the arguments that select the
+// listed items.
+func argumentsFingerprint(request mcp.CallToolRequest) string {
+	args := make(map[string]any, len(request.Params.Arguments))
+	for name, value := range request.Params.Arguments {
+		if !cursorIgnoredArguments[name] {
+			args[name] = value
+		}
+	}
+	// Map keys are marshalled in sorted order, so equal arguments give equal
+	// fingerprints.
+	data, _ := json.Marshal(map[string]any{"tool": request.Params.Name, "args": args})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// requestOffset returns the offset the cursor argument points to, or 0
+// without a cursor.
+func requestOffset(request mcp.CallToolRequest) (int, error) {
+	token := mcp.ParseString(request, "cursor", "")
+	if token == "" {
+		return 0, nil
+	}
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.Offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", token)
+	}
+	if cursor.Args != argumentsFingerprint(request) {
+		return 0, fmt.Errorf("the cursor belongs to a call with different arguments; repeat the original arguments or start without a cursor")
+	}
+	return cursor.Offset, nil
+}
+
+// pageOf returns up to limit items starting at offset (all remaining ones if
+// limit is not positive), and the offset of the next chunk, or 0 if there is
+// none.
+func pageOf[T any](items []T, offset, limit int) ([]T, int) {
+	if offset >= len(items) {
+		return items[:0], 0
+	}
+	items = items[offset:]
+	if limit <= 0 || len(items) <= limit {
+		return items, 0
+	}
+	return items[:limit], offset + limit
+}
+
+// moreResultsNote is appended to a tool result that has another chunk at
+// offset next; it returns "" if next is 0.
+func moreResultsNote(request mcp.CallToolRequest, next int) string {
+	if next == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(listCursor{Offset: next, Args: argumentsFingerprint(request)})
+	return fmt.Sprintf("\n\n"+localized(msgMoreResults),
+		request.Params.Name, base64.RawURLEncoding.EncodeToString(data))
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// objectAttribute is an attribute definition of the Zammad object manager.
+type objectAttribute struct {
+	Name       string         `json:"name"`
+	Object     string         `json:"object"`
+	Display    string         `json:"display"`
+	DataType   string         `json:"data_type"`
+	DataOption map[string]any `json:"data_option"`
+	Active     bool           `json:"active"`
+	Editable   bool           `json:"editable"`
+}
+
+// customFieldsDescription documents the custom_fields argument of the tools
+// that create or update tickets.
+const customFieldsDescription = "Custom ticket attributes defined in the object manager, by name, e.g. {\"product\": \"printer\", \"severity\": \"outage::full\"}. Values are checked against the attribute's type and options; an invalid one rejects the call with the list of custom fields."
+
+// objectAttributesCacheTTL is how long the attribute definitions are reused.
+// Admins rarely add attributes, and a new one is picked up within minutes.
+const objectAttributesCacheTTL = 5 * time.Minute
+
+var (
+	objectAttributesMu      sync.Mutex
+	objectAttributesCache   []objectAttribute
+	objectAttributesFetched time.Time
+)
+
+// fetchObjectAttributes returns the attribute definitions of all objects,
+// cached for objectAttributesCacheTTL.
+func fetchObjectAttributes() ([]objectAttribute, error) {
+	objectAttributesMu.Lock()
+	defer objectAttributesMu.Unlock()
+	if objectAttributesCache != nil && time.Since(objectAttributesFetched) < objectAttributesCacheTTL {
+		return objectAttributesCache, nil
+	}
+	var attributes []objectAttribute
+	if err := zammadRequest(http.MethodGet, "/api/v1/object_manager_attributes", nil, &attributes); err != nil {
+		return nil, err
+	}
+	objectAttributesCache, objectAttributesFetched = attributes, time.Now()
+	return attributes, nil
+}
+
+// validateTicketCustomFields checks custom ticket field values against the
+// object manager before they are sent, as Zammad silently drops attributes
+// it does not know. Built-in attributes are refused, since the tools have
+// dedicated arguments for them; values are checked against the attribute's
+// data type and, for selects, its options. null clears a field.
+func validateTicketCustomFields(fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	attributes, err := fetchObjectAttributes()
+	if err != nil {
+		return fmt.Errorf("failed to fetch the ticket attributes: %w", err)
+	}
+	custom := make(map[string]objectAttribute)
+	core := make(map[string]bool)
+	var names []string
+	for _, a := range attributes {
+		if a.Object != "Ticket" {
+			continue
+		}
+		if !a.Editable {
+			core[a.Name] = true
+			continue
+		}
+		if a.Active {
+			custom[a.Name] = a
+			names = append(names, fmt.Sprintf("%s (%s)", a.Name, a.DataType))
+		}
+	}
+	sort.Strings(names)
+	known := "none defined"
+	if len(names) > 0 {
+		known = "one of: " + strings.Join(names, ", ")
+	}
+
+	var problems []string
+	for name, value := range fields {
+		attribute, ok := custom[name]
+		switch {
+		case core[name] || coreTicketFields[name]:
+			problems = append(problems, fmt.Sprintf("%s is a built-in attribute, not a custom field", name))
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is not an active custom ticket field", name))
+		case value != nil:
+			if err := attribute.check(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s (custom ticket fields: %s)", strings.Join(problems, "; "), known)
+	}
+	return nil
+}
+
+// createTicketWithFields creates a ticket like zammad-go's TicketCreate, with
+// custom fields and attachments of the first article added to the payload,
+// which zammad.Ticket cannot carry.
+func createTicketWithFields(ticket zammad.Ticket, fields map[string]any, uploads []articleUpload) (zammad.Ticket, error) {
+	var created zammad.Ticket
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return created, err
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return created, err
+	}
+	for name, value := range fields {
+		payload[name] = value
+	}
+	if article, ok := payload["article"].(map[string]any); ok && len(uploads) > 0 {
+		article["attachments"] = uploads
+	}
+	err = zammadRequest(http.MethodPost, "/api/v1/tickets", payload, &created)
+	return created, err
+}
+
+// coreTicketFields are ticket attributes that are refused as custom fields
+// even if the object manager does not list them, such as IDs and computed
+// values.
+var coreTicketFields = map[string]bool{
+	"id": true, "number": true, "article": true, "customer": true, "group": true, "state": true,
+	"priority": true, "owner": true, "organization": true, "created_at": true, "updated_at": true,
+	"created_by_id": true, "updated_by_id": true,
+}
+
+// check reports whether value suits the attribute's data type.
+func (a objectAttribute) check(value any) error {
+	switch a.DataType {
+	case "input", "textarea", "richtext":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected a string, got %v", value)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("expected an integer, got %v", value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected true or false, got %v", value)
+		}
+	case "date":
+		if s, ok := value.(string); !ok || !validTime("2006-01-02", s) {
+			return fmt.Errorf("expected a date such as 2024-05-14, got %v", value)
+		}
+	case "datetime":
+		if s, ok := value.(string); !ok || !validTime(time.RFC3339, s) {
+			return fmt.Errorf("expected an RFC 3339 timestamp such as 2024-05-14T09:30:00Z, got %v", value)
+		}
+	case "select", "tree_select":
+		return a.checkOption(value)
+	case "multiselect", "multi_tree_select":
+		values, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("expected an array of options, got %v", value)
+		}
+		for _, v := range values {
+			if err := a.checkOption(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkOption reports whether value is one of the attribute's options.
+func (a objectAttribute) checkOption(value any) error {
+	options := attributeOptions(a.DataOption["options"])
+	if len(options) == 0 {
+		return nil
+	}
+	s, ok := value.(string)
+	if ok {
+		for _, option := range options {
+			if option == s {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%v is not an option (one of: %s)", value, strings.Join(options, ", "))
+}
+
+// attributeOptions returns the option values of a select attribute, which
+// Zammad stores as a map of values to labels, or for tree selects as a list
+// of options with nested children, whose values are paths such as
+// "Hardware::Printer".
+func attributeOptions(options any) []string {
+	var values []string
+	switch o := options.(type) {
+	case map[string]any:
+		for value := range o {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+	case []any:
+		for _, item := range o {
+			option, _ := item.(map[string]any)
+			if value, ok := option["value"].(string); ok {
+				values = append(values, value)
+			}
+			values = append(values, attributeOptions(option["children"])...)
+		}
+	}
+	return values
+}
+
+// validTime reports whether s is a time in the given layout.
+func validTime(layout, s string) bool {
+	_, err := time.Parse(layout, s)
+	return err == nil
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleDeleteTicket permanently deletes a ticket with its articles. The
+// call must set confirm, so a ticket is not deleted by a guessed argument.
+// Deletion cannot be undone.
+func handleDeleteTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if !mcp.ParseBoolean(request, "confirm", false) {
+		return mcp.NewToolResultError(fmt.Sprintf("Deleting ticket %d is permanent and cannot be undone. Confirm with the user, then call again with confirm set to true.", ticketID)), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if err := zammadClient.TicketDelete(ticketID); err != nil {
+		log.Printf("Error deleting ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to delete ticket %d", ticketID), err), nil
+	}
+	// No link to the ticket: it is gone.
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("deleted ticket %d (#%s %q)", ticketID, ticket.Number, ticket.Title), nil)
+
+	log.Printf("Deleted ticket %d (#%s)", ticketID, ticket.Number)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d deleted permanently. Its last state was:\n%s", ticketID, string(jsonData))), nil
+}
@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// doctorCheck is one line of the doctor's capability report.
+type doctorCheck struct {
+	status string // "OK", "WARN" or "FAIL"
+	name   string
+	detail string
+}
+
+// doctor runs the self-test of the `doctor` subcommand.
+type doctor struct {
+	checks []doctorCheck
+}
+
+func (d *doctor) add(status, name, format string, args ...any) {
+	d.checks = append(d.checks, doctorCheck{status, name, fmt.Sprintf(format, args...)})
+}
+
+// RunDoctor checks the configuration and the Zammad instance, prints a
+// capability report and returns the process exit code: 1 if a check failed.
+func RunDoctor() int {
+	d := &doctor{}
+	fmt.Printf("Zammad MCP doctor: checking %s\n\n", zammadClient.Url)
+
+	if d.checkConnectivity() {
+		d.checkPermissions()
+		d.checkSearch()
+		d.checkCompression()
+		d.checkCalendar()
+	}
+	d.checkWebhooks()
+	d.checkWriteQueue()
+	d.checkIntake()
+	d.checkAttachmentScanner()
+
+	failed := false
+	for _, c := range d.checks {
+		fmt.Printf("[%-4s] %-28s %s\n", c.status, c.name, c.detail)
+		failed = failed || c.status == "FAIL"
+	}
+	if failed {
+		fmt.Println("\nSome checks failed; the affected features will not work until this is fixed.")
+		return 1
+	}
+	fmt.Println("\nAll required checks passed.")
+	return 0
+}
+
+// checkConnectivity authenticates against the API and measures its latency.
+func (d *doctor) checkConnectivity() bool {
+	var me struct {
+		Login string `json:"login"`
+	}
+	var latencies []time.Duration
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if err := zammadRequest(http.MethodGet, "/api/v1/users/me", nil, &me); err != nil {
+			var apiErr *zammadAPIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+				d.add("FAIL", "Authentication", "ZAMMAD_TOKEN was rejected: %v", err)
+			} else {
+				d.add("FAIL", "Connectivity", "cannot reach ZAMMAD_URL: %v", err)
+			}
+			return false
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	d.add("OK", "Connectivity", "authenticated as %s", me.Login)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	median := latencies[len(latencies)/2].Round(time.Millisecond)
+	switch {
+	case median > 2*time.Second && config.HTTPTimeout > 0:
+		d.add("WARN", "API latency", "median %s; requests time out after %s (http_timeout), expect failures", median, time.Duration(config.HTTPTimeout))
+	case median > 500*time.Millisecond:
+		d.add("WARN", "API latency", "median %s; tools will feel slow", median)
+	default:
+		d.add("OK", "API latency", "median %s", median)
+	}
+	return true
+}
+
+// checkPermissions probes the endpoints behind each group of tools, as the
+// API offers no way to list the token's permissions directly.
+func (d *doctor) checkPermissions() {
+	probes := []struct {
+		name, path, permission, needed string
+		required                       bool
+	}{
+		{"Tickets", "/api/v1/tickets?per_page=1", "ticket.agent", "ticket tools and resources", true},
+		{"Ticket states", "/api/v1/ticket_states", "ticket.agent", "get_allowed_transitions", false},
+		{"Users", "/api/v1/users/search?query=*&limit=1", "ticket.agent or admin.user", "user tools and resources", true},
+		{"Organizations", "/api/v1/organizations?per_page=1", "ticket.agent or admin.organization", "export_organization_history", false},
+		{"Ticket history", "/api/v1/ticket_history/0", "ticket.agent", "diff_ticket_changes", false},
+		{"Email channels", "/api/v1/channels_email", "admin.channel_email", "report_channel_health", false},
+		{"Online notifications", "/api/v1/online_notifications?per_page=1", "user_preferences.notifications", "notification poller", false},
+	}
+	for _, p := range probes {
+		err := zammadRequest(http.MethodGet, p.path, nil, nil)
+		var apiErr *zammadAPIError
+		if err != nil && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			err = nil // the endpoint is reachable, the probed record just does not exist
+		}
+		switch {
+		case err == nil:
+			d.add("OK", p.name, "accessible")
+		case p.required:
+			d.add("FAIL", p.name, "%v (needs %s for %s)", err, p.permission, p.needed)
+		default:
+			d.add("WARN", p.name, "%v (needs %s for %s)", err, p.permission, p.needed)
+		}
+	}
+}
+
+// checkSearch verifies that search works and whether Elasticsearch backs it.
+// Without Elasticsearch, Zammad falls back to a limited database search.
+func (d *doctor) checkSearch() {
+	if err := zammadRequest(http.MethodGet, "/api/v1/tickets/search?query=*&limit=1", nil, nil); err != nil {
+		d.add("FAIL", "Search", "ticket search failed: %v", err)
+		return
+	}
+
+	var settings []struct {
+		Name         string `json:"name"`
+		StateCurrent struct {
+			Value any `json:"value"`
+		} `json:"state_current"`
+	}
+	if err := zammadRequest(http.MethodGet, "/api/v1/settings", nil, &settings); err != nil {
+		d.add("OK", "Search", "ticket search works (Elasticsearch status unknown: reading settings needs admin permissions)")
+		return
+	}
+	for _, s := range settings {
+		if s.Name == "es_url" {
+			if v, _ := s.StateCurrent.Value.(string); v != "" {
+				d.add("OK", "Search", "ticket search works, backed by Elasticsearch")
+				return
+			}
+		}
+	}
+	d.add("WARN", "Search", "Elasticsearch is not configured; Zammad falls back to a limited database search (no query syntax, fewer fields)")
+}
+
+// checkCompression reports whether Zammad, or the proxy in front of it,
+// gzip-compresses API responses, which speeds up large lists on slow links.
+func (d *doctor) checkCompression() {
+	req, err := zammadClient.NewRequest(http.MethodGet, zammadClient.Url+"/api/v1/users?per_page=100", nil)
+	if err != nil {
+		d.add("WARN", "Compression", "cannot build request: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Token token=%s", zammadClient.Token))
+	resp, err := zammadClient.Client.Do(req)
+	if err != nil {
+		d.add("WARN", "Compression", "request failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	size, _ := io.Copy(io.Discard, resp.Body)
+	if resp.Uncompressed {
+		d.add("OK", "Compression", "API responses are gzip-compressed (%d KiB user list after decoding)", size>>10)
+	} else {
+		d.add("WARN", "Compression", "API responses are not compressed (%d KiB user list); enable gzip in the web server in front of Zammad to speed up large lists", size>>10)
+	}
+}
+
+// checkCalendar reports which calendar SLA deltas will use.
+func (d *doctor) checkCalendar() {
+	if cal := businessCalendar(); cal != nil {
+		d.add("OK", "SLA calendar", "%s (%s)", cal.Name, cal.location())
+	} else {
+		d.add("WARN", "SLA calendar", "no calendar available (needs admin.calendar); SLA deltas use wall-clock time")
+	}
+}
+
+// checkWebhooks verifies that the configured error reporting endpoints are
+// reachable, without sending a report.
+func (d *doctor) checkWebhooks() {
+	targets := map[string]string{
+		"Error webhook": os.Getenv("ZAMMAD_MCP_ERROR_WEBHOOK_URL"),
+	}
+	if dsn := os.Getenv("ZAMMAD_MCP_SENTRY_DSN"); dsn != "" {
+		if u, err := url.Parse(dsn); err == nil {
+			targets["Sentry"] = fmt.Sprintf("%s://%s/", u.Scheme, u.Host)
+		} else {
+			d.add("FAIL", "Sentry", "invalid ZAMMAD_MCP_SENTRY_DSN: %v", err)
+		}
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	for _, name := range []string{"Error webhook", "Sentry"} {
+		target := targets[name]
+		if target == "" {
+			continue
+		}
+		resp, err := client.Head(target)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err // the wrapping error repeats the unredacted URL
+			}
+			d.add("FAIL", name, "%s is not reachable: %v", redactURL(target), err)
+			continue
+		}
+		resp.Body.Close()
+		d.add("OK", name, "%s reachable (HTTP %d)", redactURL(target), resp.StatusCode)
+	}
+}
+
+// checkWriteQueue verifies that the offline write queue file can be written.
+func (d *doctor) checkWriteQueue() {
+	path := os.Getenv("ZAMMAD_MCP_WRITE_QUEUE_FILE")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		d.add("FAIL", "Write queue", "%v", err)
+		return
+	}
+	f.Close()
+	d.add("OK", "Write queue", "%s is writable", path)
+}
+
+// checkIntake verifies that files can be moved out of the intake directory.
+func (d *doctor) checkIntake() {
+	dir := config.Intake.Directory
+	if dir == "" {
+		return
+	}
+	for _, sub := range []string{"processed", "failed"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			d.add("FAIL", "File intake", "%v", err)
+			return
+		}
+	}
+	f, err := os.CreateTemp(dir, ".doctor-")
+	if err != nil {
+		d.add("FAIL", "File intake", "%s is not writable: %v", dir, err)
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.add("OK", "File intake", "%s is writable", dir)
+}
+
+// checkAttachmentScanner verifies that the configured virus scanner can be
+// run or reached.
+func (d *doctor) checkAttachmentScanner() {
+	scanner := config.Attachments.Scan
+	switch {
+	case len(scanner.Command) > 0:
+		if path, err := exec.LookPath(scanner.Command[0]); err != nil {
+			d.add("FAIL", "Attachment scanner", "%v", err)
+		} else {
+			d.add("OK", "Attachment scanner", "command %s", path)
+		}
+	case scanner.Clamd != "":
+		if err := scanner.pingClamd(); err != nil {
+			d.add("FAIL", "Attachment scanner", "%v", err)
+		} else {
+			d.add("OK", "Attachment scanner", "clamd at %s responds", scanner.Clamd)
+		}
+	}
+}
+
+// redactURL drops credentials and query strings, which often carry secrets.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return strings.TrimSuffix(u.String(), "?")
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// parsedEmail is the part of a pasted raw email needed to open a ticket.
+type parsedEmail struct {
+	From        *mail.Address
+	To          string
+	Subject     string
+	MessageID   string
+	Body        string
+	ContentType string
+}
+
+// emailTicketResult is the outcome of create_ticket_from_email_text.
+type emailTicketResult struct {
+	Ticket          json.RawMessage `json:"ticket"` // ticketRecord in the requested output profile
+	CustomerID      int             `json:"customer_id"`
+	CustomerCreated bool            `json:"customer_created"`
+	From            string          `json:"from"`
+	Subject         string          `json:"subject"`
+}
+
+// headerGetter is implemented by both mail.Header and textproto.MIMEHeader.
+type headerGetter interface {
+	Get(key string) string
+}
+
+// parseEmailText parses a raw email (headers, blank line, body) as copied
+// from a mail client's "show original" view. The body is the first
+// text/plain part, or the first text/html part if there is no plain text.
+func parseEmailText(text string) (parsedEmail, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(strings.TrimLeft(text, " \t\r\n")))
+	if err != nil {
+		return parsedEmail{}, fmt.Errorf("not a raw email with headers: %w", err)
+	}
+	decoder := new(mime.WordDecoder)
+	parser := mail.AddressParser{WordDecoder: decoder}
+	from, err := parser.Parse(msg.Header.Get("From"))
+	if err != nil {
+		return parsedEmail{}, fmt.Errorf("invalid From header %q: %w", msg.Header.Get("From"), err)
+	}
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	body, contentType, err := emailBody(msg.Header, msg.Body)
+	if err != nil {
+		return parsedEmail{}, err
+	}
+	if body == "" {
+		return parsedEmail{}, errors.New("the email has no text or HTML body")
+	}
+	return parsedEmail{
+		From:        from,
+		To:          msg.Header.Get("To"),
+		Subject:     strings.TrimSpace(subject),
+		MessageID:   msg.Header.Get("Message-Id"),
+		Body:        body,
+		ContentType: contentType,
+	}, nil
+}
+
+// emailBody extracts the body text and its content type from a message or
+// MIME part, descending into multipart containers.
+func emailBody(header headerGetter, body io.Reader) (string, string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(header.Get("Content-Disposition"), "attachment") {
+		return "", mediaType, nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var htmlBody string
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("malformed multipart body: %w", err)
+			}
+			text, partType, err := emailBody(part.Header, part)
+			if err != nil {
+				return "", "", err
+			}
+			switch {
+			case text == "":
+			case partType == "text/plain":
+				return text, partType, nil
+			case partType == "text/html" && htmlBody == "":
+				htmlBody = text
+			}
+		}
+		if htmlBody != "" {
+			return htmlBody, "text/html", nil
+		}
+		return "", mediaType, nil
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", mediaType, nil
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s body: %w", mediaType, err)
+	}
+	text := string(data)
+	if charset := strings.ToLower(params["charset"]); charset == "iso-8859-1" || charset == "latin1" {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), mediaType, nil
+}
+
+// displayAddress renders an address as "Name <address>" without the MIME
+// encoding mail.Address.String applies to non-ASCII names.
+func displayAddress(address *mail.Address) string {
+	if address.Name == "" {
+		return address.Address
+	}
+	return fmt.Sprintf("%s <%s>", address.Name, address.Address)
+}
+
+// findOrCreateCustomer returns the ID of the user with the given email
+// address, creating a customer if there is none and create is set.
+func findOrCreateCustomer(address *mail.Address, create bool) (int, bool, error) {
+	users, err := searchUserRecords(fmt.Sprintf("email:%q", address.Address), 10)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Email, address.Address) {
+			return u.ID, false, nil
+		}
+	}
+	if !create {
+		return 0, false, fmt.Errorf("no user with email address %s (set create_customer to create one)", address.Address)
+	}
+
+	firstname, lastname, _ := strings.Cut(strings.TrimSpace(address.Name), " ")
+	payload := map[string]any{
+		"email":     address.Address,
+		"firstname": firstname,
+		"lastname":  strings.TrimSpace(lastname),
+		"roles":     []string{"Customer"},
+	}
+	var user userRecord
+	if err := zammadRequest(http.MethodPost, "/api/v1/users", payload, &user); err != nil {
+		return 0, false, fmt.Errorf("failed to create customer %s: %w", address.Address, err)
+	}
+	log.Printf("Created customer %d for %s", user.ID, address.Address)
+	return user.ID, true, nil
+}
+
+// emailTicketPayload is the request that opens a ticket in group from email,
+// with the email as an incoming customer article.
+func emailTicketPayload(email parsedEmail, group string, customerID int) map[string]any {
+	title := email.Subject
+	if title == "" {
+		title = "(no subject)"
+	}
+	return map[string]any{
+		"title":       title,
+		"group":       group,
+		"customer_id": customerID,
+		"article": map[string]any{
+			"type":         "email",
+			"sender":       "Customer",
+			"from":         displayAddress(email.From),
+			"to":           email.To,
+			"subject":      email.Subject,
+			"message_id":   email.MessageID,
+			"body":         email.Body,
+			"content_type": email.ContentType,
+			"internal":     false,
+		},
+	}
+}
+
+// handleCreateTicketFromEmailText opens a ticket from a pasted raw email. The
+// article is stored as an incoming customer email, so Zammad does not send
+// it anywhere.
+func handleCreateTicketFromEmailText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text := mcp.ParseString(request, "email", "")
+	group := mcp.ParseString(request, "group", "")
+	createCustomer := mcp.ParseBoolean(request, "create_customer", true)
+	if text == "" || group == "" {
+		return mcp.NewToolResultError("Missing required arguments: email, group"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	email, err := parseEmailText(text)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Could not parse the email: %v", err)), nil
+	}
+
+	customerID, customerCreated, err := findOrCreateCustomer(email.From, createCustomer)
+	if err != nil {
+		log.Printf("Error resolving customer %s: %v", email.From.Address, err)
+		return mcp.NewToolResultErrorFromErr("Failed to resolve the customer", err), nil
+	}
+
+	var ticket ticketRecord
+	if err := zammadRequest(http.MethodPost, "/api/v1/tickets", emailTicketPayload(email, group, customerID), &ticket); err != nil {
+		log.Printf("Error creating ticket from email in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to create ticket", err), nil
+	}
+	tickets := []ticketRecord{ticket}
+	enrichTickets(tickets)
+
+	log.Printf("Created ticket %d from email by %s", ticket.ID, email.From.Address)
+	objects := []actionObject{ticketObject(ticket.ID)}
+	if customerCreated {
+		objects = append(objects, userObject(customerID))
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("created ticket %d from email by %s", ticket.ID, email.From.Address), nil, objects...)
+	ticketData, err := profile.project(tickets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticket.ID, err) // Internal server error
+	}
+	result := emailTicketResult{
+		Ticket:          ticketData,
+		CustomerID:      customerID,
+		CustomerCreated: customerCreated,
+		From:            displayAddress(email.From),
+		Subject:         email.Subject,
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticket.ID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket created from email:\n%s", string(jsonData))), nil
+}
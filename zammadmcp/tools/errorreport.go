@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errorReport describes a failure worth telling the operator about.
+type errorReport struct {
+	Kind      string         `json:"kind"` // "panic" or "repeated_errors"
+	Tool      string         `json:"tool"`
+	Message   string         `json:"message"`
+	Count     int            `json:"count,omitempty"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Stack     string         `json:"stack,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// errorReporter sends reports of recovered panics and repeated tool errors to
+// Sentry and/or a generic webhook. It is configured through
+// ZAMMAD_MCP_SENTRY_DSN, ZAMMAD_MCP_ERROR_WEBHOOK_URL and
+// ZAMMAD_MCP_ERROR_REPORT_THRESHOLD.
+type errorReporter struct {
+	sentryDSN  *url.URL
+	webhookURL string
+	threshold  int
+	client     *http.Client
+
+	mu       sync.Mutex
+	failures map[string]int // consecutive failed calls per tool
+}
+
+// WithErrorReporting returns chain with its recovery middleware replaced by
+// one that also reports panics, and tools failing threshold times in a row,
+// to Sentry and/or a webhook. Without either destination, chain is returned
+// unchanged.
+func WithErrorReporting(chain []Middleware, sentryDSN, webhookURL string, threshold int) ([]Middleware, error) {
+	reporter, err := newErrorReporter(sentryDSN, webhookURL, threshold)
+	if err != nil || reporter == nil {
+		return chain, err
+	}
+	log.Println("Error reporting enabled.")
+	return replaceMiddleware(chain, "recovery", reporter.middleware), nil
+}
+
+// newErrorReporter returns a reporter, or nil if no destination is configured.
+func newErrorReporter(sentryDSN, webhookURL string, threshold int) (*errorReporter, error) {
+	if sentryDSN == "" && webhookURL == "" {
+		return nil, nil
+	}
+	r := &errorReporter{
+		webhookURL: webhookURL,
+		threshold:  threshold,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		failures:   make(map[string]int),
+	}
+	if sentryDSN != "" {
+		dsn, err := url.Parse(sentryDSN)
+		if err != nil || dsn.User == nil || dsn.User.Username() == "" || strings.Trim(dsn.Path, "/") == "" {
+			return nil, fmt.Errorf("invalid Sentry DSN: expected https://<key>@<host>/<project>")
+		}
+		r.sentryDSN = dsn
+	}
+	return r, nil
+}
+
+// middleware replaces server.WithRecovery: it recovers from panics in tool
+// handlers like WithRecovery does, and additionally reports them as well as
+// tools that fail threshold times in a row.
+func (r *errorReporter) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic recovered in %s tool handler: %v", request.Params.Name, p)
+				r.send(errorReport{
+					Kind:      "panic",
+					Tool:      request.Params.Name,
+					Message:   fmt.Sprint(p),
+					Arguments: sanitizeArguments(request.Params.Arguments),
+					Stack:     string(debug.Stack()),
+					Timestamp: time.Now().UTC(),
+				})
+			}
+		}()
+
+		result, err = next(ctx, request)
+		if err != nil || (result != nil && result.IsError) {
+			r.recordFailure(request, toolFailureMessage(result, err))
+		} else {
+			r.mu.Lock()
+			delete(r.failures, request.Params.Name)
+			r.mu.Unlock()
+		}
+		return result, err
+	}
+}
+
+// recordFailure counts a failed call and reports the tool once it has failed
+// threshold times in a row.
+func (r *errorReporter) recordFailure(request mcp.CallToolRequest, message string) {
+	if r.threshold <= 0 {
+		return
+	}
+	r.mu.Lock()
+	r.failures[request.Params.Name]++
+	count := r.failures[request.Params.Name]
+	r.mu.Unlock()
+	if count != r.threshold {
+		return
+	}
+	r.send(errorReport{
+		Kind:      "repeated_errors",
+		Tool:      request.Params.Name,
+		Message:   message,
+		Count:     count,
+		Arguments: sanitizeArguments(request.Params.Arguments),
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func toolFailureMessage(result *mcp.CallToolResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return truncateString(text.Text, 500)
+		}
+	}
+	return "tool returned an error result"
+}
+
+// send delivers the report in the background.
+func (r *errorReporter) send(report errorReport) {
+	log.Printf("Reporting %s in tool %s: %s", report.Kind, report.Tool, report.Message)
+	go func() {
+		if r.webhookURL != "" {
+			if err := r.post(r.webhookURL, nil, report); err != nil {
+				log.Printf("Error sending error report to webhook: %v", err)
+			}
+		}
+		if r.sentryDSN != nil {
+			if err := r.sendSentry(report); err != nil {
+				log.Printf("Error sending error report to Sentry: %v", err)
+			}
+		}
+	}()
+}
+
+// sendSentry posts the report to the store endpoint of the Sentry project.
+func (r *errorReporter) sendSentry(report errorReport) error {
+	project := strings.Trim(r.sentryDSN.Path, "/")
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", r.sentryDSN.Scheme, r.sentryDSN.Host, prefix, project)
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=zammad-mcp-go/1.0, sentry_key=%s", r.sentryDSN.User.Username())
+
+	eventID := make([]byte, 16)
+	_, _ = rand.Read(eventID)
+	level := "error"
+	if report.Kind == "panic" {
+		level = "fatal"
+	}
+	event := map[string]any{
+		"event_id":  hex.EncodeToString(eventID),
+		"timestamp": report.Timestamp.Format(time.RFC3339),
+		"level":     level,
+		"platform":  "go",
+		"logger":    "zammad-mcp-go",
+		"message":   fmt.Sprintf("%s in %s: %s", report.Kind, report.Tool, report.Message),
+		"tags":      map[string]string{"tool": report.Tool, "kind": report.Kind},
+		"extra":     map[string]any{"arguments": report.Arguments, "count": report.Count, "stack": report.Stack},
+	}
+	return r.post(endpoint, map[string]string{"X-Sentry-Auth": auth}, event)
+}
+
+func (r *errorReporter) post(endpoint string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// sanitizeArguments prepares tool arguments for an error report: secrets are
+// redacted, free text that may contain customer data is reduced to its
+// length, and other strings are shortened. Nested objects, such as
+// attachments or custom_fields, are sanitized the same way.
+func sanitizeArguments(args map[string]any) map[string]any {
+	sanitized := make(map[string]any, len(args))
+	for key, value := range args {
+		sanitized[key] = sanitizeArgument(key, value)
+	}
+	return sanitized
+}
+
+// sanitizeArgument sanitizes the value of the argument or field key; items
+// of a list are sanitized as values of key.
+func sanitizeArgument(key string, value any) any {
+	if isSecretKey(key) {
+		return "[redacted]"
+	}
+	switch v := value.(type) {
+	case string:
+		switch strings.ToLower(key) {
+		case "body", "data", "data_base64", "text", "instructions":
+			return fmt.Sprintf("[%d characters]", len(v))
+		}
+		return truncateString(v, 100)
+	case map[string]any:
+		return sanitizeArguments(v)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = sanitizeArgument(key, item)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// isSecretKey reports whether an argument or field name suggests a secret
+// value, such as an API token or a password.
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	return strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") ||
+		lower == "authorization" || lower == "cookie"
+}
+
+// truncateString shortens s to at most n bytes, cutting before a rune rather
+// than through it, and marks the cut.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// exportedTicket is a ticket in an export archive, optionally with its thread.
+type exportedTicket struct {
+	ticketRecord
+	Articles []zammad.TicketArticle `json:"articles,omitempty"`
+}
+
+// archiveWriter encodes the document produced by
+// export_organization_history incrementally: the organization, then each
+// ticket as soon as it has been fetched, then the totals. Tickets and their
+// articles can be released once written, so memory use is bounded by the
+// encoded archive rather than by the decoded tickets. As JSON Lines, the
+// archive is the tickets only, one per line.
+type archiveWriter struct {
+	buf       bytes.Buffer
+	count     int
+	jsonLines bool
+}
+
+func newArchiveWriter(organization organizationRecord, exportedAt time.Time, jsonLines bool) (*archiveWriter, error) {
+	w := &archiveWriter{jsonLines: jsonLines}
+	if jsonLines {
+		return w, nil
+	}
+	header, err := json.MarshalIndent(struct {
+		Organization organizationRecord `json:"organization"`
+		ExportedAt   time.Time          `json:"exported_at"`
+	}{organization, exportedAt}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Reopen the header object to append the tickets array.
+	w.buf.Write(bytes.TrimSuffix(header, []byte("\n}")))
+	w.buf.WriteString(",\n  \"tickets\": [")
+	return w, nil
+}
+
+// add appends a ticket to the archive.
+func (w *archiveWriter) add(ticket exportedTicket) error {
+	if w.jsonLines {
+		data, err := json.Marshal(ticket)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(data)
+		w.buf.WriteByte('\n')
+		w.count++
+		return nil
+	}
+	data, err := json.MarshalIndent(ticket, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	if w.count > 0 {
+		w.buf.WriteByte(',')
+	}
+	w.buf.WriteString("\n    ")
+	w.buf.Write(data)
+	w.count++
+	return nil
+}
+
+// finish closes the archive and returns it. If the export stopped early,
+// incomplete says why; the archive then holds the tickets exported so far,
+// and next, if set, is where a further call can continue.
+func (w *archiveWriter) finish(incomplete string, next *continuation) string {
+	if w.jsonLines {
+		return w.buf.String()
+	}
+	if w.count > 0 {
+		w.buf.WriteString("\n  ")
+	}
+	fmt.Fprintf(&w.buf, "],\n  \"ticket_count\": %d,\n  \"complete\": %t", w.count, incomplete == "")
+	if incomplete != "" {
+		reason, _ := json.Marshal(incomplete)
+		fmt.Fprintf(&w.buf, ",\n  \"incomplete_reason\": %s", reason)
+	}
+	if next != nil {
+		fmt.Fprintf(&w.buf, ",\n  \"next_cursor\": %q", next.String())
+	}
+	w.buf.WriteString("\n}")
+	return w.buf.String()
+}
+
+// handleExportOrganizationHistory pages through every ticket of an
+// organization (optionally with articles) and returns them as a JSON archive.
+// Each page is written to the archive before the next one is fetched, with a
+// progress notification per page. If a later page fails or the call is
+// cancelled, the tickets exported so far are returned, marked incomplete.
+// Once the archive exceeds the memory budget, the export stops and returns a
+// cursor for the next call.
+func handleExportOrganizationHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	organizationID := mcp.ParseInt(request, "organization_id", 0)
+	includeArticles := mcp.ParseBoolean(request, "include_articles", false)
+	perPage := mcp.ParseInt(request, "per_page", 100)
+	from, err := parseContinuation(mcp.ParseString(request, "cursor", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	if organizationID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: organization_id (must be a positive number)"), nil
+	}
+	if perPage <= 0 || perPage > 200 {
+		perPage = 100
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+
+	organization, err := zammadClient.OrganizationShow(organizationID)
+	if err != nil {
+		log.Printf("Error fetching organization %d from Zammad: %v", organizationID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get organization %d", organizationID), err), nil
+	}
+
+	archive, err := newArchiveWriter(newOrganizationRecord(organization), time.Now().UTC(), jsonLines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal organization %d: %w", organizationID, err) // Internal server error
+	}
+	pager := newTicketPager(fmt.Sprintf("organization_id:%d", organizationID), perPage, from)
+	budget := newMemoryBudget()
+	incomplete := ""
+	var next *continuation
+	position := from
+export:
+	for {
+		if budget.exhausted() {
+			incomplete = fmt.Sprintf("memory budget of %s reached", budget.limit)
+			next = &position
+			break
+		}
+		if err := yieldTurn(ctx); err != nil {
+			incomplete = fmt.Sprintf("export cancelled: %v", err)
+			break
+		}
+		tickets, err := pager.next()
+		if err != nil {
+			log.Printf("Error fetching page %d of organization %d tickets: %v", pager.pageNumber()+1, organizationID, err)
+			incomplete = fmt.Sprintf("failed to fetch page %d of tickets: %v", pager.pageNumber()+1, err)
+			break
+		}
+		if len(tickets) == 0 {
+			break
+		}
+
+		for i, ticket := range tickets {
+			position = pager.resumeAt(i)
+			if budget.exhausted() {
+				incomplete = fmt.Sprintf("memory budget of %s reached", budget.limit)
+				next = &position
+				break export
+			}
+			entry := exportedTicket{ticketRecord: ticket}
+			if includeArticles {
+				articles, err := zammadClient.TicketArticleByTicket(ticket.ID)
+				if err != nil {
+					log.Printf("Error fetching articles for ticket %d during export: %v", ticket.ID, err)
+					incomplete = fmt.Sprintf("failed to get articles for ticket %d: %v", ticket.ID, err)
+					break export
+				}
+				entry.Articles = fenceArticles(articles)
+			}
+			size := archive.buf.Len()
+			if err := archive.add(entry); err != nil {
+				return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticket.ID, err) // Internal server error
+			}
+			budget.charge(archive.buf.Len() - size)
+		}
+		position = pager.resumeAt(len(tickets))
+		sendProgress(ctx, request, float64(archive.count), 0, fmt.Sprintf("%d tickets exported (page %d)", archive.count, pager.pageNumber()))
+	}
+
+	if incomplete != "" && archive.count == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to export tickets of organization %d: %s", organizationID, incomplete)), nil
+	}
+	text := archive.finish(incomplete, next)
+	summary := fmt.Sprintf("Exported %d tickets of organization %d (%s).", archive.count, organizationID, organization.Name)
+	if incomplete != "" {
+		log.Printf("Export of organization %d stopped after %d tickets: %s", organizationID, archive.count, incomplete)
+		summary = fmt.Sprintf("Partial export: %d tickets of organization %d (%s) were exported before the export stopped (%s).", archive.count, organizationID, organization.Name, incomplete)
+		if next != nil {
+			summary += fmt.Sprintf(" Call export_organization_history again with cursor %q for the next part.", next.String())
+		}
+	} else {
+		log.Printf("Exported %d tickets of organization %d", archive.count, organizationID)
+	}
+	mimeType := "application/json"
+	if jsonLines {
+		mimeType = "application/jsonl"
+	}
+	return mcp.NewToolResultResource(
+		summary,
+		mcp.TextResourceContents{
+			URI:      fmt.Sprintf("zammad://organizations/%d/export", organizationID),
+			MIMEType: mimeType,
+			Text:     text,
+		},
+	), nil
+}
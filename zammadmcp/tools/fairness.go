@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fairnessSettings configure how tool calls of concurrent sessions share
+// the Zammad instance on the sse transport.
+type fairnessSettings struct {
+	// MaxConcurrent is how many tool calls of all sessions may work against
+	// Zammad at once; zero disables the scheduling.
+	MaxConcurrent int `yaml:"max_concurrent"`
+	// BulkWeight is what a turn of a bulk tool costs its session, in turns
+	// of an interactive tool.
+	BulkWeight int `yaml:"bulk_weight"`
+}
+
+// bulkTools are the tools that make many Zammad requests per call. Those that
+// page through Zammad data yield their turn between pages or batches (see
+// yieldTurn).
+var bulkTools = map[string]bool{
+	"bulk_update_tickets":            true,
+	"export_organization_history":    true,
+	"import_tickets":                 true,
+	"import_users":                   true,
+	"reassign_users_to_organization": true,
+	"report_tag_usage":               true,
+	"report_ticket_aging":            true,
+	"report_ticket_trends":           true,
+	"report_channel_health":          true,
+}
+
+// fairScheduler hands out a limited number of turns to work against Zammad
+// to the tool calls of all sessions. When calls wait, the turn goes to the
+// session that was served least so far, weighted by cost, so that one user's
+// bulk export does not starve everyone else's interactive lookups. Sessions
+// that become active start at the current virtual time and cannot use
+// credit saved up while idle.
+type fairScheduler struct {
+	slots      int
+	bulkWeight float64
+
+	mu       sync.Mutex
+	running  int
+	clock    float64 // virtual time: the served cost of the last granted session
+	sessions map[string]*fairQueue
+}
+
+// fairQueue is the state of one session with running or waiting calls.
+type fairQueue struct {
+	served  float64 // virtual time including the turns granted so far
+	running int
+	waiting []*fairTurn
+}
+
+// fairTurn is one tool call's claim on a turn.
+type fairTurn struct {
+	session string
+	cost    float64
+	ready   chan struct{}
+	granted bool
+}
+
+// toolScheduler is the scheduler of the sse transport, or nil.
+var toolScheduler *fairScheduler
+
+// newFairScheduler returns a scheduler for the settings, or nil if they
+// disable scheduling.
+func newFairScheduler(settings fairnessSettings) *fairScheduler {
+	if settings.MaxConcurrent <= 0 {
+		return nil
+	}
+	return &fairScheduler{
+		slots:      settings.MaxConcurrent,
+		bulkWeight: float64(max(settings.BulkWeight, 1)),
+		sessions:   make(map[string]*fairQueue),
+	}
+}
+
+// turnKey is the context key of the call's fairTurn.
+type turnKey struct{}
+
+// withFairScheduling makes each tool call wait for its turn before it runs.
+// It runs inside withToolTimeout, so waiting counts toward the timeout.
+// approve_pending_action is not scheduled itself, as the write it executes
+// is.
+func withFairScheduling(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s := toolScheduler
+		if s == nil || request.Params.Name == approveToolName {
+			return next(ctx, request)
+		}
+		cost := 1.0
+		if bulkTools[request.Params.Name] {
+			cost = s.bulkWeight
+		}
+		turn := &fairTurn{session: sessionID(ctx), cost: cost}
+		start := time.Now()
+		if err := s.acquire(ctx, turn); err != nil {
+			return nil, err
+		}
+		defer s.release(turn)
+		if waited := time.Since(start); waited >= 100*time.Millisecond {
+			log.Printf("Tool call %s waited %s for its turn", request.Params.Name, waited.Round(time.Millisecond))
+		}
+		return next(context.WithValue(ctx, turnKey{}, turn), request)
+	}
+}
+
+// yieldTurn lets calls of other sessions that wait for a turn go first, and
+// is called by bulk tools between pages or batches. It returns ctx's error if
+// the call was cancelled, also while waiting.
+func yieldTurn(ctx context.Context) error {
+	s := toolScheduler
+	turn, _ := ctx.Value(turnKey{}).(*fairTurn)
+	if s == nil || turn == nil || ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	if !s.othersWaiting(turn.session) {
+		s.mu.Unlock()
+		return nil
+	}
+	// The call is queued again before its turn is released, so its session
+	// is not dropped as idle and keeps its served cost.
+	s.enqueueLocked(turn, true)
+	s.releaseLocked(turn)
+	s.mu.Unlock()
+	return s.wait(ctx, turn)
+}
+
+// acquire waits until turn is granted or ctx is done.
+func (s *fairScheduler) acquire(ctx context.Context, turn *fairTurn) error {
+	s.mu.Lock()
+	s.enqueueLocked(turn, false)
+	s.mu.Unlock()
+	return s.wait(ctx, turn)
+}
+
+func (s *fairScheduler) wait(ctx context.Context, turn *fairTurn) error {
+	select {
+	case <-turn.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if turn.granted {
+			s.releaseLocked(turn)
+		} else {
+			s.dequeueLocked(turn)
+		}
+		return ctx.Err()
+	}
+}
+
+// release gives back a granted turn.
+func (s *fairScheduler) release(turn *fairTurn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if turn.granted {
+		s.releaseLocked(turn)
+	}
+}
+
+// enqueueLocked adds turn to its session's queue, at the front when a
+// yielding call waits again, and dispatches.
+func (s *fairScheduler) enqueueLocked(turn *fairTurn, front bool) {
+	turn.ready = make(chan struct{})
+	turn.granted = false
+	q, ok := s.sessions[turn.session]
+	if !ok {
+		q = &fairQueue{served: s.clock}
+		s.sessions[turn.session] = q
+	}
+	if front {
+		q.waiting = append([]*fairTurn{turn}, q.waiting...)
+	} else {
+		q.waiting = append(q.waiting, turn)
+	}
+	s.dispatchLocked()
+}
+
+// dequeueLocked removes a waiting turn whose call gave up.
+func (s *fairScheduler) dequeueLocked(turn *fairTurn) {
+	q := s.sessions[turn.session]
+	for i, waiting := range q.waiting {
+		if waiting == turn {
+			q.waiting = append(q.waiting[:i:i], q.waiting[i+1:]...)
+			break
+		}
+	}
+	s.dropIdleLocked(turn.session)
+}
+
+func (s *fairScheduler) releaseLocked(turn *fairTurn) {
+	turn.granted = false
+	s.running--
+	s.sessions[turn.session].running--
+	s.dropIdleLocked(turn.session)
+	s.dispatchLocked()
+}
+
+// dropIdleLocked forgets a session without running or waiting calls.
+func (s *fairScheduler) dropIdleLocked(session string) {
+	if q := s.sessions[session]; q.running == 0 && len(q.waiting) == 0 {
+		delete(s.sessions, session)
+	}
+}
+
+// dispatchLocked grants free turns to the waiting calls of the sessions
+// served least.
+func (s *fairScheduler) dispatchLocked() {
+	for s.running < s.slots {
+		var next *fairQueue
+		for _, q := range s.sessions {
+			if len(q.waiting) > 0 && (next == nil || q.served < next.served || q.served == next.served && q.running < next.running) {
+				next = q
+			}
+		}
+		if next == nil {
+			return
+		}
+		turn := next.waiting[0]
+		next.waiting = next.waiting[1:]
+		s.clock = next.served
+		next.served += turn.cost
+		next.running++
+		s.running++
+		turn.granted = true
+		close(turn.ready)
+	}
+}
+
+// stats returns the number of calls that have a turn and that wait for one.
+func (s *fairScheduler) stats() (running, waiting int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.sessions {
+		waiting += len(q.waiting)
+	}
+	return s.running, waiting
+}
+
+// othersWaiting reports whether calls of sessions other than session wait
+// for a turn.
+func (s *fairScheduler) othersWaiting(session string) bool {
+	for id, q := range s.sessions {
+		if id != session && len(q.waiting) > 0 {
+			return true
+		}
+	}
+	return false
+}
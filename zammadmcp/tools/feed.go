@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// newTicketsFeedURI is the Atom feed of recently created tickets.
+	newTicketsFeedURI = "zammad://feeds/new-tickets"
+	// newTicketsFeedWindow and newTicketsFeedLimit bound the feed to the
+	// newest tickets of the last days, as feed readers only look at the head.
+	newTicketsFeedWindow = 7 * 24 * time.Hour
+	newTicketsFeedLimit  = 50
+)
+
+// atomFeed and atomEntry are the parts of an RFC 4287 Atom feed the new
+// tickets feed uses.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Link      atomLink      `xml:"link"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   string        `xml:"summary"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// handleNewTicketsFeed serves the Atom feed of the tickets created in the
+// last seven days, newest first, optionally of a single group given as the
+// group query parameter.
+func handleNewTicketsFeed(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+
+	var group string
+	switch v := request.Params.Arguments["group"].(type) {
+	case string:
+		group = v
+	case []string: // as matched by mcp-go's URI templates
+		if len(v) > 0 {
+			group = v[0]
+		}
+	}
+	groups, err := zammadClient.GroupList()
+	if err != nil {
+		log.Printf("Error listing groups from Zammad: %v", err)
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	groupNames := make(map[int]string, len(groups))
+	groupID := 0
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+		if group != "" && strings.EqualFold(g.Name, group) {
+			groupID, group = g.ID, g.Name
+		}
+	}
+	if group != "" && groupID == 0 {
+		return nil, fmt.Errorf("%w: unknown group %q", ErrResourceNotFound, group)
+	}
+
+	now := time.Now()
+	window := reportWindow{From: now.Add(-newTicketsFeedWindow), To: now}
+	query := window.rangeQuery("created_at")
+	if groupID != 0 {
+		query += fmt.Sprintf(" AND group_id:%d", groupID)
+	}
+	candidates, err := searchTicketRecords(query, queueSearchLimit)
+	if err != nil {
+		log.Printf("Error searching new tickets in Zammad: %v", err)
+		return nil, fmt.Errorf("failed to search new tickets: %w", err)
+	}
+	tickets := make([]ticketRecord, 0, len(candidates))
+	for _, t := range candidates {
+		if !t.CreatedAt.Before(window.From) && (groupID == 0 || t.GroupID == groupID) {
+			tickets = append(tickets, t)
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool { return tickets[i].CreatedAt.After(tickets[j].CreatedAt) })
+	tickets = tickets[:min(len(tickets), newTicketsFeedLimit)]
+	priorityNames := make(map[int]string)
+	if priorities, err := fetchTicketPriorities(); err == nil {
+		for _, p := range priorities {
+			priorityNames[p.ID] = p.Name
+		}
+	}
+
+	self := newTicketsFeedURI
+	title := localized(msgFeedTitle)
+	if groupID != 0 {
+		self += "?group=" + url.QueryEscape(group)
+		title = fmt.Sprintf(localized(msgFeedGroupTitle), group)
+	}
+	feed := atomFeed{
+		ID:      self,
+		Title:   title,
+		Updated: now.UTC().Format(time.RFC3339),
+		Author:  atomPerson{Name: "Zammad"},
+		Link:    atomLink{Rel: "self", Href: self},
+		Entries: []atomEntry{},
+	}
+	if len(tickets) > 0 {
+		feed.Updated = tickets[0].CreatedAt.UTC().Format(time.RFC3339)
+	}
+	for _, t := range tickets {
+		// A feed of new tickets: later updates do not make an entry new.
+		created := t.CreatedAt.UTC().Format(time.RFC3339)
+		entry := atomEntry{
+			ID:        ticketWebURL(t.ID),
+			Title:     fmt.Sprintf("#%s %s", t.Number, t.Title),
+			Updated:   created,
+			Published: created,
+			Link:      atomLink{Href: ticketWebURL(t.ID)},
+			Summary:   fmt.Sprintf(localized(msgFeedSummary), groupNames[t.GroupID], ticketStateName(t), priorityNames[t.PriorityID]),
+		}
+		if name := groupNames[t.GroupID]; name != "" {
+			entry.Category = &atomCategory{Term: name}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling the new tickets feed: %v", err)
+		return nil, fmt.Errorf("failed to marshal the new tickets feed: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/atom+xml",
+			Text:     xml.Header + string(data),
+		},
+	}, nil
+}
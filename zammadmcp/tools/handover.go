@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handoverResult is the outcome of a successful handover_ticket call.
+type handoverResult struct {
+	Ticket json.RawMessage      `json:"ticket"` // ticketRecord in the requested output profile
+	Note   zammad.TicketArticle `json:"note"`
+}
+
+// handleHandoverTicket reassigns a ticket, optionally moves it to another
+// group, and documents the handover in an internal note. Zammad cannot do
+// both in one transaction, so if the note cannot be posted the reassignment is
+// rolled back; a failed rollback is reported with the ticket's current state.
+func handleHandoverTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	ownerRef := mcp.ParseString(request, "owner", "")
+	group := mcp.ParseString(request, "group", "")
+	note := mcp.ParseString(request, "note", "")
+	if ticketID <= 0 || ownerRef == "" || note == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, owner, note"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+	note, err = expandVariables(note, ticketID)
+	if err != nil {
+		log.Printf("Error expanding variables for ticket %d: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr("Failed to expand the variables in note", err), nil
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	owner, err := resolveUser(ownerRef)
+	if err != nil {
+		log.Printf("Error resolving new owner %q: %v", ownerRef, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the new owner %q", ownerRef), err), nil
+	}
+	update := ticketUpdate{OwnerID: setTo(owner.ID)}
+	if group != "" {
+		groupID, err := groupIDByName(group)
+		if err != nil {
+			log.Printf("Error resolving group %q: %v", group, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+		}
+		update.GroupID = setTo(groupID)
+	}
+
+	updated, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error reassigning ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to reassign ticket %d; nothing was changed", ticketID), err), nil
+	}
+
+	article := zammad.TicketArticle{
+		TicketID:    ticketID,
+		Subject:     fmt.Sprintf(localized(msgHandoverSubject), userDisplayName(owner)),
+		Body:        note,
+		ContentType: "text/plain",
+		Type:        "note",
+		Internal:    true,
+	}
+	createdArticle, err := zammadClient.TicketArticleCreate(article)
+	if err != nil {
+		log.Printf("Error posting handover note on ticket %d, rolling back: %v", ticketID, err)
+		previous := ticketUpdate{OwnerID: setTo(ticket.OwnerID), GroupID: setTo(ticket.GroupID)}
+		if _, rollbackErr := updateTicket(ticketID, previous); rollbackErr != nil {
+			log.Printf("Error rolling back handover of ticket %d: %v", ticketID, rollbackErr)
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"Partial failure: ticket %d is now owned by user %d in group %d, but the handover note could not be posted (%v) and restoring the previous owner %d and group %d failed (%v). Post the note again or restore the previous assignment.",
+				ticketID, updated.OwnerID, updated.GroupID, err, ticket.OwnerID, ticket.GroupID, rollbackErr)), nil
+		}
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to post the handover note on ticket %d; the reassignment was rolled back", ticketID), err), nil
+	}
+
+	log.Printf("Handed over ticket %d to user %d (note article %d)", ticketID, owner.ID, createdArticle.ID)
+	undo := ticketUndo(ticket, ticketUpdatedAt(ticketID, updated.UpdatedAt), update)
+	undo.Remains = fmt.Sprintf("the handover note (article %d)", createdArticle.ID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("handed ticket %d over to %s", ticketID, userDisplayName(owner)), undo, ticketObject(ticketID), userObject(owner.ID), articleObject(ticketID, createdArticle.ID))
+	ticketData, err := profile.project(updated)
+	if err != nil {
+		log.Printf("Error marshalling handover result: %v", err)
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	jsonData, err := json.MarshalIndent(handoverResult{Ticket: ticketData, Note: createdArticle}, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling handover result: %v", err)
+		return nil, fmt.Errorf("failed to marshal handover result: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d handed over to %s:\n%s", ticketID, userDisplayName(owner), string(jsonData))), nil
+}
@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// historyEntry is a single record of /api/v1/ticket_history/{id}.
+type historyEntry struct {
+	ID          int       `json:"id"`
+	OID         int       `json:"o_id"`
+	Type        string    `json:"type"`
+	Object      string    `json:"object"`
+	Attribute   string    `json:"attribute"`
+	ValueFrom   string    `json:"value_from"`
+	ValueTo     string    `json:"value_to"`
+	CreatedByID int       `json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ticketHistory is the history of a ticket with the users referenced by it.
+type ticketHistory struct {
+	Entries []historyEntry
+	Users   map[int]string
+}
+
+// actor returns the display name of the user that made a change.
+func (h ticketHistory) actor(userID int) string {
+	if name, ok := h.Users[userID]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("user %d", userID)
+}
+
+// describeHistoryEntry renders a history entry as a short change description,
+// e.g. "priority: 2 normal → 3 high". It returns "" for entries that do not
+// describe a change to the ticket (such as sent notifications).
+func describeHistoryEntry(e historyEntry) string {
+	switch {
+	case e.Type == "updated":
+		return fmt.Sprintf("%s: %s → %s", e.Attribute, displayHistoryValue(e.ValueFrom), displayHistoryValue(e.ValueTo))
+	case e.Type == "added" && e.Attribute == "tag":
+		return fmt.Sprintf("tag added: %s", e.ValueTo)
+	case e.Type == "removed" && e.Attribute == "tag":
+		return fmt.Sprintf("tag removed: %s", e.ValueTo)
+	case e.Type == "created" && e.Object == "Ticket":
+		return "ticket created"
+	case e.Type == "created" && e.Object == "Ticket::Article":
+		return fmt.Sprintf("article %d added", e.OID)
+	case e.Type == "received_merge" || e.Type == "merged_into":
+		return fmt.Sprintf("%s: %s", strings.ReplaceAll(e.Type, "_", " "), e.ValueTo)
+	case e.Type == "added" || e.Type == "removed":
+		return fmt.Sprintf("%s %s: %s", e.Attribute, e.Type, e.ValueTo)
+	default:
+		return ""
+	}
+}
+
+func displayHistoryValue(v string) string {
+	if v == "" {
+		return "(empty)"
+	}
+	return v
+}
+
+// attributeDiff is the value of an attribute before and after a time range.
+type attributeDiff struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// handleDiffTicketChanges reconstructs a readable before/after diff of a
+// ticket's changes in a time range from the history API.
+func handleDiffTicketChanges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	since, err := parseOptionalTime(mcp.ParseString(request, "since", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid since: %v", err)), nil
+	}
+	until, err := parseOptionalTime(mcp.ParseString(request, "until", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid until: %v", err)), nil
+	}
+
+	history, err := fetchTicketHistory(ticketID)
+	if err != nil {
+		log.Printf("Error fetching history of ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get history of ticket %d", ticketID), err), nil
+	}
+
+	loc := displayLocation()
+	var lines []string
+	diff := make(map[string]*attributeDiff)
+	for _, e := range history.Entries {
+		if (!since.IsZero() && e.CreatedAt.Before(since)) || (!until.IsZero() && e.CreatedAt.After(until)) {
+			continue
+		}
+		description := describeHistoryEntry(e)
+		if description == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s by %s at %s", description, history.actor(e.CreatedByID), e.CreatedAt.In(loc).Format("2006-01-02 15:04 MST")))
+
+		if e.Type == "updated" {
+			if d, ok := diff[e.Attribute]; ok {
+				d.After = e.ValueTo
+			} else {
+				diff[e.Attribute] = &attributeDiff{Before: e.ValueFrom, After: e.ValueTo}
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No changes to ticket %d in the requested time range.", ticketID)), nil
+	}
+
+	diffData, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal diff of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	log.Printf("Reconstructed %d changes for ticket %d", len(lines), ticketID)
+	return mcp.NewToolResultText(fmt.Sprintf("Changes to ticket %d (%d):\n- %s\n\nNet attribute changes (before → after):\n%s",
+		ticketID, len(lines), strings.Join(lines, "\n- "), string(diffData))), nil
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp, returning the zero time for
+// an empty string.
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 timestamp such as 2024-05-01T14:02:00Z", value)
+	}
+	return t, nil
+}
+
+// ticketToucher is a user who worked on a ticket, from its history.
+type ticketToucher struct {
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	FirstTouch time.Time `json:"first_touch"`
+	LastTouch  time.Time `json:"last_touch"`
+	Changes    int       `json:"changes"`  // attribute and tag changes
+	Articles   int       `json:"articles"` // articles written, including notes
+}
+
+// ticketTouchReport is the result of who_touched_ticket.
+type ticketTouchReport struct {
+	TicketID          int             `json:"ticket_id"`
+	CreatedAt         time.Time       `json:"created_at"`
+	FirstTouch        *time.Time      `json:"first_touch,omitempty"`
+	FirstTouchBy      string          `json:"first_touch_by,omitempty"`
+	TimeToFirstTouch  string          `json:"time_to_first_touch,omitempty"`
+	LastTouch         *time.Time      `json:"last_touch,omitempty"`
+	LastTouchBy       string          `json:"last_touch_by,omitempty"`
+	TotalParticipants int             `json:"total_participants"`
+	Participants      []ticketToucher `json:"participants"` // by first touch
+}
+
+// handleWhoTouchedTicket reports which agents worked on a ticket and when,
+// from its history. Changes by the ticket's customer and by the system user
+// (e.g. triggers) are not touches.
+func handleWhoTouchedTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	history, err := fetchTicketHistory(ticketID)
+	if err != nil {
+		log.Printf("Error fetching history of ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get history of ticket %d", ticketID), err), nil
+	}
+
+	touchers := make(map[int]*ticketToucher)
+	var order []int
+	for _, e := range history.Entries {
+		if e.CreatedByID == unassignedOwnerID || e.CreatedByID == ticket.CustomerID || describeHistoryEntry(e) == "" {
+			continue
+		}
+		if e.Type == "created" && e.Object == "Ticket" {
+			continue // opening a ticket on a customer's behalf is not working on it
+		}
+		t, ok := touchers[e.CreatedByID]
+		if !ok {
+			t = &ticketToucher{UserID: e.CreatedByID, Name: history.actor(e.CreatedByID), FirstTouch: e.CreatedAt, LastTouch: e.CreatedAt}
+			touchers[e.CreatedByID] = t
+			order = append(order, e.CreatedByID)
+		}
+		if e.CreatedAt.Before(t.FirstTouch) {
+			t.FirstTouch = e.CreatedAt
+		}
+		if e.CreatedAt.After(t.LastTouch) {
+			t.LastTouch = e.CreatedAt
+		}
+		if e.Object == "Ticket::Article" {
+			t.Articles++
+		} else {
+			t.Changes++
+		}
+	}
+
+	report := ticketTouchReport{TicketID: ticketID, CreatedAt: ticket.CreatedAt, TotalParticipants: len(order), Participants: make([]ticketToucher, 0, len(order))}
+	for _, id := range order {
+		report.Participants = append(report.Participants, *touchers[id])
+	}
+	sort.SliceStable(report.Participants, func(i, j int) bool {
+		return report.Participants[i].FirstTouch.Before(report.Participants[j].FirstTouch)
+	})
+	if len(report.Participants) > 0 {
+		first := report.Participants[0]
+		report.FirstTouch, report.FirstTouchBy = &first.FirstTouch, first.Name
+		report.TimeToFirstTouch = formatDuration(first.FirstTouch.Sub(ticket.CreatedAt))
+		last := first
+		for _, p := range report.Participants[1:] {
+			if p.LastTouch.After(last.LastTouch) {
+				last = p
+			}
+		}
+		report.LastTouch, report.LastTouchBy = &last.LastTouch, last.Name
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal touches of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	if len(report.Participants) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No agent has worked on ticket %d yet:\n%s", ticketID, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%d agents worked on ticket %d:\n%s", len(report.Participants), ticketID, string(jsonData))), nil
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// icalEvent is a deadline exported to the pending.ics calendar. Deadlines are
+// points in time, so events have a start only.
+type icalEvent struct {
+	UID         string
+	Start       time.Time
+	Summary     string
+	Description string
+	URL         string
+	Category    string
+}
+
+// pendingDeadlines returns the pending reminders and escalation deadlines of
+// the open tickets owned by userID, earliest first.
+func pendingDeadlines(userID int) ([]icalEvent, error) {
+	tickets, err := searchQueue(openStateTypes, "", false, fmt.Sprintf("owner_id:%d", userID))
+	if err != nil {
+		return nil, err
+	}
+	states, err := fetchTicketStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ticket states: %w", err)
+	}
+	stateTypes := make(map[int]string, len(states))
+	for _, s := range states {
+		stateTypes[s.ID] = s.StateType
+	}
+	host := "zammad"
+	if u, err := url.Parse(zammadClient.Url); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	type deadline struct {
+		kind, label, category string
+		at                    *time.Time
+	}
+	var events []icalEvent
+	for _, t := range tickets {
+		deadlines := []deadline{
+			{"first-response", localized(msgFirstResponseDue), localized(msgEscalation), t.FirstResponseEscalationAt},
+			{"update", localized(msgUpdateDue), localized(msgEscalation), t.UpdateEscalationAt},
+			{"solution", localized(msgSolutionDue), localized(msgEscalation), t.CloseEscalationAt},
+		}
+		if stateTypes[t.StateID] == "pending reminder" {
+			deadlines = append(deadlines, deadline{"reminder", localized(msgReminder), localized(msgPendingReminder), t.PendingTime})
+		}
+		for _, d := range deadlines {
+			if d.at == nil || d.at.IsZero() {
+				continue
+			}
+			events = append(events, icalEvent{
+				// Stable per ticket and kind, so clients update moved
+				// deadlines instead of duplicating them.
+				UID:         fmt.Sprintf("ticket-%d-%s@%s", t.ID, d.kind, host),
+				Start:       *d.at,
+				Summary:     fmt.Sprintf("%s: #%s %s", d.label, t.Number, t.Title),
+				Description: fmt.Sprintf("Ticket #%s %q (%s)\n%s", t.Number, t.Title, ticketStateName(t), ticketWebURL(t.ID)),
+				URL:         ticketWebURL(t.ID),
+				Category:    d.category,
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// icalendar renders events as an RFC 5545 calendar.
+func icalendar(name string, events []icalEvent, now time.Time) string {
+	const stamp = "20060102T150405Z"
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		b.WriteString(icalFold(fmt.Sprintf(format, args...)))
+		b.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//zammad-mcp-go//Pending deadlines//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", icalText(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:%s", e.UID)
+		line("DTSTAMP:%s", now.UTC().Format(stamp))
+		line("DTSTART:%s", e.Start.UTC().Format(stamp))
+		line("SUMMARY:%s", icalText(e.Summary))
+		line("DESCRIPTION:%s", icalText(e.Description))
+		line("URL:%s", e.URL)
+		line("CATEGORIES:%s", icalText(e.Category))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icalText escapes a TEXT property value.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalFold folds a content line into lines of at most 75 octets, without
+// splitting UTF-8 sequences; continuation lines start with a space.
+func icalFold(s string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
+
+// handlePendingCalendar exports the pending reminders and escalation
+// deadlines of the API user's tickets as iCalendar, for calendar clients.
+func handlePendingCalendar(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+
+	me, err := zammadClient.UserMe()
+	if err != nil {
+		log.Printf("Error fetching the API user from Zammad: %v", err)
+		return nil, fmt.Errorf("failed to fetch the API user: %w", err)
+	}
+	events, err := pendingDeadlines(me.ID)
+	if err != nil {
+		log.Printf("Error fetching the deadlines of user %d from Zammad: %v", me.ID, err)
+		return nil, fmt.Errorf("failed to fetch deadlines: %w", err)
+	}
+	name := strings.TrimSpace(me.Firstname + " " + me.Lastname)
+	if name == "" {
+		name = me.Login
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/calendar",
+			Text:     icalendar(fmt.Sprintf(localized(msgCalendarName), name), events, time.Now()),
+		},
+	}, nil
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// idempotencySettings protect create_ticket against duplicate tickets when a
+// client retries a call whose answer it did not receive, e.g. after a
+// timeout. Calls passing the same request_id create at most one ticket.
+type idempotencySettings struct {
+	// TTL is how long a request_id is remembered after its call finished;
+	// zero disables the check.
+	TTL duration `yaml:"ttl"`
+	// CustomField is a custom text ticket attribute the request_id is also
+	// stored in, so that retries are recognized after a restart of the
+	// server, or by another instance, by searching for it. Empty keeps the
+	// request IDs in memory only.
+	CustomField string `yaml:"custom_field"`
+}
+
+func (s idempotencySettings) validate() error {
+	if s.TTL < 0 {
+		return errors.New("idempotency.ttl must not be negative")
+	}
+	return nil
+}
+
+// maxRequestIDLength bounds the request IDs kept in memory.
+const maxRequestIDLength = 128
+
+// errRequestIDReused is returned for a request_id used before with other
+// arguments.
+var errRequestIDReused = errors.New("request_id reused with other arguments")
+
+// idempotentCall is a create_ticket call remembered by its request_id.
+type idempotentCall struct {
+	done        chan struct{} // closed when the call finished
+	fingerprint string        // identifies the call's arguments
+	ticketID    int           // the created ticket, set before done is closed
+	finished    time.Time
+}
+
+// idempotencyStore holds the create_ticket calls of all sessions by
+// request_id; a client reconnecting after a timeout gets a new session.
+type idempotencyStore struct {
+	mu    sync.Mutex
+	calls map[string]*idempotentCall
+}
+
+var createRequests = &idempotencyStore{calls: make(map[string]*idempotentCall)}
+
+// begin claims requestID for a call with the given fingerprint. It returns
+// nil if the caller is to create the ticket, and must then call finish. If an
+// earlier call with the ID is still running, begin waits for it, and returns
+// it once it has created its ticket; if that call failed, the caller takes
+// over.
+func (s *idempotencyStore) begin(ctx context.Context, requestID, fingerprint string) (*idempotentCall, error) {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		for id, call := range s.calls {
+			if !call.finished.IsZero() && now.Sub(call.finished) > time.Duration(config.Idempotency.TTL) {
+				delete(s.calls, id)
+			}
+		}
+		call, ok := s.calls[requestID]
+		if !ok {
+			s.calls[requestID] = &idempotentCall{done: make(chan struct{}), fingerprint: fingerprint}
+			s.mu.Unlock()
+			return nil, nil
+		}
+		s.mu.Unlock()
+
+		if call.fingerprint != fingerprint {
+			return nil, errRequestIDReused
+		}
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.ticketID != 0 {
+			return call, nil
+		}
+	}
+}
+
+// finish records the ticket created by the call that claimed requestID, or
+// forgets the request ID if ticketID is 0, so a retry creates the ticket.
+func (s *idempotencyStore) finish(requestID string, ticketID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call := s.calls[requestID]
+	if ticketID == 0 {
+		delete(s.calls, requestID)
+	} else {
+		call.ticketID = ticketID
+		call.finished = time.Now()
+	}
+	close(call.done)
+}
+
+// requestFingerprint identifies the arguments of a call apart from its
+// request_id and output profile, which a retry may change.
+func requestFingerprint(args map[string]any) string {
+	args = maps.Clone(args)
+	delete(args, "request_id")
+	delete(args, "profile")
+	data, _ := json.Marshal(args) // map keys are sorted
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// findTicketByRequestID searches for a ticket created with requestID in the
+// idempotency custom field, and returns its ID or 0. Zammad indexes new
+// tickets for search with a short delay, so this recognizes retries after a
+// restart, while the in-memory store covers retries in quick succession.
+func findTicketByRequestID(requestID string) (int, error) {
+	tickets, err := searchTicketRecords(fmt.Sprintf("%s:%q", config.Idempotency.CustomField, requestID), 1)
+	if err != nil || len(tickets) == 0 {
+		return 0, err
+	}
+	return tickets[0].ID, nil
+}
+
+// replayCreatedTicket answers a retried create_ticket call with the ticket
+// the first call created.
+func replayCreatedTicket(ticketID int, requestID string, profile outputProfile) (*mcp.CallToolResult, error) {
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Ticket %d was already created for request_id %q, but it could not be read", ticketID, requestID), err), nil
+	}
+	log.Printf("Ticket %d was already created for request_id %q, not creating it again", ticketID, requestID)
+	ticketData, err := profile.project(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	result := createTicketResult{ID: ticket.ID, Number: ticket.Number, WebURL: ticket.WebURL, Ticket: ticketData, RequestID: requestID, Duplicate: true}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal created ticket: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket #%s was already created (ID %d) by an earlier call with request_id %q; no new ticket was created: %s\n%s",
+		ticket.Number, ticket.ID, requestID, ticket.WebURL, string(jsonData))), nil
+}
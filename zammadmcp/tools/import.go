@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// importRow is a single record of a bulk import, keyed by lower-cased column
+// name. Values are kept as strings; list-valued columns are split on demand.
+type importRow map[string]string
+
+// parseImportRows decodes CSV (with a header line) or a JSON array of objects
+// into rows. format may be "csv", "json" or empty to detect it from the data.
+func parseImportRows(format, data string) ([]importRow, error) {
+	data = strings.TrimSpace(data)
+	if format == "" {
+		format = "csv"
+		if strings.HasPrefix(data, "[") {
+			format = "json"
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseCSVRows(data)
+	case "json":
+		return parseJSONRows(data)
+	default:
+		return nil, fmt.Errorf("unsupported format %q (expected csv or json)", format)
+	}
+}
+
+func parseCSVRows(data string) ([]importRow, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", len(rows)+1, err)
+		}
+		row := importRow{}
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONRows(data string) ([]importRow, error) {
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(data), &records); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON rows: %w", err)
+	}
+
+	rows := make([]importRow, 0, len(records))
+	for _, record := range records {
+		row := importRow{}
+		for key, value := range record {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				row[strings.ToLower(key)] = strings.TrimSpace(v)
+			case []any:
+				parts := make([]string, 0, len(v))
+				for _, item := range v {
+					parts = append(parts, fmt.Sprint(item))
+				}
+				row[strings.ToLower(key)] = strings.Join(parts, ",")
+			default:
+				row[strings.ToLower(key)] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// list splits a multi-valued column on commas or semicolons.
+func (r importRow) list(key string) []string {
+	var values []string
+	for _, part := range strings.FieldsFunc(r[key], func(c rune) bool { return c == ',' || c == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// importRowResult reports the outcome of importing one row.
+type importRowResult struct {
+	Row          int      `json:"row"`
+	Status       string   `json:"status"`
+	TicketID     int      `json:"ticket_id,omitempty"`
+	TicketNumber string   `json:"ticket_number,omitempty"`
+	WebURL       string   `json:"web_url,omitempty"`
+	Title        string   `json:"title,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	TagErrors    []string `json:"tag_errors,omitempty"`
+
+	err error // why the ticket was not created, if Zammad was asked
+}
+
+// handleImportTickets creates one ticket per CSV/JSON row, reporting progress
+// after each batch and returning a per-row result report.
+func handleImportTickets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format := mcp.ParseString(request, "format", "")
+	data := mcp.ParseString(request, "data", "")
+	defaultGroup := mcp.ParseString(request, "default_group", "")
+	batchSize := mcp.ParseInt(request, "batch_size", 10)
+	if data == "" {
+		return mcp.NewToolResultError("Missing required argument: data"), nil
+	}
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+
+	rows, err := parseImportRows(format, data)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to parse import data", err), nil
+	}
+	if len(rows) == 0 {
+		return mcp.NewToolResultError("Import data contains no rows"), nil
+	}
+
+	results := make([]importRowResult, 0, len(rows))
+	created := 0
+	for start := 0; start < len(rows); start += batchSize {
+		if err := yieldTurn(ctx); err != nil {
+			log.Printf("Ticket import cancelled after %d rows: %v", start, err)
+			break
+		}
+
+		end := min(start+batchSize, len(rows))
+		for i := start; i < end; i++ {
+			result := importTicketRow(i+1, rows[i], defaultGroup)
+			if result.Status == "created" {
+				created++
+			}
+			results = append(results, result)
+		}
+
+		log.Printf("Imported batch of tickets: %d/%d rows processed", end, len(rows))
+		sendProgress(ctx, request, float64(end), float64(len(rows)), fmt.Sprintf("%d of %d rows processed", end, len(rows)))
+	}
+
+	if created > 0 {
+		var objects []actionObject
+		for _, result := range results {
+			if result.Status == "created" {
+				objects = append(objects, ticketObject(result.TicketID))
+			}
+		}
+		sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("imported %d tickets", created), nil, objects...)
+	}
+
+	resultData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling import report: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to format import report", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket import finished: %d of %d rows created.\n%s", created, len(rows), string(resultData))), nil
+}
+
+// importTicketRow creates the ticket for a single row and applies its tags.
+func importTicketRow(rowNumber int, row importRow, defaultGroup string) importRowResult {
+	result := importRowResult{Row: rowNumber, Title: row["title"]}
+
+	group := row["group"]
+	if group == "" {
+		group = defaultGroup
+	}
+	var missing []string
+	for _, field := range [][2]string{{"title", row["title"]}, {"customer", row["customer"]}, {"group", group}, {"body", row["body"]}} {
+		if field[1] == "" {
+			missing = append(missing, field[0])
+		}
+	}
+	if len(missing) > 0 {
+		result.Status = "failed"
+		result.Error = "missing required columns: " + strings.Join(missing, ", ")
+		return result
+	}
+
+	ticket := zammad.Ticket{
+		Title:    row["title"],
+		Group:    group,
+		Customer: row["customer"],
+		Article:  zammad.TicketArticle{Body: row["body"], Type: "note"},
+	}
+	createdTicket, err := zammadClient.TicketCreate(ticket)
+	if err != nil {
+		log.Printf("Error importing ticket row %d: %v", rowNumber, err)
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.err = err
+		return result
+	}
+
+	result.Status = "created"
+	result.TicketID = createdTicket.ID
+	result.TicketNumber = createdTicket.Number
+	result.WebURL = ticketWebURL(createdTicket.ID)
+	for _, tag := range row.list("tags") {
+		if err := addTicketTag(createdTicket.ID, tag); err != nil {
+			if queued := queueWriteOnOutage(queuedWrite{Kind: queuedTagAdd, TicketID: createdTicket.ID, Tag: tag}, err); queued != "" {
+				result.TagErrors = append(result.TagErrors, fmt.Sprintf("%s: queued, Zammad unreachable", tag))
+				continue
+			}
+			log.Printf("Error tagging imported ticket %d with %q: %v", createdTicket.ID, tag, err)
+			result.TagErrors = append(result.TagErrors, fmt.Sprintf("%s: %v", tag, err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// intakeMaxFileSize is the largest file the intake reads; larger files
+	// are moved to failed/.
+	intakeMaxFileSize = 10 << 20
+	// intakeSettleTime is how long a file must be unmodified before it is
+	// picked up, so files still being written are left alone.
+	intakeSettleTime = 5 * time.Second
+)
+
+// intakeSettings configures the file-drop ticket intake, which bridges
+// legacy processes that can only write files into Zammad.
+type intakeSettings struct {
+	// Directory is watched for .eml, .txt and .json files; empty disables
+	// the intake. Processed files are moved to its processed/ and failed/
+	// subdirectories.
+	Directory string `yaml:"directory"`
+	// Group is the group of the created tickets, unless a .json ticket names
+	// its own.
+	Group string `yaml:"group"`
+	// Customer (email address or login) is the customer of tickets from
+	// .txt files and of .json tickets that name none.
+	Customer string `yaml:"customer"`
+	// CreateCustomers creates a customer for .eml senders without an
+	// account.
+	CreateCustomers bool `yaml:"create_customers"`
+	// PollInterval is how often the directory is scanned.
+	PollInterval duration `yaml:"poll_interval"`
+}
+
+func (s intakeSettings) validate() error {
+	if s.Directory == "" {
+		return nil
+	}
+	if s.Group == "" {
+		return errors.New("intake.group must be set")
+	}
+	if s.PollInterval <= 0 {
+		return errors.New("intake.poll_interval must be positive")
+	}
+	info, err := os.Stat(s.Directory)
+	if err != nil {
+		return fmt.Errorf("intake.directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("intake.directory %s is not a directory", s.Directory)
+	}
+	return nil
+}
+
+// errIntakeRetry marks a file that is left in place to be tried again on the
+// next scan, because Zammad was unreachable before any ticket was created.
+var errIntakeRetry = errors.New("cannot reach Zammad")
+
+// StartIntake creates the subdirectories of the intake directory and scans
+// it every poll interval for the lifetime of the process.
+func StartIntake() error {
+	settings := config.Intake
+	for _, sub := range []string{"processed", "failed"} {
+		if err := os.MkdirAll(filepath.Join(settings.Directory, sub), 0o750); err != nil {
+			return err
+		}
+	}
+	log.Printf("File intake enabled for %s (group %q, every %s)", settings.Directory, settings.Group, time.Duration(settings.PollInterval))
+	go func() {
+		for {
+			if err := scanIntake(settings); err != nil {
+				log.Printf("File intake stopped: %v", err)
+				return
+			}
+			time.Sleep(time.Duration(settings.PollInterval))
+		}
+	}()
+	return nil
+}
+
+// scanIntake turns each settled .eml, .txt and .json file in the intake
+// directory into tickets, in name order. Hidden files and other extensions
+// are ignored, so writers can create files under a temporary name and
+// rename them when done. An error means a handled file could not be moved
+// away, so scanning again would create its tickets twice.
+func scanIntake(settings intakeSettings) error {
+	entries, err := os.ReadDir(settings.Directory)
+	if err != nil {
+		log.Printf("Error reading intake directory %s: %v", settings.Directory, err)
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") || (ext != ".eml" && ext != ".txt" && ext != ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < intakeSettleTime {
+			continue
+		}
+
+		path := filepath.Join(settings.Directory, name)
+		var tickets []int
+		if info.Size() > intakeMaxFileSize {
+			err = fmt.Errorf("file is larger than %d bytes", intakeMaxFileSize)
+		} else {
+			tickets, err = intakeFile(settings, path, ext)
+		}
+		if errors.Is(err, errIntakeRetry) {
+			log.Printf("Intake of %s postponed: %v", name, err)
+			return nil // the remaining files would fail the same way
+		}
+		dest := "processed"
+		if err != nil {
+			dest = "failed"
+			reason, _, _ := strings.Cut(err.Error(), "\n") // the row report goes to the .error file
+			log.Printf("Error creating tickets from intake file %s: %s", name, reason)
+		} else {
+			log.Printf("Created tickets %v from intake file %s", tickets, name)
+		}
+		if err := moveIntakeFile(settings.Directory, name, dest, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveIntakeFile moves a handled file to the dest subdirectory, prefixed with
+// the time to keep names unique. A failed file gets a .error file next to it
+// with the reason.
+func moveIntakeFile(dir, name, dest string, failure error) error {
+	target := filepath.Join(dir, dest, time.Now().UTC().Format("20060102T150405Z-")+name)
+	if err := os.Rename(filepath.Join(dir, name), target); err != nil {
+		return fmt.Errorf("failed to move %s to %s/: %w", name, dest, err)
+	}
+	if failure != nil {
+		if err := os.WriteFile(target+".error", []byte(failure.Error()+"\n"), 0o640); err != nil {
+			log.Printf("Error writing %s.error: %v", target, err)
+		}
+	}
+	return nil
+}
+
+// intakeFile creates the tickets of one file and returns their IDs.
+func intakeFile(settings intakeSettings, path, ext string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext {
+	case ".eml":
+		return intakeEmail(settings, string(data))
+	case ".txt":
+		// The first line is the title, the rest the body.
+		title, body, _ := strings.Cut(strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n")), "\n")
+		if body = strings.TrimSpace(body); body == "" {
+			body = title
+		}
+		return intakeRows(settings, []importRow{{"title": strings.TrimSpace(title), "body": body}})
+	default:
+		text := strings.TrimSpace(string(data))
+		if strings.HasPrefix(text, "{") {
+			text = "[" + text + "]" // a single ticket
+		}
+		rows, err := parseJSONRows(text)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, errors.New("the file contains no tickets")
+		}
+		return intakeRows(settings, rows)
+	}
+}
+
+// intakeEmail opens a ticket from a raw email, like
+// create_ticket_from_email_text.
+func intakeEmail(settings intakeSettings, text string) ([]int, error) {
+	email, err := parseEmailText(text)
+	if err != nil {
+		return nil, err
+	}
+	customerID, _, err := findOrCreateCustomer(email.From, settings.CreateCustomers)
+	if err == nil {
+		var ticket ticketRecord
+		if err = zammadRequest(http.MethodPost, "/api/v1/tickets", emailTicketPayload(email, settings.Group, customerID), &ticket); err == nil {
+			return []int{ticket.ID}, nil
+		}
+	}
+	if isUnreachable(err) {
+		return nil, fmt.Errorf("%w: %v", errIntakeRetry, err)
+	}
+	return nil, err
+}
+
+// intakeRows creates tickets like import_tickets, with the configured
+// customer for rows that name none. If some rows fail, the error is the
+// per-row report, so only the failed rows need to be dropped again.
+func intakeRows(settings intakeSettings, rows []importRow) ([]int, error) {
+	var tickets []int
+	results := make([]importRowResult, 0, len(rows))
+	unreachable := false
+	for i, row := range rows {
+		if row["customer"] == "" {
+			row["customer"] = settings.Customer
+		}
+		result := importTicketRow(i+1, row, settings.Group)
+		if result.Status == "created" {
+			tickets = append(tickets, result.TicketID)
+		}
+		unreachable = unreachable || isUnreachable(result.err)
+		results = append(results, result)
+	}
+	if len(tickets) == len(rows) {
+		return tickets, nil
+	}
+	if len(tickets) == 0 && unreachable {
+		return nil, fmt.Errorf("%w: %s", errIntakeRetry, results[0].Error)
+	}
+	report, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return tickets, fmt.Errorf("%d of %d tickets failed", len(rows)-len(tickets), len(rows))
+	}
+	return tickets, fmt.Errorf("%d of %d tickets failed:\n%s", len(rows)-len(tickets), len(rows), report)
+}
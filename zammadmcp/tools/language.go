@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageLetters is the number of letters below which detectLanguage
+// does not guess.
+const minLanguageLetters = 20
+
+// stopWords are frequent words of the Latin-script languages detectLanguage
+// tells apart.
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "to", "of", "in", "that", "it", "for", "you", "with", "on", "this", "have", "not", "be", "we", "please", "thanks", "my", "can", "will", "when", "what", "your", "there", "from", "but", "has", "been", "would", "it's"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "auf", "für", "ein", "eine", "zu", "wir", "haben", "bitte", "danke", "den", "von", "mein", "kann"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "pas", "je", "vous", "pour", "que", "qui", "dans", "avec", "sur", "merci", "nous", "ce", "mon"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "un", "una", "por", "para", "con", "no", "gracias", "nos", "su", "lo", "mi", "está"},
+	"it": {"il", "lo", "la", "gli", "le", "e", "è", "che", "di", "un", "una", "per", "non", "con", "sono", "grazie", "ci", "mi", "questo", "ho"},
+	"nl": {"de", "het", "een", "en", "is", "van", "ik", "niet", "wij", "we", "met", "op", "voor", "dat", "zijn", "u", "bedankt", "mijn", "kan"},
+	"pt": {"o", "a", "os", "as", "e", "é", "que", "de", "um", "uma", "não", "para", "com", "obrigado", "por", "em", "do", "da", "meu"},
+	"pl": {"i", "w", "na", "nie", "jest", "się", "że", "to", "z", "do", "dziękuję", "jak", "ale", "czy", "mam", "mój"},
+	"sv": {"och", "är", "att", "det", "som", "en", "ett", "inte", "jag", "vi", "med", "för", "på", "tack", "har", "min", "kan"},
+}
+
+// stopWordLanguages indexes stopWords by word.
+var stopWordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range stopWords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// detectLanguage guesses the ISO 639-1 code of the language of a text, or
+// returns "" if the text is too short or the guess is unclear. Texts in
+// other scripts than Latin are told apart by script, Latin-script texts by
+// their most frequent words. It is a heuristic for routing and translation,
+// not a classifier.
+func detectLanguage(text string) string {
+	scripts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			scripts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		}
+	}
+	if letters < minLanguageLetters {
+		return ""
+	}
+	other := 0
+	for _, n := range scripts {
+		other += n
+	}
+	if other*2 > letters {
+		// Japanese mixes kana with Han characters.
+		if scripts["ja"] > 0 {
+			return "ja"
+		}
+		language := ""
+		for script, n := range scripts {
+			if language == "" || n > scripts[language] {
+				language = script
+			}
+		}
+		if language == "ru" && strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+			return "uk"
+		}
+		return language
+	}
+
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, language := range stopWordLanguages[word] {
+			scores[language]++
+		}
+	}
+	best, second := "", 0
+	for language, score := range scores {
+		switch {
+		case best == "" || score > scores[best]:
+			if best != "" {
+				second = scores[best]
+			}
+			best = language
+		case score > second:
+			second = score
+		}
+	}
+	// Require a few stop words and a clear lead over the runner-up.
+	if best == "" || scores[best] < 3 || scores[best]*2 < second*3 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// argumentAliases maps argument names to other names some clients send for
+// them. An alias is only applied when the tool declares the argument, does
+// not declare the alias itself and the call does not already contain the
+// argument.
+var argumentAliases = map[string][]string{
+	"query":           {"q", "search", "search_query", "term"},
+	"ticket_id":       {"id", "ticket"},
+	"user_id":         {"id", "user"},
+	"organization_id": {"id", "org_id", "organization"},
+	"body":            {"text", "content", "message"},
+	"limit":           {"per_page", "max_results"},
+}
+
+// placeholderArguments are sent by some clients for tools without arguments
+// (Cursor sends random_string) and are dropped unless a tool declares them.
+var placeholderArguments = []string{"random_string"}
+
+// toolArgumentTypes holds the declared JSON Schema type of each argument by
+// tool name, read from the tool list after registration.
+var toolArgumentTypes map[string]map[string]string
+
+// loadToolArgumentTypes reads the argument types of the served tools for
+// withLenientArguments.
+func loadToolArgumentTypes(tools []servedTool) error {
+	types := make(map[string]map[string]string, len(tools))
+	for _, tool := range tools {
+		var schema inputSchema
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+			return fmt.Errorf("failed to read the input schema of %s: %w", tool.Name, err)
+		}
+		types[tool.Name] = make(map[string]string, len(schema.Properties))
+		for name, property := range schema.Properties {
+			types[tool.Name][name] = property.Type
+		}
+	}
+	toolArgumentTypes = types
+	return nil
+}
+
+// withLenientArguments repairs the arguments of clients that mangle tool
+// schemas before the handler sees them: camelCase and aliased names are
+// mapped to the declared names, numbers and booleans sent as strings are
+// converted, and placeholder arguments are dropped. Every correction is
+// logged. It runs before the response cache, so a repaired call shares the
+// cache entry of a correct one. Disabled with lenient_arguments: false.
+func withLenientArguments(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		declared, ok := toolArgumentTypes[request.Params.Name]
+		if !config.LenientArguments || !ok || len(request.Params.Arguments) == 0 {
+			return next(ctx, request)
+		}
+		args, corrections := repairArguments(declared, request.Params.Arguments)
+		if len(corrections) > 0 {
+			log.Printf("Corrected arguments of %s: %s", request.Params.Name, strings.Join(corrections, "; "))
+			request.Params.Arguments = args
+		}
+		return next(ctx, request)
+	}
+}
+
+// repairArguments returns a corrected copy of args for a tool declaring the
+// given argument types, and a description of each correction.
+func repairArguments(declared map[string]string, args map[string]any) (map[string]any, []string) {
+	repaired := make(map[string]any, len(args))
+	var corrections []string
+	for name, value := range args {
+		target := name
+		if _, ok := declared[name]; !ok {
+			target = declaredArgumentName(declared, name)
+		}
+		switch {
+		case target == "":
+			repaired[name] = value
+			continue
+		case target == "-":
+			corrections = append(corrections, fmt.Sprintf("dropped placeholder %s", name))
+			continue
+		case target != name:
+			_, given := args[target]
+			if _, taken := repaired[target]; given || taken {
+				corrections = append(corrections, fmt.Sprintf("ignored %s, as %s is given", name, target))
+				continue
+			}
+			corrections = append(corrections, fmt.Sprintf("renamed %s to %s", name, target))
+		}
+		if converted, ok := coerceArgument(declared[target], value); ok {
+			corrections = append(corrections, fmt.Sprintf("converted %s %q to a %s", target, value, declared[target]))
+			value = converted
+		}
+		repaired[target] = value
+	}
+	sort.Strings(corrections)
+	return repaired, corrections
+}
+
+// declaredArgumentName returns the declared argument an undeclared name
+// stands for, "-" for a placeholder to drop, or "" if there is none.
+func declaredArgumentName(declared map[string]string, name string) string {
+	if snake := snakeCase(name); snake != name {
+		if _, ok := declared[snake]; ok {
+			return snake
+		}
+		name = snake
+	}
+	// An alias such as id may stand for several arguments; it is only
+	// applied if the tool declares just one of them.
+	var matches []string
+	for target, aliases := range argumentAliases {
+		if _, ok := declared[target]; !ok {
+			continue
+		}
+		for _, alias := range aliases {
+			if alias == name {
+				matches = append(matches, target)
+			}
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0]
+	}
+	for _, placeholder := range placeholderArguments {
+		if placeholder == name {
+			return "-"
+		}
+	}
+	return ""
+}
+
+// snakeCase converts a camelCase name such as ticketId or ticketID to
+// ticket_id.
+func snakeCase(name string) string {
+	var b strings.Builder
+	previousUpper := false
+	for i, r := range name {
+		upper := unicode.IsUpper(r)
+		if upper {
+			if i > 0 && !previousUpper {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		previousUpper = upper
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// coerceArgument converts a string value to the declared number or boolean
+// type. It reports false if the value needs no or allows no conversion.
+func coerceArgument(declaredType string, value any) (any, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	s = strings.TrimSpace(s)
+	switch declaredType {
+	case "number", "integer":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n, true
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
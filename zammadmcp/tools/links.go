@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ticketLink is a link of a ticket as listed by the link API. The link type
+// is seen from the listed ticket: "child" links point to its children,
+// "parent" links to its parents and "normal" links are undirected.
+type ticketLink struct {
+	LinkType        string `json:"link_type"`
+	LinkObject      string `json:"link_object"`
+	LinkObjectValue int    `json:"link_object_value"`
+}
+
+// linkedTicket is a linked ticket in the assets of the link API.
+type linkedTicket struct {
+	ticketRecord
+	CloseAt  *time.Time `json:"close_at"`
+	linkType string
+}
+
+// ticketLinks is the response of the link API: the links of a ticket with the
+// linked tickets and their users as assets.
+type ticketLinks struct {
+	Links  []ticketLink `json:"links"`
+	Assets struct {
+		Ticket map[string]linkedTicket `json:"Ticket"`
+		User   map[string]zammad.User  `json:"User"`
+	} `json:"assets"`
+}
+
+// linkedTickets returns the child tickets of a ticket's links in the order
+// listed and, with includeRelated, the tickets linked as related (normal
+// links).
+func (l ticketLinks) linkedTickets(includeRelated bool) []linkedTicket {
+	var tickets []linkedTicket
+	for _, link := range l.Links {
+		if link.LinkObject != "Ticket" || !(link.LinkType == "child" || (includeRelated && link.LinkType == "normal")) {
+			continue
+		}
+		ticket, ok := l.Assets.Ticket[strconv.Itoa(link.LinkObjectValue)]
+		if !ok {
+			log.Printf("Linked ticket %d is missing from the link assets, skipping it", link.LinkObjectValue)
+			continue
+		}
+		ticket.linkType = link.LinkType
+		tickets = append(tickets, ticket)
+	}
+	return tickets
+}
+
+// linkedIncident is a ticket linked to a problem ticket.
+type linkedIncident struct {
+	ID        int        `json:"id"`
+	Number    string     `json:"number"`
+	Title     string     `json:"title"`
+	Link      string     `json:"link"` // child or normal
+	State     string     `json:"state"`
+	StateType string     `json:"state_type"`
+	Priority  string     `json:"priority,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	CloseAt   *time.Time `json:"close_at,omitempty"`
+	WebURL    string     `json:"web_url,omitempty"`
+	open      bool
+}
+
+// linkedIncidentsReport is the result of report_linked_incidents.
+type linkedIncidentsReport struct {
+	Problem struct {
+		ID     int    `json:"id"`
+		Number string `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		WebURL string `json:"web_url,omitempty"`
+		// ParentIDs are set when the ticket is itself a child, i.e. probably
+		// an incident rather than the problem.
+		ParentIDs []int `json:"parent_ids,omitempty"`
+	} `json:"problem"`
+	Total                 int              `json:"total"`
+	Open                  int              `json:"open"`
+	Closed                int              `json:"closed"`
+	ByState               map[string]int   `json:"by_state"`
+	AffectedCustomers     int              `json:"affected_customers"`
+	AffectedOrganizations int              `json:"affected_organizations"`
+	Incidents             []linkedIncident `json:"incidents"`
+}
+
+// handleReportLinkedIncidents lists the incidents linked to a problem ticket
+// as its children, with their states, for tracking major incidents the ITIL
+// way: one problem ticket with a child ticket per affected customer.
+func handleReportLinkedIncidents(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	includeRelated := mcp.ParseBoolean(request, "include_related", false)
+
+	problem, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	links, err := fetchTicketLinks(ticketID)
+	if err != nil {
+		log.Printf("Error fetching links of ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get links of ticket %d", ticketID), err), nil
+	}
+	states, err := fetchTicketStates()
+	if err != nil {
+		log.Printf("Error fetching ticket states from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get ticket states", err), nil
+	}
+	stateByID := make(map[int]ticketState, len(states))
+	for _, s := range states {
+		stateByID[s.ID] = s
+	}
+	priorityNames := make(map[int]string)
+	if priorities, err := fetchTicketPriorities(); err != nil {
+		log.Printf("Error fetching ticket priorities, leaving them out: %v", err)
+	} else {
+		for _, p := range priorities {
+			priorityNames[p.ID] = p.Name
+		}
+	}
+
+	report := linkedIncidentsReport{ByState: make(map[string]int), Incidents: []linkedIncident{}}
+	report.Problem.ID, report.Problem.Number, report.Problem.Title = problem.ID, problem.Number, problem.Title
+	report.Problem.State, report.Problem.WebURL = ticketStateName(problem), ticketWebURL(problem.ID)
+	customers, organizations := make(map[int]bool), make(map[int]bool)
+	for _, link := range links.Links {
+		if link.LinkObject == "Ticket" && link.LinkType == "parent" {
+			report.Problem.ParentIDs = append(report.Problem.ParentIDs, link.LinkObjectValue)
+		}
+	}
+	for _, ticket := range links.linkedTickets(includeRelated) {
+		state := stateByID[ticket.StateID]
+		if state.Name == "" {
+			state.Name = ticket.State
+		}
+		incident := linkedIncident{
+			ID: ticket.ID, Number: ticket.Number, Title: ticket.Title, Link: ticket.linkType,
+			State: state.Name, StateType: state.StateType, Priority: priorityNames[ticket.PriorityID],
+			CreatedAt: ticket.CreatedAt, UpdatedAt: ticket.UpdatedAt, WebURL: ticketWebURL(ticket.ID),
+			open: slices.Contains(openStateTypes, state.StateType),
+		}
+		if owner, ok := links.Assets.User[strconv.Itoa(ticket.OwnerID)]; ok && ticket.OwnerID != unassignedOwnerID {
+			incident.Owner = userDisplayName(owner)
+		}
+		if !incident.open {
+			incident.CloseAt = ticket.CloseAt
+		}
+		report.Incidents = append(report.Incidents, incident)
+		report.ByState[incident.State]++
+		if incident.open {
+			report.Open++
+		} else {
+			report.Closed++
+		}
+		if ticket.CustomerID > 0 {
+			customers[ticket.CustomerID] = true
+		}
+		if ticket.OrganizationID > 0 {
+			organizations[ticket.OrganizationID] = true
+		}
+	}
+	report.Total = len(report.Incidents)
+	report.AffectedCustomers, report.AffectedOrganizations = len(customers), len(organizations)
+	// Open incidents first, the longest waiting at the top.
+	sort.SliceStable(report.Incidents, func(i, j int) bool {
+		a, b := report.Incidents[i], report.Incidents[j]
+		if a.open != b.open {
+			return a.open
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal linked incidents of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	log.Printf("Found %d linked incidents of ticket %d, %d open", report.Total, ticketID, report.Open)
+	header := fmt.Sprintf("Incidents linked to ticket %d (%d open of %d)", ticketID, report.Open, report.Total)
+	if report.Total == 0 && len(report.Problem.ParentIDs) > 0 {
+		header += fmt.Sprintf(", it is a child of ticket %d: pass that ticket for the incidents", report.Problem.ParentIDs[0])
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// languageCodePattern matches an ISO 639-1 language code.
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// languageNames are the English names of common languages by ISO 639-1 code,
+// used to tell the model which language to write in.
+var languageNames = map[string]string{
+	"en": "English", "de": "German", "fr": "French", "es": "Spanish", "it": "Italian", "nl": "Dutch",
+	"pt": "Portuguese", "pl": "Polish", "sv": "Swedish", "da": "Danish", "no": "Norwegian", "fi": "Finnish",
+	"cs": "Czech", "hu": "Hungarian", "ro": "Romanian", "tr": "Turkish", "el": "Greek", "ru": "Russian",
+	"uk": "Ukrainian", "ja": "Japanese", "zh": "Chinese", "ko": "Korean",
+}
+
+// responseLanguageName names the configured response_language for prompts.
+func responseLanguageName() string {
+	if name, ok := languageNames[config.ResponseLanguage]; ok {
+		return name
+	}
+	return fmt.Sprintf("the language with the ISO 639-1 code %q", config.ResponseLanguage)
+}
+
+// serverInstructions are the instructions sent to clients on initialization,
+// asking the model to answer in the response_language unless it is English.
+func serverInstructions() string {
+	instructions := "This server provides access to Zammad tickets and users via resources and tools (e.g., create_ticket, get_ticket, search_tickets, get_user, search_users)."
+	if config.ResponseLanguage != "en" {
+		instructions += fmt.Sprintf(" Answer the user in %s.", responseLanguageName())
+	}
+	return instructions
+}
+
+// localized returns the translation of a server-generated English text into
+// the configured response_language, or the text itself if there is none. Texts
+// with verbs keep them in the same order, so the result can be used as a
+// format string in place of the original.
+func localized(text string) string {
+	if translated, ok := messageCatalogs[config.ResponseLanguage][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// Texts translated in messageCatalogs.
+const (
+	msgSummarySubject     = "AI-generated summary"
+	msgSummaryHeader      = "AI-generated summary (%s, via MCP sampling). Verify against the thread before relying on it."
+	msgSamplingMissing    = "This MCP client does not support sampling, so the server cannot ask its model for a summary. Summarize the thread yourself and use add_note_to_ticket instead."
+	msgHandoverSubject    = "Handover to %s"
+	msgMoreResults        = "More results are available: call %s again with the same arguments and cursor %q."
+	msgCondensed          = "[Condensed: the full result is about %d tokens, more than the server's token budget of %d. Only identifying fields are shown and long texts are shortened; fetch single items for the details, or narrow the request with limit, profile or cursor where the tool has them.]"
+	msgItemsOmitted       = "[%d of %d items are omitted.]"
+	msgConflict           = "Conflict: ticket %d was modified at %s (by user %d), after the expected_updated_at %s. No changes were made. Re-read the ticket and retry with its current updated_at. Current values:\n%s"
+	msgTimeout            = "Tool %s timed out after %s. Narrow the request or raise tool_timeouts.%s in the server configuration."
+	msgWriteTimeout       = "Tool %s timed out after %s, but its change may still be applied in Zammad. Check whether it was made before retrying, or it may be made twice."
+	msgStaged             = "Staged %s as pending action %d; nothing was changed yet. Show it to the user for review. It runs only once approve_pending_action is called with id %d after the user approved it:\n%s"
+	msgFeedTitle          = "New Zammad tickets"
+	msgFeedGroupTitle     = "New Zammad tickets in %s"
+	msgFeedSummary        = "New ticket in group %s, state %s, priority %s."
+	msgCalendarName       = "Zammad deadlines of %s"
+	msgFirstResponseDue   = "First response due"
+	msgUpdateDue          = "Update due"
+	msgSolutionDue        = "Solution due"
+	msgReminder           = "Reminder"
+	msgEscalation         = "Escalation"
+	msgPendingReminder    = "Pending reminder"
+	msgScheduledReport    = "Scheduled report %s of %s"
+	msgScheduledReportErr = "Scheduled report %s of %s failed"
+)
+
+// messageCatalogs are the translations of server-generated texts by
+// language. Texts missing from a catalog, and all tool names and arguments,
+// stay English.
+var messageCatalogs = map[string]map[string]string{
+	"de": {
+		msgSummarySubject:     "KI-generierte Zusammenfassung",
+		msgSummaryHeader:      "KI-generierte Zusammenfassung (%s, über MCP-Sampling). Vor der Verwendung mit dem Verlauf abgleichen.",
+		msgSamplingMissing:    "Dieser MCP-Client unterstützt kein Sampling, daher kann der Server sein Modell nicht um eine Zusammenfassung bitten. Fasse den Verlauf selbst zusammen und verwende stattdessen add_note_to_ticket.",
+		msgHandoverSubject:    "Übergabe an %s",
+		msgMoreResults:        "Weitere Ergebnisse sind verfügbar: rufe %s erneut mit denselben Argumenten und dem cursor %q auf.",
+		msgCondensed:          "[Gekürzt: das vollständige Ergebnis umfasst etwa %d Tokens, mehr als das Token-Budget des Servers von %d. Es werden nur identifizierende Felder gezeigt und lange Texte gekürzt; rufe einzelne Einträge für Details ab oder grenze die Anfrage mit limit, profile oder cursor ein, wo das Tool sie anbietet.]",
+		msgItemsOmitted:       "[%d von %d Einträgen sind ausgelassen.]",
+		msgConflict:           "Konflikt: Ticket %d wurde um %s (von Benutzer %d) geändert, nach dem expected_updated_at %s. Es wurde nichts geändert. Lies das Ticket erneut und versuche es mit seinem aktuellen updated_at noch einmal. Aktuelle Werte:\n%s",
+		msgTimeout:            "Das Tool %s hat nach %s das Zeitlimit überschritten. Grenze die Anfrage ein oder erhöhe tool_timeouts.%s in der Serverkonfiguration.",
+		msgWriteTimeout:       "Das Tool %s hat nach %s das Zeitlimit überschritten, seine Änderung kann in Zammad aber trotzdem noch ausgeführt werden. Prüfe vor einem erneuten Versuch, ob sie erfolgt ist, sonst wird sie womöglich doppelt ausgeführt.",
+		msgStaged:             "%s wurde als ausstehende Aktion %d vorgemerkt; es wurde noch nichts geändert. Zeige sie dem Benutzer zur Prüfung. Sie wird erst ausgeführt, wenn approve_pending_action nach der Freigabe durch den Benutzer mit id %d aufgerufen wird:\n%s",
+		msgFeedTitle:          "Neue Zammad-Tickets",
+		msgFeedGroupTitle:     "Neue Zammad-Tickets in %s",
+		msgFeedSummary:        "Neues Ticket in Gruppe %s, Status %s, Priorität %s.",
+		msgCalendarName:       "Zammad-Fristen von %s",
+		msgFirstResponseDue:   "Erste Reaktion fällig",
+		msgUpdateDue:          "Aktualisierung fällig",
+		msgSolutionDue:        "Lösung fällig",
+		msgReminder:           "Erinnerung",
+		msgEscalation:         "Eskalation",
+		msgPendingReminder:    "Warten auf Erinnerung",
+		msgScheduledReport:    "Geplanter Bericht %s vom %s",
+		msgScheduledReportErr: "Geplanter Bericht %s vom %s fehlgeschlagen",
+	},
+	"fr": {
+		msgSummarySubject:     "Résumé généré par IA",
+		msgSummaryHeader:      "Résumé généré par IA (%s, via l'échantillonnage MCP). À vérifier avec le fil avant de s'y fier.",
+		msgSamplingMissing:    "Ce client MCP ne prend pas en charge l'échantillonnage, le serveur ne peut donc pas demander de résumé à son modèle. Résume le fil toi-même et utilise plutôt add_note_to_ticket.",
+		msgHandoverSubject:    "Transfert à %s",
+		msgMoreResults:        "D'autres résultats sont disponibles : appelle de nouveau %s avec les mêmes arguments et le cursor %q.",
+		msgCondensed:          "[Condensé : le résultat complet fait environ %d tokens, plus que le budget de tokens du serveur de %d. Seuls les champs d'identification sont affichés et les textes longs sont raccourcis ; récupère les éléments un par un pour les détails, ou affine la requête avec limit, profile ou cursor lorsque l'outil les propose.]",
+		msgItemsOmitted:       "[%d éléments sur %d sont omis.]",
+		msgConflict:           "Conflit : le ticket %d a été modifié à %s (par l'utilisateur %d), après le expected_updated_at %s. Rien n'a été modifié. Relis le ticket et réessaie avec son updated_at actuel. Valeurs actuelles :\n%s",
+		msgTimeout:            "L'outil %s a dépassé le délai de %s. Affine la requête ou augmente tool_timeouts.%s dans la configuration du serveur.",
+		msgWriteTimeout:       "L'outil %s a dépassé le délai de %s, mais sa modification peut encore être appliquée dans Zammad. Vérifie si elle a été faite avant de réessayer, sinon elle risque d'être faite deux fois.",
+		msgStaged:             "%s est en attente comme action %d ; rien n'a encore été modifié. Montre-la à l'utilisateur pour validation. Elle ne s'exécute que lorsque approve_pending_action est appelé avec l'id %d après l'accord de l'utilisateur :\n%s",
+		msgFeedTitle:          "Nouveaux tickets Zammad",
+		msgFeedGroupTitle:     "Nouveaux tickets Zammad dans %s",
+		msgFeedSummary:        "Nouveau ticket dans le groupe %s, état %s, priorité %s.",
+		msgCalendarName:       "Échéances Zammad de %s",
+		msgFirstResponseDue:   "Première réponse due",
+		msgUpdateDue:          "Mise à jour due",
+		msgSolutionDue:        "Solution due",
+		msgReminder:           "Rappel",
+		msgEscalation:         "Escalade",
+		msgPendingReminder:    "Rappel en attente",
+		msgScheduledReport:    "Rapport planifié %s du %s",
+		msgScheduledReportErr: "Échec du rapport planifié %s du %s",
+	},
+	"es": {
+		msgSummarySubject:     "Resumen generado por IA",
+		msgSummaryHeader:      "Resumen generado por IA (%s, mediante muestreo MCP). Verifícalo con el hilo antes de confiar en él.",
+		msgSamplingMissing:    "Este cliente MCP no admite muestreo, por lo que el servidor no puede pedir un resumen a su modelo. Resume tú el hilo y usa add_note_to_ticket en su lugar.",
+		msgHandoverSubject:    "Traspaso a %s",
+		msgMoreResults:        "Hay más resultados disponibles: vuelve a llamar a %s con los mismos argumentos y el cursor %q.",
+		msgCondensed:          "[Resumido: el resultado completo tiene unos %d tokens, más que el presupuesto de tokens del servidor de %d. Solo se muestran los campos identificativos y los textos largos se acortan; obtén los elementos por separado para ver los detalles, o acota la solicitud con limit, profile o cursor si la herramienta los admite.]",
+		msgItemsOmitted:       "[Se omiten %d de %d elementos.]",
+		msgConflict:           "Conflicto: el ticket %d se modificó a las %s (por el usuario %d), después del expected_updated_at %s. No se ha cambiado nada. Vuelve a leer el ticket y reinténtalo con su updated_at actual. Valores actuales:\n%s",
+		msgTimeout:            "La herramienta %s agotó el tiempo de espera tras %s. Acota la solicitud o aumenta tool_timeouts.%s en la configuración del servidor.",
+		msgWriteTimeout:       "La herramienta %s agotó el tiempo de espera tras %s, pero su cambio aún puede aplicarse en Zammad. Comprueba si se ha hecho antes de reintentarlo, o podría hacerse dos veces.",
+		msgStaged:             "%s queda pendiente como acción %d; todavía no se ha cambiado nada. Muéstrasela al usuario para que la revise. Solo se ejecuta cuando se llama a approve_pending_action con el id %d tras la aprobación del usuario:\n%s",
+		msgFeedTitle:          "Nuevos tickets de Zammad",
+		msgFeedGroupTitle:     "Nuevos tickets de Zammad en %s",
+		msgFeedSummary:        "Nuevo ticket en el grupo %s, estado %s, prioridad %s.",
+		msgCalendarName:       "Plazos de Zammad de %s",
+		msgFirstResponseDue:   "Vence la primera respuesta",
+		msgUpdateDue:          "Vence la actualización",
+		msgSolutionDue:        "Vence la solución",
+		msgReminder:           "Recordatorio",
+		msgEscalation:         "Escalado",
+		msgPendingReminder:    "Recordatorio pendiente",
+		msgScheduledReport:    "Informe programado %s del %s",
+		msgScheduledReportErr: "Falló el informe programado %s del %s",
+	},
+	"it": {
+		msgSummarySubject:     "Riepilogo generato dall'IA",
+		msgSummaryHeader:      "Riepilogo generato dall'IA (%s, tramite campionamento MCP). Verificalo con la conversazione prima di farvi affidamento.",
+		msgSamplingMissing:    "Questo client MCP non supporta il campionamento, quindi il server non può chiedere un riepilogo al suo modello. Riassumi tu la conversazione e usa invece add_note_to_ticket.",
+		msgHandoverSubject:    "Passaggio a %s",
+		msgMoreResults:        "Sono disponibili altri risultati: chiama di nuovo %s con gli stessi argomenti e il cursor %q.",
+		msgCondensed:          "[Condensato: il risultato completo è di circa %d token, più del budget di token del server di %d. Vengono mostrati solo i campi identificativi e i testi lunghi sono abbreviati; recupera i singoli elementi per i dettagli, o restringi la richiesta con limit, profile o cursor se lo strumento li prevede.]",
+		msgItemsOmitted:       "[%d elementi su %d sono omessi.]",
+		msgConflict:           "Conflitto: il ticket %d è stato modificato alle %s (dall'utente %d), dopo l'expected_updated_at %s. Non è stato modificato nulla. Rileggi il ticket e riprova con il suo updated_at attuale. Valori attuali:\n%s",
+		msgTimeout:            "Lo strumento %s ha superato il tempo limite dopo %s. Restringi la richiesta o aumenta tool_timeouts.%s nella configurazione del server.",
+		msgWriteTimeout:       "Lo strumento %s ha superato il tempo limite dopo %s, ma la sua modifica può ancora essere applicata in Zammad. Verifica se è stata fatta prima di riprovare, altrimenti potrebbe essere fatta due volte.",
+		msgStaged:             "%s è in attesa come azione %d; non è stato ancora modificato nulla. Mostrala all'utente per la verifica. Viene eseguita solo quando approve_pending_action viene chiamato con l'id %d dopo l'approvazione dell'utente:\n%s",
+		msgFeedTitle:          "Nuovi ticket Zammad",
+		msgFeedGroupTitle:     "Nuovi ticket Zammad in %s",
+		msgFeedSummary:        "Nuovo ticket nel gruppo %s, stato %s, priorità %s.",
+		msgCalendarName:       "Scadenze Zammad di %s",
+		msgFirstResponseDue:   "Prima risposta in scadenza",
+		msgUpdateDue:          "Aggiornamento in scadenza",
+		msgSolutionDue:        "Soluzione in scadenza",
+		msgReminder:           "Promemoria",
+		msgEscalation:         "Escalation",
+		msgPendingReminder:    "Promemoria in attesa",
+		msgScheduledReport:    "Report pianificato %s del %s",
+		msgScheduledReportErr: "Report pianificato %s del %s non riuscito",
+	},
+	"nl": {
+		msgSummarySubject:     "Door AI gegenereerde samenvatting",
+		msgSummaryHeader:      "Door AI gegenereerde samenvatting (%s, via MCP-sampling). Controleer deze aan de hand van de conversatie voordat je erop vertrouwt.",
+		msgSamplingMissing:    "Deze MCP-client ondersteunt geen sampling, dus de server kan zijn model niet om een samenvatting vragen. Vat de conversatie zelf samen en gebruik in plaats daarvan add_note_to_ticket.",
+		msgHandoverSubject:    "Overdracht aan %s",
+		msgMoreResults:        "Er zijn meer resultaten beschikbaar: roep %s opnieuw aan met dezelfde argumenten en cursor %q.",
+		msgCondensed:          "[Ingekort: het volledige resultaat is ongeveer %d tokens, meer dan het tokenbudget van de server van %d. Alleen identificerende velden worden getoond en lange teksten zijn ingekort; haal afzonderlijke items op voor de details, of beperk de aanvraag met limit, profile of cursor als de tool die heeft.]",
+		msgItemsOmitted:       "[%d van %d items zijn weggelaten.]",
+		msgConflict:           "Conflict: ticket %d is gewijzigd om %s (door gebruiker %d), na de expected_updated_at %s. Er is niets gewijzigd. Lees het ticket opnieuw en probeer het nogmaals met de huidige updated_at. Huidige waarden:\n%s",
+		msgTimeout:            "De tool %s heeft na %s de tijdslimiet overschreden. Beperk de aanvraag of verhoog tool_timeouts.%s in de serverconfiguratie.",
+		msgWriteTimeout:       "De tool %s heeft na %s de tijdslimiet overschreden, maar de wijziging kan in Zammad nog worden doorgevoerd. Controleer of ze is uitgevoerd voordat je het opnieuw probeert, anders gebeurt ze mogelijk twee keer.",
+		msgStaged:             "%s staat klaar als openstaande actie %d; er is nog niets gewijzigd. Laat deze aan de gebruiker zien ter controle. De actie wordt pas uitgevoerd als approve_pending_action na goedkeuring door de gebruiker met id %d wordt aangeroepen:\n%s",
+		msgFeedTitle:          "Nieuwe Zammad-tickets",
+		msgFeedGroupTitle:     "Nieuwe Zammad-tickets in %s",
+		msgFeedSummary:        "Nieuw ticket in groep %s, status %s, prioriteit %s.",
+		msgCalendarName:       "Zammad-deadlines van %s",
+		msgFirstResponseDue:   "Eerste reactie verwacht",
+		msgUpdateDue:          "Update verwacht",
+		msgSolutionDue:        "Oplossing verwacht",
+		msgReminder:           "Herinnering",
+		msgEscalation:         "Escalatie",
+		msgPendingReminder:    "Wachtend op herinnering",
+		msgScheduledReport:    "Gepland rapport %s van %s",
+		msgScheduledReportErr: "Gepland rapport %s van %s mislukt",
+	},
+	"pt": {
+		msgSummarySubject:     "Resumo gerado por IA",
+		msgSummaryHeader:      "Resumo gerado por IA (%s, via amostragem MCP). Verifique com a conversa antes de confiar nele.",
+		msgSamplingMissing:    "Este cliente MCP não suporta amostragem, por isso o servidor não pode pedir um resumo ao seu modelo. Resuma a conversa você mesmo e use add_note_to_ticket em vez disso.",
+		msgHandoverSubject:    "Transferência para %s",
+		msgMoreResults:        "Há mais resultados disponíveis: chame %s novamente com os mesmos argumentos e o cursor %q.",
+		msgCondensed:          "[Condensado: o resultado completo tem cerca de %d tokens, mais do que o orçamento de tokens do servidor de %d. Apenas os campos de identificação são mostrados e os textos longos são encurtados; obtenha os itens individualmente para os detalhes, ou restrinja o pedido com limit, profile ou cursor quando a ferramenta os tiver.]",
+		msgItemsOmitted:       "[%d de %d itens foram omitidos.]",
+		msgConflict:           "Conflito: o ticket %d foi modificado às %s (pelo utilizador %d), depois do expected_updated_at %s. Nada foi alterado. Leia o ticket novamente e tente outra vez com o updated_at atual. Valores atuais:\n%s",
+		msgTimeout:            "A ferramenta %s excedeu o tempo limite após %s. Restrinja o pedido ou aumente tool_timeouts.%s na configuração do servidor.",
+		msgWriteTimeout:       "A ferramenta %s excedeu o tempo limite após %s, mas a sua alteração ainda pode ser aplicada no Zammad. Verifique se foi feita antes de tentar novamente, ou poderá ser feita duas vezes.",
+		msgStaged:             "%s ficou pendente como ação %d; nada foi alterado ainda. Mostre-a ao utilizador para revisão. Só é executada quando approve_pending_action for chamado com o id %d após a aprovação do utilizador:\n%s",
+		msgFeedTitle:          "Novos tickets do Zammad",
+		msgFeedGroupTitle:     "Novos tickets do Zammad em %s",
+		msgFeedSummary:        "Novo ticket no grupo %s, estado %s, prioridade %s.",
+		msgCalendarName:       "Prazos do Zammad de %s",
+		msgFirstResponseDue:   "Primeira resposta pendente",
+		msgUpdateDue:          "Atualização pendente",
+		msgSolutionDue:        "Solução pendente",
+		msgReminder:           "Lembrete",
+		msgEscalation:         "Escalonamento",
+		msgPendingReminder:    "Lembrete pendente",
+		msgScheduledReport:    "Relatório agendado %s de %s",
+		msgScheduledReportErr: "Falha no relatório agendado %s de %s",
+	},
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	zammad "github.com/AlessandroSechi/zammad-go"
+)
+
+// zammadMetrics counts the requests to Zammad and the connections of the
+// HTTP client's pool. They are served in the Prometheus text format at
+// /metrics on ZAMMAD_MCP_METRICS_ADDR.
+type zammadMetrics struct {
+	mu             sync.Mutex
+	requests       int64
+	failed         int64 // network errors and non-2xx responses
+	slow           int64
+	inFlight       int64
+	seconds        float64 // total duration, up to the end of the response body
+	connWaitSecs   float64 // time waiting for a connection from the pool
+	connsOpened    int64
+	connsReused    int64
+	connsOpen      int64
+	tlsHandshakes  int64
+	connectFailure int64
+}
+
+var zammadStats = &zammadMetrics{}
+
+// requestTiming records the phases of one request to Zammad, for the
+// slow-call log.
+type requestTiming struct {
+	mu           sync.Mutex // the hooks may run on the transport's goroutines
+	start        time.Time
+	getConn      time.Time
+	gotConn      time.Time
+	reused       bool
+	connect      time.Duration
+	tlsHandshake time.Duration
+	firstByte    time.Time
+}
+
+// clientTrace returns the httptrace hooks that fill t and count connections.
+func (m *zammadMetrics) clientTrace(t *requestTiming) *httptrace.ClientTrace {
+	var connectStart, tlsStart time.Time
+	return &httptrace.ClientTrace{
+		GetConn: func(string) { t.set(func() { t.getConn = time.Now() }) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.set(func() { t.gotConn, t.reused = time.Now(), info.Reused })
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if info.Reused {
+				m.connsReused++
+			}
+			m.connWaitSecs += t.connWait().Seconds()
+		},
+		ConnectStart: func(string, string) { t.set(func() { connectStart = time.Now() }) },
+		ConnectDone: func(_, _ string, err error) {
+			t.set(func() { t.connect = time.Since(connectStart) })
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if err != nil {
+				m.connectFailure++
+			}
+		},
+		TLSHandshakeStart: func() { t.set(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.set(func() { t.tlsHandshake = time.Since(tlsStart) })
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.tlsHandshakes++
+		},
+		GotFirstResponseByte: func() { t.set(func() { t.firstByte = time.Now() }) },
+	}
+}
+
+func (t *requestTiming) set(update func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update()
+}
+
+// connWait is how long the request waited for a connection.
+func (t *requestTiming) connWait() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.gotConn.Sub(t.getConn)
+}
+
+// describe summarizes where the time of a request went.
+func (t *requestTiming) describe() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var parts []string
+	if !t.gotConn.IsZero() {
+		if t.reused {
+			parts = append(parts, "reused connection")
+		} else {
+			parts = append(parts, "new connection")
+		}
+		parts = append(parts, fmt.Sprintf("waited %s for it", t.gotConn.Sub(t.getConn).Round(time.Millisecond)))
+	}
+	if t.connect > 0 {
+		parts = append(parts, fmt.Sprintf("connect %s", t.connect.Round(time.Millisecond)))
+	}
+	if t.tlsHandshake > 0 {
+		parts = append(parts, fmt.Sprintf("TLS handshake %s", t.tlsHandshake.Round(time.Millisecond)))
+	}
+	if !t.firstByte.IsZero() {
+		parts = append(parts, fmt.Sprintf("first byte after %s", t.firstByte.Sub(t.start).Round(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// finish counts a completed request and logs it if it took at least
+// config.SlowCallThreshold.
+func (m *zammadMetrics) finish(req *http.Request, t *requestTiming, status string, failed bool) {
+	elapsed := time.Since(t.start)
+	threshold := time.Duration(config.SlowCallThreshold)
+	slow := threshold > 0 && elapsed >= threshold
+
+	m.mu.Lock()
+	m.requests++
+	m.inFlight--
+	m.seconds += elapsed.Seconds()
+	if failed {
+		m.failed++
+	}
+	if slow {
+		m.slow++
+	}
+	m.mu.Unlock()
+
+	if slow {
+		details := t.describe()
+		if details != "" {
+			details = "; " + details
+		}
+		log.Printf("Warning: slow Zammad call %s %s took %s (%s%s)", req.Method, req.URL.Path, elapsed.Round(time.Millisecond), status, details)
+	}
+}
+
+// metricsDoer times the requests to Zammad. The request ends when its
+// response body is closed, so reading a large body counts toward it.
+type metricsDoer struct {
+	m    *zammadMetrics
+	next zammad.Doer
+}
+
+func (d metricsDoer) Do(req *http.Request) (*http.Response, error) {
+	t := &requestTiming{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), d.m.clientTrace(t)))
+	d.m.mu.Lock()
+	d.m.inFlight++
+	d.m.mu.Unlock()
+
+	resp, err := d.next.Do(req)
+	if err != nil {
+		d.m.finish(req, t, "failed: "+err.Error(), true)
+		return nil, err
+	}
+	resp.Body = &timedBody{ReadCloser: resp.Body, finish: func() {
+		d.m.finish(req, t, resp.Status, resp.StatusCode < 200 || resp.StatusCode > 299)
+	}}
+	return resp, nil
+}
+
+// timedBody calls finish when it is closed the first time.
+type timedBody struct {
+	io.ReadCloser
+	finish func()
+	once   sync.Once
+}
+
+func (b *timedBody) Close() error {
+	b.once.Do(b.finish)
+	return b.ReadCloser.Close()
+}
+
+// countConnections wraps the dial function of the Zammad HTTP transport to
+// count the connections it opens and closes.
+func (m *zammadMetrics) countConnections(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.connsOpened++
+		m.connsOpen++
+		return &countedConn{Conn: conn, m: m}, nil
+	}
+}
+
+// countedConn decrements the open connections when it is closed.
+type countedConn struct {
+	net.Conn
+	m    *zammadMetrics
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		c.m.mu.Lock()
+		defer c.m.mu.Unlock()
+		c.m.connsOpen--
+	})
+	return c.Conn.Close()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *zammadMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	metric := func(name, kind, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	m.mu.Lock()
+	metric("zammad_mcp_zammad_requests_total", "counter", "Requests sent to Zammad.", m.requests)
+	metric("zammad_mcp_zammad_request_failures_total", "counter", "Requests to Zammad that failed or got a non-2xx response.", m.failed)
+	metric("zammad_mcp_zammad_slow_requests_total", "counter", "Requests to Zammad that took at least slow_call_threshold.", m.slow)
+	metric("zammad_mcp_zammad_requests_in_flight", "gauge", "Requests to Zammad in progress.", m.inFlight)
+	metric("zammad_mcp_zammad_request_seconds_total", "counter", "Total duration of the requests to Zammad, including reading the response.", m.seconds)
+	metric("zammad_mcp_zammad_connection_wait_seconds_total", "counter", "Total time requests waited for a connection from the pool.", m.connWaitSecs)
+	metric("zammad_mcp_zammad_connections_opened_total", "counter", "Connections opened to Zammad.", m.connsOpened)
+	metric("zammad_mcp_zammad_connections_reused_total", "counter", "Requests that reused a pooled connection.", m.connsReused)
+	metric("zammad_mcp_zammad_connections_open", "gauge", "Connections to Zammad currently open, idle or in use.", m.connsOpen)
+	metric("zammad_mcp_zammad_connect_failures_total", "counter", "Failed attempts to connect to Zammad.", m.connectFailure)
+	metric("zammad_mcp_zammad_tls_handshakes_total", "counter", "TLS handshakes with Zammad.", m.tlsHandshakes)
+	m.mu.Unlock()
+	metric("zammad_mcp_zammad_max_idle_connections", "gauge", "Idle connections the pool keeps for reuse.", zammadMaxIdleConns)
+	if s := toolScheduler; s != nil {
+		running, waiting := s.stats()
+		metric("zammad_mcp_tool_turns_running", "gauge", "Tool calls working against Zammad (fair scheduling).", running)
+		metric("zammad_mcp_tool_turns_waiting", "gauge", "Tool calls waiting for their turn (fair scheduling).", waiting)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	io.WriteString(w, b.String())
+}
+
+// ServeMetrics serves /metrics on addr in the background.
+func ServeMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", zammadStats)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Error serving metrics on %s: %v", addr, err)
+		}
+	}()
+	log.Printf("Serving metrics on %s at /metrics", addr)
+}
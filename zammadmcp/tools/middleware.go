@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Middleware is a named tool handler middleware. The cross-cutting behavior
+// of the toolset, such as timeouts, access control and the response cache,
+// is a chain of them, which programs embedding the toolset can rearrange or
+// extend with their own.
+type Middleware struct {
+	Name string
+	Wrap server.ToolHandlerMiddleware
+}
+
+// DefaultMiddleware returns the middleware chain of the toolset, outermost
+// first. Each middleware is inactive unless its setting enables it, except
+// logging, timeout, lenient_arguments, current_ticket and recovery.
+func DefaultMiddleware() []Middleware {
+	return []Middleware{
+		{"logging", withCallLogging},                // Log calls and failures with their duration
+		{"audit", withAuditLog},                     // Append every call to the audit log
+		{"timeout", withToolTimeout},                // Bound tool calls (outside recovery, so it runs inside)
+		{"access", withToolAccess},                  // Refuse tools not allowed by the access settings
+		{"rate_limit", withRateLimit},               // Bound the calls of each session
+		{"fair_scheduling", withFairScheduling},     // Share Zammad fairly between sse sessions
+		{"lenient_arguments", withLenientArguments}, // Repair mangled argument names and types
+		{"current_ticket", withCurrentTicket},       // Resolve ticket_id "current" to the session's ticket
+		{"dry_run", withDryRun},                     // Answer writes with what they would do
+		{"approval", withApproval},                  // Stage writes for human review in approval mode
+		{"token_budget", withTokenBudget},           // Condense results too large for small contexts
+		{"response_cache", withResponseCache},       // Reuse results of identical read-only calls
+		{"recovery", withRecovery},                  // Recover from panics in handlers
+	}
+}
+
+// InsertMiddleware returns chain with m inserted before the middleware named
+// before, or appended as the innermost if there is none of that name.
+func InsertMiddleware(chain []Middleware, before string, m Middleware) []Middleware {
+	for i, existing := range chain {
+		if existing.Name == before {
+			return append(chain[:i:i], append([]Middleware{m}, chain[i:]...)...)
+		}
+	}
+	return append(chain[:len(chain):len(chain)], m)
+}
+
+// replaceMiddleware returns chain with the middleware named name replaced
+// by wrap.
+func replaceMiddleware(chain []Middleware, name string, wrap server.ToolHandlerMiddleware) []Middleware {
+	replaced := append([]Middleware{}, chain...)
+	for i := range replaced {
+		if replaced[i].Name == name {
+			replaced[i].Wrap = wrap
+		}
+	}
+	return replaced
+}
+
+// isWriteCall reports whether a call changes Zammad data, judged by
+// stagedTools. The arguments are judged as the handler receives them, after
+// the lenient-argument repair, even where the check runs before the repair
+// in the chain, as the access check does.
+func isWriteCall(request mcp.CallToolRequest) bool {
+	writes, ok := stagedTools[request.Params.Name]
+	if !ok {
+		return false
+	}
+	if declared, ok := toolArgumentTypes[request.Params.Name]; ok && config.LenientArguments {
+		request.Params.Arguments, _ = repairArguments(declared, request.Params.Arguments)
+	}
+	return writes(request)
+}
+
+// withCallLogging logs each tool call, and calls that fail with their
+// duration.
+func withCallLogging(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		log.Printf("Handling tool call: %s", request.Params.Name)
+		start := time.Now()
+		result, err := next(ctx, request)
+		if err != nil {
+			log.Printf("Tool call %s failed after %s: %v", request.Params.Name, time.Since(start).Round(time.Millisecond), err)
+		} else if result != nil && result.IsError {
+			log.Printf("Tool call %s returned an error after %s", request.Params.Name, time.Since(start).Round(time.Millisecond))
+		}
+		return result, err
+	}
+}
+
+// withRecovery turns a panic in a handler into an error of the call, like
+// mcp-go's server.WithRecovery.
+func withRecovery(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic recovered in %s tool handler: %v", request.Params.Name, p)
+			}
+		}()
+		return next(ctx, request)
+	}
+}
+
+// accessSettings restrict which tools may be called, for deployments where
+// the model should only read, or only use some tools.
+type accessSettings struct {
+	// ReadOnly refuses every call that changes Zammad data.
+	ReadOnly bool `yaml:"read_only"`
+	// Allow lists the tools that may be called, by name or pattern such as
+	// get_*; empty allows all tools.
+	Allow []string `yaml:"allow"`
+	// Deny lists tools that may not be called; it wins over Allow.
+	Deny []string `yaml:"deny"`
+}
+
+func (a accessSettings) validate() error {
+	for _, pattern := range append(append([]string{}, a.Allow...), a.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("access: invalid tool pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// allows reports whether the named tool may be called.
+func (a accessSettings) allows(tool string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, tool); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return !matches(a.Deny) && (len(a.Allow) == 0 || matches(a.Allow))
+}
+
+// withToolAccess refuses calls of tools the access settings do not allow.
+func withToolAccess(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !config.Access.allows(request.Params.Name) {
+			log.Printf("Refused call of %s: not allowed by the access settings", request.Params.Name)
+			return mcp.NewToolResultError(fmt.Sprintf("Access denied: %s is not allowed on this server.", request.Params.Name)), nil
+		}
+		if config.Access.ReadOnly && isWriteCall(request) {
+			log.Printf("Refused call of %s: the server is read-only", request.Params.Name)
+			return mcp.NewToolResultError(fmt.Sprintf("Access denied: %s changes Zammad data, and this server is read-only.", request.Params.Name)), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// withDryRun answers calls that change Zammad data with the call that would
+// have been made instead of running them, when dry_run is on. It runs after
+// the argument repair and the current ticket resolution, so the reported
+// arguments are those that would be executed.
+func withDryRun(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !config.DryRun || !isWriteCall(request) {
+			return next(ctx, request)
+		}
+		log.Printf("Dry run: skipped %s", request.Params.Name)
+		jsonData, err := json.MarshalIndent(request.Params.Arguments, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal arguments: %w", err) // Internal server error
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: %s was not executed and nothing was changed. It would have been called with:\n%s", request.Params.Name, string(jsonData))), nil
+	}
+}
@@ -0,0 +1,1157 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// mockZammadURL is the base URL reported by the mock; it is never dialed.
+const mockZammadURL = "http://zammad.mock"
+
+// NewMockClient returns a client for an in-memory fake Zammad seeded with
+// sample data, for trying the tools without an instance. Changes are lost
+// when the process exits.
+func NewMockClient() *zammad.Client {
+	return &zammad.Client{Url: mockZammadURL, Token: "mock", Client: mockDoer{handler: newMockZammad()}}
+}
+
+// record is a Zammad object as its JSON attributes.
+type record = map[string]any
+
+// mockZammad is an in-memory fake of the part of the Zammad REST API used by
+// this server, seeded with sample data. It backs the --mock flag so the server
+// can be tried without a Zammad instance. Writes are kept in memory only.
+type mockZammad struct {
+	mu            sync.Mutex
+	me            int
+	users         map[int]record
+	organizations map[int]record
+	groups        map[int]record
+	signatures    map[int]record
+	states        map[int]record
+	priorities    map[int]record
+	tickets       map[int]record
+	articles      map[int]record
+	tags          map[int][]string
+	history       map[int][]record
+	links         map[int][]record // links by ticket ID, as listed by the link API
+	calendar      record
+	channels      record   // /api/v1/channels_email response
+	attributes    []record // object manager attribute definitions
+	templates     []record
+	notifications map[int]record
+	files         map[int]mockFile // attachment contents by attachment ID
+	nextID        int
+}
+
+// mockFile is the content of an attachment, served as is instead of JSON.
+type mockFile struct {
+	contentType string
+	data        []byte
+}
+
+// mockDoer serves requests of the zammad-go client from the mock without a
+// network round trip.
+type mockDoer struct {
+	handler http.Handler
+}
+
+func (d mockDoer) Do(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	d.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// newMockZammad returns a mock seeded with a small help desk: an agent, a few
+// customers in two organizations and tickets in different states.
+func newMockZammad() *mockZammad {
+	m := &mockZammad{
+		users:         make(map[int]record),
+		organizations: make(map[int]record),
+		groups:        make(map[int]record),
+		signatures:    make(map[int]record),
+		states:        make(map[int]record),
+		priorities:    make(map[int]record),
+		tickets:       make(map[int]record),
+		articles:      make(map[int]record),
+		tags:          make(map[int][]string),
+		history:       make(map[int][]record),
+		links:         make(map[int][]record),
+		notifications: make(map[int]record),
+		files:         make(map[int]mockFile),
+		nextID:        100,
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	ago := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+
+	m.organizations[1] = record{"id": 1, "name": "Acme Corp", "domain": "acme.example", "active": true, "shared": true, "vip": true, "note": "Key account", "account_manager": "Sam Support", "contract_tier": "gold", "member_ids": []int{2, 3}, "created_at": ago(400 * 24 * time.Hour), "updated_at": ago(30 * 24 * time.Hour)}
+	m.organizations[2] = record{"id": 2, "name": "Globex", "domain": "globex.example", "active": true, "shared": true, "vip": false, "note": "", "account_manager": "", "contract_tier": "standard", "member_ids": []int{4}, "created_at": ago(200 * 24 * time.Hour), "updated_at": ago(10 * 24 * time.Hour)}
+	m.organizations[3] = record{"id": 3, "name": "ACME Corporation", "domain": "www.acme.example", "active": true, "shared": true, "vip": false, "note": "Created by the web form", "member_ids": []int{}, "created_at": ago(20 * 24 * time.Hour), "updated_at": ago(20 * 24 * time.Hour)}
+
+	m.me = 6
+	for _, u := range []record{
+		{"id": unassignedOwnerID, "login": "-", "firstname": "-", "lastname": "", "email": "", "organization_id": 0, "role_ids": []int{}},
+		{"id": 2, "login": "anna.smith@acme.example", "firstname": "Anna", "lastname": "Smith", "email": "anna.smith@acme.example", "organization_id": 1, "role_ids": []int{3}},
+		{"id": 3, "login": "bob.jones@acme.example", "firstname": "Bob", "lastname": "Jones", "email": "bob.jones@acme.example", "organization_id": 1, "role_ids": []int{3}},
+		{"id": 4, "login": "carla.diaz@globex.example", "firstname": "Carla", "lastname": "Diaz", "email": "carla.diaz@globex.example", "organization_id": 2, "role_ids": []int{3}},
+		{"id": 5, "login": "sam.support@helpdesk.example", "firstname": "Sam", "lastname": "Support", "email": "sam.support@helpdesk.example", "organization_id": 0, "role_ids": []int{2},
+			"group_ids": record{"1": []string{"full"}, "2": []string{"full"}}},
+		{"id": 6, "login": "alex.agent@helpdesk.example", "firstname": "Alex", "lastname": "Agent", "email": "alex.agent@helpdesk.example", "organization_id": 0, "role_ids": []int{2},
+			"group_ids": record{"1": []string{"read"}, "2": []string{"full"}}},
+	} {
+		u["active"] = u["id"] != unassignedOwnerID
+		u["vip"] = u["id"] == 2
+		u["created_at"] = ago(365 * 24 * time.Hour)
+		u["updated_at"] = ago(24 * time.Hour)
+		m.users[u["id"].(int)] = u
+	}
+
+	m.groups[1] = record{"id": 1, "name": "Users", "active": true}
+	m.groups[2] = record{"id": 2, "name": "Support", "active": true, "signature_id": 1}
+	m.signatures[1] = record{"id": 1, "name": "Support", "active": true,
+		"body": "<p>Best regards,<br>#{user.firstname} #{user.lastname}<br>Example Support Team</p>"}
+
+	for _, s := range []record{
+		{"id": 1, "name": "new", "state_type": "new"},
+		{"id": 2, "name": "open", "state_type": "open"},
+		{"id": 3, "name": "pending reminder", "state_type": "pending reminder"},
+		{"id": 4, "name": "closed", "state_type": "closed"},
+		{"id": 5, "name": "merged", "state_type": "merged"},
+		{"id": 6, "name": "pending close", "state_type": "pending action", "next_state_id": 4},
+	} {
+		s["active"] = true
+		m.states[s["id"].(int)] = s
+	}
+	m.priorities[1] = record{"id": 1, "name": "1 low", "active": true}
+	m.priorities[2] = record{"id": 2, "name": "2 normal", "active": true}
+	m.priorities[3] = record{"id": 3, "name": "3 high", "active": true}
+
+	for i, a := range []record{
+		{"name": "title", "data_type": "input", "editable": false},
+		{"name": "group_id", "data_type": "select", "editable": false},
+		{"name": "state_id", "data_type": "select", "editable": false},
+		{"name": "priority_id", "data_type": "select", "editable": false},
+		{"name": "owner_id", "data_type": "select", "editable": false},
+		{"name": "customer_id", "data_type": "user_autocompletion", "editable": false},
+		{"name": "product", "display": "Product", "data_type": "select", "editable": true,
+			"data_option": record{"options": record{"printer": "Printer", "scanner": "Scanner", "billing": "Billing portal"}}},
+		{"name": "severity", "display": "Severity", "data_type": "tree_select", "editable": true,
+			"data_option": record{"options": []record{
+				{"name": "Outage", "value": "outage", "children": []record{{"name": "Full", "value": "outage::full"}, {"name": "Partial", "value": "outage::partial"}}},
+				{"name": "Question", "value": "question"},
+			}}},
+		{"name": "contract_reference", "display": "Contract reference", "data_type": "input", "editable": true},
+		{"name": "affected_users", "display": "Affected users", "data_type": "integer", "editable": true},
+		{"name": "legacy_code", "display": "Legacy code", "data_type": "input", "editable": true, "active": false},
+	} {
+		a["id"], a["object"] = i+1, "Ticket"
+		if a["active"] == nil {
+			a["active"] = true
+		}
+		if a["data_option"] == nil {
+			a["data_option"] = record{}
+		}
+		m.attributes = append(m.attributes, a)
+	}
+
+	// Templates in the format of Zammad 5.2 and later, the legacy format
+	// without wrapped values, and an inactive one.
+	m.templates = []record{
+		{"id": 1, "name": "Hardware request", "active": true, "options": record{
+			"ticket.title":       record{"value": "Hardware request"},
+			"ticket.group_id":    record{"value": "2"},
+			"ticket.priority_id": record{"value": "3"},
+			"ticket.product":     record{"value": "printer"},
+			"ticket.tags":        record{"value": "hardware", "operator": "add"},
+			"article.body":       record{"value": "Device:\nProblem:\nSince when:"},
+		}},
+		{"id": 2, "name": "Password reset", "options": record{
+			"ticket.title":    "Password reset",
+			"ticket.group_id": "1",
+			"article.body":    "The customer cannot log in and asks for a password reset.",
+		}},
+		{"id": 3, "name": "Old intake form", "active": false, "options": record{"ticket.title": record{"value": "Intake"}}},
+	}
+
+	m.channels = record{
+		"channel_ids": []int{1, 2},
+		"assets": record{
+			"Channel": record{
+				"1": record{"id": 1, "area": "Email::Account", "group_id": 2, "active": true,
+					"options": record{
+						"inbound":  record{"adapter": "imap", "options": record{"host": "imap.example.com", "user": "support@example.com", "password": "secret"}},
+						"outbound": record{"adapter": "smtp", "options": record{"host": "smtp.example.com", "user": "support@example.com", "password": "secret"}},
+					},
+					"preferences": record{"last_fetch": ago(time.Minute)},
+					"status_in":   "ok", "status_out": "ok", "last_log_in": "", "last_log_out": ""},
+				"2": record{"id": 2, "area": "Email::Account", "group_id": 1, "active": true,
+					"options": record{
+						"inbound":  record{"adapter": "imap", "options": record{"host": "imap.example.com", "user": "billing@example.com", "password": "secret"}},
+						"outbound": record{"adapter": "smtp", "options": record{"host": "smtp.example.com", "user": "billing@example.com", "password": "secret"}},
+					},
+					"preferences": record{"last_fetch": ago(3 * time.Hour)},
+					"status_in":   "ok", "status_out": "error", "last_log_in": "",
+					"last_log_out": "Can't use Channel::Driver::Smtp: #<Net::SMTPAuthenticationError: 535 5.7.8 Authentication failed>"},
+			},
+			"EmailAddress": record{
+				"1": record{"id": 1, "channel_id": 1, "email": "support@example.com"},
+				"2": record{"id": 2, "channel_id": 2, "email": "billing@example.com"},
+			},
+		},
+	}
+
+	m.calendar = record{
+		"id": 1, "name": "Standard", "timezone": "Europe/Berlin", "default": true,
+		"business_hours":  record{},
+		"public_holidays": record{},
+	}
+	for _, day := range []string{"mon", "tue", "wed", "thu", "fri"} {
+		m.calendar["business_hours"].(record)[day] = record{"active": true, "timeframes": [][2]string{{"09:00", "17:00"}}}
+	}
+
+	seed := []struct {
+		title                                 string
+		customer, group, state, priority, age int
+		escalateIn                            time.Duration
+		tags                                  []string
+		thread                                []string
+	}{
+		{"Printer on fire", 2, 2, 2, 3, 3, 2 * time.Hour, []string{"hardware", "urgent"}, []string{
+			"Hi, the printer on the 3rd floor is smoking and smells burnt. Please help!",
+			"Thanks Anna, please unplug it right away. A technician is on the way.",
+			"It's unplugged now. When will the technician arrive?",
+		}},
+		{"Cannot log in to VPN", 3, 2, 1, 2, 1, 30 * time.Minute, []string{"vpn"}, []string{
+			"Since this morning the VPN client says 'authentication failed'. My password works everywhere else.",
+		}},
+		{"Invoice 2024-118 has wrong VAT", 4, 1, 3, 2, 8, 0, []string{"billing"}, []string{
+			"The invoice lists 19% VAT but we are VAT exempt, see our certificate attached.",
+			"Thank you, we forwarded this to accounting and will get back to you by Friday.",
+		}},
+		{"Feature request: dark mode", 2, 1, 4, 1, 20, 0, nil, []string{
+			"Would be great to have a dark mode in the customer portal.",
+			"Thanks for the suggestion, we added it to our roadmap.",
+		}},
+		{"VPN authentication failed for all users", 4, 2, 2, 3, 1, 4 * time.Hour, []string{"vpn"}, []string{
+			"None of us can connect to the VPN since this morning, the client says 'authentication failed'.",
+		}},
+	}
+	for i, s := range seed {
+		id := i + 1
+		created := now.Add(-time.Duration(s.age) * 24 * time.Hour)
+		customer := m.users[s.customer]
+		owner := m.me
+		if s.state == 1 {
+			owner = unassignedOwnerID // new tickets are not dispatched yet
+		}
+		ticket := record{
+			"id": id, "number": strconv.Itoa(31000 + id), "title": s.title,
+			"group_id": s.group, "group": m.groups[s.group]["name"],
+			"state_id": s.state, "state": m.states[s.state]["name"],
+			"priority_id": s.priority, "priority": m.priorities[s.priority]["name"],
+			"customer_id": s.customer, "customer": customer["email"], "organization_id": customer["organization_id"],
+			"owner_id": owner, "created_by_id": s.customer, "updated_by_id": m.me,
+			"created_at": created.Format(time.RFC3339), "updated_at": ago(time.Duration(s.age) * time.Hour),
+		}
+		if m.states[s.state]["state_type"] == "closed" {
+			ticket["close_at"] = created.Add(time.Duration(s.age) * 12 * time.Hour).Format(time.RFC3339)
+		}
+		if s.escalateIn != 0 {
+			ticket["escalation_at"] = now.Add(s.escalateIn).Format(time.RFC3339)
+			ticket["first_response_escalation_at"] = now.Add(s.escalateIn).Format(time.RFC3339)
+		}
+		m.tickets[id] = ticket
+		m.tags[id] = s.tags
+		m.addHistory(id, record{"type": "created", "object": "Ticket", "o_id": id, "created_by_id": s.customer, "created_at": created.Format(time.RFC3339)})
+
+		for j, body := range s.thread {
+			from, sender, author := customer["email"].(string), "Customer", s.customer
+			if j%2 == 1 {
+				from, sender, author = "Alex Agent", "Agent", m.me
+			}
+			m.addArticle(id, record{
+				"body": body, "content_type": "text/plain", "type": "email", "sender": sender, "from": from,
+				"internal": false, "created_by_id": author,
+				"created_at": created.Add(time.Duration(j) * time.Hour).Format(time.RFC3339),
+			})
+		}
+	}
+	m.tickets[4]["csat_rating"] = 4 // rated by a survey add-on
+	// Ticket 5 has the same cause as ticket 2, the expired VPN certificate.
+	m.addLink(2, 5)
+	m.addAttachment(2, "vpn-client.log", "text/plain", []byte(strings.Join([]string{
+		"2024-05-06 08:02:11 INFO  Connecting to vpn.helpdesk.example:443",
+		"2024-05-06 08:02:12 INFO  TLS handshake complete",
+		"2024-05-06 08:02:12 ERROR Authentication failed: certificate for user bob.jones has expired",
+		"2024-05-06 08:02:12 INFO  Disconnected",
+	}, "\n")))
+	m.addAttachment(1, "printer.jpg", "image/jpeg", mockPhoto(2400, 1800))
+	m.addAttachment(3, "vat-exemption-certificate.pdf", "application/pdf", mockPDF(
+		"VAT Exemption Certificate",
+		"Globex is exempt from value added tax under section 4 no. 21 UStG.",
+		"Valid from 2024-01-01 until 2026-12-31.",
+	))
+	m.addHistory(1, record{"type": "updated", "object": "Ticket", "o_id": 1, "attribute": "priority", "value_from": "2 normal", "value_to": "3 high", "created_by_id": m.me, "created_at": ago(70 * time.Hour)})
+	m.addHistory(1, record{"type": "updated", "object": "Ticket", "o_id": 1, "attribute": "state", "value_from": "new", "value_to": "open", "created_by_id": m.me, "created_at": ago(69 * time.Hour)})
+	m.addHistory(1, record{"type": "added", "object": "Ticket", "o_id": 1, "attribute": "tag", "value_to": "hardware", "created_by_id": 5, "created_at": ago(71 * time.Hour)})
+	for i, n := range []record{
+		{"o_id": 1, "type": "update", "seen": false, "created_by": "Anna Smith", "created_at": ago(2 * time.Hour)},
+		{"o_id": 2, "type": "create", "seen": false, "created_by": "Bob Jones", "created_at": ago(24 * time.Hour)},
+		{"o_id": 3, "type": "update", "seen": true, "created_by": "Carla Diaz", "created_at": ago(48 * time.Hour)},
+	} {
+		n["id"], n["object"], n["user_id"] = i+1, "Ticket", m.me
+		m.notifications[i+1] = n
+	}
+	return m
+}
+
+func (m *mockZammad) id() int {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *mockZammad) addHistory(ticketID int, entry record) {
+	entry["id"] = m.id()
+	m.history[ticketID] = append(m.history[ticketID], entry)
+}
+
+// addLink links a child ticket to its parent, listed from both sides.
+func (m *mockZammad) addLink(parentID, childID int) {
+	m.links[parentID] = append(m.links[parentID], record{"link_type": "child", "link_object": "Ticket", "link_object_value": childID})
+	m.links[childID] = append(m.links[childID], record{"link_type": "parent", "link_object": "Ticket", "link_object_value": parentID})
+}
+
+func (m *mockZammad) addArticle(ticketID int, article record) record {
+	id := m.id()
+	article["id"] = id
+	article["ticket_id"] = ticketID
+	// zammad-go sends zero timestamps for unset fields.
+	if created, _ := article["created_at"].(string); created == "" || strings.HasPrefix(created, "0001-") {
+		article["created_at"] = time.Now().UTC().Format(time.RFC3339)
+	}
+	m.articles[id] = article
+	ticket := m.tickets[ticketID]
+	count, _ := ticket["article_count"].(int)
+	ticket["article_count"] = count + 1
+	if internal, _ := article["internal"].(bool); !internal {
+		ticket["last_contact_at"] = article["created_at"]
+		if article["sender"] == "Customer" {
+			ticket["last_contact_customer_at"] = article["created_at"]
+		} else {
+			ticket["last_contact_agent_at"] = article["created_at"]
+			if _, ok := ticket["first_response_at"]; !ok {
+				ticket["first_response_at"] = article["created_at"]
+			}
+		}
+	}
+	m.addHistory(ticketID, record{"type": "created", "object": "Ticket::Article", "o_id": id, "created_by_id": article["created_by_id"], "created_at": article["created_at"]})
+	return article
+}
+
+// addAttachment attaches a file to the first article of a ticket.
+func (m *mockZammad) addAttachment(ticketID int, filename, contentType string, data []byte) {
+	for _, a := range sortedRecords(m.articles) {
+		if a["ticket_id"] == ticketID {
+			m.attachFile(a, filename, contentType, data)
+			return
+		}
+	}
+}
+
+func (m *mockZammad) attachFile(article record, filename, contentType string, data []byte) {
+	id := m.id()
+	attachments, _ := article["attachments"].([]record)
+	article["attachments"] = append(attachments, record{"id": id, "filename": filename, "size": strconv.Itoa(len(data)),
+		"preferences": record{"Content-Type": contentType}})
+	m.files[id] = mockFile{contentType, data}
+}
+
+// attachUploads attaches the files uploaded with a new article, as sent in
+// its attachments field.
+func (m *mockZammad) attachUploads(article record, uploads []any) bool {
+	for _, u := range uploads {
+		upload, _ := u.(record)
+		data, err := base64.StdEncoding.DecodeString(fmt.Sprint(upload["data"]))
+		if err != nil {
+			return false
+		}
+		m.attachFile(article, fmt.Sprint(upload["filename"]), fmt.Sprint(upload["mime-type"]), data)
+	}
+	return true
+}
+
+// mockPDF returns a one-page PDF showing lines of text, with a compressed
+// content stream like those of office suites.
+func mockPDF(lines ...string) []byte {
+	var content bytes.Buffer
+	content.WriteString("BT /F1 12 Tf 72 720 Td 14 TL\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(line))
+	}
+	content.WriteString("ET\n")
+	var stream bytes.Buffer
+	w := zlib.NewWriter(&stream)
+	w.Write(content.Bytes())
+	w.Close()
+
+	var pdf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, pdf.Len())
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	pdf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>")
+	object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return pdf.Bytes()
+}
+
+// mockPhoto returns a JPEG of the given size, a gradient standing in for a
+// photo taken with a phone.
+func mockPhoto(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(255 * x / width), uint8(255 * y / height), 96, 255})
+		}
+	}
+	var b bytes.Buffer
+	jpeg.Encode(&b, img, &jpeg.Options{Quality: 90})
+	return b.Bytes()
+}
+
+// mockRoute matches a request path against a pattern such as
+// "/api/v1/tickets/{id}" and returns the numeric {id}.
+func mockRoute(path, pattern string) (int, bool) {
+	prefix, suffix, hasID := strings.Cut(pattern, "{id}")
+	if !hasID {
+		return 0, path == pattern
+	}
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix))
+	return id, err == nil
+}
+
+func (m *mockZammad) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var body record
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	status, response := m.route(r.Method, r.URL.Path, r.URL.Query(), body)
+	if file, ok := response.(mockFile); ok {
+		w.Header().Set("Content-Type", file.contentType)
+		w.WriteHeader(status)
+		w.Write(file.data)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func notFound(method, path string) (int, any) {
+	return http.StatusNotFound, record{"error": fmt.Sprintf("No route matches [%s] %s", method, path)}
+}
+
+func (m *mockZammad) route(method, path string, query url.Values, body record) (int, any) {
+	get := method == http.MethodGet
+	if _, ok := mockRoute(path, "/api/v1/users/me"); ok && get {
+		return http.StatusOK, m.users[m.me]
+	}
+	if _, ok := mockRoute(path, "/api/v1/users/search"); ok && get {
+		return http.StatusOK, searchRecords(m.users, query.Get("query"), "login", "firstname", "lastname", "email")
+	}
+	if _, ok := mockRoute(path, "/api/v1/users"); ok {
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, page(m.users, query)
+		case http.MethodPost:
+			return m.createUser(body)
+		}
+	}
+	if id, ok := mockRoute(path, "/api/v1/users/{id}"); ok {
+		switch method {
+		case http.MethodGet:
+			return found(m.users, "User", id)
+		case http.MethodPut:
+			if to, ok := body["organization_id"]; ok {
+				m.moveMember(id, intValue(to))
+			}
+			return m.updateAttributes(m.users, "User", id, body)
+		}
+	}
+	if _, ok := mockRoute(path, "/api/v1/organizations/search"); ok && get {
+		return http.StatusOK, searchRecords(m.organizations, query.Get("query"), "name", "domain")
+	}
+	if _, ok := mockRoute(path, "/api/v1/organizations"); ok && get {
+		return http.StatusOK, page(m.organizations, query)
+	}
+	if id, ok := mockRoute(path, "/api/v1/organizations/{id}"); ok {
+		switch method {
+		case http.MethodGet:
+			return found(m.organizations, "Organization", id)
+		case http.MethodPut:
+			return m.updateAttributes(m.organizations, "Organization", id, body)
+		}
+	}
+	if _, ok := mockRoute(path, "/api/v1/groups"); ok && get {
+		return http.StatusOK, page(m.groups, query)
+	}
+	if id, ok := mockRoute(path, "/api/v1/groups/{id}"); ok && get {
+		return found(m.groups, "Group", id)
+	}
+	if id, ok := mockRoute(path, "/api/v1/signatures/{id}"); ok && get {
+		return found(m.signatures, "Signature", id)
+	}
+	if _, ok := mockRoute(path, "/api/v1/ticket_states"); ok && get {
+		return http.StatusOK, sortedRecords(m.states)
+	}
+	if _, ok := mockRoute(path, "/api/v1/ticket_priorities"); ok && get {
+		return http.StatusOK, sortedRecords(m.priorities)
+	}
+	if _, ok := mockRoute(path, "/api/v1/object_manager_attributes"); ok && get {
+		return http.StatusOK, m.attributes
+	}
+	if _, ok := mockRoute(path, "/api/v1/templates"); ok && get {
+		return http.StatusOK, m.templates
+	}
+	if _, ok := mockRoute(path, "/api/v1/channels_email"); ok && get {
+		return http.StatusOK, m.channels
+	}
+	if _, ok := mockRoute(path, "/api/v1/calendars"); ok && get {
+		return http.StatusOK, []record{m.calendar}
+	}
+	if _, ok := mockRoute(path, "/api/v1/online_notifications"); ok && get {
+		return http.StatusOK, sortedRecords(m.notifications)
+	}
+	if id, ok := mockRoute(path, "/api/v1/online_notifications/{id}"); ok && method == http.MethodPut {
+		return m.updateAttributes(m.notifications, "OnlineNotification", id, body)
+	}
+	if _, ok := mockRoute(path, "/api/v1/core_workflows/perform"); ok && method == http.MethodPost {
+		return http.StatusOK, record{"restrict_values": record{}}
+	}
+	if _, ok := mockRoute(path, "/api/v1/links"); ok && get {
+		return m.listLinks(query)
+	}
+	if _, ok := mockRoute(path, "/api/v1/links/add"); ok && method == http.MethodPost {
+		return m.createLink(body)
+	}
+	if _, ok := mockRoute(path, "/api/v1/tickets/search"); ok && get {
+		return http.StatusOK, m.searchTickets(query)
+	}
+	if _, ok := mockRoute(path, "/api/v1/tickets"); ok {
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, page(m.tickets, query)
+		case http.MethodPost:
+			return m.createTicket(body)
+		}
+	}
+	if id, ok := mockRoute(path, "/api/v1/tickets/{id}"); ok {
+		switch method {
+		case http.MethodGet:
+			return found(m.tickets, "Ticket", id)
+		case http.MethodPut:
+			return m.updateTicket(id, body)
+		case http.MethodDelete:
+			return m.deleteTicket(id)
+		}
+	}
+	if id, ok := mockRoute(path, "/api/v1/ticket_articles/by_ticket/{id}"); ok && get {
+		articles := make([]record, 0)
+		for _, a := range sortedRecords(m.articles) {
+			if a["ticket_id"] == id {
+				articles = append(articles, a)
+			}
+		}
+		return http.StatusOK, articles
+	}
+	if id, ok := mockRoute(path, "/api/v1/ticket_articles/{id}"); ok {
+		switch method {
+		case http.MethodGet:
+			return found(m.articles, "Ticket::Article", id)
+		case http.MethodPut:
+			// Zammad touches the ticket of an updated article.
+			status, updated := m.updateAttributes(m.articles, "Ticket::Article", id, body)
+			if ticket, ok := m.tickets[intValue(m.articles[id]["ticket_id"])]; ok && status == http.StatusOK {
+				ticket["updated_at"] = time.Now().UTC().Format(time.RFC3339)
+			}
+			return status, updated
+		}
+	}
+	if _, ok := mockRoute(path, "/api/v1/ticket_articles"); ok && method == http.MethodPost {
+		ticketID := intValue(body["ticket_id"])
+		if _, ok := m.tickets[ticketID]; !ok {
+			return found(m.tickets, "Ticket", ticketID)
+		}
+		delete(body, "id")
+		body["created_by_id"] = m.me
+		body["from"] = "Alex Agent"
+		body["sender"] = "Agent"
+		uploads, _ := body["attachments"].([]any)
+		delete(body, "attachments")
+		article := m.addArticle(ticketID, body)
+		if !m.attachUploads(article, uploads) {
+			return http.StatusUnprocessableEntity, record{"error": "Invalid attachment data"}
+		}
+		return http.StatusCreated, article
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/v1/ticket_attachment/"); ok && get {
+		// /api/v1/ticket_attachment/{ticket_id}/{article_id}/{id}
+		ids := strings.Split(rest, "/")
+		if len(ids) == 3 {
+			article, ok := m.articles[intValue(ids[1])]
+			file, exists := m.files[intValue(ids[2])]
+			if ok && exists && article["ticket_id"] == intValue(ids[0]) {
+				return http.StatusOK, file
+			}
+		}
+		return http.StatusNotFound, record{"error": "Couldn't find Store"}
+	}
+	if id, ok := mockRoute(path, "/api/v1/ticket_history/{id}"); ok && get {
+		if _, ok := m.tickets[id]; !ok {
+			return found(m.tickets, "Ticket", id)
+		}
+		users := record{}
+		for id, u := range m.users {
+			users[strconv.Itoa(id)] = u
+		}
+		return http.StatusOK, record{"history": m.history[id], "assets": record{"User": users}}
+	}
+	if _, ok := mockRoute(path, "/api/v1/tags"); ok && get {
+		return http.StatusOK, record{"tags": m.tags[intValue(query.Get("o_id"))]}
+	}
+	if _, ok := mockRoute(path, "/api/v1/tags/add"); ok && method == http.MethodPost {
+		return m.changeTag(body, true)
+	}
+	if _, ok := mockRoute(path, "/api/v1/tags/remove"); ok && method == http.MethodDelete {
+		return m.changeTag(body, false)
+	}
+	if strings.HasPrefix(path, "/api/v1/settings") {
+		return http.StatusForbidden, record{"error": "Not authorized (user)!"}
+	}
+	return notFound(method, path)
+}
+
+// updateAttributes applies body to a user or organization. Like Zammad, it
+// ignores attributes the record does not have.
+func (m *mockZammad) updateAttributes(records map[int]record, object string, id int, body record) (int, any) {
+	r, ok := records[id]
+	if !ok {
+		return found(records, object, id)
+	}
+	for key, value := range body {
+		if _, known := r[key]; known && key != "id" {
+			r[key] = value
+		}
+	}
+	r["updated_at"] = time.Now().UTC().Format(time.RFC3339)
+	return http.StatusOK, r
+}
+
+func found[T any](records map[int]T, object string, id int) (int, any) {
+	if r, ok := records[id]; ok {
+		return http.StatusOK, r
+	}
+	return http.StatusNotFound, record{"error": fmt.Sprintf("Couldn't find %s with 'id'=%d", object, id)}
+}
+
+func sortedRecords(records map[int]record) []record {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	sorted := make([]record, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, records[id])
+	}
+	return sorted
+}
+
+// page returns the page of records selected by the page/per_page parameters.
+func page(records map[int]record, query url.Values) []record {
+	all := sortedRecords(records)
+	p, perPage := intValue(query.Get("page")), intValue(query.Get("per_page"))
+	if p < 1 {
+		p = 1
+	}
+	if perPage < 1 {
+		perPage = 50
+	}
+	start := min((p-1)*perPage, len(all))
+	return all[start:min(start+perPage, len(all))]
+}
+
+func intValue(v any) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// matchesQuery reports whether r matches every term of a simplified search
+// query: "field:value" terms compare an attribute (with "field:(a OR b)"
+// alternatives, "field:[from TO to}" ranges and "_exists_:field"),
+// parenthesized terms are matched as a query of their own, and other terms
+// are matched case-insensitively against the given text fields. "AND" is
+// implied, "NOT" or "!" negates the next term and "*" matches everything.
+func matchesQuery(r record, query string, fields ...string) bool {
+	negate := false
+	for _, term := range queryTerms(query) {
+		switch term {
+		case "*", "AND":
+			continue
+		case "NOT":
+			negate = true
+			continue
+		}
+		if rest, ok := strings.CutPrefix(term, "!"); ok && rest != "" {
+			negate, term = true, rest
+		}
+		if matchesTerm(r, term, fields) == negate {
+			return false
+		}
+		negate = false
+	}
+	return true
+}
+
+func matchesTerm(r record, term string, fields []string) bool {
+	if strings.HasPrefix(term, "(") && strings.HasSuffix(term, ")") {
+		return matchesQuery(r, term[1:len(term)-1], fields...)
+	}
+	if field, value, ok := strings.Cut(term, ":"); ok {
+		if field == "_exists_" {
+			v, ok := r[value]
+			return ok && v != nil && v != ""
+		}
+		field = strings.TrimSuffix(field, ".name")
+		if from, to, ok := strings.Cut(strings.Trim(value, "[]{}"), " TO "); ok && strings.HasPrefix(value, "[") {
+			// Timestamp range [from TO to] or [from TO to}; RFC 3339 UTC
+			// timestamps compare like strings.
+			v, _ := r[field].(string)
+			return v != "" && (from == "*" || v >= from) && (to == "*" || v < to || (strings.HasSuffix(value, "]") && v == to))
+		}
+		alternatives := []string{value}
+		if strings.HasPrefix(value, "(") {
+			alternatives = strings.Split(strings.Trim(value, "()"), " OR ")
+		}
+		values := []string{fmt.Sprint(r[field])}
+		if list, ok := r[field].([]string); ok {
+			values = list
+		}
+		for _, alt := range alternatives {
+			for _, v := range values {
+				if strings.EqualFold(v, strings.Trim(strings.TrimSpace(alt), `"`)) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	text := strings.ToLower(strings.Trim(term, `"`))
+	for _, f := range fields {
+		if s, ok := r[f].(string); ok && strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTerms splits a search query at whitespace outside of quotes and
+// parentheses.
+func queryTerms(query string) []string {
+	var terms []string
+	var term strings.Builder
+	depth, quoted := 0, false
+	for _, c := range query {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case (c == '(' || c == '[') && !quoted:
+			depth++
+		case (c == ')' || c == ']' || c == '}') && !quoted:
+			depth--
+		case unicode.IsSpace(c) && !quoted && depth == 0:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+			continue
+		}
+		term.WriteRune(c)
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms
+}
+
+func searchRecords(records map[int]record, query string, fields ...string) []record {
+	result := make([]record, 0)
+	for _, r := range sortedRecords(records) {
+		if matchesQuery(r, query, fields...) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// createLink links the ticket with the source number to the target ticket:
+// as its child for link type "child", as related for "normal".
+func (m *mockZammad) createLink(body record) (int, any) {
+	target := intValue(body["link_object_target_value"])
+	source := 0
+	for id, t := range m.tickets {
+		if t["number"] == body["link_object_source_number"] {
+			source = id
+		}
+	}
+	if m.tickets[target] == nil || source == 0 {
+		return http.StatusUnprocessableEntity, record{"error": "No such link object"}
+	}
+	switch body["link_type"] {
+	case "child":
+		m.addLink(target, source)
+	case "normal":
+		m.links[target] = append(m.links[target], record{"link_type": "normal", "link_object": "Ticket", "link_object_value": source})
+		m.links[source] = append(m.links[source], record{"link_type": "normal", "link_object": "Ticket", "link_object_value": target})
+	default:
+		return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("Invalid link type %v", body["link_type"])}
+	}
+	return http.StatusCreated, record{}
+}
+
+// listLinks returns the links of a ticket with the linked tickets and their
+// owners as assets.
+func (m *mockZammad) listLinks(query url.Values) (int, any) {
+	id := intValue(query.Get("link_object_value"))
+	if query.Get("link_object") != "Ticket" || m.tickets[id] == nil {
+		return http.StatusNotFound, record{"error": fmt.Sprintf("Couldn't find Ticket with 'id'=%d", id)}
+	}
+	links := make([]record, 0, len(m.links[id]))
+	tickets, users := record{}, record{}
+	for _, link := range m.links[id] {
+		linked := link["link_object_value"].(int)
+		links = append(links, link)
+		tickets[strconv.Itoa(linked)] = m.tickets[linked]
+		if u, ok := m.users[intValue(m.tickets[linked]["owner_id"])]; ok {
+			users[strconv.Itoa(intValue(m.tickets[linked]["owner_id"]))] = u
+		}
+	}
+	return http.StatusOK, record{"links": links, "assets": record{"Ticket": tickets, "User": users}}
+}
+
+func (m *mockZammad) searchTickets(query url.Values) record {
+	// Zammad's search index includes the tags of a ticket.
+	tagged := make(map[int]record, len(m.tickets))
+	for id, t := range m.tickets {
+		tagged[id] = maps.Clone(t)
+		tagged[id]["tags"] = m.tags[id]
+	}
+	matches := make([]record, 0)
+	for _, t := range searchRecords(tagged, query.Get("query"), "title", "number") {
+		matches = append(matches, m.tickets[t["id"].(int)])
+	}
+	limit := intValue(query.Get("limit"))
+	if query.Get("per_page") != "" {
+		matches = page(recordsByID(matches), query)
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	ids := make([]int, 0, len(matches))
+	tickets, users, organizations := record{}, record{}, record{}
+	for _, t := range matches {
+		ids = append(ids, t["id"].(int))
+		tickets[strconv.Itoa(t["id"].(int))] = t
+		if u, ok := m.users[intValue(t["customer_id"])]; ok {
+			users[strconv.Itoa(intValue(t["customer_id"]))] = u
+		}
+		if o, ok := m.organizations[intValue(t["organization_id"])]; ok {
+			organizations[strconv.Itoa(intValue(t["organization_id"]))] = o
+		}
+	}
+	return record{"tickets": ids, "tickets_count": len(ids), "assets": record{"Ticket": tickets, "User": users, "Organization": organizations}}
+}
+
+func recordsByID(records []record) map[int]record {
+	byID := make(map[int]record, len(records))
+	for _, r := range records {
+		byID[r["id"].(int)] = r
+	}
+	return byID
+}
+
+// lookupUser resolves a customer reference (ID or email) of a create request.
+func (m *mockZammad) lookupUser(ref any) (record, bool) {
+	if id := intValue(ref); id > 0 {
+		u, ok := m.users[id]
+		return u, ok
+	}
+	email := strings.TrimPrefix(fmt.Sprint(ref), "guess:")
+	for _, u := range m.users {
+		if strings.EqualFold(u["email"].(string), email) {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (m *mockZammad) lookupByName(records map[int]record, name any) (record, bool) {
+	for _, r := range records {
+		if r["name"] == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func (m *mockZammad) createTicket(body record) (int, any) {
+	customerRef := body["customer"]
+	if customerRef == nil || customerRef == "" {
+		customerRef = body["customer_id"]
+	}
+	customer, ok := m.lookupUser(customerRef)
+	if !ok {
+		return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("No such customer %v", customerRef)}
+	}
+	group, ok := m.lookupByName(m.groups, body["group"])
+	if !ok {
+		group, ok = m.groups[intValue(body["group_id"])]
+	}
+	if !ok {
+		return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("No such group %v", body["group"])}
+	}
+	title, _ := body["title"].(string)
+	if title == "" {
+		return http.StatusUnprocessableEntity, record{"error": "Title can't be blank"}
+	}
+
+	id := m.id()
+	now := time.Now().UTC().Format(time.RFC3339)
+	ticket := record{
+		"id": id, "number": strconv.Itoa(31000 + id), "title": title,
+		"group_id": group["id"], "group": group["name"],
+		"state_id": 1, "state": "new", "priority_id": 2, "priority": "2 normal",
+		"customer_id": customer["id"], "customer": customer["email"], "organization_id": customer["organization_id"],
+		"owner_id": unassignedOwnerID, "created_by_id": m.me, "updated_by_id": m.me, "article_count": 0,
+		"created_at": now, "updated_at": now,
+	}
+	for _, a := range m.attributes {
+		if name := a["name"].(string); a["editable"] == true && a["active"] == true {
+			if value, ok := body[name]; ok {
+				ticket[name] = value
+			}
+		}
+	}
+	if priority, ok := m.priorities[intValue(body["priority_id"])]; ok {
+		ticket["priority_id"], ticket["priority"] = priority["id"], priority["name"]
+	}
+	if state, ok := m.states[intValue(body["state_id"])]; ok {
+		ticket["state_id"], ticket["state"] = state["id"], state["name"]
+	}
+	if tags, ok := body["tags"].(string); ok {
+		for _, tag := range strings.Split(tags, ",") {
+			m.tags[id] = append(m.tags[id], strings.TrimSpace(tag))
+		}
+	}
+	m.tickets[id] = ticket
+	m.addHistory(id, record{"type": "created", "object": "Ticket", "o_id": id, "created_by_id": m.me, "created_at": now})
+	if article, ok := body["article"].(record); ok && article["body"] != nil && article["body"] != "" {
+		delete(article, "id")
+		article["created_by_id"] = m.me
+		if article["sender"] == nil {
+			article["from"], article["sender"] = "Alex Agent", "Agent"
+		}
+		uploads, _ := article["attachments"].([]any)
+		delete(article, "attachments")
+		if !m.attachUploads(m.addArticle(id, article), uploads) {
+			return http.StatusUnprocessableEntity, record{"error": "Invalid attachment data"}
+		}
+	}
+	return http.StatusCreated, ticket
+}
+
+// moveMember keeps the organizations' member_ids in step with a user's
+// organization_id, as Zammad does.
+func (m *mockZammad) moveMember(userID, organizationID int) {
+	for _, o := range m.organizations {
+		members, _ := o["member_ids"].([]int)
+		o["member_ids"] = slices.DeleteFunc(slices.Clone(members), func(id int) bool { return id == userID })
+	}
+	if o, ok := m.organizations[organizationID]; ok {
+		o["member_ids"] = append(o["member_ids"].([]int), userID)
+	}
+}
+
+func (m *mockZammad) createUser(body record) (int, any) {
+	email, _ := body["email"].(string)
+	if email == "" && body["firstname"] == "" && body["lastname"] == "" {
+		return http.StatusUnprocessableEntity, record{"error": "At least one identifier (firstname, lastname, phone or email) for user is required."}
+	}
+	for _, u := range m.users {
+		if email != "" && strings.EqualFold(fmt.Sprint(u["email"]), email) {
+			return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("Email address '%s' is already used for another user.", email)}
+		}
+	}
+	organizationID := intValue(body["organization_id"])
+	if name, ok := body["organization"].(string); ok {
+		for _, o := range m.organizations {
+			if strings.EqualFold(fmt.Sprint(o["name"]), name) {
+				organizationID = o["id"].(int)
+			}
+		}
+		if organizationID == 0 {
+			return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("No lookup value found for 'organization': %q", name)}
+		}
+	}
+	id := m.id()
+	now := time.Now().UTC().Format(time.RFC3339)
+	user := record{
+		"id": id, "login": email, "firstname": body["firstname"], "lastname": body["lastname"], "email": email,
+		"organization_id": organizationID, "role_ids": []int{3}, "active": true, "vip": false, "created_at": now, "updated_at": now,
+	}
+	for _, key := range []string{"phone", "mobile", "note"} {
+		if value, ok := body[key]; ok {
+			user[key] = value
+		}
+	}
+	m.users[id] = user
+	m.moveMember(id, organizationID)
+	return http.StatusCreated, user
+}
+
+// deleteTicket removes a ticket with its articles.
+func (m *mockZammad) deleteTicket(id int) (int, any) {
+	if _, ok := m.tickets[id]; !ok {
+		return found(m.tickets, "Ticket", id)
+	}
+	delete(m.tickets, id)
+	for articleID, a := range m.articles {
+		if a["ticket_id"] == id {
+			delete(m.articles, articleID)
+		}
+	}
+	return http.StatusOK, record{}
+}
+
+// updateTicket applies the attributes of body to a ticket, resolving state,
+// priority and group names, and records the changes in the ticket history.
+func (m *mockZammad) updateTicket(id int, body record) (int, any) {
+	ticket, ok := m.tickets[id]
+	if !ok {
+		return found(m.tickets, "Ticket", id)
+	}
+	if owner, ok := body["owner_id"]; ok {
+		if _, exists := m.users[intValue(owner)]; !exists {
+			return http.StatusUnprocessableEntity, record{"error": fmt.Sprintf("Invalid value for param 'owner_id': %v", owner)}
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	change := func(attribute string, from, to any) {
+		if fmt.Sprint(from) == fmt.Sprint(to) {
+			return
+		}
+		m.addHistory(id, record{"type": "updated", "object": "Ticket", "o_id": id, "attribute": attribute,
+			"value_from": fmt.Sprint(from), "value_to": fmt.Sprint(to), "created_by_id": m.me, "created_at": now})
+	}
+	named := []struct {
+		attribute string
+		records   map[int]record
+	}{{"state", m.states}, {"priority", m.priorities}, {"group", m.groups}}
+	for _, n := range named {
+		target, ok := m.lookupByName(n.records, body[n.attribute])
+		if !ok {
+			target, ok = n.records[intValue(body[n.attribute+"_id"])]
+		}
+		if ok {
+			if n.attribute == "state" && target["state_type"] == "closed" && ticket["state"] != target["name"] {
+				ticket["close_at"] = now
+			}
+			change(n.attribute, ticket[n.attribute], target["name"])
+			ticket[n.attribute] = target["name"]
+			ticket[n.attribute+"_id"] = target["id"]
+		}
+		delete(body, n.attribute)
+		delete(body, n.attribute+"_id")
+	}
+	for key, value := range body {
+		switch key {
+		case "id", "number", "created_at", "updated_at", "article", "customer":
+			continue
+		}
+		change(key, ticket[key], value)
+		ticket[key] = value
+	}
+	if article, ok := body["article"].(record); ok && article["body"] != nil && article["body"] != "" {
+		article["created_by_id"] = m.me
+		m.addArticle(id, article)
+	}
+	ticket["updated_at"] = now
+	ticket["updated_by_id"] = m.me
+	return http.StatusOK, ticket
+}
+
+func (m *mockZammad) changeTag(body record, add bool) (int, any) {
+	id := intValue(body["o_id"])
+	if _, ok := m.tickets[id]; !ok {
+		return found(m.tickets, "Ticket", id)
+	}
+	item := fmt.Sprint(body["item"])
+	tags := make([]string, 0, len(m.tags[id])+1)
+	for _, t := range m.tags[id] {
+		if t != item {
+			tags = append(tags, t)
+		}
+	}
+	historyType := "removed"
+	if add {
+		tags = append(tags, item)
+		historyType = "added"
+	}
+	m.tags[id] = tags
+	m.addHistory(id, record{"type": historyType, "object": "Ticket", "o_id": id, "attribute": "tag", "value_to": item,
+		"created_by_id": m.me, "created_at": time.Now().UTC().Format(time.RFC3339)})
+	return http.StatusOK, true
+}
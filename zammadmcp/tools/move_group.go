@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// matchGroup finds the active group with the given name (case-insensitive)
+// among groups. Subgroups also match by their last name segment if that is
+// unique. The error lists the active groups.
+func matchGroup(groups []zammad.Group, name string) (zammad.Group, error) {
+	name = strings.TrimSpace(name)
+	var byLast []zammad.Group
+	var names []string
+	for _, g := range groups {
+		if !g.Active {
+			continue
+		}
+		if strings.EqualFold(g.Name, name) {
+			return g, nil
+		}
+		if g.NameLast != "" && strings.EqualFold(g.NameLast, name) {
+			byLast = append(byLast, g)
+		}
+		names = append(names, g.Name)
+	}
+	if len(byLast) == 1 {
+		return byLast[0], nil
+	}
+	for _, g := range groups {
+		if !g.Active && strings.EqualFold(g.Name, name) {
+			return zammad.Group{}, fmt.Errorf("group %q is inactive (active groups: %s)", g.Name, strings.Join(names, ", "))
+		}
+	}
+	if len(byLast) > 1 {
+		return zammad.Group{}, fmt.Errorf("%q matches %d groups; use the full name (active groups: %s)", name, len(byLast), strings.Join(names, ", "))
+	}
+	return zammad.Group{}, fmt.Errorf("unknown group %q (active groups: %s)", name, strings.Join(names, ", "))
+}
+
+// handleMoveTicketToGroup moves a ticket to another group. An owner without
+// full access to the new group could no longer work on the ticket, so the
+// ticket is unassigned in that case.
+func handleMoveTicketToGroup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	groupName := mcp.ParseString(request, "group", "")
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if strings.TrimSpace(groupName) == "" {
+		return mcp.NewToolResultError("Missing or invalid required argument: group (must be a non-empty string)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	groups, err := zammadClient.GroupList()
+	if err != nil {
+		log.Printf("Error listing groups from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list groups", err), nil
+	}
+	group, err := matchGroup(groups, groupName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument group: %v", err)), nil
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if before.GroupID == group.ID {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d is already in group %q; nothing changed.", ticketID, group.Name)), nil
+	}
+	from := fmt.Sprintf("group %d", before.GroupID)
+	for _, g := range groups {
+		if g.ID == before.GroupID {
+			from = g.Name
+		}
+	}
+
+	update := ticketUpdate{GroupID: setTo(group.ID)}
+	unassigned := ""
+	if before.OwnerID != 0 && before.OwnerID != unassignedOwnerID {
+		var owner groupAgent
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", before.OwnerID), nil, &owner); err != nil {
+			log.Printf("Error fetching owner %d of ticket %d from Zammad: %v", before.OwnerID, ticketID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get owner %d of ticket %d", before.OwnerID, ticketID), err), nil
+		}
+		if !owner.canOwn(group.ID) {
+			update.OwnerID = setTo(unassignedOwnerID)
+			unassigned = owner.displayName()
+		}
+	}
+
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error moving ticket %d to group %d in Zammad: %v", ticketID, group.ID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to move ticket %d to group %q", ticketID, group.Name), err), nil
+	}
+	summary := fmt.Sprintf("moved ticket %d from group %q to %q", ticketID, from, group.Name)
+	if unassigned != "" {
+		summary += fmt.Sprintf(" and unassigned %s", unassigned)
+	}
+	sessionActions.record(ctx, request.Params.Name, summary, ticketUndo(before, ticket.UpdatedAt, update), ticketObject(ticketID))
+
+	log.Printf("Moved ticket %d from group %q to %q", ticketID, from, group.Name)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	header := fmt.Sprintf("Ticket %d moved from group %q to %q", ticketID, from, group.Name)
+	if unassigned != "" {
+		header += fmt.Sprintf("; it was unassigned because %s has no full access to the new group", unassigned)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// onlineNotification is an expanded Zammad online notification
+// (/api/v1/online_notifications?expand=true).
+type onlineNotification struct {
+	ID        int       `json:"id"`
+	OID       int       `json:"o_id"`
+	Object    string    `json:"object"`
+	Type      string    `json:"type"`
+	Seen      bool      `json:"seen"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func fetchOnlineNotifications() ([]onlineNotification, error) {
+	var notifications []onlineNotification
+	err := zammadRequest(http.MethodGet, "/api/v1/online_notifications?expand=true", nil, &notifications)
+	return notifications, err
+}
+
+// PollNotifications periodically checks the current user's unread online
+// notifications (which include mentions) and pushes an MCP logging
+// notification to every connected client for each new one. Notifications that
+// are already unread when the poller starts are not announced. It polls until
+// the process exits, so callers run it in a goroutine.
+func PollNotifications(s *server.MCPServer, interval time.Duration) {
+	log.Printf("Polling Zammad online notifications every %s", interval)
+
+	announced := make(map[int]bool)
+	first := true
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		notifications, err := fetchOnlineNotifications()
+		if err != nil {
+			log.Printf("Error polling Zammad online notifications: %v", err)
+			continue
+		}
+
+		unseen := make(map[int]bool, len(notifications))
+		for _, n := range notifications {
+			if n.Seen {
+				continue
+			}
+			unseen[n.ID] = true
+			if announced[n.ID] {
+				continue
+			}
+			announced[n.ID] = true
+			if first {
+				continue
+			}
+
+			message := describeNotification(n)
+			log.Printf("New Zammad notification: %s", message)
+			s.SendNotificationToAllClients("notifications/message", map[string]any{
+				"level":  "info",
+				"logger": "zammad",
+				"data": map[string]any{
+					"message":         message,
+					"notification_id": n.ID,
+					"type":            n.Type,
+					"object":          n.Object,
+					"object_id":       n.OID,
+					"created_by":      n.CreatedBy,
+					"created_at":      n.CreatedAt,
+				},
+			})
+		}
+		// Forget notifications that were read or deleted, so the set stays
+		// as small as the unread list.
+		for id := range announced {
+			if !unseen[id] {
+				delete(announced, id)
+			}
+		}
+		first = false
+	}
+}
+
+// describeNotification renders a notification as a sentence the assistant can
+// relay, e.g. "you were mentioned on ticket #4711 (Printer on fire)".
+func describeNotification(n onlineNotification) string {
+	subject := fmt.Sprintf("%s %d", n.Object, n.OID)
+	if n.Object == "Ticket" {
+		if ticket, err := fetchTicket(n.OID); err == nil {
+			subject = fmt.Sprintf("ticket #%s (%s)", ticket.Number, ticket.Title)
+		}
+	}
+
+	switch n.Type {
+	case "mention":
+		return fmt.Sprintf("you were mentioned on %s by %s", subject, n.CreatedBy)
+	case "create":
+		return fmt.Sprintf("%s was created by %s", subject, n.CreatedBy)
+	case "escalation", "escalation_warning":
+		return fmt.Sprintf("%s is escalating", subject)
+	case "reminder_reached":
+		return fmt.Sprintf("the pending reminder of %s was reached", subject)
+	default:
+		return fmt.Sprintf("%s was updated by %s", subject, n.CreatedBy)
+	}
+}
@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// legalFormWords are dropped when comparing organization names, so "Acme
+// Corp." and "ACME Corporation" count as the same name.
+var legalFormWords = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"ltd": true, "limited": true, "llc": true, "plc": true, "gmbh": true, "ag": true, "kg": true,
+	"se": true, "sa": true, "sas": true, "sarl": true, "srl": true, "spa": true, "bv": true, "nv": true,
+	"oy": true, "ab": true, "as": true, "pty": true, "the": true,
+}
+
+// normalizeOrganizationName reduces a name to its distinctive words:
+// lower-cased, without punctuation and legal forms.
+func normalizeOrganizationName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if !legalFormWords[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// normalizeDomain returns a domain without case, surrounding space, scheme
+// or "www." prefix.
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(strings.TrimPrefix(domain, "https://"), "http://")
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "www."), "/")
+	return domain
+}
+
+// duplicateOrganization is an organization in a duplicate group.
+type duplicateOrganization struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Domain  string `json:"domain,omitempty"`
+	Active  bool   `json:"active"`
+	Members int    `json:"members"`
+	WebURL  string `json:"web_url"`
+}
+
+// duplicateOrganizationGroup is a set of organizations that look like the
+// same company.
+type duplicateOrganizationGroup struct {
+	Reasons       []string                `json:"reasons"` // e.g. "same domain acme.example"
+	Organizations []duplicateOrganization `json:"organizations"`
+	// SuggestedTarget is the organization with the most members, which is
+	// usually the one to keep.
+	SuggestedTarget int `json:"suggested_target"`
+}
+
+// findDuplicateOrganizations groups organizations sharing a domain or a
+// normalized name. Groups are joined transitively, so an organization with
+// the name of one and the domain of another ends up in a single group.
+func findDuplicateOrganizations(organizations []organizationRecord, includeInactive bool) []duplicateOrganizationGroup {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	byID := make(map[int]organizationRecord)
+	keys := make(map[string][]int) // "same domain x" or "same name x" to organization IDs
+	for _, o := range organizations {
+		if !o.Active && !includeInactive {
+			continue
+		}
+		byID[o.ID] = o
+		parent[o.ID] = o.ID
+		if domain := normalizeDomain(o.Domain); domain != "" {
+			keys["same domain "+domain] = append(keys["same domain "+domain], o.ID)
+		}
+		if name := normalizeOrganizationName(o.Name); name != "" {
+			key := fmt.Sprintf("same name %q", name)
+			keys[key] = append(keys[key], o.ID)
+		}
+	}
+
+	reasons := make(map[int][]string) // by root, filled after all unions
+	var duplicateKeys []string
+	for key, ids := range keys {
+		if len(ids) < 2 {
+			continue
+		}
+		duplicateKeys = append(duplicateKeys, key)
+		for _, id := range ids[1:] {
+			parent[find(id)] = find(ids[0])
+		}
+	}
+	sort.Strings(duplicateKeys)
+	for _, key := range duplicateKeys {
+		root := find(keys[key][0])
+		reasons[root] = append(reasons[root], key)
+	}
+
+	members := make(map[int][]int)
+	for id := range byID {
+		if root := find(id); len(reasons[root]) > 0 {
+			members[root] = append(members[root], id)
+		}
+	}
+	groups := make([]duplicateOrganizationGroup, 0, len(members))
+	for root, ids := range members {
+		sort.Ints(ids)
+		group := duplicateOrganizationGroup{Reasons: reasons[root]}
+		best := -1
+		for _, id := range ids {
+			o := byID[id]
+			group.Organizations = append(group.Organizations, duplicateOrganization{
+				ID: o.ID, Name: o.Name, Domain: o.Domain, Active: o.Active, Members: len(o.MemberIds), WebURL: o.WebURL,
+			})
+			if len(o.MemberIds) > best {
+				best = len(o.MemberIds)
+				group.SuggestedTarget = o.ID
+			}
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Organizations[0].ID < groups[j].Organizations[0].ID })
+	return groups
+}
+
+// handleFindDuplicateOrganizations lists groups of organizations that share
+// a domain or a name.
+func handleFindDuplicateOrganizations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	includeInactive := mcp.ParseBoolean(request, "include_inactive", false)
+	organizations, _, err := listPaged[organizationRecord]("/api/v1/organizations", continuation{Page: 1}, &memoryBudget{})
+	if err != nil {
+		log.Printf("Error listing organizations from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list organizations", err), nil
+	}
+	for i := range organizations {
+		organizations[i].WebURL = organizationWebURL(organizations[i].ID)
+	}
+
+	groups := findDuplicateOrganizations(organizations, includeInactive)
+	jsonData, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal duplicate organizations: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Found %d groups of possibly duplicate organizations among %d organizations:\n%s", len(groups), len(organizations), string(jsonData))), nil
+}
+
+// userReassignment is the outcome of moving one user.
+type userReassignment struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email,omitempty"`
+	Status string `json:"status"` // moved, would_move or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// reassignUsersResult is the outcome of reassign_users_to_organization.
+type reassignUsersResult struct {
+	From              duplicateOrganization `json:"from"`
+	To                duplicateOrganization `json:"to"`
+	Users             []userReassignment    `json:"users"`
+	SourceDeactivated bool                  `json:"source_deactivated,omitempty"`
+}
+
+// handleReassignUsersToOrganization moves the members of one organization to
+// another. Without confirm it only reports what it would do.
+func handleReassignUsersToOrganization(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	fromID := mcp.ParseInt(request, "from_organization_id", 0)
+	toID := mcp.ParseInt(request, "to_organization_id", 0)
+	confirm := mcp.ParseBoolean(request, "confirm", false)
+	deactivate := mcp.ParseBoolean(request, "deactivate_source", false)
+	if fromID <= 0 || toID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required arguments: from_organization_id, to_organization_id (must be positive numbers)"), nil
+	}
+	if fromID == toID {
+		return mcp.NewToolResultError("from_organization_id and to_organization_id must differ"), nil
+	}
+
+	from, err := zammadClient.OrganizationShow(fromID)
+	if err != nil {
+		log.Printf("Error fetching organization %d from Zammad: %v", fromID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get organization %d", fromID), err), nil
+	}
+	to, err := zammadClient.OrganizationShow(toID)
+	if err != nil {
+		log.Printf("Error fetching organization %d from Zammad: %v", toID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get organization %d", toID), err), nil
+	}
+	if !to.Active {
+		return mcp.NewToolResultError(fmt.Sprintf("Target organization %d (%s) is inactive; activate it first or choose another target", toID, to.Name)), nil
+	}
+
+	summarize := func(o organizationRecord) duplicateOrganization {
+		return duplicateOrganization{ID: o.ID, Name: o.Name, Domain: o.Domain, Active: o.Active, Members: len(o.MemberIds), WebURL: o.WebURL}
+	}
+	result := reassignUsersResult{From: summarize(newOrganizationRecord(from)), To: summarize(newOrganizationRecord(to)), Users: []userReassignment{}}
+	moved, failed := 0, 0
+	for _, userID := range from.MemberIds {
+		if err := yieldTurn(ctx); err != nil {
+			log.Printf("Reassigning members of organization %d cancelled: %v", fromID, err)
+			break
+		}
+		entry := userReassignment{UserID: userID, Status: "would_move"}
+		if user, err := zammadClient.UserShow(userID); err == nil {
+			entry.Name, entry.Email = userDisplayName(user), user.Email
+		}
+		if confirm {
+			if err := updateUser(userID, userUpdate{OrganizationID: setTo(toID)}); err != nil {
+				log.Printf("Error moving user %d to organization %d: %v", userID, toID, err)
+				entry.Status, entry.Error = "failed", err.Error()
+				failed++
+			} else {
+				entry.Status = "moved"
+				moved++
+			}
+		}
+		result.Users = append(result.Users, entry)
+	}
+
+	result.From.Members -= moved
+	result.To.Members += moved
+
+	if confirm && deactivate && failed == 0 && len(result.Users) == len(from.MemberIds) {
+		path := fmt.Sprintf("/api/v1/organizations/%d", fromID)
+		if err := zammadRequest(http.MethodPut, path, map[string]any{"active": false}, nil); err != nil {
+			log.Printf("Error deactivating organization %d: %v", fromID, err)
+		} else {
+			result.SourceDeactivated = true
+			result.From.Active = false
+		}
+	}
+
+	if moved > 0 || result.SourceDeactivated {
+		objects := []actionObject{organizationObject(fromID), organizationObject(toID)}
+		for _, entry := range result.Users {
+			if entry.Status == "moved" {
+				objects = append(objects, userObject(entry.UserID))
+			}
+		}
+		summary := fmt.Sprintf("moved %d users from organization %d to %d", moved, fromID, toID)
+		if result.SourceDeactivated {
+			summary += fmt.Sprintf(" and deactivated organization %d", fromID)
+		}
+		sessionActions.record(ctx, request.Params.Name, summary, nil, objects...)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reassignment result: %w", err) // Internal server error
+	}
+	if !confirm {
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: %d users of %s (%d) would be moved to %s (%d). Nothing was changed; call again with confirm set to true to move them.\n%s",
+			len(result.Users), from.Name, fromID, to.Name, toID, string(jsonData))), nil
+	}
+	log.Printf("Moved %d users from organization %d to %d (%d failed)", moved, fromID, toID, failed)
+	summary := fmt.Sprintf("Moved %d of %d users of %s (%d) to %s (%d).", moved, len(from.MemberIds), from.Name, fromID, to.Name, toID)
+	if deactivate && !result.SourceDeactivated {
+		summary += fmt.Sprintf(" Organization %d was not deactivated because not all users were moved or the update failed.", fromID)
+	}
+	if failed > 0 || len(result.Users) < len(from.MemberIds) {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: %s\n%s", summary, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(jsonData))), nil
+}
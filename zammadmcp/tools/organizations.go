@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// coreOrganizationAttributes are the built-in organization attributes that
+// update_organization does not change through its attributes argument.
+var coreOrganizationAttributes = map[string]bool{
+	"id": true, "name": true, "shared": true, "domain": true, "domain_assignment": true,
+	"active": true, "vip": true, "note": true, "member_ids": true, "secondary_member_ids": true,
+	"created_at": true, "updated_at": true, "created_by_id": true, "updated_by_id": true,
+}
+
+// fetchOrganizationDetails retrieves an organization with every attribute the
+// API returns, including custom fields such as an account manager or
+// contract tier, which zammad-go's Organization does not model.
+func fetchOrganizationDetails(organizationID int) (map[string]any, error) {
+	var organization map[string]any
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/organizations/%d", organizationID), nil, &organization); err != nil {
+		return nil, err
+	}
+	organization["web_url"] = organizationWebURL(organizationID)
+	return organization, nil
+}
+
+// handleGetOrganization returns an organization including its note and
+// custom attributes.
+func handleGetOrganization(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	organizationID := mcp.ParseInt(request, "organization_id", 0)
+	if organizationID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: organization_id (must be a positive number)"), nil
+	}
+	organization, err := fetchOrganizationDetails(organizationID)
+	if err != nil {
+		log.Printf("Error fetching organization %d from Zammad: %v", organizationID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get organization %d", organizationID), err), nil
+	}
+	jsonData, err := json.MarshalIndent(organization, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal organization %d: %w", organizationID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Organization %d details:\n%s", organizationID, string(jsonData))), nil
+}
+
+// handleUpdateOrganization updates an organization's note and custom
+// attributes. Zammad ignores attributes it does not know, so the updated
+// organization is checked and attributes that were not stored are reported.
+func handleUpdateOrganization(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	organizationID := mcp.ParseInt(request, "organization_id", 0)
+	attributes := mcp.ParseStringMap(request, "attributes", nil)
+	if organizationID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: organization_id (must be a positive number)"), nil
+	}
+	changes := make(map[string]any, len(attributes)+1)
+	var core []string
+	for name, value := range attributes {
+		if coreOrganizationAttributes[name] {
+			core = append(core, name)
+			continue
+		}
+		changes[name] = value
+	}
+	if len(core) > 0 {
+		sort.Strings(core)
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument attributes: %s cannot be changed with this tool; only the note and custom attributes can", strings.Join(core, ", "))), nil
+	}
+	if note, ok := request.Params.Arguments["note"].(string); ok {
+		changes["note"] = note
+	}
+	if len(changes) == 0 {
+		return mcp.NewToolResultError("Nothing to update: provide note and/or attributes"), nil
+	}
+
+	var updated map[string]any
+	if err := zammadRequest(http.MethodPut, fmt.Sprintf("/api/v1/organizations/%d", organizationID), changes, &updated); err != nil {
+		log.Printf("Error updating organization %d in Zammad: %v", organizationID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to update organization %d", organizationID), err), nil
+	}
+	updated["web_url"] = organizationWebURL(organizationID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("updated %d attributes of organization %d", len(changes), organizationID), nil, organizationObject(organizationID))
+
+	var ignored []string
+	for name := range changes {
+		if _, ok := updated[name]; !ok {
+			ignored = append(ignored, name)
+		}
+	}
+	jsonData, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal organization %d: %w", organizationID, err) // Internal server error
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		return mcp.NewToolResultError(fmt.Sprintf("Organization %d was updated, but Zammad did not store these attributes (not defined in the object manager?): %s. Current values:\n%s",
+			organizationID, strings.Join(ignored, ", "), string(jsonData))), nil
+	}
+	log.Printf("Updated organization %d (%d attributes)", organizationID, len(changes))
+	return mcp.NewToolResultText(fmt.Sprintf("Organization %d updated:\n%s", organizationID, string(jsonData))), nil
+}
@@ -0,0 +1,96 @@
+package tools
+
+// ticketPager walks the results of a ticket search one page at a time, so
+// callers can process each page before the next one is fetched instead of
+// collecting every ticket first.
+type ticketPager struct {
+	query   string
+	perPage int
+	page    int
+	skip    int // entries of the first page to skip when resuming
+	after   int // ID of the ticket returned last before resuming
+	seen    map[int]bool
+	done    bool
+
+	// positions and ids hold the index in the page and the ID of each
+	// ticket returned by the last call of next, and last the ID of the
+	// ticket returned before them.
+	positions []int
+	ids       []int
+	last      int
+	end       int // entries of the last page read
+}
+
+// newTicketPager returns a pager that starts at the given position.
+func newTicketPager(query string, perPage int, from continuation) *ticketPager {
+	return &ticketPager{query: query, perPage: perPage, page: from.Page - 1, skip: from.Skip, after: from.After, last: from.After, seen: make(map[int]bool)}
+}
+
+// next returns the tickets of the next page that were not returned before.
+// It returns an empty slice once all pages have been read.
+func (p *ticketPager) next() ([]ticketRecord, error) {
+	for !p.done {
+		p.page++
+		tickets, err := searchTicketRecordsPage(p.query, p.page, p.perPage)
+		if err != nil {
+			p.page--
+			return nil, err
+		}
+		skip := p.skip
+		for i, ticket := range tickets {
+			if p.after != 0 && ticket.ID == p.after {
+				skip = i + 1
+				break
+			}
+		}
+		skip = min(skip, len(tickets))
+		p.skip, p.after = 0, 0
+		// The tickets before the resume position were returned by an
+		// earlier call.
+		for _, ticket := range tickets[:skip] {
+			p.seen[ticket.ID] = true
+		}
+
+		if len(p.ids) > 0 {
+			p.last = p.ids[len(p.ids)-1]
+		}
+		p.positions, p.ids, p.end = p.positions[:0], p.ids[:0], len(tickets)
+		fresh := make([]ticketRecord, 0, len(tickets)-skip)
+		for i := skip; i < len(tickets); i++ {
+			ticket := tickets[i]
+			// Guard against instances that ignore the page parameter and
+			// return the first page over and over.
+			if p.seen[ticket.ID] {
+				continue
+			}
+			p.seen[ticket.ID] = true
+			fresh = append(fresh, ticket)
+			p.positions = append(p.positions, i)
+			p.ids = append(p.ids, ticket.ID)
+		}
+		p.done = len(tickets) < p.perPage || (len(fresh) == 0 && skip == 0)
+		if len(fresh) > 0 {
+			return fresh, nil
+		}
+	}
+	return nil, nil
+}
+
+// resumeAt returns the position of the i-th ticket of the last page
+// returned by next, or of the end of that page for i equal to the number of
+// tickets returned, for continuing there later.
+func (p *ticketPager) resumeAt(i int) continuation {
+	at := continuation{Page: p.page, Skip: p.end, After: p.last}
+	if i < len(p.positions) {
+		at.Skip = p.positions[i]
+	}
+	if i > 0 {
+		at.After = p.ids[i-1]
+	}
+	return at
+}
+
+// pageNumber returns the number of the last page read.
+func (p *ticketPager) pageNumber() int {
+	return p.page
+}
@@ -0,0 +1,278 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// priorityMatrix maps the impact and urgency of an issue to a Zammad
+// priority, configured in the priority_matrix section of the configuration
+// file.
+type priorityMatrix struct {
+	// Impacts and Urgencies are the levels agents choose from, with a
+	// description that tells the model when a level applies.
+	Impacts   []priorityLevel `yaml:"impacts"`
+	Urgencies []priorityLevel `yaml:"urgencies"`
+	// Priorities maps impact level to urgency level to priority name.
+	Priorities map[string]map[string]string `yaml:"priorities"`
+}
+
+// priorityLevel is one impact or urgency level of the priority matrix.
+type priorityLevel struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// defaultPriorityMatrix is the ITIL-style 3x3 matrix on Zammad's default
+// priorities.
+var defaultPriorityMatrix = priorityMatrix{
+	Impacts: []priorityLevel{
+		{"high", "A whole organization, many customers or a business-critical service is affected."},
+		{"medium", "A team, several users or an important function is affected."},
+		{"low", "A single user or a minor function is affected."},
+	},
+	Urgencies: []priorityLevel{
+		{"high", "Work is blocked and there is no workaround, or a deadline is imminent."},
+		{"medium", "Work is impaired but a workaround exists."},
+		{"low", "A question, request or cosmetic issue; work can continue normally."},
+	},
+	Priorities: map[string]map[string]string{
+		"high":   {"high": "3 high", "medium": "3 high", "low": "2 normal"},
+		"medium": {"high": "3 high", "medium": "2 normal", "low": "1 low"},
+		"low":    {"high": "2 normal", "medium": "1 low", "low": "1 low"},
+	},
+}
+
+// validate checks that the matrix has a priority for every combination of
+// impact and urgency.
+func (m priorityMatrix) validate() error {
+	if len(m.Impacts) == 0 || len(m.Urgencies) == 0 {
+		return fmt.Errorf("priority_matrix needs at least one impact and one urgency level")
+	}
+	for _, impact := range m.Impacts {
+		for _, urgency := range m.Urgencies {
+			if m.Priorities[impact.Name][urgency.Name] == "" {
+				return fmt.Errorf("priority_matrix has no priority for impact %q and urgency %q", impact.Name, urgency.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// findPriorityLevel returns the level named name, ignoring case.
+func findPriorityLevel(levels []priorityLevel, name string) (priorityLevel, bool) {
+	for _, l := range levels {
+		if strings.EqualFold(l.Name, strings.TrimSpace(name)) {
+			return l, true
+		}
+	}
+	return priorityLevel{}, false
+}
+
+// priorityLevelNames lists the level names for error messages.
+func priorityLevelNames(levels []priorityLevel) string {
+	names := make([]string, len(levels))
+	for i, l := range levels {
+		names[i] = l.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// priorityLevelsDescription describes the levels for a tool argument.
+func priorityLevelsDescription(levels []priorityLevel) string {
+	parts := make([]string, len(levels))
+	for i, l := range levels {
+		parts[i] = fmt.Sprintf("'%s': %s", l.Name, l.Description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ticketPriority is a ticket priority definition (/api/v1/ticket_priorities).
+type ticketPriority struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// fetchTicketPriorities returns the ticket priority definitions of the
+// instance.
+func fetchTicketPriorities() ([]ticketPriority, error) {
+	var priorities []ticketPriority
+	if err := zammadRequest(http.MethodGet, "/api/v1/ticket_priorities", nil, &priorities); err != nil {
+		return nil, err
+	}
+	return priorities, nil
+}
+
+// resolveTicketPriority maps a priority given by the model to one of the
+// instance's active priorities: by ID, by exact name (ignoring case), or by
+// name without the numeric prefix of Zammad's defaults ("high" for "3 high").
+func resolveTicketPriority(name string) (ticketPriority, error) {
+	priorities, err := fetchTicketPriorities()
+	if err != nil {
+		return ticketPriority{}, fmt.Errorf("failed to list ticket priorities: %w", err)
+	}
+	return matchTicketPriority(priorities, name)
+}
+
+// matchTicketPriority picks the priority named by the model among
+// priorities, as resolveTicketPriority.
+func matchTicketPriority(priorities []ticketPriority, name string) (ticketPriority, error) {
+	wanted := strings.TrimSpace(name)
+	id, _ := strconv.Atoi(wanted)
+	var names []string
+	var match *ticketPriority
+	for i, p := range priorities {
+		if !p.Active {
+			continue
+		}
+		names = append(names, p.Name)
+		if p.ID == id || strings.EqualFold(p.Name, wanted) {
+			return p, nil
+		}
+		if _, bare, ok := strings.Cut(p.Name, " "); ok && strings.EqualFold(bare, wanted) {
+			match = &priorities[i]
+		}
+	}
+	if match != nil {
+		return *match, nil
+	}
+	return ticketPriority{}, fmt.Errorf("unknown ticket priority %q (one of: %s)", name, strings.Join(names, ", "))
+}
+
+// prioritySuggestion is the result of suggest_priority.
+type prioritySuggestion struct {
+	Priority          string        `json:"priority"`
+	PriorityID        int           `json:"priority_id"`
+	Impact            priorityLevel `json:"impact"`
+	Urgency           priorityLevel `json:"urgency"`
+	Reasoning         string        `json:"reasoning"`
+	CurrentPriority   string        `json:"current_priority,omitempty"`
+	CurrentPriorityID int           `json:"current_priority_id,omitempty"`
+	ChangeRecommended *bool         `json:"change_recommended,omitempty"`
+}
+
+// handleSuggestPriority looks up the priority for an impact and urgency in the
+// configured priority matrix, and compares it with the ticket's current
+// priority if a ticket is given. It does not change the ticket.
+func handleSuggestPriority(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	matrix := config.PriorityMatrix
+	impact, ok := findPriorityLevel(matrix.Impacts, mcp.ParseString(request, "impact", ""))
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Missing or invalid required argument: impact (one of: %s)", priorityLevelNames(matrix.Impacts))), nil
+	}
+	urgency, ok := findPriorityLevel(matrix.Urgencies, mcp.ParseString(request, "urgency", ""))
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Missing or invalid required argument: urgency (one of: %s)", priorityLevelNames(matrix.Urgencies))), nil
+	}
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+
+	suggestion := prioritySuggestion{
+		Priority: matrix.Priorities[impact.Name][urgency.Name],
+		Impact:   impact,
+		Urgency:  urgency,
+	}
+	suggestion.Reasoning = fmt.Sprintf("Impact %q (%s) combined with urgency %q (%s) maps to priority %q in the priority matrix.",
+		impact.Name, strings.TrimSuffix(impact.Description, "."), urgency.Name, strings.TrimSuffix(urgency.Description, "."), suggestion.Priority)
+
+	priorities, err := fetchTicketPriorities()
+	if err != nil {
+		log.Printf("Error fetching ticket priorities from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list ticket priorities", err), nil
+	}
+	names := make(map[int]string, len(priorities))
+	for _, p := range priorities {
+		names[p.ID] = p.Name
+		if p.Active && strings.EqualFold(p.Name, suggestion.Priority) {
+			suggestion.PriorityID = p.ID
+		}
+	}
+	if suggestion.PriorityID == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("The priority matrix suggests %q, but Zammad has no active priority of that name; check the priority_matrix configuration", suggestion.Priority)), nil
+	}
+
+	if ticketID > 0 {
+		ticket, err := fetchTicket(ticketID)
+		if err != nil {
+			log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+		}
+		change := ticket.PriorityID != suggestion.PriorityID
+		suggestion.CurrentPriorityID = ticket.PriorityID
+		suggestion.CurrentPriority = names[ticket.PriorityID]
+		suggestion.ChangeRecommended = &change
+	}
+
+	jsonData, err := json.MarshalIndent(suggestion, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal priority suggestion: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Suggested priority:\n%s", string(jsonData))), nil
+}
+
+// handleSetTicketPriority changes the priority of a ticket, given by name or
+// ID. An unknown priority is rejected with the list of valid ones.
+func handleSetTicketPriority(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	priorityName := mcp.ParseString(request, "priority", "")
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if strings.TrimSpace(priorityName) == "" {
+		return mcp.NewToolResultError("Missing or invalid required argument: priority (must be a non-empty string)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	priorities, err := fetchTicketPriorities()
+	if err != nil {
+		log.Printf("Error fetching ticket priorities from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list ticket priorities", err), nil
+	}
+	priority, err := matchTicketPriority(priorities, priorityName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument priority: %v", err)), nil
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if before.PriorityID == priority.ID {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d already has priority %q; nothing changed.", ticketID, priority.Name)), nil
+	}
+	from := fmt.Sprintf("priority %d", before.PriorityID)
+	for _, p := range priorities {
+		if p.ID == before.PriorityID {
+			from = p.Name
+		}
+	}
+
+	update := ticketUpdate{PriorityID: setTo(priority.ID)}
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error changing the priority of ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to change the priority of ticket %d", ticketID), err), nil
+	}
+	summary := fmt.Sprintf("changed the priority of ticket %d from %q to %q", ticketID, from, priority.Name)
+	sessionActions.record(ctx, request.Params.Name, summary, ticketUndo(before, ticket.UpdatedAt, update), ticketObject(ticketID))
+
+	log.Printf("Changed the priority of ticket %d from %q to %q", ticketID, from, priority.Name)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d priority changed from %q to %q:\n%s", ticketID, from, priority.Name, string(jsonData))), nil
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// outputProfile lists the ticket fields a tool result includes, in output
+// order. A nil profile keeps every field in the default order.
+type outputProfile []string
+
+// defaultOutputProfiles are the built-in profiles; the output_profiles
+// section of the configuration file adds to or overrides them.
+var defaultOutputProfiles = map[string]outputProfile{
+	"minimal": {"id", "number", "title", "state_id", "state", "updated_at", "waiting", "matches", "web_url"},
+	"triage": {
+		"id", "number", "title", "state_id", "state", "priority_id", "group_id", "group",
+		"owner_id", "customer_id", "customer", "organization_id", "vip", "created_at", "updated_at",
+		"last_contact_customer_at", "last_contact_agent_at", "pending_time", "sla", "waiting", "matches", "web_url",
+	},
+	"full": nil,
+}
+
+// outputProfileNames returns the configured profile names, sorted.
+func outputProfileNames() []string {
+	names := make([]string, 0, len(config.OutputProfiles))
+	for name := range config.OutputProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// outputProfileOption is the profile argument of tools that return tickets.
+func outputProfileOption() mcp.ToolOption {
+	return mcp.WithString("profile", mcp.Description(fmt.Sprintf(
+		"Which ticket fields to return: %s. Smaller profiles save tokens when scanning many tickets. Default: '%s'.",
+		strings.Join(outputProfileNames(), ", "), config.OutputProfile)))
+}
+
+// requestOutputProfile returns the profile selected by the profile argument,
+// or the configured default.
+func requestOutputProfile(request mcp.CallToolRequest) (outputProfile, error) {
+	name := mcp.ParseString(request, "profile", config.OutputProfile)
+	profile, ok := config.OutputProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output profile %q (one of: %s)", name, strings.Join(outputProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// project renders v, which must marshal to a JSON object, with only the
+// profile's fields, in profile order.
+func (p outputProfile) project(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil || p == nil {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, name := range p {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalIndent renders a ticket, or a slice of tickets, like
+// json.MarshalIndent with two-space indentation, keeping only the profile's
+// fields of each ticket.
+func (p outputProfile) marshalIndent(v any) ([]byte, error) {
+	if p == nil {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	var projected any
+	if items := reflect.ValueOf(v); items.Kind() == reflect.Slice {
+		tickets := make([]json.RawMessage, items.Len())
+		for i := range tickets {
+			data, err := p.project(items.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			tickets[i] = data
+		}
+		projected = tickets
+	} else {
+		data, err := p.project(v)
+		if err != nil {
+			return nil, err
+		}
+		projected = data
+	}
+	return json.MarshalIndent(projected, "", "  ")
+}
+
+// listFormatOption is the format argument of tools that return long lists.
+func listFormatOption() mcp.ToolOption {
+	return mcp.WithString("format", mcp.Enum("json", "jsonl"), mcp.DefaultString("json"), mcp.Description(
+		"'json' (default): an indented JSON array; 'jsonl': JSON Lines, one compact object per line, which is smaller and can be processed line by line."))
+}
+
+// requestJSONLines reports whether the format argument selects JSON Lines.
+func requestJSONLines(request mcp.CallToolRequest) (bool, error) {
+	switch format := mcp.ParseString(request, "format", "json"); format {
+	case "json":
+		return false, nil
+	case "jsonl":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown format %q (one of: json, jsonl)", format)
+	}
+}
+
+// marshalList renders a slice like marshalIndent or, with jsonLines, as
+// JSON Lines: each item compact on its own line, keeping only the profile's
+// fields.
+func (p outputProfile) marshalList(v any, jsonLines bool) ([]byte, error) {
+	if !jsonLines {
+		return p.marshalIndent(v)
+	}
+	var buf bytes.Buffer
+	items := reflect.ValueOf(v)
+	for i := range items.Len() {
+		data, err := p.project(items.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// Kinds of write operations that can be queued while Zammad is unreachable.
+const (
+	queuedTicketArticle = "ticket_article"
+	queuedTagAdd        = "tag_add"
+	queuedTagRemove     = "tag_remove"
+)
+
+// queuedWrite is a write operation waiting to be replayed against Zammad.
+type queuedWrite struct {
+	ID        string                `json:"id"`
+	Kind      string                `json:"kind"`
+	TicketID  int                   `json:"ticket_id"`
+	Article   *zammad.TicketArticle `json:"article,omitempty"`
+	Tag       string                `json:"tag,omitempty"`
+	QueuedAt  time.Time             `json:"queued_at"`
+	Attempts  int                   `json:"attempts"`
+	LastError string                `json:"last_error,omitempty"`
+}
+
+// writeQueue durably stores write operations in a JSON file and replays them
+// in order once Zammad is reachable again.
+type writeQueue struct {
+	mu    sync.Mutex
+	path  string
+	items []queuedWrite
+}
+
+// pendingWrites is the queue used by write tools. It is nil unless
+// ZAMMAD_MCP_WRITE_QUEUE_FILE is set.
+var pendingWrites *writeQueue
+
+// openWriteQueue loads the queue stored at path, creating it if necessary.
+func openWriteQueue(path string) (*writeQueue, error) {
+	q := &writeQueue{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q.items); err != nil {
+			return nil, fmt.Errorf("failed to parse write queue %s: %w", path, err)
+		}
+	}
+	return q, nil
+}
+
+// enqueue appends w to the queue and persists it. It returns the number of
+// operations now waiting.
+func (q *writeQueue) enqueue(w queuedWrite) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+	w.QueuedAt = time.Now().UTC()
+	q.items = append(q.items, w)
+	if err := q.persist(); err != nil {
+		q.items = q.items[:len(q.items)-1]
+		return len(q.items), err
+	}
+	return len(q.items), nil
+}
+
+// persist writes the queue atomically. Callers must hold q.mu.
+func (q *writeQueue) persist() error {
+	data, err := json.MarshalIndent(q.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// replay applies queued operations in order. It stops at the first operation
+// that fails because Zammad is still unreachable; operations that fail
+// otherwise are dropped and logged, since retrying them cannot succeed or, if
+// Zammad answered too late, would apply them twice.
+func (q *writeQueue) replay() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return
+	}
+
+	done := 0
+	for i := range q.items {
+		w := &q.items[i]
+		w.Attempts++
+		err := applyQueuedWrite(*w)
+		if err != nil && isUnreachable(err) {
+			w.LastError = err.Error()
+			break
+		}
+		if err != nil {
+			log.Printf("Dropping queued %s for ticket %d (id %s) rejected by Zammad: %v", w.Kind, w.TicketID, w.ID, err)
+		} else {
+			log.Printf("Replayed queued %s for ticket %d (id %s)", w.Kind, w.TicketID, w.ID)
+		}
+		done++
+	}
+
+	q.items = q.items[done:]
+	if err := q.persist(); err != nil {
+		log.Printf("Error persisting write queue %s: %v", q.path, err)
+	}
+}
+
+// StartWriteQueue enables the offline write queue stored in the JSON file at
+// path, and replays it every retry interval for the lifetime of the process.
+func StartWriteQueue(path string, retry time.Duration) error {
+	q, err := openWriteQueue(path)
+	if err != nil {
+		return err
+	}
+	pendingWrites = q
+	log.Printf("Offline write queue enabled at %s (retry every %s)", path, retry)
+	go pendingWrites.run(retry)
+	return nil
+}
+
+// run replays the queue every interval until the process exits.
+func (q *writeQueue) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		q.replay()
+	}
+}
+
+func applyQueuedWrite(w queuedWrite) error {
+	switch w.Kind {
+	case queuedTicketArticle:
+		if w.Article == nil {
+			return fmt.Errorf("queued article is empty")
+		}
+		_, err := zammadClient.TicketArticleCreate(*w.Article)
+		return err
+	case queuedTagAdd:
+		return addTicketTag(w.TicketID, w.Tag)
+	case queuedTagRemove:
+		return removeTicketTag(w.TicketID, w.Tag)
+	default:
+		return fmt.Errorf("unknown queued operation %q", w.Kind)
+	}
+}
+
+// isUnreachable reports whether err means a request never reached Zammad: the
+// connection could not be established, or a gateway in front of Zammad
+// answered 502 or 503. Errors after the request was sent, such as a response
+// timeout or a 504, are not, as Zammad may have carried the write out, and
+// retrying it would apply it twice.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var apiErr *zammadAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusBadGateway || apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// queueWriteOnOutage queues w if the write queue is enabled and err indicates
+// an outage. It returns a message for the caller describing the queued
+// operation, or "" if the failure should be reported as usual.
+func queueWriteOnOutage(w queuedWrite, err error) string {
+	if pendingWrites == nil || !isUnreachable(err) {
+		return ""
+	}
+	pending, qErr := pendingWrites.enqueue(w)
+	if qErr != nil {
+		log.Printf("Error queueing %s for ticket %d: %v", w.Kind, w.TicketID, qErr)
+		return ""
+	}
+	log.Printf("Zammad unreachable, queued %s for ticket %d (%d pending): %v", w.Kind, w.TicketID, pending, err)
+	return fmt.Sprintf("Zammad is currently unreachable (%v). The %s for ticket %d was queued and will be sent automatically once Zammad is reachable again (%d operations pending).",
+		err, describeQueuedKind(w.Kind), w.TicketID, pending)
+}
+
+func describeQueuedKind(kind string) string {
+	switch kind {
+	case queuedTicketArticle:
+		return "article"
+	case queuedTagAdd:
+		return "tag addition"
+	case queuedTagRemove:
+		return "tag removal"
+	default:
+		return kind
+	}
+}
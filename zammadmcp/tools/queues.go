@@ -0,0 +1,256 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// unassignedOwnerID is the owner of tickets nobody is assigned to: Zammad
+// assigns them to its system user instead of leaving the owner empty.
+const unassignedOwnerID = 1
+
+// queueSearchLimit caps how many candidates a queue tool fetches before
+// filtering and sorting them locally.
+const queueSearchLimit = 500
+
+// queuedTicket is a ticket in a work queue, with how long it has been waiting.
+type queuedTicket struct {
+	ticketRecord
+	Waiting string `json:"waiting"`
+}
+
+// searchQueue returns the tickets in a state of one of the given state types,
+// optionally restricted to a group (by name), VIP customers and further
+// search terms. The state and group are checked again locally, as the search
+// index may lag behind recent changes.
+func searchQueue(stateTypes []string, group string, vipOnly bool, terms ...string) ([]ticketRecord, error) {
+	states, err := fetchTicketStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ticket states: %w", err)
+	}
+	stateIDs := make(map[int]bool)
+	var names []string
+	for _, s := range states {
+		for _, t := range stateTypes {
+			if s.Active && s.StateType == t {
+				stateIDs[s.ID] = true
+				names = append(names, fmt.Sprintf("%q", s.Name))
+			}
+		}
+	}
+	if len(names) == 0 {
+		return []ticketRecord{}, nil
+	}
+
+	clauses := []string{fmt.Sprintf("state.name:(%s)", strings.Join(names, " OR "))}
+	groupID := 0
+	if group != "" {
+		if groupID, err = groupIDByName(group); err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, fmt.Sprintf("group_id:%d", groupID))
+	}
+	for _, t := range terms {
+		if t != "" {
+			clauses = append(clauses, t)
+		}
+	}
+
+	candidates, err := searchTicketRecords(strings.Join(clauses, " AND "), queueSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]ticketRecord, 0, len(candidates))
+	for _, t := range candidates {
+		if stateIDs[t.StateID] && (groupID == 0 || t.GroupID == groupID) && (!vipOnly || t.VIP) {
+			tickets = append(tickets, t)
+		}
+	}
+	return tickets, nil
+}
+
+// groupIDByName resolves a group name (case-insensitive) to its ID.
+func groupIDByName(name string) (int, error) {
+	groups, err := zammadClient.GroupList()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, g := range groups {
+		if strings.EqualFold(g.Name, name) {
+			return g.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown group %q", name)
+}
+
+// queueEntries selects the chunk of tickets, which are already sorted, at
+// offset and wraps them with the time elapsed since the timestamp returned by
+// since. It also returns the offset of the next chunk (0 if none).
+func queueEntries(tickets []ticketRecord, offset, limit int, since func(ticketRecord) time.Time) ([]queuedTicket, int) {
+	tickets, next := pageOf(tickets, offset, limit)
+	now := time.Now()
+	entries := make([]queuedTicket, 0, len(tickets))
+	for _, t := range tickets {
+		entries = append(entries, queuedTicket{ticketRecord: t, Waiting: formatDuration(now.Sub(since(t)))})
+	}
+	return entries, next
+}
+
+// handleListUnassignedTickets lists new and open tickets without an owner,
+// oldest first.
+func handleListUnassignedTickets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	group := mcp.ParseString(request, "group", "")
+	vipOnly := mcp.ParseBoolean(request, "vip_only", false)
+	limit := mcp.ParseInt(request, "limit", 50)
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	windows, err := requestDateWindows(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument", err), nil
+	}
+
+	candidates, err := searchQueue([]string{"new", "open"}, group, vipOnly, fmt.Sprintf("owner_id:%d", unassignedOwnerID), windows)
+	if err != nil {
+		log.Printf("Error searching unassigned tickets in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list unassigned tickets", err), nil
+	}
+	tickets := make([]ticketRecord, 0, len(candidates))
+	for _, t := range candidates {
+		if t.OwnerID == unassignedOwnerID || t.OwnerID == 0 {
+			tickets = append(tickets, t)
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool { return tickets[i].CreatedAt.Before(tickets[j].CreatedAt) })
+
+	entries, next := queueEntries(tickets, offset, limit, func(t ticketRecord) time.Time { return t.CreatedAt })
+	jsonData, err := profile.marshalList(entries, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling unassigned tickets: %v", err)
+		return nil, fmt.Errorf("failed to marshal unassigned tickets: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Unassigned tickets (%d found, oldest first):\n%s%s", len(tickets), string(jsonData), moreResultsNote(request, next))), nil
+}
+
+// handleListAwaitingFirstResponse lists new and open tickets no agent has
+// replied to yet. By default, tickets closest to their first response
+// escalation come first, followed by the remaining tickets oldest first.
+func handleListAwaitingFirstResponse(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	group := mcp.ParseString(request, "group", "")
+	vipOnly := mcp.ParseBoolean(request, "vip_only", false)
+	order := mcp.ParseString(request, "sort", "escalation")
+	limit := mcp.ParseInt(request, "limit", 50)
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	windows, err := requestDateWindows(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument", err), nil
+	}
+	if order != "escalation" && order != "created" {
+		return mcp.NewToolResultError("Invalid argument: sort must be 'escalation' or 'created'"), nil
+	}
+
+	candidates, err := searchQueue([]string{"new", "open"}, group, vipOnly, "!_exists_:first_response_at", windows)
+	if err != nil {
+		log.Printf("Error searching tickets awaiting a first response in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list tickets awaiting a first response", err), nil
+	}
+	tickets := make([]ticketRecord, 0, len(candidates))
+	for _, t := range candidates {
+		if t.FirstResponseAt == nil && t.LastContactAgentAt == nil {
+			tickets = append(tickets, t)
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool {
+		a, b := tickets[i].FirstResponseEscalationAt, tickets[j].FirstResponseEscalationAt
+		if order == "escalation" && (a != nil || b != nil) {
+			if a == nil || b == nil {
+				return a != nil
+			}
+			if !a.Equal(*b) {
+				return a.Before(*b)
+			}
+		}
+		return tickets[i].CreatedAt.Before(tickets[j].CreatedAt)
+	})
+
+	entries, next := queueEntries(tickets, offset, limit, func(t ticketRecord) time.Time { return t.CreatedAt })
+	jsonData, err := profile.marshalList(entries, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling tickets awaiting a first response: %v", err)
+		return nil, fmt.Errorf("failed to marshal tickets awaiting a first response: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Tickets awaiting a first response (%d found, sorted by %s):\n%s%s", len(tickets), order, string(jsonData), moreResultsNote(request, next))), nil
+}
+
+// handleListWaitingOnAgent lists open tickets whose last communication came
+// from the customer, longest waiting first.
+func handleListWaitingOnAgent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	group := mcp.ParseString(request, "group", "")
+	vipOnly := mcp.ParseBoolean(request, "vip_only", false)
+	limit := mcp.ParseInt(request, "limit", 50)
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	windows, err := requestDateWindows(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument", err), nil
+	}
+
+	candidates, err := searchQueue([]string{"open"}, group, vipOnly, "_exists_:last_contact_customer_at", windows)
+	if err != nil {
+		log.Printf("Error searching tickets waiting on an agent in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list tickets waiting on an agent", err), nil
+	}
+	// Internal notes do not update the last contact times, so a ticket with
+	// only a note after the customer's message is still waiting.
+	tickets := make([]ticketRecord, 0, len(candidates))
+	for _, t := range candidates {
+		if t.LastContactCustomerAt != nil && (t.LastContactAgentAt == nil || t.LastContactCustomerAt.After(*t.LastContactAgentAt)) {
+			tickets = append(tickets, t)
+		}
+	}
+	sort.SliceStable(tickets, func(i, j int) bool { return tickets[i].LastContactCustomerAt.Before(*tickets[j].LastContactCustomerAt) })
+
+	entries, next := queueEntries(tickets, offset, limit, func(t ticketRecord) time.Time { return *t.LastContactCustomerAt })
+	jsonData, err := profile.marshalList(entries, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling tickets waiting on an agent: %v", err)
+		return nil, fmt.Errorf("failed to marshal tickets waiting on an agent: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Tickets waiting on an agent (%d found, longest waiting first):\n%s%s", len(tickets), string(jsonData), moreResultsNote(request, next))), nil
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// rateLimitSettings bound the tool calls of each session, so a model stuck
+// in a loop cannot flood Zammad.
+type rateLimitSettings struct {
+	// CallsPerMinute is the sustained rate of calls allowed per session;
+	// zero disables the limit.
+	CallsPerMinute int `yaml:"calls_per_minute"`
+	// Burst is how many calls a session may make at once after a pause.
+	Burst int `yaml:"burst"`
+}
+
+func (r rateLimitSettings) validate() error {
+	if r.CallsPerMinute < 0 || r.Burst < 0 {
+		return errors.New("rate_limit.calls_per_minute and burst must not be negative")
+	}
+	return nil
+}
+
+// tokenBucket holds the calls a session may still make.
+type tokenBucket struct {
+	tokens float64
+	filled time.Time
+}
+
+// rateLimiter keeps a token bucket per session.
+type rateLimiter struct {
+	mu        sync.Mutex
+	bySession map[string]*tokenBucket
+}
+
+var sessionRates = &rateLimiter{bySession: make(map[string]*tokenBucket)}
+
+// take spends a call of the session's bucket. If there is none left, it
+// returns how long until there is.
+func (r *rateLimiter) take(ctx context.Context, settings rateLimitSettings) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	burst := float64(max(settings.Burst, 1))
+	perSecond := float64(settings.CallsPerMinute) / 60
+	bucket, ok := r.bySession[sessionID(ctx)]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, filled: now}
+		r.bySession[sessionID(ctx)] = bucket
+	}
+	bucket.tokens = min(burst, bucket.tokens+now.Sub(bucket.filled).Seconds()*perSecond)
+	bucket.filled = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// forget drops the bucket of a session that ended.
+func (r *rateLimiter) forget(ctx context.Context, session server.ClientSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.bySession, session.SessionID())
+}
+
+// withRateLimit refuses calls of a session that exceed rate_limit.
+func withRateLimit(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if config.RateLimit.CallsPerMinute <= 0 {
+			return next(ctx, request)
+		}
+		if ok, wait := sessionRates.take(ctx, config.RateLimit); !ok {
+			log.Printf("Rate limited call of %s", request.Params.Name)
+			return mcp.NewToolResultError(fmt.Sprintf("Rate limit exceeded: at most %d tool calls per minute are allowed. Retry in %s, and combine requests where possible (e.g. bulk_update_tickets).",
+				config.RateLimit.CallsPerMinute, wait.Truncate(time.Second)+time.Second)), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expressions accepted by parseWhen besides absolute timestamps.
+var (
+	offsetPattern  = regexp.MustCompile(`^(?:in\s+|\+)?(\d+)\s*(m|mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?)$`)
+	dayPattern     = regexp.MustCompile(`^(today|tomorrow|next week|next business day|(?:next\s+)?(?:mon|tue|wed|thu|fri|sat|sun)[a-z]*)(?:\s+(?:at\s+)?(.+))?$`)
+	clockPattern   = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	absoluteLayout = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+)
+
+// parseWhen resolves a point in time given as an absolute timestamp or as a
+// relative expression such as "+3d", "in 2 hours", "tomorrow", "until Monday
+// 9am", "next business day" or "17:30". Wall-clock times are interpreted in
+// the time zone of the business calendar (UTC without one), and days without
+// a time of day resolve to the start of business hours (09:00 without a
+// calendar). The result must lie after now.
+func parseWhen(input string, now time.Time) (time.Time, error) {
+	cal := businessCalendar()
+	loc := displayLocation()
+	now = now.In(loc)
+
+	expr := strings.ToLower(strings.Join(strings.Fields(input), " "))
+	for _, prefix := range []string{"until ", "on ", "at "} {
+		expr = strings.TrimPrefix(expr, prefix)
+	}
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("empty time expression")
+	}
+
+	t, err := resolveWhen(expr, now, loc, cal)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%q resolves to %s, which is not in the future", input, t.Format("2006-01-02 15:04 MST"))
+	}
+	return t.In(loc), nil
+}
+
+func resolveWhen(expr string, now time.Time, loc *time.Location, cal *zammadCalendar) (time.Time, error) {
+	for _, layout := range absoluteLayout {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(expr), loc); err == nil {
+			if layout == "2006-01-02" {
+				return openingTime(t, cal), nil
+			}
+			return t, nil
+		}
+	}
+
+	if m := offsetPattern.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2][0] {
+		case 'm':
+			return now.Add(time.Duration(n) * time.Minute), nil
+		case 'h':
+			return now.Add(time.Duration(n) * time.Hour), nil
+		case 'd':
+			return now.AddDate(0, 0, n), nil
+		default:
+			return now.AddDate(0, 0, 7*n), nil
+		}
+	}
+
+	if h, m, ok := parseClock(expr); ok {
+		t := time.Date(now.Year(), now.Month(), now.Day(), h, m, 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	m := dayPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized time %q: use an RFC 3339 timestamp, an offset such as +3d or 'in 2 hours', or a day such as 'tomorrow 9am' or 'Monday'", expr)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	var day time.Time
+	switch m[1] {
+	case "today":
+		day = today
+	case "tomorrow":
+		day = today.AddDate(0, 0, 1)
+	case "next week":
+		day = nextWeekday(today, time.Monday)
+	case "next business day":
+		day = today.AddDate(0, 0, 1)
+		for i := 0; i < 366 && !isBusinessDay(day, cal); i++ {
+			day = day.AddDate(0, 0, 1)
+		}
+	default:
+		weekday, ok := parseWeekday(strings.TrimPrefix(m[1], "next "))
+		if !ok {
+			return time.Time{}, fmt.Errorf("unrecognized day %q", m[1])
+		}
+		day = nextWeekday(today, weekday)
+	}
+
+	if m[2] == "" {
+		return openingTime(day, cal), nil
+	}
+	h, minute, ok := parseClock(m[2])
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized time of day %q: use e.g. 9am, 14:30 or 5:15pm", m[2])
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), h, minute, 0, 0, loc), nil
+}
+
+// parseClock parses a time of day such as "9am", "5:30pm" or "14:00".
+func parseClock(s string) (hour, minute int, ok bool) {
+	m := clockPattern.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	return hour, minute, hour < 24 && minute < 60
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if len(s) >= 3 && strings.HasPrefix(name, s) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// nextWeekday returns the first day after today that falls on weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+	return today.AddDate(0, 0, days)
+}
+
+// openingTime returns the start of business hours on day, or 09:00 if there
+// is no calendar or the day has no business hours.
+func openingTime(day time.Time, cal *zammadCalendar) time.Time {
+	if cal != nil {
+		hours := cal.BusinessHours[strings.ToLower(day.Weekday().String()[:3])]
+		if hours.Active && len(hours.Timeframes) > 0 {
+			if t, err := clockOn(day, hours.Timeframes[0][0]); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, day.Location())
+}
+
+// isBusinessDay reports whether day has business hours and is not an active
+// public holiday. Without a calendar, Monday to Friday are business days.
+func isBusinessDay(day time.Time, cal *zammadCalendar) bool {
+	if cal == nil {
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	}
+	if holiday, ok := cal.Holidays[day.Format("2006-01-02")]; ok && holiday.Active {
+		return false
+	}
+	hours, ok := cal.BusinessHours[strings.ToLower(day.Weekday().String()[:3])]
+	return ok && hours.Active && len(hours.Timeframes) > 0
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addressList checks a comma-separated list of email addresses and returns it
+// normalized, or "" for an empty list.
+func addressList(list string) (string, error) {
+	if strings.TrimSpace(list) == "" {
+		return "", nil
+	}
+	addresses, err := mail.ParseAddressList(list)
+	if err != nil {
+		return "", err
+	}
+	formatted := make([]string, len(addresses))
+	for i, a := range addresses {
+		formatted[i] = a.Address
+		if a.Name != "" {
+			formatted[i] = a.String()
+		}
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// quoteArticle renders an article as the quote below a reply: its plain
+// text, trimmed and without the quotes it carries itself, with each line
+// prefixed with "> " under an attribution line.
+func quoteArticle(article zammad.TicketArticle) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(articlePlainText(article)), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(line, ">") {
+			continue // earlier messages the customer quoted
+		}
+		lines = append(lines, line)
+	}
+	// Dropping quotes can leave blank lines at the end.
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var quote strings.Builder
+	fmt.Fprintf(&quote, "On %s, %s wrote:\n", article.CreatedAt.Format("Mon, 2 Jan 2006 15:04 MST"), cmp.Or(article.From, "the customer"))
+	for _, line := range lines {
+		quote.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+	return quote.String()
+}
+
+// lastCustomerArticle returns the latest article the customer of a ticket
+// sent, or false if there is none.
+func lastCustomerArticle(ticketID int) (zammad.TicketArticle, bool, error) {
+	articles, err := zammadClient.TicketArticleByTicket(ticketID)
+	if err != nil {
+		return zammad.TicketArticle{}, false, err
+	}
+	for i := len(articles) - 1; i >= 0; i-- {
+		if articles[i].Sender == "Customer" && !articles[i].Internal {
+			return articles[i], true, nil
+		}
+	}
+	return zammad.TicketArticle{}, false, nil
+}
+
+// handleReplyToTicket sends an email to the customer of a ticket, or to the
+// given recipients, as a public email article, so the answer is part of the
+// ticket's thread. The group's signature is appended like in the web UI, and
+// with quote_previous the last customer article is quoted below it.
+func handleReplyToTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	body := mcp.ParseString(request, "body", "")
+	subject := mcp.ParseString(request, "subject", "")
+	skipSignature := mcp.ParseBoolean(request, "skip_signature", false)
+	quotePrevious := mcp.ParseBoolean(request, "quote_previous", false)
+	if ticketID <= 0 || strings.TrimSpace(body) == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, body"), nil
+	}
+	to, err := addressList(mcp.ParseString(request, "to", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument to: %v", err)), nil
+	}
+	cc, err := addressList(mcp.ParseString(request, "cc", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cc: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if to == "" {
+		customer, err := zammadClient.UserShow(ticket.CustomerID)
+		if err != nil {
+			log.Printf("Error fetching customer %d from Zammad: %v", ticket.CustomerID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get customer %d of ticket %d", ticket.CustomerID, ticketID), err), nil
+		}
+		if customer.Email == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("The customer of ticket %d has no email address; pass the recipient as to", ticketID)), nil
+		}
+		to = customer.Email
+	}
+	if subject == "" {
+		subject = ticket.Title
+	}
+	if body, err = expandVariables(body, ticketID); err != nil {
+		log.Printf("Error expanding variables for ticket %d: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr("Failed to expand the variables in body", err), nil
+	}
+	if !skipSignature {
+		groups, err := zammadClient.GroupList()
+		if err != nil {
+			log.Printf("Error listing groups from Zammad: %v", err)
+			return mcp.NewToolResultErrorFromErr("Failed to list groups for the signature (set skip_signature to send without it)", err), nil
+		}
+		for _, g := range groups {
+			if g.ID != ticket.GroupID {
+				continue
+			}
+			signature, err := groupSignature(g.Name)
+			if err != nil {
+				log.Printf("Error loading signature of group %q: %v", g.Name, err)
+				return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to load the signature of group %q (set skip_signature to send without it)", g.Name), err), nil
+			}
+			body = appendSignature(body, signature)
+		}
+	}
+	if quotePrevious {
+		previous, found, err := lastCustomerArticle(ticketID)
+		if err != nil {
+			log.Printf("Error fetching articles of ticket %d from Zammad: %v", ticketID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get the articles of ticket %d to quote", ticketID), err), nil
+		}
+		if !found {
+			return mcp.NewToolResultError(fmt.Sprintf("Ticket %d has no customer article to quote; reply without quote_previous", ticketID)), nil
+		}
+		body = strings.TrimRight(body, "\n") + "\n\n" + quoteArticle(previous)
+	}
+
+	article := zammad.TicketArticle{TicketID: ticketID, To: to, Subject: subject, Body: body, ContentType: "text/plain", Type: "email", Internal: false}
+	if cc != "" {
+		article.Cc = cc
+	}
+	createdArticle, err := zammadClient.TicketArticleCreate(article)
+	if err != nil {
+		log.Printf("Error sending reply to ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to send the reply to ticket %d", ticketID), err), nil
+	}
+	log.Printf("Sent reply (Article ID %d) on ticket ID %d to %s", createdArticle.ID, ticketID, to)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("emailed %s on ticket %d (article %d)", to, ticketID, createdArticle.ID), nil, ticketObject(ticketID), articleObject(ticketID, createdArticle.ID))
+	jsonData, err := json.MarshalIndent(createdArticle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal article %d: %w", createdArticle.ID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Reply sent to %s on ticket %d:\n%s", to, ticketID, string(jsonData))), nil
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// reportPeriodLengths are the periods report tools compare, by name.
+var reportPeriodLengths = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// reportWindow is the time range [From, To) of a report period.
+type reportWindow struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// rangeQuery returns a search clause matching timestamps of field within the
+// window.
+func (w reportWindow) rangeQuery(field string) string {
+	return fmt.Sprintf("%s:[%s TO %s}", field, w.From.UTC().Format(time.RFC3339), w.To.UTC().Format(time.RFC3339))
+}
+
+// reportWindows returns the period of the given name ending at now and the
+// period before it.
+func reportWindows(period string, now time.Time) (current, previous reportWindow, err error) {
+	length, ok := reportPeriodLengths[period]
+	if !ok {
+		return reportWindow{}, reportWindow{}, fmt.Errorf("unknown period %q (one of: day, week, month)", period)
+	}
+	// Whole minutes keep repeated calls identical for the response cache.
+	now = now.UTC().Truncate(time.Minute)
+	current = reportWindow{From: now.Add(-length), To: now}
+	previous = reportWindow{From: now.Add(-2 * length), To: now.Add(-length)}
+	return current, previous, nil
+}
+
+// countTrend compares a count in the current period with the previous one.
+type countTrend struct {
+	Current  int    `json:"current"`
+	Previous int    `json:"previous"`
+	Delta    int    `json:"delta"`
+	Change   string `json:"change,omitempty"` // relative change, e.g. "+25%"; omitted if previous is 0
+}
+
+func newCountTrend(current, previous int) countTrend {
+	trend := countTrend{Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		trend.Change = fmt.Sprintf("%+.0f%%", float64(trend.Delta)*100/float64(previous))
+	}
+	return trend
+}
+
+// reportScope returns the search clause restricting a report to a group, or
+// "" for all groups.
+func reportScope(group string) (string, error) {
+	if group == "" {
+		return "", nil
+	}
+	groupID, err := groupIDByName(group)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(" AND group_id:%d", groupID), nil
+}
+
+// countTickets returns the number of tickets matching a search query, paging
+// through all results.
+func countTickets(query string) (int, error) {
+	pager := newTicketPager(query, 100, continuation{Page: 1})
+	count := 0
+	for {
+		tickets, err := pager.next()
+		if err != nil {
+			return 0, err
+		}
+		if len(tickets) == 0 {
+			return count, nil
+		}
+		count += len(tickets)
+	}
+}
+
+// ticketTrendReport is the result of report_ticket_trends.
+type ticketTrendReport struct {
+	Period         string       `json:"period"`
+	Group          string       `json:"group,omitempty"`
+	CurrentPeriod  reportWindow `json:"current_period"`
+	PreviousPeriod reportWindow `json:"previous_period"`
+	Created        countTrend   `json:"created"`
+	Closed         countTrend   `json:"closed"`
+	Backlog        countTrend   `json:"backlog"` // unclosed tickets at the end of each period
+}
+
+// openStateTypes are the state types of tickets in the backlog.
+var openStateTypes = []string{"new", "open", "pending reminder", "pending action"}
+
+// openStateQuery returns a search clause matching tickets in the backlog.
+func openStateQuery() (string, error) {
+	states, err := fetchTicketStates()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, s := range states {
+		if slices.Contains(openStateTypes, s.StateType) {
+			names = append(names, fmt.Sprintf("%q", s.Name))
+		}
+	}
+	return fmt.Sprintf("state.name:(%s)", strings.Join(names, " OR ")), nil
+}
+
+// handleReportTicketTrends compares the tickets created and closed in the
+// current period with the period before, and how the backlog changed.
+func handleReportTicketTrends(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	period := mcp.ParseString(request, "period", "week")
+	group := mcp.ParseString(request, "group", "")
+	current, previous, err := reportWindows(period, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument period: %v", err)), nil
+	}
+	scope, err := reportScope(group)
+	if err != nil {
+		log.Printf("Error resolving group %q: %v", group, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+	}
+
+	openQuery, err := openStateQuery()
+	if err != nil {
+		log.Printf("Error fetching ticket states: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to fetch ticket states", err), nil
+	}
+
+	queries := []string{
+		current.rangeQuery("created_at"), previous.rangeQuery("created_at"),
+		current.rangeQuery("close_at"), previous.rangeQuery("close_at"),
+		openQuery,
+	}
+	counts := make([]int, len(queries))
+	for i, query := range queries {
+		if counts[i], err = countTickets(query + scope); err != nil {
+			log.Printf("Error counting tickets for %q: %v", query, err)
+			return mcp.NewToolResultErrorFromErr("Failed to count tickets", err), nil
+		}
+	}
+	createdNow, createdBefore, closedNow, closedBefore, backlog := counts[0], counts[1], counts[2], counts[3], counts[4]
+
+	report := ticketTrendReport{
+		Period:         period,
+		Group:          group,
+		CurrentPeriod:  current,
+		PreviousPeriod: previous,
+		Created:        newCountTrend(createdNow, createdBefore),
+		Closed:         newCountTrend(closedNow, closedBefore),
+		// The backlog at the end of the previous period is derived from
+		// today's, as Zammad cannot search past states.
+		Backlog: newCountTrend(backlog, backlog-createdNow+closedNow),
+	}
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket trends: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket trends, last %s vs the %s before:\n%s", period, period, string(jsonData))), nil
+}
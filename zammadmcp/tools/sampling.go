@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrSamplingUnsupported is returned when the connected client did not declare
+// the sampling capability during initialization, or when the server is not
+// serving stdio.
+var ErrSamplingUnsupported = errors.New("the MCP client does not support sampling")
+
+// samplingBridge adds server-to-client requests (sampling/createMessage) to
+// the stdio transport, which mcp-go only implements for client-to-server
+// requests. It sits between os.Stdin/os.Stdout and the StdioServer: responses
+// to our own requests are taken out of the input stream and handed to the
+// waiting caller, everything else is passed through unchanged.
+type samplingBridge struct {
+	out       *lockedWriter
+	attached  atomic.Bool
+	supported atomic.Bool
+	nextID    atomic.Int64
+
+	mu      sync.Mutex
+	pending map[string]chan samplingResponse
+}
+
+type samplingResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// sampler is the bridge of the running stdio server.
+var sampler = &samplingBridge{
+	out:     &lockedWriter{w: os.Stdout},
+	pending: make(map[string]chan samplingResponse),
+}
+
+// lockedWriter serializes writes so that messages written concurrently by the
+// StdioServer and the bridge are not interleaved.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// hooks returns the server hooks recording whether the client supports
+// sampling.
+func (b *samplingBridge) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		b.supported.Store(message.Params.Capabilities.Sampling != nil)
+		log.Printf("Client %s %s, sampling supported: %t", message.Params.ClientInfo.Name, message.Params.ClientInfo.Version, b.supported.Load())
+	})
+	return hooks
+}
+
+// serveStdio serves s on stdin/stdout like server.ServeStdio, with the bridge
+// in between.
+func (b *samplingBridge) serveStdio(s *server.MCPServer) error {
+	stdio := server.NewStdioServer(s)
+	stdio.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	if wireTrace != nil {
+		b.out.w = traceWriter{t: wireTrace, w: b.out.w}
+	}
+	in, forward := io.Pipe()
+	go b.pump(os.Stdin, forward)
+	b.attached.Store(true)
+	return stdio.Listen(ctx, in, b.out)
+}
+
+// pump reads messages from the client. Responses to pending requests are
+// delivered directly; other messages are queued for the StdioServer. The
+// queue is unbounded so that a tool handler waiting for a response never
+// blocks the reading of that response.
+func (b *samplingBridge) pump(r io.Reader, forward *io.PipeWriter) {
+	var (
+		mu     sync.Mutex
+		cond   = sync.NewCond(&mu)
+		queue  [][]byte
+		closed bool
+	)
+	go func() {
+		for {
+			mu.Lock()
+			for len(queue) == 0 && !closed {
+				cond.Wait()
+			}
+			if len(queue) == 0 {
+				mu.Unlock()
+				forward.Close()
+				return
+			}
+			line := queue[0]
+			queue = queue[1:]
+			mu.Unlock()
+			if _, err := forward.Write(line); err != nil {
+				return
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			wireTrace.mcp("<-", line)
+		}
+		if len(line) > 0 && !b.deliver(line) {
+			mu.Lock()
+			queue = append(queue, line)
+			cond.Signal()
+			mu.Unlock()
+		}
+		if err != nil {
+			mu.Lock()
+			closed = true
+			cond.Signal()
+			mu.Unlock()
+			return
+		}
+	}
+}
+
+// deliver hands line to the caller waiting for it, if it is a response to a
+// request sent by the bridge.
+func (b *samplingBridge) deliver(line []byte) bool {
+	var message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		samplingResponse
+	}
+	if json.Unmarshal(line, &message) != nil || message.Method != "" || len(message.ID) == 0 {
+		return false
+	}
+	var id string
+	if json.Unmarshal(message.ID, &id) != nil {
+		return false
+	}
+
+	b.mu.Lock()
+	ch, ok := b.pending[id]
+	delete(b.pending, id)
+	b.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ch <- message.samplingResponse
+	return true
+}
+
+// createMessage asks the client's LLM to sample a message. The client may show
+// the request to the user for approval, so this can take a while; it waits
+// until ctx is done.
+func (b *samplingBridge) createMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	if !b.attached.Load() || !b.supported.Load() {
+		return nil, ErrSamplingUnsupported
+	}
+
+	id := fmt.Sprintf("zammad-mcp-sampling-%d", b.nextID.Add(1))
+	ch := make(chan samplingResponse, 1)
+	b.mu.Lock()
+	b.pending[id] = ch
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+	}()
+
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"method":  "sampling/createMessage",
+		"params":  request.Params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sampling request: %w", err)
+	}
+	if _, err := fmt.Fprintf(b.out, "%s\n", message); err != nil {
+		return nil, fmt.Errorf("failed to send sampling request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response := <-ch:
+		if response.Error != nil {
+			return nil, fmt.Errorf("client rejected sampling request: %s (code %d)", response.Error.Message, response.Error.Code)
+		}
+		var result mcp.CreateMessageResult
+		if err := json.Unmarshal(response.Result, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode sampling result: %w", err)
+		}
+		return &result, nil
+	}
+}
+
+// sampledText returns the text of a sampled message, or "" if the client
+// returned non-text content.
+func sampledText(result *mcp.CreateMessageResult) string {
+	content, ok := result.Content.(map[string]any)
+	if !ok || content["type"] != "text" {
+		return ""
+	}
+	text, _ := content["text"].(string)
+	return text
+}
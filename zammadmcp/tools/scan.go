@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// attachmentScanner configures the virus scan of attachments passed through
+// the server. Either Command or Clamd may be set; without both, attachments
+// are not scanned.
+type attachmentScanner struct {
+	// Command is an external scanner, e.g. [clamdscan, --no-summary, -]. It
+	// reads the attachment on stdin; the placeholder {filename} in an
+	// argument is replaced with the attachment's file name. Exit status 0
+	// means clean, 1 means infected, anything else is a scan failure.
+	Command []string `yaml:"command"`
+	// Clamd is the address of a clamd daemon, tcp://host:3310 or
+	// unix:///path/to/clamd.sock, scanned with the INSTREAM command.
+	Clamd string `yaml:"clamd"`
+	// Timeout bounds each scan; zero means no timeout.
+	Timeout duration `yaml:"timeout"`
+}
+
+// clamdChunkSize is the size of the chunks attachments are streamed to clamd
+// in.
+const clamdChunkSize = 64 << 10
+
+// attachmentInfectedError reports an attachment the scanner flagged.
+type attachmentInfectedError struct {
+	filename string
+	finding  string
+}
+
+func (e *attachmentInfectedError) Error() string {
+	return fmt.Sprintf("attachment %q was rejected by the virus scanner: %s", e.filename, e.finding)
+}
+
+// enabled reports whether a scanner is configured.
+func (s attachmentScanner) enabled() bool {
+	return len(s.Command) > 0 || s.Clamd != ""
+}
+
+// scan checks an attachment with the configured scanner. It returns an
+// *attachmentInfectedError if the attachment was flagged, and another error
+// if it could not be scanned; callers must not pass the attachment through in
+// either case.
+func (s attachmentScanner) scan(filename string, data []byte) error {
+	if !s.enabled() {
+		return nil
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	start := time.Now()
+	var err error
+	if len(s.Command) > 0 {
+		err = s.scanWithCommand(ctx, filename, data)
+	} else {
+		err = s.scanWithClamd(ctx, filename, data)
+	}
+	var infected *attachmentInfectedError
+	switch {
+	case errors.As(err, &infected):
+		log.Printf("Virus scan flagged attachment %q: %s", filename, infected.finding)
+	case err != nil:
+		log.Printf("Virus scan of attachment %q failed: %v", filename, err)
+		err = fmt.Errorf("attachment %q could not be scanned for viruses: %w", filename, err)
+	default:
+		log.Printf("Virus scan of attachment %q (%s) passed in %s", filename, byteSize(len(data)), time.Since(start).Round(time.Millisecond))
+	}
+	return err
+}
+
+// scanWithCommand runs the external scanner command.
+func (s attachmentScanner) scanWithCommand(ctx context.Context, filename string, data []byte) error {
+	args := make([]string, len(s.Command))
+	for i, arg := range s.Command {
+		args[i] = strings.ReplaceAll(arg, "{filename}", filename)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		finding := strings.TrimSpace(string(output))
+		if finding == "" {
+			finding = "flagged by " + args[0]
+		}
+		return &attachmentInfectedError{filename, finding}
+	}
+	if err != nil {
+		if output := strings.TrimSpace(string(output)); output != "" {
+			return fmt.Errorf("%s: %w: %s", args[0], err, output)
+		}
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+// scanWithClamd streams the attachment to clamd.
+func (s attachmentScanner) scanWithClamd(ctx context.Context, filename string, data []byte) error {
+	conn, err := s.dialClamd(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	for len(data) > 0 {
+		chunk := data[:min(len(data), clamdChunkSize)]
+		data = data[len(chunk):]
+		binary.Write(w, binary.BigEndian, uint32(len(chunk)))
+		w.Write(chunk)
+	}
+	binary.Write(w, binary.BigEndian, uint32(0))
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	// Replies are "stream: OK", "stream: <signature> FOUND" or
+	// "... ERROR".
+	reply = strings.TrimPrefix(strings.TrimRight(reply, "\x00"), "stream: ")
+	switch {
+	case reply == "OK":
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &attachmentInfectedError{filename, strings.TrimSuffix(reply, " FOUND")}
+	}
+	return fmt.Errorf("clamd: %s", reply)
+}
+
+// context returns a context bounded by the scan timeout.
+func (s attachmentScanner) context() (context.Context, context.CancelFunc) {
+	if s.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(s.Timeout))
+}
+
+// pingClamd checks that clamd is reachable and responding.
+func (s attachmentScanner) pingClamd() error {
+	ctx, cancel := s.context()
+	defer cancel()
+	conn, err := s.dialClamd(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	if reply = strings.TrimRight(reply, "\x00"); reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected reply %q to PING", reply)
+	}
+	return nil
+}
+
+// dialClamd connects to the configured clamd address, bounding the whole
+// exchange by ctx.
+func (s attachmentScanner) dialClamd(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(s.Clamd)
+	if err != nil || (u.Scheme != "tcp" && u.Scheme != "unix") {
+		return nil, fmt.Errorf("invalid clamd address %q: expected tcp://host:port or unix:///path", s.Clamd)
+	}
+	address := u.Host
+	if u.Scheme == "unix" {
+		address = u.Path
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, u.Scheme, address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// checkAttachment applies the attachment policy and the virus scan to an
+// attachment about to be uploaded to or returned from Zammad.
+func checkAttachment(filename, mimeType string, data []byte) error {
+	if err := config.Attachments.check(filename, mimeType, len(data)); err != nil {
+		return err
+	}
+	return config.Attachments.Scan.scan(filename, data)
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// scheduledReportMaxChars caps the report text posted to a webhook; chat
+// services reject longer messages.
+const scheduledReportMaxChars = 35000
+
+// cronSchedule is a parsed five-field cron expression (minute, hour, day of
+// month, month, day of week) as used by crontab, including lists, ranges,
+// steps, month and weekday names and the @hourly, @daily, @weekly and
+// @monthly shorthands. As in crontab, a day matches either the day of month
+// or the day of week if both are restricted.
+type cronSchedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // bit n set if value n matches
+	domRestricted, dowRestricted  bool
+}
+
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var (
+	cronMonthNames   = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronWeekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+func parseCron(expr string) (cronSchedule, error) {
+	c := cronSchedule{expr: expr}
+	fields := strings.Fields(strings.ToLower(expr))
+	if len(fields) == 1 {
+		if full, ok := cronShorthands[fields[0]]; ok {
+			fields = strings.Fields(full)
+		}
+	}
+	if len(fields) != 5 {
+		return c, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return c, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return c, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return c, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return c, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronWeekdayNames); err != nil {
+		return c, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 { // 7 is Sunday, too
+		c.dow |= 1
+	}
+	// Like crontab, fields starting with * (such as */2) do not restrict.
+	c.domRestricted, c.dowRestricted = !strings.HasPrefix(fields[2], "*"), !strings.HasPrefix(fields[4], "*")
+	if c.next(time.Now()).IsZero() {
+		return c, fmt.Errorf("cron expression %q never matches", expr)
+	}
+	return c, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges ("1-5") and
+// steps ("*/15", "9-17/2") into a bit set.
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[s]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("invalid value %q (%d-%d)", s, min, max)
+		}
+		return n, nil
+	}
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		span, stepText, stepped := strings.Cut(part, "/")
+		step := 1
+		if stepped {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		if span != "*" {
+			from, to, ranged := strings.Cut(span, "-")
+			var err error
+			if lo, err = value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if ranged {
+				if hi, err = value(to); err != nil {
+					return 0, err
+				}
+			} else if stepped {
+				hi = max // "5/15" means every 15 from 5
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", span)
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			bits |= 1 << n
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := parseCron(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*c = parsed
+	return nil
+}
+
+// dayMatches reports whether the schedule runs on the day of t.
+func (c cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next returns the first time after t the schedule matches, in t's location,
+// or the zero time if there is none within five years.
+func (c cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// scheduledReport is an entry of the schedules section of the configuration
+// file: a read-only tool run on a cron schedule, with its output posted to a
+// chat webhook or added to a ticket as an internal note.
+type scheduledReport struct {
+	// Name identifies the schedule in logs and messages; it defaults to the
+	// tool name.
+	Name string `yaml:"name"`
+	// Cron is evaluated in the time zone of the business calendar.
+	Cron      cronSchedule   `yaml:"cron"`
+	Tool      string         `yaml:"tool"`
+	Arguments map[string]any `yaml:"arguments"`
+	// WebhookURL receives the output as a Slack-compatible {"text": ...}
+	// message. Exclusive with TicketID.
+	WebhookURL string `yaml:"webhook_url"`
+	// TicketID is the ticket the output is added to as an internal note.
+	TicketID int `yaml:"ticket_id"`
+}
+
+func (r *scheduledReport) validate(index int) error {
+	if r.Cron.expr == "" {
+		return fmt.Errorf("schedules[%d].cron must be set", index)
+	}
+	if !cachedTools[r.Tool] {
+		return fmt.Errorf("schedules[%d].tool %q is not a read-only tool (such as the report_* tools)", index, r.Tool)
+	}
+	if (r.WebhookURL == "") == (r.TicketID == 0) {
+		return fmt.Errorf("schedules[%d] needs exactly one of webhook_url and ticket_id", index)
+	}
+	if r.WebhookURL != "" {
+		if u, err := url.Parse(r.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("schedules[%d].webhook_url is not an http(s) URL", index)
+		}
+	}
+	if r.TicketID < 0 {
+		return fmt.Errorf("schedules[%d].ticket_id must be positive", index)
+	}
+	if r.Name == "" {
+		r.Name = r.Tool
+	}
+	return nil
+}
+
+// RunSchedules runs each configured schedule in its own goroutine for the
+// lifetime of the process. The tool calls go through the server like client
+// calls, so tool timeouts apply.
+func RunSchedules(s *server.MCPServer) {
+	loc := displayLocation()
+	for _, report := range config.Schedules {
+		log.Printf("Scheduled report %s: %s with cron %q (%s), next run at %s", report.Name, report.Tool, report.Cron.expr, loc, report.Cron.next(time.Now().In(loc)).Format(time.RFC3339))
+		go func() {
+			for {
+				next := report.Cron.next(time.Now().In(loc))
+				if next.IsZero() {
+					log.Printf("Scheduled report %s has no further runs", report.Name)
+					return
+				}
+				time.Sleep(time.Until(next))
+				runScheduledReport(s, report, next)
+			}
+		}()
+	}
+}
+
+// runScheduledReport calls the report's tool and delivers its output, also if
+// the call failed, so a broken schedule does not go unnoticed.
+func runScheduledReport(s *server.MCPServer, report scheduledReport, at time.Time) {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  string(mcp.MethodToolsCall),
+		"params":  map[string]any{"name": report.Tool, "arguments": report.Arguments},
+	})
+	if err != nil {
+		log.Printf("Scheduled report %s: invalid arguments: %v", report.Name, err)
+		return
+	}
+	output, failed := scheduledReportOutput(s.HandleMessage(context.Background(), message))
+	name := report.Name
+	if report.Name != report.Tool {
+		name += fmt.Sprintf(" (%s)", report.Tool)
+	}
+	title := fmt.Sprintf(localized(msgScheduledReport), name, at.Format("Mon 2006-01-02 15:04 MST"))
+	if failed {
+		title = fmt.Sprintf(localized(msgScheduledReportErr), name, at.Format("Mon 2006-01-02 15:04 MST"))
+		log.Printf("Scheduled report %s failed: %s", report.Name, truncateString(output, 500))
+	}
+
+	if report.WebhookURL != "" {
+		text := fmt.Sprintf("*%s*\n```\n%s\n```", title, truncateString(output, scheduledReportMaxChars))
+		if err := postWebhookText(report.WebhookURL, text); err != nil {
+			log.Printf("Error posting scheduled report %s to its webhook: %v", report.Name, err)
+			return
+		}
+		log.Printf("Posted scheduled report %s to its webhook", report.Name)
+		return
+	}
+	article := zammad.TicketArticle{
+		TicketID:    report.TicketID,
+		Subject:     title,
+		Body:        output,
+		ContentType: "text/plain",
+		Type:        "note",
+		Internal:    true,
+	}
+	created, err := zammadClient.TicketArticleCreate(article)
+	if err != nil {
+		log.Printf("Error adding scheduled report %s to ticket %d: %v", report.Name, report.TicketID, err)
+		return
+	}
+	log.Printf("Added scheduled report %s to ticket %d (article %d)", report.Name, report.TicketID, created.ID)
+}
+
+// scheduledReportOutput returns the text of a tools/call response and
+// whether the call failed.
+func scheduledReportOutput(response mcp.JSONRPCMessage) (string, bool) {
+	switch r := response.(type) {
+	case mcp.JSONRPCError:
+		return r.Error.Message, true
+	case mcp.JSONRPCResponse:
+		result, ok := r.Result.(mcp.CallToolResult)
+		if !ok {
+			return fmt.Sprintf("unexpected result %T", r.Result), true
+		}
+		var texts []string
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		if len(texts) == 0 {
+			return "The tool returned no text.", result.IsError
+		}
+		return strings.Join(texts, "\n\n"), result.IsError
+	default:
+		return fmt.Sprintf("unexpected response %T", response), true
+	}
+}
+
+// postWebhookText posts a Slack-compatible message, as also accepted by the
+// incoming webhooks of Mattermost and Rocket.Chat.
+func postWebhookText(endpoint, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
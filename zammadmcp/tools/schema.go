@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// addTool registers a tool with a fully specified input schema: properties
+// and required are emitted even when empty. mcp-go omits them in that case,
+// which some clients render as a single made-up parameter.
+func addTool(s *server.MCPServer, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	properties := tool.InputSchema.Properties
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	if property, ok := properties["ticket_id"].(map[string]any); ok && property["type"] == "number" && tool.Name != "set_current_ticket" {
+		property["description"] = fmt.Sprint(property["description"]) + currentTicketHint
+	}
+	required := tool.InputSchema.Required
+	if required == nil {
+		required = []string{}
+	}
+	schema, err := json.Marshal(map[string]any{"type": "object", "properties": properties, "required": required})
+	if err != nil {
+		log.Fatalf("Invalid input schema of tool %s: %v", tool.Name, err)
+	}
+	tool.InputSchema = mcp.ToolInputSchema{}
+	tool.RawInputSchema = schema
+	s.AddTool(tool, handler)
+}
+
+// examples adds example values to an argument's schema.
+func examples(values ...any) mcp.PropertyOption {
+	return func(schema map[string]any) {
+		schema["examples"] = values
+	}
+}
+
+// additionalProperties declares the schema of the values of a free-form
+// object argument; true allows any value.
+func additionalProperties(schema any) mcp.PropertyOption {
+	return func(property map[string]any) {
+		property["additionalProperties"] = schema
+	}
+}
+
+// servedTool is a tool as listed to clients, with its input schema exactly
+// as served.
+type servedTool struct {
+	Name        string          `json:"name"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// servedTools returns the registered tools as a client receives them from
+// tools/list, sorted by name.
+func servedTools(s *server.MCPServer) ([]servedTool, error) {
+	request, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": 0, "method": string(mcp.MethodToolsList)})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(s.HandleMessage(context.Background(), request))
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Result struct {
+			Tools []servedTool `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to read the tool list: %w", err)
+	}
+	return response.Result.Tools, nil
+}
+
+// propertySchema is the part of an argument's JSON Schema that
+// checkToolSchemas validates.
+type propertySchema struct {
+	Type                 string          `json:"type"`
+	Description          string          `json:"description"`
+	Enum                 []any           `json:"enum"`
+	Default              any             `json:"default"`
+	Examples             []any           `json:"examples"`
+	Items                *propertySchema `json:"items"`
+	Properties           map[string]any  `json:"properties"`
+	AdditionalProperties any             `json:"additionalProperties"`
+}
+
+// inputSchema is a tool's input schema as checked by checkToolSchemas.
+type inputSchema struct {
+	Type       string                    `json:"type"`
+	Properties map[string]propertySchema `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+// checkToolSchemas validates the input schemas served for each tool, so a
+// tool definition that clients would render wrongly fails at startup rather
+// than in some client. It returns one message per problem.
+func checkToolSchemas(tools []servedTool) []string {
+	var problems []string
+	for _, tool := range tools {
+		report := func(format string, args ...any) {
+			problems = append(problems, tool.Name+": "+fmt.Sprintf(format, args...))
+		}
+		var presence struct {
+			Properties json.RawMessage `json:"properties"`
+			Required   json.RawMessage `json:"required"`
+		}
+		var schema inputSchema
+		if err := json.Unmarshal(tool.InputSchema, &presence); err != nil {
+			report("input schema is not a JSON object: %v", err)
+			continue
+		}
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+			report("malformed input schema: %v", err)
+			continue
+		}
+		if schema.Type != "object" {
+			report("input schema type is %q, not object", schema.Type)
+		}
+		if presence.Properties == nil {
+			report("input schema has no properties")
+		}
+		if presence.Required == nil {
+			report("input schema has no required array")
+		}
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; !ok {
+				report("required argument %s is not declared", name)
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, problem := range checkPropertySchema(schema.Properties[name]) {
+				report("argument %s: %s", name, problem)
+			}
+		}
+	}
+	return problems
+}
+
+// checkPropertySchema validates the schema of one argument.
+func checkPropertySchema(p propertySchema) []string {
+	var problems []string
+	if !jsonSchemaTypes[p.Type] {
+		return []string{fmt.Sprintf("unknown or missing type %q", p.Type)}
+	}
+	if p.Description == "" {
+		problems = append(problems, "no description")
+	}
+	if p.Enum != nil && len(p.Enum) == 0 {
+		problems = append(problems, "empty enum")
+	}
+	for _, v := range p.Enum {
+		if !matchesSchemaType(p.Type, v) {
+			problems = append(problems, fmt.Sprintf("enum value %v is not a %s", v, p.Type))
+		}
+	}
+	if p.Default != nil {
+		if !matchesSchemaType(p.Type, p.Default) {
+			problems = append(problems, fmt.Sprintf("default %v is not a %s", p.Default, p.Type))
+		} else if len(p.Enum) > 0 && !slices.Contains(p.Enum, p.Default) {
+			problems = append(problems, fmt.Sprintf("default %v is not one of the enum values", p.Default))
+		}
+	}
+	for _, v := range p.Examples {
+		if !matchesSchemaType(p.Type, v) {
+			problems = append(problems, fmt.Sprintf("example %v is not a %s", v, p.Type))
+		}
+	}
+	switch p.Type {
+	case "array":
+		if p.Items == nil {
+			problems = append(problems, "array without items")
+		} else if !jsonSchemaTypes[p.Items.Type] {
+			problems = append(problems, fmt.Sprintf("items of unknown or missing type %q", p.Items.Type))
+		}
+	case "object":
+		if p.Properties == nil && p.AdditionalProperties == nil {
+			problems = append(problems, "object without properties or additionalProperties")
+		}
+	}
+	return problems
+}
+
+// jsonSchemaTypes are the argument types clients are expected to render.
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "array": true, "object": true,
+}
+
+// matchesSchemaType reports whether a decoded JSON value has the given JSON
+// Schema type.
+func matchesSchemaType(schemaType string, v any) bool {
+	switch v := v.(type) {
+	case string:
+		return schemaType == "string"
+	case float64:
+		return schemaType == "number" || schemaType == "integer" && v == float64(int64(v))
+	case bool:
+		return schemaType == "boolean"
+	case []any:
+		return schemaType == "array"
+	case map[string]any:
+		return schemaType == "object"
+	}
+	return false
+}
+
+// toolSchemaReport is the output of debug_tool_schema and --dump-schemas.
+type toolSchemaReport struct {
+	Tools    []servedTool `json:"tools"`
+	Problems []string     `json:"problems"` // findings of checkToolSchemas
+}
+
+// newToolSchemaReport returns the served schemas of the named tool, or of
+// all tools if name is empty, with the schema check's findings.
+func newToolSchemaReport(s *server.MCPServer, name string) (toolSchemaReport, error) {
+	tools, err := servedTools(s)
+	if err != nil {
+		return toolSchemaReport{}, err
+	}
+	if name != "" {
+		i := slices.IndexFunc(tools, func(t servedTool) bool { return t.Name == name })
+		if i < 0 {
+			return toolSchemaReport{}, fmt.Errorf("unknown tool %q", name)
+		}
+		tools = tools[i : i+1]
+	}
+	problems := checkToolSchemas(tools)
+	if problems == nil {
+		problems = []string{}
+	}
+	return toolSchemaReport{Tools: tools, Problems: problems}, nil
+}
+
+// handleDebugToolSchema returns the handler of debug_tool_schema, which shows
+// the input schemas exactly as s serves them to clients.
+func handleDebugToolSchema(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := mcp.ParseString(request, "tool", "")
+		report, err := newToolSchemaReport(s, name)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to read tool schemas", err), nil
+		}
+		jsonData, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tool schemas: %w", err) // Internal server error
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Input schemas of %d tools as served by tools/list (%d schema problems):\n%s", len(report.Tools), len(report.Problems), string(jsonData))), nil
+	}
+}
+
+// DumpToolSchemas implements the --dump-schemas flag: it prints the input
+// schemas of all tools as served, without connecting to Zammad, and returns
+// the process exit code, 1 if the schema check found problems.
+func DumpToolSchemas() int {
+	s := server.NewMCPServer("Zammad MCP Server", "1.0.0", server.WithToolCapabilities(true))
+	registerTools(s)
+	report, err := newToolSchemaReport(s, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read tool schemas: %v\n", err)
+		return 1
+	}
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal tool schemas: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(jsonData))
+	if len(report.Problems) > 0 {
+		return 1
+	}
+	return 0
+}
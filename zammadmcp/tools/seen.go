@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ticketSeenState is whether the API user has seen a ticket, as shown by the
+// unread markers of the web UI, which follow the user's online notifications
+// for the ticket.
+type ticketSeenState struct {
+	TicketID      int                  `json:"ticket_id"`
+	Seen          bool                 `json:"seen"`
+	Notifications []onlineNotification `json:"notifications"`
+}
+
+// ticketNotifications returns the API user's online notifications about a
+// ticket.
+func ticketNotifications(ticketID int) ([]onlineNotification, error) {
+	notifications, err := fetchOnlineNotifications()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]onlineNotification, 0)
+	for _, n := range notifications {
+		if n.Object == "Ticket" && n.OID == ticketID {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func newTicketSeenState(ticketID int, notifications []onlineNotification) ticketSeenState {
+	state := ticketSeenState{TicketID: ticketID, Seen: true, Notifications: notifications}
+	for _, n := range notifications {
+		if !n.Seen {
+			state.Seen = false
+		}
+	}
+	return state
+}
+
+// handleGetTicketSeenState reports whether the API user has unread
+// notifications about a ticket.
+func handleGetTicketSeenState(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	notifications, err := ticketNotifications(ticketID)
+	if err != nil {
+		log.Printf("Error fetching online notifications from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get online notifications", err), nil
+	}
+
+	jsonData, err := json.MarshalIndent(newTicketSeenState(ticketID, notifications), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal seen state: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Seen state of ticket %d:\n%s", ticketID, string(jsonData))), nil
+}
+
+// handleMarkTicketSeen marks the API user's online notifications about a
+// ticket as seen, or as unseen again.
+func handleMarkTicketSeen(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	seen := mcp.ParseBoolean(request, "seen", true)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	notifications, err := ticketNotifications(ticketID)
+	if err != nil {
+		log.Printf("Error fetching online notifications from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get online notifications", err), nil
+	}
+
+	changed := 0
+	for i, n := range notifications {
+		if n.Seen == seen {
+			continue
+		}
+		path := fmt.Sprintf("/api/v1/online_notifications/%d", n.ID)
+		if err := zammadRequest(http.MethodPut, path, map[string]any{"seen": seen}, nil); err != nil {
+			log.Printf("Error updating online notification %d: %v", n.ID, err)
+			if changed > 0 {
+				sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("marked %d notifications of ticket %d as seen=%t", changed, ticketID, seen), nil, ticketObject(ticketID))
+			}
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to update notification %d of ticket %d (%d of its notifications were already updated)", n.ID, ticketID, changed), err), nil
+		}
+		notifications[i].Seen = seen
+		changed++
+	}
+	log.Printf("Marked %d notifications of ticket %d as seen=%t", changed, ticketID, seen)
+	if changed > 0 {
+		sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("marked %d notifications of ticket %d as seen=%t", changed, ticketID, seen), nil, ticketObject(ticketID))
+	}
+
+	jsonData, err := json.MarshalIndent(newTicketSeenState(ticketID, notifications), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal seen state: %w", err) // Internal server error
+	}
+	verb := "seen"
+	if !seen {
+		verb = "unseen"
+	}
+	if len(notifications) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d has no notifications for the API user, so it is shown as read; nothing to mark as %s:\n%s", ticketID, verb, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Marked %d notifications of ticket %d as %s:\n%s", changed, ticketID, verb, string(jsonData))), nil
+}
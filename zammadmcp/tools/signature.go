@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// groupSignature returns the signature configured for the named group as
+// plain text, with the agent placeholders filled in for the API user. It
+// returns "" if the group has no active signature.
+func groupSignature(group string) (string, error) {
+	signatureID := 0
+	found := false
+	for page := 1; !found; page++ {
+		var groups []struct {
+			Name        string `json:"name"`
+			SignatureID int    `json:"signature_id"`
+		}
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/groups?page=%d&per_page=100", page), nil, &groups); err != nil {
+			return "", err
+		}
+		if len(groups) == 0 {
+			return "", fmt.Errorf("unknown group %q", group)
+		}
+		for _, g := range groups {
+			if strings.EqualFold(g.Name, group) {
+				signatureID, found = g.SignatureID, true
+				break
+			}
+		}
+	}
+	if signatureID == 0 {
+		return "", nil
+	}
+
+	var signature struct {
+		Body   string `json:"body"`
+		Active bool   `json:"active"`
+	}
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/signatures/%d", signatureID), nil, &signature); err != nil {
+		return "", err
+	}
+	if !signature.Active {
+		return "", nil
+	}
+	agent, err := zammadClient.UserMe()
+	if err != nil {
+		return "", err
+	}
+	text := strings.NewReplacer(
+		"#{user.firstname}", agent.Firstname,
+		"#{user.lastname}", agent.Lastname,
+		"#{user.email}", agent.Email,
+	).Replace(htmlToPlainText(signature.Body))
+	return strings.TrimSpace(text), nil
+}
+
+// appendSignature adds signature below body, separated by a blank line.
+func appendSignature(body, signature string) string {
+	if signature == "" {
+		return body
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + signature
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// zammadCalendar is a Zammad business-hours calendar (/api/v1/calendars).
+type zammadCalendar struct {
+	ID            int                              `json:"id"`
+	Name          string                           `json:"name"`
+	Timezone      string                           `json:"timezone"`
+	Default       bool                             `json:"default"`
+	BusinessHours map[string]calendarBusinessDay   `json:"business_hours"`
+	Holidays      map[string]calendarPublicHoliday `json:"public_holidays"`
+}
+
+type calendarBusinessDay struct {
+	Active     bool        `json:"active"`
+	Timeframes [][2]string `json:"timeframes"`
+}
+
+type calendarPublicHoliday struct {
+	Active  bool   `json:"active"`
+	Summary string `json:"summary"`
+}
+
+// slaStatus holds human-readable time remaining until each escalation
+// deadline of a ticket, so clients don't have to do the date arithmetic.
+type slaStatus struct {
+	Calendar string `json:"calendar,omitempty"`
+	// CalendarNote warns that the calendar may not be the one of the
+	// ticket's SLA, when the instance has several.
+	CalendarNote       string `json:"calendar_note,omitempty"`
+	FirstResponseDueIn string `json:"first_response_due_in,omitempty"`
+	UpdateDueIn        string `json:"update_due_in,omitempty"`
+	SolutionDueIn      string `json:"solution_due_in,omitempty"`
+	NextEscalationIn   string `json:"next_escalation_in,omitempty"`
+}
+
+// calendarRetryBackoff is how long businessCalendar waits before asking for
+// the calendars again after a failure that may be transient.
+const calendarRetryBackoff = time.Minute
+
+var (
+	slaCalendarMu      sync.Mutex
+	slaCalendarLoaded  bool
+	slaCalendarRetryAt time.Time
+	slaCalendar        *zammadCalendar
+	// slaCalendarCount is the number of calendars of the instance.
+	slaCalendarCount int
+)
+
+// businessCalendar returns the calendar used for SLA deltas. It is the
+// calendar named (or numbered) by ZAMMAD_MCP_CALENDAR, otherwise the default
+// calendar of the instance. Zammad does not say which SLA applies to a ticket,
+// so the same calendar is used for all tickets even if their SLAs use other
+// calendars. Reading calendars requires admin.calendar; without it nil is
+// returned and deltas fall back to wall-clock time.
+func businessCalendar() *zammadCalendar {
+	slaCalendarMu.Lock()
+	defer slaCalendarMu.Unlock()
+	if slaCalendarLoaded || time.Now().Before(slaCalendarRetryAt) {
+		return slaCalendar
+	}
+
+	var calendars []zammadCalendar
+	if err := zammadRequest(http.MethodGet, "/api/v1/calendars", nil, &calendars); err != nil {
+		// Without permission to read calendars, asking again is pointless.
+		// Other failures may be transient, so they are retried after a
+		// backoff rather than on every ticket of a list.
+		var apiErr *zammadAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+			slaCalendarLoaded = true
+		} else {
+			slaCalendarRetryAt = time.Now().Add(calendarRetryBackoff)
+		}
+		log.Printf("Could not load Zammad calendars, SLA deltas will use wall-clock time: %v", err)
+		return nil
+	}
+	slaCalendarLoaded = true
+	slaCalendarCount = len(calendars)
+
+	wanted := os.Getenv("ZAMMAD_MCP_CALENDAR")
+	for i := range calendars {
+		cal := &calendars[i]
+		if wanted != "" && (strings.EqualFold(cal.Name, wanted) || strconv.Itoa(cal.ID) == wanted) {
+			slaCalendar = cal
+			break
+		}
+		if wanted == "" && cal.Default {
+			slaCalendar = cal
+			break
+		}
+	}
+	if slaCalendar == nil && wanted != "" {
+		log.Printf("Calendar %q from ZAMMAD_MCP_CALENDAR not found, SLA deltas will use wall-clock time", wanted)
+	}
+	return slaCalendar
+}
+
+// computeSLAStatus derives the remaining time for each escalation deadline of
+// the ticket. It returns nil if the ticket has no pending escalation.
+func computeSLAStatus(ticket *ticketRecord, now time.Time) *slaStatus {
+	if ticket.FirstResponseEscalationAt == nil && ticket.UpdateEscalationAt == nil &&
+		ticket.CloseEscalationAt == nil && ticket.EscalationAt == nil {
+		return nil
+	}
+
+	cal := businessCalendar()
+	status := &slaStatus{}
+	if cal != nil {
+		status.Calendar = cal.Name
+		slaCalendarMu.Lock()
+		several := slaCalendarCount > 1
+		slaCalendarMu.Unlock()
+		if several {
+			status.CalendarNote = "The instance has several calendars; deltas use this one for all tickets, which may not be the calendar of this ticket's SLA."
+		}
+	}
+	status.FirstResponseDueIn = describeDeadline(cal, now, ticket.FirstResponseEscalationAt)
+	status.UpdateDueIn = describeDeadline(cal, now, ticket.UpdateEscalationAt)
+	status.SolutionDueIn = describeDeadline(cal, now, ticket.CloseEscalationAt)
+	status.NextEscalationIn = describeDeadline(cal, now, ticket.EscalationAt)
+	return status
+}
+
+// describeDeadline renders the time between now and deadline, e.g.
+// "3h12m (business hours)" or "overdue by 45m (business hours)".
+func describeDeadline(cal *zammadCalendar, now time.Time, deadline *time.Time) string {
+	if deadline == nil {
+		return ""
+	}
+
+	from, to, overdue := now, *deadline, false
+	if to.Before(from) {
+		from, to, overdue = to, from, true
+	}
+
+	var d time.Duration
+	kind := "wall clock"
+	if cal != nil {
+		d = cal.businessDuration(from, to)
+		kind = "business hours"
+	} else {
+		d = to.Sub(from)
+	}
+
+	if overdue {
+		return fmt.Sprintf("overdue by %s (%s)", formatDuration(d), kind)
+	}
+	return fmt.Sprintf("%s (%s)", formatDuration(d), kind)
+}
+
+// formatDuration renders d rounded to minutes as e.g. "3h12m" or "45m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+// location returns the calendar's time zone, defaulting to UTC.
+func (c *zammadCalendar) location() *time.Location {
+	if c.Timezone != "" {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// displayLocation returns the time zone used when rendering timestamps for
+// humans: the business calendar's time zone if available, otherwise UTC.
+func displayLocation() *time.Location {
+	if cal := businessCalendar(); cal != nil {
+		return cal.location()
+	}
+	return time.UTC
+}
+
+// businessDuration returns how much of the interval [from, to) falls within
+// the calendar's business hours, skipping active public holidays. Intervals
+// longer than a year are clamped to keep the computation bounded.
+func (c *zammadCalendar) businessDuration(from, to time.Time) time.Duration {
+	loc := c.location()
+	from, to = from.In(loc), to.In(loc)
+	if limit := from.AddDate(1, 0, 0); to.After(limit) {
+		to = limit
+	}
+
+	var total time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if holiday, ok := c.Holidays[day.Format("2006-01-02")]; ok && holiday.Active {
+			continue
+		}
+		hours, ok := c.BusinessHours[strings.ToLower(day.Weekday().String()[:3])]
+		if !ok || !hours.Active {
+			continue
+		}
+		for _, frame := range hours.Timeframes {
+			start, errStart := clockOn(day, frame[0])
+			end, errEnd := clockOn(day, frame[1])
+			if errStart != nil || errEnd != nil {
+				continue
+			}
+			if !end.After(start) {
+				// "00:00" as end of a timeframe means midnight of the next day.
+				end = end.AddDate(0, 0, 1)
+			}
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+	}
+	return total
+}
+
+// clockOn returns the time on day given as "HH:MM".
+func clockOn(day time.Time, clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetContextChars is the text shown on each side of a matched term.
+	snippetContextChars = 60
+	// maxSnippetsPerTicket caps the snippets of a search result.
+	maxSnippetsPerTicket = 3
+)
+
+// searchSnippet is a fragment of a ticket field containing a search term.
+type searchSnippet struct {
+	Field     string `json:"field"` // "title" or "article"
+	ArticleID int    `json:"article_id,omitempty"`
+	Term      string `json:"term"`
+	Snippet   string `json:"snippet"`
+}
+
+// matchedTicket is a search result with the fragments that matched.
+type matchedTicket struct {
+	ticketRecord
+	Matches []searchSnippet `json:"matches"`
+}
+
+// queryOperators are the words of Zammad's search syntax that are not
+// search terms.
+var queryOperators = map[string]bool{"AND": true, "OR": true, "NOT": true, "TO": true}
+
+// textFields are the search fields whose values are looked for in the title
+// and articles; values of other fields, such as state.name:open, are filters
+// rather than text.
+var textFields = map[string]bool{
+	"title": true, "body": true, "subject": true, "article.body": true, "article.subject": true,
+}
+
+// searchTerms extracts the free-text terms of a search query: quoted phrases
+// and words, including the values of text fields, without operators,
+// wildcards, ranges and other fields. Terms of fewer than two characters are
+// dropped.
+func searchTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(term string) {
+		term = strings.Trim(term, "*?()[]{}\"'+-!~^")
+		if len([]rune(term)) < 2 || queryOperators[term] || seen[strings.ToLower(term)] {
+			return
+		}
+		seen[strings.ToLower(term)] = true
+		terms = append(terms, term)
+	}
+
+	rest := query
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		// A phrase, possibly as a field value (title:"printer on fire").
+		field, value, quotedField := strings.Cut(rest, ":\"")
+		if quotedField && !strings.ContainsFunc(field, unicode.IsSpace) {
+			rest = "\"" + value
+		}
+		if strings.HasPrefix(rest, "\"") {
+			phrase, after, _ := strings.Cut(rest[1:], "\"")
+			if !quotedField || textFields[strings.TrimLeft(field, "(+-!")] {
+				add(phrase)
+			}
+			rest = after
+			continue
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		token := rest[:end]
+		rest = rest[end:]
+		if strings.ContainsAny(token, "[{") {
+			// Skip a range such as created_at:[now-7d TO now].
+			if closing := strings.IndexAny(rest, "]}"); closing >= 0 && !strings.ContainsAny(token, "]}") {
+				rest = rest[closing+1:]
+			}
+			continue
+		}
+		if field, value, ok := strings.Cut(token, ":"); ok {
+			if !textFields[strings.TrimLeft(field, "(+-!")] {
+				continue
+			}
+			token = value
+		}
+		add(token)
+	}
+	return terms
+}
+
+// ticketSnippets returns up to maxSnippetsPerTicket fragments of the title
+// and the given articles that contain one of the terms, title first.
+func ticketSnippets(ticket ticketRecord, articles []articleText, terms []string) []searchSnippet {
+	snippets := make([]searchSnippet, 0)
+	for _, term := range terms {
+		if passages := findPassages(ticket.Title, term, snippetContextChars); len(passages) > 0 {
+			snippets = append(snippets, searchSnippet{Field: "title", Term: term, Snippet: passages[0].Passage})
+			break
+		}
+	}
+	for _, article := range articles {
+		for _, term := range terms {
+			if len(snippets) == maxSnippetsPerTicket {
+				return snippets
+			}
+			if passages := findPassages(article.Text, term, snippetContextChars); len(passages) > 0 {
+				snippet := passages[0].Passage
+				if article.Fence {
+					snippet = fenceUntrusted(fmt.Sprintf("article %d", article.ID), snippet)
+				}
+				snippets = append(snippets, searchSnippet{Field: "article", ArticleID: article.ID, Term: term, Snippet: snippet})
+				break // one snippet per article
+			}
+		}
+	}
+	return snippets
+}
+
+// withSnippets adds the matching fragments to search results. Articles that
+// cannot be read are skipped, leaving the title's fragment.
+func withSnippets(tickets []ticketRecord, terms []string) []matchedTicket {
+	results := make([]matchedTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		var articles []articleText
+		if len(terms) > 0 {
+			var err error
+			if articles, err = fetchArticleTexts(ticket.ID); err != nil {
+				log.Printf("Could not read the articles of ticket %d for snippets: %v", ticket.ID, err)
+			}
+		}
+		results = append(results, matchedTicket{ticketRecord: ticket, Matches: ticketSnippets(ticket, articles, terms)})
+	}
+	return results
+}
+
+// articleText is the plain text of an article for snippet extraction.
+type articleText struct {
+	ID    int
+	Text  string
+	Fence bool // a customer article shown fenced, see fence_customer_content
+}
+
+// fetchArticleTexts returns the plain text of a ticket's articles.
+func fetchArticleTexts(ticketID int) ([]articleText, error) {
+	articles, err := zammadClient.TicketArticleByTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]articleText, 0, len(articles))
+	for _, article := range articles {
+		if config.FenceCustomerContent {
+			article.Body = stripHiddenContent(article.Body)
+		}
+		texts = append(texts, articleText{
+			ID:    article.ID,
+			Text:  articlePlainText(article),
+			Fence: config.FenceCustomerContent && isCustomerArticle(article),
+		})
+	}
+	return texts, nil
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleSnoozeTicket puts a ticket into the "pending reminder" state until a
+// given time, which may be relative ("+3d", "until Monday 9am"). The state
+// change and the optional note are sent in one update.
+func handleSnoozeTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	until := mcp.ParseString(request, "until", "")
+	note := mcp.ParseString(request, "note", "")
+	if ticketID <= 0 || until == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, until"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	when, err := parseWhen(until, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument until: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+	note, err = expandVariables(note, ticketID)
+	if err != nil {
+		log.Printf("Error expanding variables for ticket %d: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr("Failed to expand the variables in note", err), nil
+	}
+
+	state, err := stateOfType("pending reminder")
+	if err != nil {
+		log.Printf("Error finding the pending reminder state: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to find the pending reminder state", err), nil
+	}
+	display := when.Format("Mon 2006-01-02 15:04 MST")
+	update := ticketUpdate{StateID: setTo(state.ID), PendingTime: pendingAt(when)}
+	if note != "" {
+		update.Article = &updateArticle{
+			Subject:     fmt.Sprintf("Snoozed until %s", display),
+			Body:        note,
+			ContentType: "text/plain",
+			Type:        "note",
+			Internal:    true,
+		}
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error snoozing ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to snooze ticket %d", ticketID), err), nil
+	}
+	undo := ticketUndo(before, ticket.UpdatedAt, update)
+	if note != "" {
+		undo.Remains = "the snooze note"
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("snoozed ticket %d until %s", ticketID, display), undo, ticketObject(ticketID))
+
+	log.Printf("Snoozed ticket %d until %s", ticketID, when.UTC().Format(time.RFC3339))
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d snoozed until %s (state %q, in %s):\n%s",
+		ticketID, display, state.Name, formatDuration(time.Until(when)), string(jsonData))), nil
+}
+
+// pendingStateTypes maps the pending kinds of set_pending_time to state types.
+var pendingStateTypes = map[string]string{
+	"pending reminder": "pending reminder",
+	"pending close":    "pending action",
+}
+
+// handleSetPendingTime sets a ticket to "pending reminder" or "pending close"
+// with a pending time given as a timestamp or relative to now, or moves the
+// pending time of a ticket already in that state.
+func handleSetPendingTime(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	pendingTime := mcp.ParseString(request, "pending_time", "")
+	kind := strings.ToLower(strings.TrimSpace(mcp.ParseString(request, "state", "pending reminder")))
+	if ticketID <= 0 || pendingTime == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, pending_time"), nil
+	}
+	stateType, ok := pendingStateTypes[kind]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument state: %q (one of: pending reminder, pending close)", kind)), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	when, err := parseWhen(pendingTime, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument pending_time: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	state, err := stateOfType(stateType)
+	if err != nil {
+		log.Printf("Error finding the %s state: %v", kind, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the %s state", kind), err), nil
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if before.StateID != state.ID {
+		allowed, err := coreWorkflowAllowedStates(before)
+		if err != nil {
+			log.Printf("Could not evaluate core workflows for ticket %d, not checking the transition: %v", ticketID, err)
+		}
+		if allowed != nil && !allowed[state.ID] {
+			return mcp.NewToolResultError(fmt.Sprintf("Ticket %d cannot be moved from %q to %q: the core workflow does not allow it. Use get_allowed_transitions to see the allowed states.", ticketID, ticketStateName(before), state.Name)), nil
+		}
+	}
+
+	display := when.Format("Mon 2006-01-02 15:04 MST")
+	update := ticketUpdate{StateID: setTo(state.ID), PendingTime: pendingAt(when)}
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error setting the pending time of ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to set the pending time of ticket %d", ticketID), err), nil
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("set ticket %d to %q until %s", ticketID, state.Name, display), ticketUndo(before, ticket.UpdatedAt, update), ticketObject(ticketID))
+
+	log.Printf("Set ticket %d to %q until %s", ticketID, state.Name, when.UTC().Format(time.RFC3339))
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d set to %q until %s (in %s):\n%s",
+		ticketID, state.Name, display, formatDuration(time.Until(when)), string(jsonData))), nil
+}
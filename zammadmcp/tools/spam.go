@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// spamWorkflow is the sequence of changes mark_as_spam applies to a ticket,
+// configured in the spam section of the configuration file.
+type spamWorkflow struct {
+	// Tag is added to the ticket; empty skips tagging.
+	Tag string `yaml:"tag"`
+	// State is the name of the state the ticket is set to.
+	State string `yaml:"state"`
+	// Group is the name of the group the ticket is moved to; empty keeps it.
+	Group string `yaml:"group"`
+	// DeactivateCustomer deactivates the ticket's customer, so further mails
+	// from the sender do not show up as active customer tickets.
+	DeactivateCustomer bool `yaml:"deactivate_customer"`
+}
+
+// spamStep is the outcome of one step of the spam workflow.
+type spamStep struct {
+	Action string `json:"action"`
+	Status string `json:"status"` // "done", "failed" or "skipped"
+	Detail string `json:"detail,omitempty"`
+}
+
+// handleMarkAsSpam applies the configured spam workflow to a ticket. The
+// state and group are changed first, in one update; if that fails nothing
+// was changed. Failures of the remaining steps are reported per step.
+func handleMarkAsSpam(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	workflow := config.Spam
+	workflow.DeactivateCustomer = mcp.ParseBoolean(request, "deactivate_customer", workflow.DeactivateCustomer)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	var update ticketUpdate
+	if workflow.State != "" {
+		state, err := resolveTicketState(workflow.State)
+		if err != nil {
+			log.Printf("Error resolving spam state %q: %v", workflow.State, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the configured spam state %q", workflow.State), err), nil
+		}
+		update.StateID = setTo(state.ID)
+		workflow.State = state.Name
+	}
+	if workflow.Group != "" {
+		groupID, err := groupIDByName(workflow.Group)
+		if err != nil {
+			log.Printf("Error resolving spam group %q: %v", workflow.Group, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the configured spam group %q", workflow.Group), err), nil
+		}
+		update.GroupID = setTo(groupID)
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	hadTag := true // if the tags cannot be read, undo leaves the tag alone
+	if workflow.Tag != "" {
+		if tags, err := fetchTicketTags(ticketID); err != nil {
+			log.Printf("Error fetching tags of ticket %d for the undo history: %v", ticketID, err)
+		} else {
+			hadTag = slices.Contains(tags, workflow.Tag)
+		}
+	}
+
+	var ticket ticketRecord
+	if !update.empty() {
+		ticket, err = updateTicket(ticketID, update)
+	} else {
+		ticket, err = fetchTicket(ticketID)
+	}
+	if err != nil {
+		log.Printf("Error marking ticket %d as spam in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to mark ticket %d as spam; nothing was changed", ticketID), err), nil
+	}
+	steps := []spamStep{{Action: "update ticket", Status: "done", Detail: fmt.Sprintf("state %q", workflow.State)}}
+	if workflow.Group != "" {
+		steps[0].Detail += fmt.Sprintf(", moved to group %q", workflow.Group)
+	}
+
+	failed := false
+	step := func(action, skipReason string, run func() error) {
+		if skipReason != "" {
+			steps = append(steps, spamStep{Action: action, Status: "skipped", Detail: skipReason})
+			return
+		}
+		if err := run(); err != nil {
+			log.Printf("Error in spam workflow step %q for ticket %d: %v", action, ticketID, err)
+			steps = append(steps, spamStep{Action: action, Status: "failed", Detail: err.Error()})
+			failed = true
+			return
+		}
+		steps = append(steps, spamStep{Action: action, Status: "done"})
+	}
+
+	skipTag := ""
+	if workflow.Tag == "" {
+		skipTag = "no spam tag configured"
+	}
+	tagAdded := false
+	step(fmt.Sprintf("add tag %q", workflow.Tag), skipTag, func() error {
+		if err := addTicketTag(ticketID, workflow.Tag); err != nil {
+			return err
+		}
+		tagAdded = !hadTag
+		return nil
+	})
+
+	skipDeactivate := ""
+	switch {
+	case !workflow.DeactivateCustomer:
+		skipDeactivate = "customer deactivation is disabled"
+	case ticket.CustomerID == 0:
+		skipDeactivate = "ticket has no customer"
+	}
+	customerDeactivated := false
+	step(fmt.Sprintf("deactivate customer %d", ticket.CustomerID), skipDeactivate, func() error {
+		path := fmt.Sprintf("/api/v1/users/%d", ticket.CustomerID)
+		var customer struct {
+			Active bool `json:"active"`
+		}
+		if err := zammadRequest(http.MethodGet, path, nil, &customer); err != nil {
+			return err
+		}
+		if err := updateUser(ticket.CustomerID, userUpdate{Active: setTo(false)}); err != nil {
+			return err
+		}
+		customerDeactivated = customer.Active
+		return nil
+	})
+
+	undo := ticketUndo(before, ticketUpdatedAt(ticketID, ticket.UpdatedAt), update)
+	if tagAdded {
+		undo.RemoveTag = workflow.Tag
+	}
+	if customerDeactivated {
+		undo.ReactivateUserID = ticket.CustomerID
+	}
+	objects := []actionObject{ticketObject(ticketID)}
+	if customerDeactivated {
+		objects = append(objects, userObject(ticket.CustomerID))
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("marked ticket %d as spam", ticketID), undo, objects...)
+
+	jsonData, err := json.MarshalIndent(steps, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spam workflow steps: %w", err) // Internal server error
+	}
+	if failed {
+		return mcp.NewToolResultError(fmt.Sprintf("Ticket %d was only partially marked as spam:\n%s", ticketID, string(jsonData))), nil
+	}
+	log.Printf("Marked ticket %d as spam", ticketID)
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d marked as spam:\n%s", ticketID, string(jsonData))), nil
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// splitTicketResult is the outcome of split_ticket.
+type splitTicketResult struct {
+	createTicketResult
+	SourceTicketID    int      `json:"source_ticket_id"`
+	SourceArticleID   int      `json:"source_article_id"`
+	Link              string   `json:"link"`
+	AttachmentsCopied []string `json:"attachments_copied,omitempty"`
+	LinkError         string   `json:"link_error,omitempty"`
+}
+
+// handleSplitTicket creates a new ticket from one article of a ticket, like
+// Zammad's split: the article's text and attachments become the first
+// article of the new ticket, which is linked to the original. The original
+// ticket and article are left unchanged.
+func handleSplitTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	articleID := mcp.ParseInt(request, "article_id", 0)
+	title := mcp.ParseString(request, "title", "")
+	group := mcp.ParseString(request, "group", "")
+	customer := mcp.ParseString(request, "customer", "")
+	link := mcp.ParseString(request, "link", "child")
+	copyAttachments := mcp.ParseBoolean(request, "copy_attachments", true)
+	if ticketID <= 0 || articleID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, article_id"), nil
+	}
+	if link != "child" && link != "normal" && link != "none" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument link: %q (one of: child, normal, none)", link)), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+
+	source, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	article, err := fetchArticle(articleID)
+	if err != nil {
+		log.Printf("Error fetching article %d from Zammad: %v", articleID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get article %d", articleID), err), nil
+	}
+	if article.TicketID != ticketID {
+		return mcp.NewToolResultError(fmt.Sprintf("Article %d does not belong to ticket %d", articleID, ticketID)), nil
+	}
+
+	if title == "" {
+		title = article.Subject
+	}
+	if title == "" {
+		title = fmt.Sprintf("Split from #%s: %s", source.Number, source.Title)
+	}
+	payload := map[string]any{"title": title, "customer_id": source.CustomerID}
+	if group != "" {
+		payload["group"] = group
+	} else {
+		payload["group_id"] = source.GroupID
+	}
+	if customer != "" {
+		delete(payload, "customer_id")
+		payload["customer"] = customer
+	}
+	// Always a note: an email article created through the API would be sent
+	// to its recipients again.
+	newArticle := map[string]any{
+		"type":         "note",
+		"sender":       article.Sender,
+		"from":         article.From,
+		"subject":      article.Subject,
+		"body":         article.Body,
+		"content_type": article.ContentType,
+		"internal":     article.Internal,
+	}
+
+	var copied []string
+	if copyAttachments && len(article.Attachments) > 0 {
+		var uploads []articleUpload
+		for _, attachment := range article.Attachments {
+			// The attachment policy and the virus scan apply as to any
+			// download, so a blocked file is not re-uploaded.
+			data, refused := downloadAttachment(ticketID, articleID, attachment)
+			if refused != nil {
+				return refused, nil
+			}
+			uploads = append(uploads, articleUpload{Filename: attachment.Filename, Data: base64.StdEncoding.EncodeToString(data), MimeType: attachment.contentType()})
+			copied = append(copied, attachment.Filename)
+		}
+		newArticle["attachments"] = uploads
+	}
+	payload["article"] = newArticle
+
+	var ticket ticketRecord
+	if err := zammadRequest(http.MethodPost, "/api/v1/tickets", payload, &ticket); err != nil {
+		log.Printf("Error creating ticket split from ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr("Failed to create ticket", err), nil
+	}
+	tickets := []ticketRecord{ticket}
+	enrichTickets(tickets)
+	ticket = tickets[0]
+	log.Printf("Created ticket %d from article %d of ticket %d", ticket.ID, articleID, ticketID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("split article %d of ticket %d into new ticket %d", articleID, ticketID, ticket.ID), nil, ticketObject(ticket.ID), ticketObject(ticketID))
+
+	ticketData, err := profile.project(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticket.ID, err) // Internal server error
+	}
+	result := splitTicketResult{
+		createTicketResult: createTicketResult{ID: ticket.ID, Number: ticket.Number, WebURL: ticket.WebURL, Ticket: ticketData},
+		SourceTicketID:     ticketID,
+		SourceArticleID:    articleID,
+		Link:               link,
+		AttachmentsCopied:  copied,
+	}
+	if link != "none" {
+		// The new ticket is the source, so a "child" link makes it a child
+		// of the original.
+		if err := linkTickets(ticket.Number, ticketID, link); err != nil {
+			log.Printf("Error linking ticket %d to ticket %d: %v", ticket.ID, ticketID, err)
+			result.LinkError = err.Error()
+		}
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal split result: %w", err) // Internal server error
+	}
+	summary := fmt.Sprintf("Ticket #%s (ID %d) created from article %d of ticket %d: %s", ticket.Number, ticket.ID, articleID, ticketID, ticket.WebURL)
+	if result.LinkError != "" {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: %s, but it could not be linked to ticket %d; link it by hand.\n%s", summary, ticketID, string(jsonData))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(jsonData))), nil
+}
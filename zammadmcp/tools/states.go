@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ticketState is a ticket state definition (/api/v1/ticket_states?expand=true).
+type ticketState struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	StateTypeID int    `json:"state_type_id"`
+	StateType   string `json:"state_type"`
+	NextStateID int    `json:"next_state_id,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+// ticketStatesCacheTTL is how long the state definitions are reused. They
+// change rarely, and are needed for most state-related tool calls.
+const ticketStatesCacheTTL = 5 * time.Minute
+
+var (
+	ticketStatesMu      sync.Mutex
+	ticketStatesCache   []ticketState
+	ticketStatesFetched time.Time
+)
+
+// fetchTicketStates returns the ticket state definitions of the instance,
+// cached for ticketStatesCacheTTL.
+func fetchTicketStates() ([]ticketState, error) {
+	ticketStatesMu.Lock()
+	defer ticketStatesMu.Unlock()
+	if ticketStatesCache != nil && time.Since(ticketStatesFetched) < ticketStatesCacheTTL {
+		return ticketStatesCache, nil
+	}
+	var states []ticketState
+	if err := zammadRequest(http.MethodGet, "/api/v1/ticket_states?expand=true", nil, &states); err != nil {
+		return nil, err
+	}
+	ticketStatesCache, ticketStatesFetched = states, time.Now()
+	return states, nil
+}
+
+// stateSynonyms maps common names of states, in English and in the languages
+// Zammad instances are often set up in, to the state type they mean. They let
+// "closed" find a state named "geschlossen" and vice versa.
+var stateSynonyms = map[string]string{
+	"new": "new", "neu": "new", "nouveau": "new", "nuevo": "new", "nieuw": "new", "unassigned": "new",
+	"open": "open", "opened": "open", "reopen": "open", "reopened": "open", "in progress": "open", "active": "open",
+	"offen": "open", "geöffnet": "open", "in bearbeitung": "open", "ouvert": "open", "abierto": "open", "geopend": "open",
+	"closed": "closed", "close": "closed", "resolved": "closed", "solved": "closed", "done": "closed", "completed": "closed",
+	"geschlossen": "closed", "erledigt": "closed", "gelöst": "closed", "fermé": "closed", "ferme": "closed",
+	"résolu": "closed", "cerrado": "closed", "resuelto": "closed", "gesloten": "closed",
+	"pending reminder": "pending reminder", "pending": "pending reminder", "snoozed": "pending reminder",
+	"on hold": "pending reminder", "waiting": "pending reminder", "reminder": "pending reminder",
+	"warten auf erinnerung": "pending reminder", "wartend": "pending reminder", "warten": "pending reminder",
+	"en attente": "pending reminder", "pendiente": "pending reminder",
+	"pending close": "pending action", "pending closure": "pending action", "auto close": "pending action",
+	"warten auf schließen": "pending action", "warten auf schliessen": "pending action",
+	"merged": "merged", "zusammengeführt": "merged", "zusammengefügt": "merged", "fusionné": "merged", "fusionado": "merged",
+}
+
+// resolveTicketState maps a state name given by the model or the
+// configuration to one of the instance's active states: by exact name
+// (ignoring case), otherwise through stateSynonyms to the state of that type.
+func resolveTicketState(name string) (ticketState, error) {
+	states, err := fetchTicketStates()
+	if err != nil {
+		return ticketState{}, fmt.Errorf("failed to fetch ticket states: %w", err)
+	}
+	wanted := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(name, "_", " "))), " ")
+	var names []string
+	for _, s := range states {
+		if !s.Active {
+			continue
+		}
+		if strings.EqualFold(s.Name, wanted) {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	if stateType, ok := stateSynonyms[wanted]; ok {
+		if state, err := stateOfType(stateType); err == nil {
+			if !strings.EqualFold(state.Name, wanted) {
+				log.Printf("Mapped state %q to %q", name, state.Name)
+			}
+			return state, nil
+		}
+	}
+	return ticketState{}, fmt.Errorf("unknown ticket state %q (one of: %s)", name, strings.Join(names, ", "))
+}
+
+// selectableStateType reports whether agents can set a state of this type
+// directly. Merged and removed states are only reached through dedicated
+// actions (merging, deleting).
+func selectableStateType(stateType string) bool {
+	return stateType != "merged" && stateType != "removed"
+}
+
+// stateOfType returns the active state of the given type. If several states
+// share the type, the one named like the type (the Zammad default) wins.
+func stateOfType(stateType string) (ticketState, error) {
+	states, err := fetchTicketStates()
+	if err != nil {
+		return ticketState{}, err
+	}
+	var match *ticketState
+	for i, s := range states {
+		if !s.Active || s.StateType != stateType {
+			continue
+		}
+		if match == nil || strings.EqualFold(s.Name, stateType) {
+			match = &states[i]
+		}
+	}
+	if match == nil {
+		return ticketState{}, fmt.Errorf("no active state of type %q", stateType)
+	}
+	return *match, nil
+}
+
+// allowedTransition is a state a ticket may be moved to.
+type allowedTransition struct {
+	StateID   int    `json:"state_id"`
+	Name      string `json:"name"`
+	StateType string `json:"state_type"`
+	Note      string `json:"note,omitempty"`
+}
+
+// coreWorkflowAllowedStates asks Zammad's core workflow engine which state IDs
+// are offered for the ticket in the edit screen. It returns nil (and no error)
+// when the workflow response does not restrict the states.
+func coreWorkflowAllowedStates(ticket ticketRecord) (map[int]bool, error) {
+	payload := map[string]any{
+		"class_name": "Ticket",
+		"screen":     "edit",
+		"params": map[string]any{
+			"id":          ticket.ID,
+			"state_id":    ticket.StateID,
+			"group_id":    ticket.GroupID,
+			"priority_id": ticket.PriorityID,
+			"owner_id":    ticket.OwnerID,
+			"customer_id": ticket.CustomerID,
+		},
+	}
+	var result struct {
+		RestrictValues map[string][]json.RawMessage `json:"restrict_values"`
+	}
+	if err := zammadRequest(http.MethodPost, "/api/v1/core_workflows/perform", payload, &result); err != nil {
+		return nil, err
+	}
+
+	values, ok := result.RestrictValues["state_id"]
+	if !ok {
+		return nil, nil
+	}
+	allowed := make(map[int]bool, len(values))
+	for _, raw := range values {
+		// IDs are returned as strings or numbers depending on the version; the
+		// empty string stands for "no selection".
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			s = string(raw)
+		}
+		if id, err := strconv.Atoi(s); err == nil {
+			allowed[id] = true
+		}
+	}
+	return allowed, nil
+}
+
+// handleGetAllowedTransitions reports which states a ticket can be moved to,
+// based on the state definitions and, where available, core workflow rules.
+func handleGetAllowedTransitions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	states, err := fetchTicketStates()
+	if err != nil {
+		log.Printf("Error fetching ticket states from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to get ticket states", err), nil
+	}
+
+	source := "core workflow"
+	workflowAllowed, err := coreWorkflowAllowedStates(ticket)
+	if err != nil {
+		log.Printf("Could not evaluate core workflows for ticket %d, using state definitions only: %v", ticketID, err)
+	}
+	if workflowAllowed == nil {
+		source = "state definitions"
+	}
+
+	current := ticketState{ID: ticket.StateID, Name: ticket.State}
+	transitions := make([]allowedTransition, 0, len(states))
+	for _, state := range states {
+		if state.ID == ticket.StateID {
+			current = state
+			continue
+		}
+		if !state.Active || !selectableStateType(state.StateType) {
+			continue
+		}
+		if workflowAllowed != nil && !workflowAllowed[state.ID] {
+			continue
+		}
+		transition := allowedTransition{StateID: state.ID, Name: state.Name, StateType: state.StateType}
+		switch state.StateType {
+		case "pending reminder":
+			transition.Note = "requires pending_time"
+		case "pending action":
+			transition.Note = "requires pending_time; moves to the next state when it is reached"
+			for _, next := range states {
+				if next.ID == state.NextStateID {
+					transition.Note = fmt.Sprintf("requires pending_time; moves to %q when it is reached", next.Name)
+				}
+			}
+		}
+		transitions = append(transitions, transition)
+	}
+
+	jsonData, err := json.MarshalIndent(transitions, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transitions of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	log.Printf("Ticket %d in state %q can move to %d states (%s)", ticketID, current.Name, len(transitions), source)
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d is in state %q (%s). Allowed transitions (from %s):\n%s",
+		ticketID, current.Name, current.StateType, source, string(jsonData))), nil
+}
+
+// stateChange resolves the state a ticket is to be moved to and sets its
+// state_id, and for pending states the pending_time, in u. It returns the
+// state and a description of the change, or a result for the tool to return
+// if the state or the pending time is invalid.
+func stateChange(stateName, pendingUntil string, u *ticketUpdate) (ticketState, string, *mcp.CallToolResult) {
+	state, err := resolveTicketState(stateName)
+	if err != nil {
+		log.Printf("Error resolving state %q: %v", stateName, err)
+		return state, "", mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find state %q", stateName), err)
+	}
+	if !selectableStateType(state.StateType) {
+		return state, "", mcp.NewToolResultError(fmt.Sprintf("Invalid argument state: %q is a %s state, which cannot be set directly", state.Name, state.StateType))
+	}
+	u.StateID = setTo(state.ID)
+	change := fmt.Sprintf("state %q", state.Name)
+	pending := strings.HasPrefix(state.StateType, "pending")
+	switch {
+	case pending && pendingUntil == "":
+		return state, "", mcp.NewToolResultError(fmt.Sprintf("Missing argument pending_until: the state %q requires a pending time", state.Name))
+	case !pending && pendingUntil != "":
+		return state, "", mcp.NewToolResultError(fmt.Sprintf("Invalid argument pending_until: the state %q is not a pending state", state.Name))
+	case pending:
+		when, err := parseWhen(pendingUntil, time.Now())
+		if err != nil {
+			return state, "", mcp.NewToolResultError(fmt.Sprintf("Invalid argument pending_until: %v", err))
+		}
+		u.PendingTime = pendingAt(when)
+		change += " until " + when.Format("Mon 2006-01-02 15:04 MST")
+	}
+	return state, change, nil
+}
+
+// ticketStateName returns the name of a ticket's state, which Zammad only
+// includes in expanded tickets.
+func ticketStateName(ticket ticketRecord) string {
+	if ticket.State != "" {
+		return ticket.State
+	}
+	if states, err := fetchTicketStates(); err == nil {
+		for _, state := range states {
+			if state.ID == ticket.StateID {
+				return state.Name
+			}
+		}
+	}
+	return fmt.Sprintf("state %d", ticket.StateID)
+}
+
+// handleChangeTicketState moves a ticket to a state given by name, which is
+// resolved to the instance's state ID. Transitions the core workflow does not
+// offer for the ticket are refused.
+func handleChangeTicketState(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	stateName := mcp.ParseString(request, "state", "")
+	pendingUntil := mcp.ParseString(request, "pending_until", "")
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if strings.TrimSpace(stateName) == "" {
+		return mcp.NewToolResultError("Missing or invalid required argument: state (must be a non-empty string)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	var update ticketUpdate
+	state, change, failure := stateChange(stateName, pendingUntil, &update)
+	if failure != nil {
+		return failure, nil
+	}
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	if before.StateID == state.ID && pendingUntil == "" {
+		return mcp.NewToolResultText(fmt.Sprintf("Ticket %d is already in state %q; nothing changed.", ticketID, state.Name)), nil
+	}
+	from := ticketStateName(before)
+	allowed, err := coreWorkflowAllowedStates(before)
+	if err != nil {
+		log.Printf("Could not evaluate core workflows for ticket %d, not checking the transition: %v", ticketID, err)
+	}
+	if allowed != nil && !allowed[state.ID] && before.StateID != state.ID {
+		return mcp.NewToolResultError(fmt.Sprintf("Ticket %d cannot be moved from %q to %q: the core workflow does not allow it. Use get_allowed_transitions to see the allowed states.", ticketID, from, state.Name)), nil
+	}
+
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error changing the state of ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to change the state of ticket %d", ticketID), err), nil
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("moved ticket %d from %q to %s", ticketID, from, change), ticketUndo(before, ticket.UpdatedAt, update), ticketObject(ticketID))
+
+	log.Printf("Moved ticket %d from %q to %s", ticketID, from, change)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d moved from %q to %s:\n%s", ticketID, from, change, string(jsonData))), nil
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// summaryMaxThreadChars bounds the thread text sent for sampling.
+	summaryMaxThreadChars = 60000
+	// summarySamplingTimeout bounds how long we wait for the client, which
+	// may ask the user to approve the request first.
+	summarySamplingTimeout = 5 * time.Minute
+)
+
+const summarySystemPrompt = "You summarize customer support ticket threads for the support agents working on them. Be factual and concise: state the customer's problem, what has been tried or agreed, and what is still open. Do not invent details that are not in the thread."
+
+// ticketThreadText renders the articles of a ticket as a plain text
+// transcript, keeping the most recent articles if it exceeds maxChars.
+func ticketThreadText(articles []zammad.TicketArticle, maxChars int) string {
+	parts := make([]string, 0, len(articles))
+	for _, article := range articles {
+		visibility := "public"
+		if article.Internal {
+			visibility = "internal"
+		}
+		if config.FenceCustomerContent {
+			article.Body = stripHiddenContent(article.Body)
+		}
+		text := strings.TrimSpace(articlePlainText(article))
+		if config.FenceCustomerContent && isCustomerArticle(article) {
+			text = fenceUntrusted(fmt.Sprintf("article %d", article.ID), text)
+		}
+		parts = append(parts, fmt.Sprintf("--- Article %d, %s %s by %s at %s ---\n%s",
+			article.ID, visibility, article.Type, article.From, article.CreatedAt.Format(time.RFC3339), text))
+	}
+
+	total, start := 0, len(parts)
+	for start > 0 && total+len(parts[start-1]) <= maxChars {
+		start--
+		total += len(parts[start])
+	}
+	thread := strings.Join(parts[start:], "\n\n")
+	if start > 0 {
+		thread = fmt.Sprintf("[%d earlier articles omitted]\n\n%s", start, thread)
+	}
+	return thread
+}
+
+// handleSummarizeAndNote asks the client's LLM (via MCP sampling) to summarize
+// a ticket thread and stores the summary as an internal note.
+func handleSummarizeAndNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	instructions := mcp.ParseString(request, "instructions", "")
+	maxTokens := mcp.ParseInt(request, "max_tokens", 800)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if maxTokens <= 0 {
+		maxTokens = 800
+	}
+
+	// The thread is fetched while the ticket is checked for changes.
+	var (
+		articles    []zammad.TicketArticle
+		articlesErr error
+		wg          sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		articles, articlesErr = zammadClient.TicketArticleByTicket(ticketID)
+	}()
+	conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", ""))
+	wg.Wait()
+	if conflict != nil || err != nil {
+		return conflict, err
+	}
+	if articlesErr != nil {
+		log.Printf("Error fetching articles for ticket %d from Zammad: %v", ticketID, articlesErr)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get articles for ticket %d", ticketID), articlesErr), nil
+	}
+	if len(articles) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Ticket %d has no articles to summarize", ticketID)), nil
+	}
+
+	prompt := fmt.Sprintf("Summarize the following thread of ticket %d.", ticketID)
+	if instructions != "" {
+		prompt += " " + instructions
+	}
+	prompt += fmt.Sprintf(" Write the summary in %s.", responseLanguageName())
+	prompt += "\n\n" + ticketThreadText(articles, summaryMaxThreadChars)
+
+	var sampling mcp.CreateMessageRequest
+	sampling.Params.Messages = []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent(prompt)}}
+	sampling.Params.SystemPrompt = summarySystemPrompt
+	sampling.Params.MaxTokens = maxTokens
+
+	samplingCtx, cancel := context.WithTimeout(ctx, summarySamplingTimeout)
+	defer cancel()
+	result, err := sampler.createMessage(samplingCtx, sampling)
+	if errors.Is(err, ErrSamplingUnsupported) {
+		return mcp.NewToolResultError(localized(msgSamplingMissing)), nil
+	}
+	if err != nil {
+		log.Printf("Sampling a summary of ticket %d failed: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to obtain a summary of ticket %d from the client", ticketID), err), nil
+	}
+	summary := strings.TrimSpace(sampledText(result))
+	if summary == "" {
+		return mcp.NewToolResultError("The client returned no text summary"), nil
+	}
+
+	model := result.Model
+	if model == "" {
+		model = "unknown model"
+	}
+	body := fmt.Sprintf(localized(msgSummaryHeader)+"\n\n%s", model, summary)
+	article := zammad.TicketArticle{TicketID: ticketID, Subject: localized(msgSummarySubject), Body: body, ContentType: "text/plain", Type: "note", Internal: true}
+	createdArticle, err := zammadClient.TicketArticleCreate(article)
+	if err != nil {
+		if queued := queueWriteOnOutage(queuedWrite{Kind: queuedTicketArticle, TicketID: ticketID, Article: &article}, err); queued != "" {
+			return mcp.NewToolResultText(fmt.Sprintf("%s\n\nSummary:\n%s", queued, summary)), nil
+		}
+		log.Printf("Error adding summary note to ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to add summary note to ticket %d", ticketID), err), nil
+	}
+
+	log.Printf("Successfully added AI-generated summary (Article ID %d) to ticket ID %d", createdArticle.ID, ticketID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("added summary note %d to ticket %d", createdArticle.ID, ticketID), nil, ticketObject(ticketID), articleObject(ticketID, createdArticle.ID))
+	return mcp.NewToolResultText(fmt.Sprintf("Added AI-generated summary as internal note (article %d) to ticket %d:\n\n%s", createdArticle.ID, ticketID, summary)), nil
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// tagReportTicketLimit caps the tickets per period whose tags are read, as
+// each one takes a request.
+const tagReportTicketLimit = 2000
+
+// tagUsage is a tag's ticket count in the current and previous period.
+type tagUsage struct {
+	Tag string `json:"tag"`
+	countTrend
+	Share string `json:"share"` // of the tickets created in the current period
+}
+
+// tagUsageReport is the result of report_tag_usage.
+type tagUsageReport struct {
+	Period         string       `json:"period"`
+	Group          string       `json:"group,omitempty"`
+	CurrentPeriod  reportWindow `json:"current_period"`
+	PreviousPeriod reportWindow `json:"previous_period"`
+	Tickets        countTrend   `json:"tickets"`
+	Untagged       countTrend   `json:"untagged"`
+	Tags           []tagUsage   `json:"tags"`
+	TagCount       int          `json:"tag_count"` // distinct tags in either period
+	Truncated      bool         `json:"truncated,omitempty"`
+}
+
+// periodTags counts the tags of tickets created in a window, up to
+// tagReportTicketLimit tickets.
+type periodTags struct {
+	counts    map[string]int
+	tickets   int
+	untagged  int
+	truncated bool
+}
+
+func countPeriodTags(ctx context.Context, request mcp.CallToolRequest, window reportWindow, scope, label string) (periodTags, error) {
+	result := periodTags{counts: make(map[string]int)}
+	pager := newTicketPager(window.rangeQuery("created_at")+scope, 100, continuation{Page: 1})
+	for result.tickets < tagReportTicketLimit {
+		tickets, err := pager.next()
+		if err != nil {
+			return result, err
+		}
+		if len(tickets) == 0 {
+			return result, nil
+		}
+		for _, ticket := range tickets {
+			if result.tickets == tagReportTicketLimit {
+				result.truncated = true
+				break
+			}
+			if err := yieldTurn(ctx); err != nil {
+				return result, err
+			}
+			tags, err := fetchTicketTags(ticket.ID)
+			if err != nil {
+				return result, fmt.Errorf("failed to get tags of ticket %d: %w", ticket.ID, err)
+			}
+			result.tickets++
+			if len(tags) == 0 {
+				result.untagged++
+			}
+			for _, tag := range tags {
+				result.counts[tag]++
+			}
+		}
+		sendProgress(ctx, request, 0, 0, fmt.Sprintf("%d tickets of the %s period read", result.tickets, label))
+	}
+	return result, nil
+}
+
+// handleReportTagUsage reports the most used tags of the tickets created in
+// the current period, compared with the period before.
+func handleReportTagUsage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	period := mcp.ParseString(request, "period", "month")
+	group := mcp.ParseString(request, "group", "")
+	limit := mcp.ParseInt(request, "limit", 20)
+	current, previous, err := reportWindows(period, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument period: %v", err)), nil
+	}
+	scope, err := reportScope(group)
+	if err != nil {
+		log.Printf("Error resolving group %q: %v", group, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+	}
+
+	now, err := countPeriodTags(ctx, request, current, scope, "current")
+	if err != nil {
+		log.Printf("Error reading tags of the current period: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to read the tags of the current period", err), nil
+	}
+	before, err := countPeriodTags(ctx, request, previous, scope, "previous")
+	if err != nil {
+		log.Printf("Error reading tags of the previous period: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to read the tags of the previous period", err), nil
+	}
+
+	usage := make([]tagUsage, 0, len(now.counts)+len(before.counts))
+	for tag := range now.counts {
+		usage = append(usage, tagUsage{Tag: tag, countTrend: newCountTrend(now.counts[tag], before.counts[tag])})
+	}
+	for tag, count := range before.counts {
+		if _, ok := now.counts[tag]; !ok {
+			usage = append(usage, tagUsage{Tag: tag, countTrend: newCountTrend(0, count)})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.Current != b.Current {
+			return a.Current > b.Current
+		}
+		if a.Previous != b.Previous {
+			return a.Previous > b.Previous
+		}
+		return a.Tag < b.Tag
+	})
+	report := tagUsageReport{
+		Period:         period,
+		Group:          group,
+		CurrentPeriod:  current,
+		PreviousPeriod: previous,
+		Tickets:        newCountTrend(now.tickets, before.tickets),
+		Untagged:       newCountTrend(now.untagged, before.untagged),
+		TagCount:       len(usage),
+		Truncated:      now.truncated || before.truncated,
+	}
+	if limit > 0 && len(usage) > limit {
+		usage = usage[:limit]
+	}
+	for i := range usage {
+		usage[i].Share = "0%"
+		if now.tickets > 0 {
+			usage[i].Share = fmt.Sprintf("%.0f%%", float64(usage[i].Current)*100/float64(now.tickets))
+		}
+	}
+	report.Tags = usage
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tag usage: %w", err) // Internal server error
+	}
+	header := fmt.Sprintf("Tag usage of tickets created in the last %s vs the %s before", period, period)
+	if report.Truncated {
+		header += fmt.Sprintf(" (only the first %d tickets of a period were read)", tagReportTicketLimit)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s", header, string(jsonData))), nil
+}
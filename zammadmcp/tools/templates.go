@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ticketTemplate is a Zammad ticket template. Zammad 5.2 and later store each
+// option as an object such as {"value": "2"}; older versions store the value
+// itself.
+type ticketTemplate struct {
+	ID      int            `json:"id"`
+	Name    string         `json:"name"`
+	Active  *bool          `json:"active"` // missing before Zammad 5.2
+	Options map[string]any `json:"options"`
+}
+
+// active reports whether the template can be used.
+func (t ticketTemplate) active() bool {
+	return t.Active == nil || *t.Active
+}
+
+// values returns the template's options as plain values keyed by attribute,
+// e.g. "ticket.title" or "article.body". Relative pending times are resolved
+// against now, and empty options are left out.
+func (t ticketTemplate) values(now time.Time) map[string]any {
+	values := make(map[string]any, len(t.Options))
+	for key, option := range t.Options {
+		value := option
+		if o, ok := option.(map[string]any); ok {
+			value = o["value"]
+			if o["operator"] == "relative" {
+				value = relativeTemplateTime(now, o["value"], o["range"])
+			}
+		}
+		if value != nil && value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// relativeTemplateTime resolves a relative time option such as 3 days.
+func relativeTemplateTime(now time.Time, amount, unit any) any {
+	n, err := strconv.Atoi(fmt.Sprint(amount))
+	if err != nil {
+		return nil
+	}
+	switch unit {
+	case "minute":
+		now = now.Add(time.Duration(n) * time.Minute)
+	case "hour":
+		now = now.Add(time.Duration(n) * time.Hour)
+	case "day":
+		now = now.AddDate(0, 0, n)
+	case "week":
+		now = now.AddDate(0, 0, 7*n)
+	case "month":
+		now = now.AddDate(0, n, 0)
+	case "year":
+		now = now.AddDate(n, 0, 0)
+	default:
+		return nil
+	}
+	return now.UTC().Format(time.RFC3339)
+}
+
+// fetchTicketTemplates returns the ticket templates of the instance.
+func fetchTicketTemplates() ([]ticketTemplate, error) {
+	var templates []ticketTemplate
+	err := zammadRequest(http.MethodGet, "/api/v1/templates", nil, &templates)
+	return templates, err
+}
+
+// matchTicketTemplate finds the active template with the given ID or name
+// (case-insensitive) among templates. The error lists the active templates.
+func matchTicketTemplate(templates []ticketTemplate, ref string) (ticketTemplate, error) {
+	ref = strings.TrimSpace(ref)
+	id, _ := strconv.Atoi(ref)
+	var names []string
+	for _, t := range templates {
+		if !t.active() {
+			continue
+		}
+		if t.ID == id || strings.EqualFold(t.Name, ref) {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	if len(names) == 0 {
+		return ticketTemplate{}, fmt.Errorf("unknown template %q (there are no active ticket templates)", ref)
+	}
+	return ticketTemplate{}, fmt.Errorf("unknown template %q (active templates: %s)", ref, strings.Join(names, ", "))
+}
+
+// templateDefaults are the create_ticket arguments a template pre-fills.
+type templateDefaults struct {
+	Title    string
+	Group    string
+	Customer string
+	Body     string
+	Subject  string
+	Internal *bool
+	// Fields are the other ticket attributes the template sets, such as
+	// state_id, priority_id, tags and custom fields, by attribute name.
+	Fields map[string]any
+}
+
+// ticketTemplateDefaults maps the options of a template to create_ticket
+// arguments. The group is given by name, as create_ticket takes it.
+func ticketTemplateDefaults(t ticketTemplate) (templateDefaults, error) {
+	d := templateDefaults{Fields: make(map[string]any)}
+	for key, value := range t.values(time.Now()) {
+		text := fmt.Sprint(value)
+		switch key {
+		case "ticket.title":
+			d.Title = text
+		case "ticket.customer_id":
+			d.Customer = text
+		case "ticket.group_id":
+			groupID, err := strconv.Atoi(text)
+			if err != nil {
+				return d, fmt.Errorf("template %q has an invalid group %q", t.Name, text)
+			}
+			group, err := zammadClient.GroupShow(groupID)
+			if err != nil {
+				return d, fmt.Errorf("failed to get group %d of template %q: %w", groupID, t.Name, err)
+			}
+			d.Group = group.Name
+		case "article.body":
+			d.Body = text
+		case "article.subject":
+			d.Subject = text
+		case "article.internal":
+			internal := text == "true"
+			d.Internal = &internal
+		default:
+			if name, ok := strings.CutPrefix(key, "ticket."); ok {
+				d.Fields[name] = value
+			}
+		}
+	}
+	return d, nil
+}
+
+// listedTemplate is a ticket template as list_ticket_templates shows it.
+type listedTemplate struct {
+	ID     int            `json:"id"`
+	Name   string         `json:"name"`
+	Active bool           `json:"active"`
+	Fields map[string]any `json:"fields"`
+}
+
+// handleListTicketTemplates lists the ticket templates with the values they
+// pre-fill in create_ticket.
+func handleListTicketTemplates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	includeInactive := mcp.ParseBoolean(request, "include_inactive", false)
+	templates, err := fetchTicketTemplates()
+	if err != nil {
+		log.Printf("Error fetching ticket templates from Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to list ticket templates", err), nil
+	}
+	listed := []listedTemplate{}
+	now := time.Now()
+	for _, t := range templates {
+		if t.active() || includeInactive {
+			listed = append(listed, listedTemplate{ID: t.ID, Name: t.Name, Active: t.active(), Fields: t.values(now)})
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return strings.ToLower(listed[i].Name) < strings.ToLower(listed[j].Name) })
+	jsonData, err := json.MarshalIndent(listed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket templates: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket templates (%d):\n%s", len(listed), string(jsonData))), nil
+}
@@ -0,0 +1,519 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// documentTimeLayout is how times are shown in ticket documents, in the
+// display time zone.
+const documentTimeLayout = "2006-01-02 15:04 MST"
+
+// expandedTicket is a ticket fetched with expand=true, in which Zammad adds
+// the names of the referenced objects.
+type expandedTicket struct {
+	ticketRecord
+	Priority string `json:"priority"`
+}
+
+// ticketDocument is the content of a document rendered by
+// export_ticket_document, independent of the output format.
+type ticketDocument struct {
+	Title      string
+	Fields     [][2]string // header table rows, label and value
+	Articles   []documentArticle
+	ExportedAt time.Time
+}
+
+// documentArticle is an article of a ticket document, with its body as
+// plain text.
+type documentArticle struct {
+	Heading     string
+	Subject     string
+	Internal    bool
+	Body        string
+	Attachments []string
+}
+
+// documentUserName returns the name and email address of a user for a ticket
+// document, or the user ID if the user cannot be fetched.
+func documentUserName(userID int) string {
+	user, err := zammadClient.UserShow(userID)
+	if err != nil {
+		log.Printf("Error fetching user %d from Zammad: %v", userID, err)
+		return fmt.Sprintf("user %d", userID)
+	}
+	if user.Email == "" {
+		return userDisplayName(user)
+	}
+	return fmt.Sprintf("%s <%s>", userDisplayName(user), user.Email)
+}
+
+// newTicketDocument collects the header and thread of a ticket. Internal
+// articles are left out unless includeInternal is set.
+func newTicketDocument(ticketID int, includeInternal bool) (ticketDocument, error) {
+	// The ticket, its articles and its tags are fetched concurrently, and
+	// then the people and organization the ticket refers to.
+	var (
+		ticket               expandedTicket
+		articles             []articleWithAttachments
+		tags                 []string
+		articlesErr, tagsErr error
+		wg                   sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		articlesErr = zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/ticket_articles/by_ticket/%d", ticketID), nil, &articles)
+	}()
+	go func() {
+		defer wg.Done()
+		tags, tagsErr = fetchTicketTags(ticketID)
+	}()
+	ticketErr := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/tickets/%d?expand=true", ticketID), nil, &ticket)
+	wg.Wait()
+	if ticketErr != nil {
+		return ticketDocument{}, fmt.Errorf("failed to get ticket %d: %w", ticketID, ticketErr)
+	}
+	if articlesErr != nil {
+		return ticketDocument{}, fmt.Errorf("failed to get the articles of ticket %d: %w", ticketID, articlesErr)
+	}
+	if tagsErr != nil {
+		return ticketDocument{}, fmt.Errorf("failed to get the tags of ticket %d: %w", ticketID, tagsErr)
+	}
+
+	owner, organization := "unassigned", "-"
+	if ticket.OwnerID > 0 && ticket.OwnerID != unassignedOwnerID {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			owner = documentUserName(ticket.OwnerID)
+		}()
+	}
+	if ticket.OrganizationID > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if org, err := zammadClient.OrganizationShow(ticket.OrganizationID); err != nil {
+				log.Printf("Error fetching organization %d from Zammad: %v", ticket.OrganizationID, err)
+				organization = fmt.Sprintf("organization %d", ticket.OrganizationID)
+			} else {
+				organization = org.Name
+			}
+		}()
+	}
+	customer := documentUserName(ticket.CustomerID)
+	wg.Wait()
+	loc := displayLocation()
+	doc := ticketDocument{
+		Title: fmt.Sprintf("Ticket #%s: %s", ticket.Number, ticket.Title),
+		Fields: [][2]string{
+			{"Number", ticket.Number},
+			{"Title", ticket.Title},
+			{"State", ticket.State},
+			{"Priority", ticket.Priority},
+			{"Group", ticket.Group},
+			{"Customer", customer},
+			{"Organization", organization},
+			{"Owner", owner},
+			{"Created", ticket.CreatedAt.In(loc).Format(documentTimeLayout)},
+			{"Last updated", ticket.UpdatedAt.In(loc).Format(documentTimeLayout)},
+		},
+		ExportedAt: time.Now().In(loc),
+	}
+	if len(tags) > 0 {
+		doc.Fields = append(doc.Fields, [2]string{"Tags", strings.Join(tags, ", ")})
+	}
+	doc.Fields = append(doc.Fields, [2]string{"Link", ticketWebURL(ticketID)})
+
+	for _, article := range articles {
+		if article.Internal && !includeInternal {
+			continue
+		}
+		if config.FenceCustomerContent {
+			article.Body = stripHiddenContent(article.Body)
+		}
+		entry := documentArticle{
+			Heading:  fmt.Sprintf("%s %s from %s, %s", article.Sender, article.Type, article.From, article.CreatedAt.In(loc).Format(documentTimeLayout)),
+			Subject:  article.Subject,
+			Internal: article.Internal,
+			Body:     strings.TrimSpace(articlePlainText(article.TicketArticle)),
+		}
+		for _, attachment := range article.Attachments {
+			size, _ := attachment.Size.Int64()
+			entry.Attachments = append(entry.Attachments, fmt.Sprintf("%s (%s)", attachment.Filename, byteSize(size)))
+		}
+		doc.Articles = append(doc.Articles, entry)
+	}
+	return doc, nil
+}
+
+// documentStyle is the stylesheet of HTML ticket documents, inlined so the
+// document has no external references.
+const documentStyle = `body { font-family: Helvetica, Arial, sans-serif; font-size: 14px; color: #222; max-width: 50em; margin: 2em auto; }
+h1 { font-size: 20px; }
+table.header { border-collapse: collapse; margin-bottom: 2em; }
+table.header th { text-align: left; padding: 2px 1.5em 2px 0; color: #666; font-weight: normal; }
+table.header td { padding: 2px 0; }
+.article { border-top: 1px solid #ccc; padding: 1em 0; }
+.article.internal { background: #fff8e1; padding: 1em; }
+.article h2 { font-size: 14px; margin: 0 0 0.5em; }
+.subject { font-weight: bold; margin-bottom: 0.5em; }
+.body { white-space: pre-wrap; }
+.attachments { color: #666; font-size: 12px; margin-top: 0.5em; }
+footer { border-top: 1px solid #ccc; color: #666; font-size: 12px; padding-top: 1em; }`
+
+// renderTicketHTML renders a ticket document as a standalone HTML page.
+// Article bodies are included as escaped plain text, so the page runs no
+// script and loads no remote content from the thread.
+func renderTicketHTML(doc ticketDocument) string {
+	var b strings.Builder
+	esc := html.EscapeString
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", esc(doc.Title), documentStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<table class=\"header\">\n", esc(doc.Title))
+	for _, field := range doc.Fields {
+		value := esc(field[1])
+		if field[0] == "Link" {
+			value = fmt.Sprintf("<a href=\"%s\">%s</a>", value, value)
+		}
+		fmt.Fprintf(&b, "<tr><th>%s</th><td>%s</td></tr>\n", esc(field[0]), value)
+	}
+	b.WriteString("</table>\n")
+	for _, article := range doc.Articles {
+		class, heading := "article", esc(article.Heading)
+		if article.Internal {
+			class, heading = "article internal", heading+" (internal)"
+		}
+		fmt.Fprintf(&b, "<div class=\"%s\">\n<h2>%s</h2>\n", class, heading)
+		if article.Subject != "" {
+			fmt.Fprintf(&b, "<div class=\"subject\">%s</div>\n", esc(article.Subject))
+		}
+		fmt.Fprintf(&b, "<div class=\"body\">%s</div>\n", esc(article.Body))
+		if len(article.Attachments) > 0 {
+			fmt.Fprintf(&b, "<div class=\"attachments\">Attachments: %s</div>\n", esc(strings.Join(article.Attachments, ", ")))
+		}
+		b.WriteString("</div>\n")
+	}
+	fmt.Fprintf(&b, "<footer>Exported %s, %d articles.</footer>\n</body>\n</html>\n", doc.ExportedAt.Format(documentTimeLayout), len(doc.Articles))
+	return b.String()
+}
+
+// Page geometry of PDF documents, in points: A4 with 2 cm margins.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 56
+	pdfTextWidth  = pdfPageWidth - 2*pdfMargin
+)
+
+// helveticaWidths are the advance widths of the printable ASCII characters
+// (32 to 126) in Helvetica, in thousandths of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// winAnsiPunctuation maps the characters of WinAnsiEncoding outside Latin-1.
+var winAnsiPunctuation = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, 'Š': 0x8a, 'š': 0x9a, 'Œ': 0x8c, 'œ': 0x9c,
+	'Ž': 0x8e, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfEncode converts text to WinAnsiEncoding, the encoding of the standard
+// PDF fonts. Characters it lacks, such as those of non-Latin scripts, become
+// question marks.
+func pdfEncode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			encoded = append(encoded, "    "...)
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		case winAnsiPunctuation[r] != 0:
+			encoded = append(encoded, winAnsiPunctuation[r])
+		case r < 0x20:
+			// Drop other control characters.
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// pdfStringWidth returns the width of WinAnsi-encoded text in Helvetica at
+// the given size. Characters outside ASCII are counted with the width of a
+// digit, which is close for accented letters. Bold text is about 10% wider.
+func pdfStringWidth(text []byte, size float64, bold bool) float64 {
+	width := 0.0
+	for _, c := range text {
+		width += pdfCharWidth(c, size, bold)
+	}
+	return width
+}
+
+// pdfCharWidth returns the width of a WinAnsi-encoded character, as
+// pdfStringWidth.
+func pdfCharWidth(c byte, size float64, bold bool) float64 {
+	width := 556
+	if c >= 32 && c < 127 {
+		width = helveticaWidths[c-32]
+	}
+	if bold {
+		width = width * 11 / 10
+	}
+	return float64(width) * size / 1000
+}
+
+// wrapPDFLine breaks an encoded line into lines no wider than maxWidth, at
+// spaces where possible.
+func wrapPDFLine(line []byte, size, maxWidth float64, bold bool) [][]byte {
+	var lines [][]byte
+	for {
+		// Find the longest prefix that fits, then back up to a space.
+		fits, width := 0, 0.0
+		for fits < len(line) && width+pdfCharWidth(line[fits], size, bold) <= maxWidth {
+			width += pdfCharWidth(line[fits], size, bold)
+			fits++
+		}
+		if fits == len(line) {
+			return append(lines, line)
+		}
+		end := bytes.LastIndexByte(line[:fits+1], ' ')
+		if end <= 0 {
+			end = max(fits, 1)
+		}
+		lines = append(lines, bytes.TrimRight(line[:end], " "))
+		line = bytes.TrimLeft(line[end:], " ")
+	}
+}
+
+// pdfLine is a line of text placed on a PDF page.
+type pdfLine struct {
+	bold bool
+	size float64
+	x, y float64
+	gray bool
+	text []byte
+}
+
+// pdfPage is the content of a PDF page: lines of text and horizontal rules.
+type pdfPage struct {
+	lines []pdfLine
+	rules []float64 // vertical positions
+}
+
+// pdfLayout flows text onto pages from top to bottom.
+type pdfLayout struct {
+	pages []*pdfPage
+	y     float64
+}
+
+// reserve starts a new page unless height fits above the bottom margin.
+func (l *pdfLayout) reserve(height float64) {
+	if len(l.pages) == 0 || l.y-height < pdfMargin {
+		l.pages = append(l.pages, &pdfPage{})
+		l.y = pdfPageHeight - pdfMargin
+	}
+}
+
+// text adds a paragraph, wrapped to the text width minus indent.
+func (l *pdfLayout) text(s string, size, indent float64, bold, gray bool) {
+	leading := size * 1.3
+	for _, paragraph := range strings.Split(s, "\n") {
+		for _, line := range wrapPDFLine(pdfEncode(strings.TrimRight(paragraph, " \r")), size, pdfTextWidth-indent, bold) {
+			l.reserve(leading)
+			l.y -= leading
+			page := l.pages[len(l.pages)-1]
+			page.lines = append(page.lines, pdfLine{bold: bold, size: size, x: pdfMargin + indent, y: l.y, gray: gray, text: line})
+		}
+	}
+}
+
+// rule adds a horizontal rule with some space around it.
+func (l *pdfLayout) rule() {
+	l.reserve(24)
+	l.y -= 10
+	page := l.pages[len(l.pages)-1]
+	page.rules = append(page.rules, l.y)
+	l.y -= 6
+}
+
+// pdfString returns text as a PDF literal string.
+func pdfString(text []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTextString returns text as a PDF text string for the document
+// information, which is UTF-16 so any script is kept.
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// renderTicketPDF renders a ticket document as a PDF in Helvetica, with a
+// page footer. It returns the PDF and its page count.
+func renderTicketPDF(doc ticketDocument) ([]byte, int) {
+	var layout pdfLayout
+	layout.text(doc.Title, 16, 0, true, false)
+	layout.y -= 8
+	for _, field := range doc.Fields {
+		// The label goes on the first line of the value.
+		layout.reserve(13)
+		page := layout.pages[len(layout.pages)-1]
+		page.lines = append(page.lines, pdfLine{size: 10, x: pdfMargin, y: layout.y - 13, gray: true, text: pdfEncode(field[0] + ":")})
+		layout.text(field[1], 10, 90, false, false)
+	}
+	for _, article := range doc.Articles {
+		layout.rule()
+		heading := article.Heading
+		if article.Internal {
+			heading += " (internal)"
+		}
+		layout.text(heading, 10, 0, true, article.Internal)
+		if article.Subject != "" {
+			layout.text(article.Subject, 10, 0, true, false)
+		}
+		layout.y -= 4
+		layout.text(article.Body, 10, 0, false, false)
+		if len(article.Attachments) > 0 {
+			layout.y -= 4
+			layout.text("Attachments: "+strings.Join(article.Attachments, ", "), 8, 0, false, true)
+		}
+	}
+
+	// Objects 1 to 5 are the catalog, the page tree, the two fonts and the
+	// document information; each page adds a page and a content object.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // page tree, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title %s /Producer (zammad-mcp-go) /CreationDate (D:%s) >>", pdfTextString(doc.Title), doc.ExportedAt.UTC().Format("20060102150405Z")),
+	}
+	footer := pdfEncode(fmt.Sprintf("%s, exported %s", doc.Title, doc.ExportedAt.Format(documentTimeLayout)))
+	footer = wrapPDFLine(footer, 8, pdfTextWidth-60, false)[0]
+	var kids []string
+	for i, page := range layout.pages {
+		var content bytes.Buffer
+		for _, line := range page.lines {
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			color := "0 g"
+			if line.gray {
+				color = "0.4 g"
+			}
+			fmt.Fprintf(&content, "BT %s /%s %g Tf %g %g Td %s Tj ET\n", color, font, line.size, line.x, line.y, pdfString(line.text))
+		}
+		for _, y := range page.rules {
+			fmt.Fprintf(&content, "0.75 G 0.5 w %d %g m %d %g l S\n", pdfMargin, y, pdfMargin+pdfTextWidth, y)
+		}
+		number := pdfEncode(fmt.Sprintf("Page %d of %d", i+1, len(layout.pages)))
+		fmt.Fprintf(&content, "BT 0.4 g /F1 8 Tf %d %d Td %s Tj ET\n", pdfMargin, pdfMargin/2, pdfString(footer))
+		fmt.Fprintf(&content, "BT 0.4 g /F1 8 Tf %g %d Td %s Tj ET\n", pdfMargin+pdfTextWidth-pdfStringWidth(number, 8, false), pdfMargin/2, pdfString(number))
+
+		var stream bytes.Buffer
+		w := zlib.NewWriter(&stream)
+		w.Write(content.Bytes())
+		w.Close()
+		pageObject := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents %d 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> >>", pdfPageWidth, pdfPageHeight, pageObject+1),
+			fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var pdf bytes.Buffer
+	offsets := make([]int, len(objects))
+	pdf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes(), len(layout.pages)
+}
+
+// handleExportTicketDocument renders the header and thread of a ticket as a
+// standalone HTML or PDF document, for attaching the case record to other
+// systems. The document is returned as an embedded resource.
+func handleExportTicketDocument(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	format := strings.ToLower(mcp.ParseString(request, "format", "html"))
+	includeInternal := mcp.ParseBoolean(request, "include_internal", false)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if format != "html" && format != "pdf" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %q (must be html or pdf)", format)), nil
+	}
+
+	doc, err := newTicketDocument(ticketID, includeInternal)
+	if err != nil {
+		log.Printf("Error collecting ticket %d for export: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to export ticket %d", ticketID), err), nil
+	}
+	uri := fmt.Sprintf("zammad://tickets/%d/document", ticketID)
+	visibility := "public articles"
+	if includeInternal {
+		visibility = "articles including internal notes"
+	}
+
+	if format == "pdf" {
+		data, pages := renderTicketPDF(doc)
+		pageCount := fmt.Sprintf("%d pages", pages)
+		if pages == 1 {
+			pageCount = "1 page"
+		}
+		log.Printf("Exported ticket %d as PDF: %d articles, %s, %s", ticketID, len(doc.Articles), pageCount, byteSize(len(data)))
+		return mcp.NewToolResultResource(
+			fmt.Sprintf("%s exported as PDF (%d %s, %s, %s).", doc.Title, len(doc.Articles), visibility, pageCount, byteSize(len(data))),
+			mcp.BlobResourceContents{URI: uri, MIMEType: "application/pdf", Blob: base64.StdEncoding.EncodeToString(data)},
+		), nil
+	}
+	page := renderTicketHTML(doc)
+	log.Printf("Exported ticket %d as HTML: %d articles, %s", ticketID, len(doc.Articles), byteSize(len(page)))
+	return mcp.NewToolResultResource(
+		fmt.Sprintf("%s exported as HTML (%d %s, %s).", doc.Title, len(doc.Articles), visibility, byteSize(len(page))),
+		mcp.TextResourceContents{URI: uri, MIMEType: "text/html", Text: page},
+	), nil
+}
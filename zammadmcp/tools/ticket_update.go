@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleUpdateTicket changes the core fields of a ticket (title, state,
+// priority, owner, group) and custom fields in one update. Names are resolved
+// to IDs first, so nothing is changed if any of them is unknown. Fields listed
+// in clear are emptied: the owner is unassigned and the pending time removed.
+func handleUpdateTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	title := strings.TrimSpace(mcp.ParseString(request, "title", ""))
+	stateName := mcp.ParseString(request, "state", "")
+	priorityName := mcp.ParseString(request, "priority", "")
+	ownerRef := mcp.ParseString(request, "owner", "")
+	group := mcp.ParseString(request, "group", "")
+	pendingUntil := mcp.ParseString(request, "pending_until", "")
+	customFields := mcp.ParseStringMap(request, "custom_fields", nil)
+	clear := listArgument(request, "clear")
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if title == "" && stateName == "" && priorityName == "" && ownerRef == "" && group == "" && len(customFields) == 0 && len(clear) == 0 {
+		return mcp.NewToolResultError("Nothing to update: pass at least one of title, state, priority, owner, group, custom_fields, clear"), nil
+	}
+	for _, name := range clear {
+		switch {
+		case name != "owner" && name != "pending_time":
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid argument clear: %q cannot be cleared (clearable: owner, pending_time; clear custom fields by setting them to null)", name)), nil
+		case name == "owner" && ownerRef != "":
+			return mcp.NewToolResultError("Invalid arguments: owner cannot be both set and cleared"), nil
+		case name == "pending_time" && pendingUntil != "":
+			return mcp.NewToolResultError("Invalid arguments: pending_time cannot be both set (pending_until) and cleared"), nil
+		}
+	}
+	if pendingUntil != "" && stateName == "" {
+		return mcp.NewToolResultError("Invalid argument pending_until: only used together with a pending state"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if err := validateTicketCustomFields(customFields); err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument custom_fields", err), nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+
+	var update ticketUpdate
+	var changes []string
+	if title != "" {
+		update.Title = setTo(title)
+		changes = append(changes, fmt.Sprintf("title %q", title))
+	}
+	if stateName != "" {
+		_, change, failure := stateChange(stateName, pendingUntil, &update)
+		if failure != nil {
+			return failure, nil
+		}
+		changes = append(changes, change)
+	}
+	if priorityName != "" {
+		priority, err := resolveTicketPriority(priorityName)
+		if err != nil {
+			log.Printf("Error resolving priority %q: %v", priorityName, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find priority %q", priorityName), err), nil
+		}
+		update.PriorityID = setTo(priority.ID)
+		changes = append(changes, fmt.Sprintf("priority %q", priority.Name))
+	}
+	var ownerID int
+	if ownerRef != "" {
+		owner, err := resolveUser(ownerRef)
+		if err != nil {
+			log.Printf("Error resolving owner %q: %v", ownerRef, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find the owner %q", ownerRef), err), nil
+		}
+		ownerID = owner.ID
+		update.OwnerID = setTo(owner.ID)
+		changes = append(changes, fmt.Sprintf("owner %s", userDisplayName(owner)))
+	}
+	if group != "" {
+		groupID, err := groupIDByName(group)
+		if err != nil {
+			log.Printf("Error resolving group %q: %v", group, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to find group %q", group), err), nil
+		}
+		update.GroupID = setTo(groupID)
+		changes = append(changes, fmt.Sprintf("group %q", group))
+	}
+	for _, name := range clear {
+		switch name {
+		case "owner":
+			// Zammad keeps unassigned tickets with its system user rather
+			// than without an owner.
+			update.OwnerID = setTo(unassignedOwnerID)
+			changes = append(changes, "unassigned")
+		case "pending_time":
+			update.PendingTime = cleared[time.Time]()
+			changes = append(changes, "pending time cleared")
+		}
+	}
+
+	var customNames []string
+	for name := range customFields {
+		customNames = append(customNames, name)
+	}
+	update.CustomFields = customFields
+	sort.Strings(customNames)
+	for _, name := range customNames {
+		value, _ := json.Marshal(customFields[name])
+		changes = append(changes, fmt.Sprintf("%s %s", name, value))
+	}
+
+	before, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	// ticketRecord does not hold custom fields; read their previous values
+	// for the undo history from the raw ticket.
+	var beforeFields map[string]any
+	if len(customNames) > 0 {
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/tickets/%d", ticketID), nil, &beforeFields); err != nil {
+			log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+		}
+	}
+	ticket, err := updateTicket(ticketID, update)
+	if err != nil {
+		log.Printf("Error updating ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to update ticket %d", ticketID), err), nil
+	}
+	summary := strings.Join(changes, ", ")
+	objects := []actionObject{ticketObject(ticketID)}
+	if ownerID != 0 {
+		objects = append(objects, userObject(ownerID))
+	}
+	undo := ticketUndo(before, ticket.UpdatedAt, update)
+	if len(customNames) > 0 {
+		undo.Restore.CustomFields = make(map[string]any, len(customNames))
+		for _, name := range customNames {
+			undo.Restore.CustomFields[name] = beforeFields[name]
+		}
+	}
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("updated ticket %d: %s", ticketID, summary), undo, objects...)
+
+	log.Printf("Updated ticket %d: %s", ticketID, summary)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d updated (%s):\n%s", ticketID, summary, string(jsonData))), nil
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// ticketRecord is a ticket as returned by the Zammad REST API. It embeds the
+// zammad-go Ticket and adds the attributes the upstream type does not model,
+// plus fields computed by this server.
+type ticketRecord struct {
+	zammad.Ticket
+	PendingTime               *time.Time `json:"pending_time,omitempty"`
+	FirstResponseAt           *time.Time `json:"first_response_at,omitempty"`
+	FirstResponseEscalationAt *time.Time `json:"first_response_escalation_at,omitempty"`
+	UpdateEscalationAt        *time.Time `json:"update_escalation_at,omitempty"`
+	CloseEscalationAt         *time.Time `json:"close_escalation_at,omitempty"`
+	EscalationAt              *time.Time `json:"escalation_at,omitempty"`
+
+	// Shadow the zammad-go fields, which render unset timestamps as
+	// 0001-01-01 and drop a zero article count, so the model can prioritize
+	// from search results alone.
+	ArticleCount          int        `json:"article_count"`
+	LastContactAt         *time.Time `json:"last_contact_at"`
+	LastContactAgentAt    *time.Time `json:"last_contact_agent_at"`
+	LastContactCustomerAt *time.Time `json:"last_contact_customer_at"`
+
+	// Computed by this server, not part of the Zammad payload.
+	WebURL string     `json:"web_url,omitempty"`
+	SLA    *slaStatus `json:"sla,omitempty"`
+	// VIP is set when the customer or their organization is marked VIP.
+	VIP bool `json:"vip,omitempty"`
+}
+
+// vipFlag decodes the VIP attribute of a user or organization.
+type vipFlag struct {
+	VIP bool `json:"vip"`
+}
+
+// newTicketRecord wraps a ticket returned by the zammad-go client and fills in
+// the computed fields.
+func newTicketRecord(ticket zammad.Ticket) ticketRecord {
+	tickets := []ticketRecord{{
+		Ticket:                ticket,
+		ArticleCount:          ticket.ArticleCount,
+		LastContactAt:         optionalTime(ticket.LastContactAt),
+		LastContactAgentAt:    optionalTime(ticket.LastContactAgentAt),
+		LastContactCustomerAt: optionalTime(ticket.LastContactCustomerAt),
+	}}
+	enrichTickets(tickets)
+	return tickets[0]
+}
+
+// optionalTime returns nil for the zero time, so it is rendered as null.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// fetchTicket retrieves a single ticket including escalation attributes.
+func fetchTicket(ticketID int) (ticketRecord, error) {
+	var ticket ticketRecord
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/tickets/%d", ticketID), nil, &ticket); err != nil {
+		return ticket, err
+	}
+	tickets := []ticketRecord{ticket}
+	enrichTickets(tickets)
+	return tickets[0], nil
+}
+
+// listTicketRecords pages through /api/v1/tickets from the given position and
+// returns the tickets accessible by the API token, stopping early with a
+// continuation if the memory budget is exhausted.
+func listTicketRecords(from continuation, budget *memoryBudget) ([]ticketRecord, *continuation, error) {
+	tickets, next, err := listPaged[ticketRecord]("/api/v1/tickets", from, budget)
+	if err != nil {
+		return tickets, nil, err
+	}
+	enrichTickets(tickets)
+	return tickets, next, nil
+}
+
+// searchTicketRecords runs a ticket search and returns the matching tickets in
+// the order Zammad ranked them.
+func searchTicketRecords(query string, limit int) ([]ticketRecord, error) {
+	return searchTickets(fmt.Sprintf("query=%s&limit=%d", url.QueryEscape(query), limit))
+}
+
+// searchTicketRecordsPage returns one page of search results, for callers
+// that need to walk through more tickets than a single search returns.
+func searchTicketRecordsPage(query string, page, perPage int) ([]ticketRecord, error) {
+	return searchTickets(fmt.Sprintf("query=%s&page=%d&per_page=%d&limit=%d", url.QueryEscape(query), page, perPage, perPage))
+}
+
+func searchTickets(params string) ([]ticketRecord, error) {
+	var result struct {
+		Tickets []int `json:"tickets"`
+		Assets  struct {
+			Ticket       map[string]ticketRecord `json:"Ticket"`
+			User         map[string]vipFlag      `json:"User"`
+			Organization map[string]vipFlag      `json:"Organization"`
+		} `json:"assets"`
+	}
+	if err := zammadRequest(http.MethodGet, "/api/v1/tickets/search?"+params, nil, &result); err != nil {
+		return nil, err
+	}
+
+	// The assets include each ticket's customer and organization.
+	tickets := make([]ticketRecord, 0, len(result.Tickets))
+	for _, id := range result.Tickets {
+		if ticket, ok := result.Assets.Ticket[fmt.Sprint(id)]; ok {
+			ticket.VIP = result.Assets.User[fmt.Sprint(ticket.CustomerID)].VIP ||
+				result.Assets.Organization[fmt.Sprint(ticket.OrganizationID)].VIP
+			tickets = append(tickets, ticket)
+		}
+	}
+	enrichTickets(tickets)
+	return tickets, nil
+}
+
+// lookupTicketVIP sets the VIP flag of a single ticket, which requires
+// fetching its customer and organization. Both are fetched concurrently, as
+// they do not depend on each other. Lookup failures leave it unset.
+func lookupTicketVIP(ticket *ticketRecord) {
+	var customer, organization vipFlag
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", ticket.CustomerID), nil, &customer); err != nil {
+			log.Printf("Could not look up customer %d of ticket %d: %v", ticket.CustomerID, ticket.ID, err)
+		}
+	}()
+	if ticket.OrganizationID != 0 {
+		if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/organizations/%d", ticket.OrganizationID), nil, &organization); err != nil {
+			log.Printf("Could not look up organization %d of ticket %d: %v", ticket.OrganizationID, ticket.ID, err)
+		}
+	}
+	wg.Wait()
+	ticket.VIP = customer.VIP || organization.VIP
+}
+
+// vipTickets returns the tickets whose customer or organization is VIP.
+func vipTickets(tickets []ticketRecord) []ticketRecord {
+	vip := make([]ticketRecord, 0, len(tickets))
+	for _, t := range tickets {
+		if t.VIP {
+			vip = append(vip, t)
+		}
+	}
+	return vip
+}
+
+// enrichTickets fills in the computed fields of each ticket in place.
+func enrichTickets(tickets []ticketRecord) {
+	now := time.Now()
+	for i := range tickets {
+		tickets[i].WebURL = ticketWebURL(tickets[i].ID)
+		tickets[i].SLA = computeSLAStatus(&tickets[i], now)
+	}
+}
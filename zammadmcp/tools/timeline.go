@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// timelinePreviewChars is the length of the article previews in a timeline.
+const timelinePreviewChars = 200
+
+// timelineEvent is an event of get_ticket_timeline. Every event has a time,
+// kind and summary; the other fields depend on the kind.
+type timelineEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Summary string    `json:"summary"`
+	Actor   string    `json:"actor,omitempty"`
+	ActorID int       `json:"actor_id,omitempty"`
+
+	// Articles.
+	ArticleID   int    `json:"article_id,omitempty"`
+	Sender      string `json:"sender,omitempty"`
+	ArticleType string `json:"article_type,omitempty"`
+	Internal    bool   `json:"internal,omitempty"`
+	Preview     string `json:"preview,omitempty"`
+
+	// Attribute and tag changes.
+	Attribute string `json:"attribute,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+
+	// Escalation deadlines.
+	Overdue bool `json:"overdue,omitempty"`
+}
+
+// timelineAttributeKinds are the event kinds of changes to the attributes
+// clients usually show distinctly; other attributes are attribute_change.
+var timelineAttributeKinds = map[string]string{
+	"state":    "state_change",
+	"priority": "priority_change",
+	"owner":    "owner_change",
+	"group":    "group_change",
+}
+
+// historyTimelineEvent converts a history entry into a timeline event. It
+// returns false for entries the timeline leaves out: article creation, which
+// the article events cover, and entries such as sent notifications.
+func historyTimelineEvent(e historyEntry, history ticketHistory) (timelineEvent, bool) {
+	event := timelineEvent{Time: e.CreatedAt, Actor: history.actor(e.CreatedByID), ActorID: e.CreatedByID}
+	switch {
+	case e.Object == "Ticket::Article":
+		return event, false
+	case e.Type == "created" && e.Object == "Ticket":
+		event.Kind = "created"
+	case e.Type == "updated":
+		event.Kind = timelineAttributeKinds[e.Attribute]
+		if event.Kind == "" {
+			event.Kind = "attribute_change"
+		}
+		event.Attribute, event.From, event.To = e.Attribute, e.ValueFrom, e.ValueTo
+	case (e.Type == "added" || e.Type == "removed") && e.Attribute == "tag":
+		event.Kind = "tag_" + e.Type
+		event.Tag = e.ValueTo
+	case e.Type == "received_merge" || e.Type == "merged_into":
+		event.Kind = "merge"
+		event.To = e.ValueTo
+	case e.Type == "escalation" || e.Type == "escalation_warning":
+		event.Kind = "escalation"
+		event.Attribute, event.To = e.Attribute, e.ValueTo
+		event.Summary = strings.ReplaceAll(e.Type, "_", " ")
+		if e.ValueTo != "" {
+			event.Summary += ": " + e.ValueTo
+		}
+		return event, true
+	default:
+		return event, false
+	}
+	event.Summary = describeHistoryEntry(e)
+	return event, true
+}
+
+// articleTimelineEvent converts an article into a timeline event with a
+// preview of its body.
+func articleTimelineEvent(article articleWithAttachments, history ticketHistory) timelineEvent {
+	if config.FenceCustomerContent {
+		article.Body = stripHiddenContent(article.Body)
+	}
+	preview := strings.Join(strings.Fields(articlePlainText(article.TicketArticle)), " ")
+	if runes := []rune(preview); len(runes) > timelinePreviewChars {
+		preview = string(runes[:timelinePreviewChars]) + "…"
+	}
+	if config.FenceCustomerContent && isCustomerArticle(article.TicketArticle) {
+		preview = fenceUntrusted(fmt.Sprintf("article %d", article.ID), preview)
+	}
+	visibility := ""
+	if article.Internal {
+		visibility = "internal "
+	}
+	summary := fmt.Sprintf("%s%s %s by %s", visibility, article.Sender, article.Type, article.From)
+	if article.Subject != "" {
+		summary += ": " + article.Subject
+	}
+	actor := article.From
+	if name, ok := history.Users[article.CreatedByID]; ok && name != "" {
+		actor = name
+	}
+	return timelineEvent{
+		Time: article.CreatedAt, Kind: "article", Summary: summary, Actor: actor, ActorID: article.CreatedByID,
+		ArticleID: article.ID, Sender: article.Sender, ArticleType: article.Type, Internal: article.Internal, Preview: preview,
+	}
+}
+
+// deadlineTimelineEvents returns the escalation deadlines of a ticket that
+// are still set, i.e. not yet met, as events. Passed deadlines are overdue.
+func deadlineTimelineEvents(ticket ticketRecord, now time.Time) []timelineEvent {
+	var events []timelineEvent
+	for _, deadline := range []struct {
+		name string
+		at   *time.Time
+	}{
+		{"first response", ticket.FirstResponseEscalationAt},
+		{"update", ticket.UpdateEscalationAt},
+		{"solution", ticket.CloseEscalationAt},
+	} {
+		if deadline.at == nil {
+			continue
+		}
+		event := timelineEvent{Time: *deadline.at, Kind: "deadline", Attribute: strings.ReplaceAll(deadline.name, " ", "_")}
+		if deadline.at.Before(now) {
+			event.Overdue = true
+			event.Summary = fmt.Sprintf("%s deadline passed, ticket escalated", deadline.name)
+		} else {
+			event.Summary = fmt.Sprintf("%s due", deadline.name)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// handleGetTicketTimeline merges a ticket's articles, history and escalation
+// deadlines into one chronological list of normalized events, for clients
+// that render a timeline.
+func handleGetTicketTimeline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	since, err := parseOptionalTime(mcp.ParseString(request, "since", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid since: %v", err)), nil
+	}
+	until, err := parseOptionalTime(mcp.ParseString(request, "until", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid until: %v", err)), nil
+	}
+	includeInternal := mcp.ParseBoolean(request, "include_internal", true)
+
+	// The ticket, its articles and its history are fetched concurrently.
+	var (
+		ticket                 ticketRecord
+		articles               []articleWithAttachments
+		ticketErr, articlesErr error
+		wg                     sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ticket, ticketErr = fetchTicket(ticketID)
+	}()
+	go func() {
+		defer wg.Done()
+		articlesErr = zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/ticket_articles/by_ticket/%d", ticketID), nil, &articles)
+	}()
+	history, err := fetchTicketHistory(ticketID)
+	wg.Wait()
+	if ticketErr != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, ticketErr)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), ticketErr), nil
+	}
+	if articlesErr != nil {
+		log.Printf("Error fetching articles of ticket %d from Zammad: %v", ticketID, articlesErr)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get articles of ticket %d", ticketID), articlesErr), nil
+	}
+	if err != nil {
+		log.Printf("Error fetching history of ticket %d from Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get history of ticket %d", ticketID), err), nil
+	}
+
+	var events []timelineEvent
+	for _, article := range articles {
+		if article.Internal && !includeInternal {
+			continue
+		}
+		events = append(events, articleTimelineEvent(article, history))
+	}
+	for _, e := range history.Entries {
+		if event, ok := historyTimelineEvent(e, history); ok {
+			events = append(events, event)
+		}
+	}
+	events = append(events, deadlineTimelineEvents(ticket, time.Now())...)
+
+	filtered := make([]timelineEvent, 0, len(events))
+	for _, event := range events {
+		if (!since.IsZero() && event.Time.Before(since)) || (!until.IsZero() && event.Time.After(until)) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	// The ticket is created together with its first article; list it first.
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Time.Equal(filtered[j].Time) {
+			return filtered[i].Kind == "created" && filtered[j].Kind != "created"
+		}
+		return filtered[i].Time.Before(filtered[j].Time)
+	})
+
+	jsonData, err := json.MarshalIndent(filtered, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal timeline of ticket %d: %w", ticketID, err) // Internal server error
+	}
+	log.Printf("Built a timeline of %d events for ticket %d", len(filtered), ticketID)
+	return mcp.NewToolResultText(fmt.Sprintf("Timeline of ticket %d (%d events):\n%s", ticketID, len(filtered), string(jsonData))), nil
+}
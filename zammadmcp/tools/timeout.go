@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolCallOutcome is the return value of a tool handler.
+type toolCallOutcome struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// withToolTimeout bounds each tool call by its configured timeout (see
+// toolTimeout). A call that runs out of time returns an error result right
+// away; the handler itself keeps running in the background until its pending
+// Zammad requests complete, and its result is discarded. For writes, the
+// error says that the change may still be applied.
+func withToolTimeout(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		timeout := toolTimeout(request.Params.Name)
+		// The write executed by approve_pending_action is bounded by the
+		// timeout of its own tool.
+		if timeout <= 0 || request.Params.Name == approveToolName {
+			return next(ctx, request)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		done := make(chan toolCallOutcome, 1)
+		go func() {
+			result, err := next(ctx, request)
+			done <- toolCallOutcome{result, err}
+		}()
+
+		select {
+		case outcome := <-done:
+			return outcome.result, outcome.err
+		case <-ctx.Done():
+			log.Printf("Tool call %s timed out after %s", request.Params.Name, timeout)
+			// A write keeps running and usually still succeeds, so a retry
+			// would apply it twice.
+			if isWriteCall(request) {
+				return mcp.NewToolResultError(fmt.Sprintf(localized(msgWriteTimeout), request.Params.Name, timeout)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf(localized(msgTimeout), request.Params.Name, timeout, request.Params.Name)), nil
+		}
+	}
+}
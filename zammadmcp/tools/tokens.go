@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// condensedFields are the fields kept of each object when a result is
+// condensed: enough to tell the items apart and fetch one in full.
+var condensedFields = []string{
+	"id", "ticket_id", "article_id", "user_id", "organization_id", "number", "title", "name", "login", "email",
+	"subject", "from", "state", "status", "created_at", "updated_at", "waiting", "web_url",
+}
+
+// condensedStringLimit is the length, in characters, long strings are cut to
+// when a result is condensed.
+const condensedStringLimit = 200
+
+// estimateTokens estimates the tokens a text takes in the model's context.
+// Tokenizers average about four characters per token for prose but fewer for
+// JSON, so the estimate errs on the high side.
+func estimateTokens(text string) int {
+	return (len(text)*2 + 6) / 7
+}
+
+// withTokenBudget condenses tool results that are estimated to exceed
+// token_budget, so clients with a small context window are not flooded by a
+// huge JSON dump: JSON items are reduced to their identifying fields and, if
+// that is not enough, cut off, with a notice telling the model how to get the
+// details. Embedded resources and images are passed through, as they are
+// documents rather than text for the model.
+func withTokenBudget(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || config.TokenBudget <= 0 {
+			return result, err
+		}
+		total := 0
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				total += estimateTokens(text.Text)
+			}
+		}
+		if total <= config.TokenBudget {
+			return result, nil
+		}
+
+		// The result may be shared with the response cache; change a copy.
+		condensed := *result
+		condensed.Content = make([]mcp.Content, len(result.Content))
+		budget := config.TokenBudget
+		for i, content := range result.Content {
+			text, ok := content.(mcp.TextContent)
+			if !ok {
+				condensed.Content[i] = content
+				continue
+			}
+			text.Text = condenseText(text.Text, total, budget)
+			budget = max(budget-estimateTokens(text.Text), 0)
+			condensed.Content[i] = text
+		}
+		log.Printf("Condensed the result of %s from about %d tokens to fit the token budget of %d", request.Params.Name, total, config.TokenBudget)
+		return &condensed, nil
+	}
+}
+
+// condenseText shrinks a tool result text to about budget tokens. Results are
+// a header line followed by a JSON document or JSON Lines and possibly a
+// closing note, which are kept; the JSON is condensed. Other text is cut.
+func condenseText(text string, total, budget int) string {
+	notice := fmt.Sprintf(localized(msgCondensed)+"\n", total, config.TokenBudget)
+	start := -1
+	for offset := 0; offset < len(text); {
+		if text[offset] == '{' || text[offset] == '[' {
+			start = offset
+			break
+		}
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			break
+		}
+		offset += i + 1
+	}
+	if start < 0 {
+		return truncateText(text, budget, notice)
+	}
+
+	// Decode the values up to the first that is not JSON: one for a document,
+	// several for JSON Lines.
+	decoder := json.NewDecoder(strings.NewReader(text[start:]))
+	decoder.UseNumber()
+	var values []any
+	end := start
+	for {
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		values = append(values, value)
+		end = start + int(decoder.InputOffset())
+		rest := strings.TrimLeft(text[end:], " \t\r\n")
+		if rest == "" || (rest[0] != '{' && rest[0] != '[') {
+			break
+		}
+	}
+	if len(values) == 0 {
+		return truncateText(text, budget, notice)
+	}
+	header, trailer := text[:start], text[end:]
+	jsonLines := len(values) > 1
+	items, isList := values[0].([]any)
+	if jsonLines {
+		items, isList = values, true
+	}
+	if !isList {
+		// A single document: keep its fields, condense what is nested.
+		data, err := json.MarshalIndent(condenseValue(values[0], true), "", "  ")
+		if err != nil {
+			return truncateText(text, budget, notice)
+		}
+		if estimateTokens(string(data)) > budget-estimateTokens(header+trailer+notice) {
+			return header + truncateText(string(data)+trailer, budget-estimateTokens(header), notice)
+		}
+		return header + notice + string(data) + trailer
+	}
+
+	// Keep as many items as fit, leaving room for the header, trailer and
+	// notice.
+	room := budget - estimateTokens(header+trailer+notice)
+	kept := make([][]byte, 0, len(items))
+	used := 0
+	for _, item := range items {
+		data, err := marshalCondensed(condenseValue(item, false), jsonLines)
+		if err != nil {
+			return truncateText(text, budget, notice)
+		}
+		if used += estimateTokens(string(data)) + 1; used > room && len(kept) > 0 {
+			break
+		}
+		kept = append(kept, data)
+	}
+	body := string(bytes.Join(kept, []byte("\n")))
+	if !jsonLines {
+		body = "[\n  " + string(bytes.Join(kept, []byte(",\n  "))) + "\n]"
+	}
+	if omitted := len(items) - len(kept); omitted > 0 {
+		notice += fmt.Sprintf(localized(msgItemsOmitted)+"\n", omitted, len(items))
+	}
+	return header + notice + body + trailer
+}
+
+// condenseValue reduces objects to their condensedFields, or keeps all fields
+// of objects that have none of them, and shortens long strings. The fields
+// of a top-level object are all kept, with their values condensed.
+func condenseValue(value any, top bool) any {
+	switch v := value.(type) {
+	case string:
+		if utf8.RuneCountInString(v) > condensedStringLimit {
+			runes := []rune(v)
+			return string(runes[:condensedStringLimit]) + "…"
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = condenseValue(v[i], false)
+		}
+		return v
+	case map[string]any:
+		kept := make(map[string]any)
+		for _, name := range condensedFields {
+			if field, ok := v[name]; ok {
+				kept[name] = condenseValue(field, false)
+			}
+		}
+		if len(kept) > 0 && !top {
+			// In the order of condensedFields, identifiers first.
+			if data, err := outputProfile(condensedFields).project(kept); err == nil {
+				return json.RawMessage(data)
+			}
+			return kept
+		}
+		for name, field := range v {
+			v[name] = condenseValue(field, false)
+		}
+		return v
+	}
+	return value
+}
+
+// marshalCondensed renders a condensed item compactly for JSON Lines, or
+// indented to nest in an array.
+func marshalCondensed(value any, jsonLines bool) ([]byte, error) {
+	if jsonLines {
+		return json.Marshal(value)
+	}
+	return json.MarshalIndent(value, "  ", "  ")
+}
+
+// truncateText cuts text to about budget tokens at a line break, with the
+// notice in front.
+func truncateText(text string, budget int, notice string) string {
+	limit := max(budget-estimateTokens(notice), 0) * 7 / 2
+	if len(text) <= limit {
+		return notice + text
+	}
+	cut := text[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return notice + cut + "\n[… truncated]"
+}
@@ -0,0 +1,1224 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go" // Import the Zammad client
+	"github.com/mark3labs/mcp-go/mcp"      // Import the MCP types
+	"github.com/mark3labs/mcp-go/server"   // Import the MCP server
+)
+
+var (
+	ErrResourceNotFound error = errors.New("resource not found")
+)
+
+var zammadClient *zammad.Client
+
+// =====================================
+// MCP Resource Registration & Handlers
+// =====================================
+
+func registerResources(s *server.MCPServer) {
+	// 1. List Tickets Resource
+	listTicketsResource := mcp.NewResource(
+		"zammad://tickets", // URI for listing all tickets
+		"List Tickets",
+		mcp.WithResourceDescription("Lists all tickets accessible by the API token."),
+		mcp.WithMIMEType("application/json"),
+	)
+	s.AddResource(listTicketsResource, handleListTickets)
+	continueTicketsTemplate := mcp.NewResourceTemplate(
+		"zammad://tickets{?continuation}",
+		"List Tickets (Continued)",
+		mcp.WithTemplateDescription("Continues the ticket list where a read of zammad://tickets stopped at the memory budget."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(continueTicketsTemplate, handleListTickets)
+
+	// 2. Show Ticket Resource (Dynamic via Template)
+	showTicketTemplate := mcp.NewResourceTemplate(
+		"zammad://tickets/{ticket_id}", // URI template
+		"Show Ticket (Resource)",       // Renamed slightly to distinguish from tool
+		mcp.WithTemplateDescription("Shows details for a specific ticket by its ID (via resource read)."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(showTicketTemplate, handleShowTicket)
+
+	// 3. List Users Resource
+	listUsersResource := mcp.NewResource(
+		"zammad://users",
+		"List Users",
+		mcp.WithResourceDescription("Lists all users accessible by the API token."),
+		mcp.WithMIMEType("application/json"),
+	)
+	s.AddResource(listUsersResource, handleListUsers)
+	continueUsersTemplate := mcp.NewResourceTemplate(
+		"zammad://users{?continuation}",
+		"List Users (Continued)",
+		mcp.WithTemplateDescription("Continues the user list where a read of zammad://users stopped at the memory budget."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(continueUsersTemplate, handleListUsers)
+
+	// 4. Show User Resource (Dynamic via Template) <-- NEW RESOURCE
+	showUserTemplate := mcp.NewResourceTemplate(
+		"zammad://users/{user_id}", // URI template
+		"Show User (Resource)",
+		mcp.WithTemplateDescription("Shows details for a specific user by their ID (via resource read)."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.AddResourceTemplate(showUserTemplate, handleShowUser) // Register new handler
+
+	// 5. Session Actions Resource
+	sessionActionsResource := mcp.NewResource(
+		"zammad://session/actions",
+		"Session Actions",
+		mcp.WithResourceDescription("Lists every write performed in this session, with links to the affected tickets, articles, users and organizations, for reviewing the changes before ending the conversation."),
+		mcp.WithMIMEType("application/json"),
+	)
+	s.AddResource(sessionActionsResource, handleSessionActions)
+
+	// 6. Pending Deadlines Calendar Resource
+	pendingCalendarResource := mcp.NewResource(
+		"zammad://calendar/pending.ics",
+		"Pending Deadlines Calendar",
+		mcp.WithResourceDescription("Exports the pending reminders and escalation deadlines of the open tickets owned by the API user as iCalendar, for importing into calendar clients."),
+		mcp.WithMIMEType("text/calendar"),
+	)
+	s.AddResource(pendingCalendarResource, handlePendingCalendar)
+
+	// 7. New Tickets Feed Resource
+	newTicketsFeedResource := mcp.NewResource(
+		newTicketsFeedURI,
+		"New Tickets Feed",
+		mcp.WithResourceDescription("Atom feed of the tickets created in the last seven days, newest first (up to 50), with each ticket's group as category."),
+		mcp.WithMIMEType("application/atom+xml"),
+	)
+	s.AddResource(newTicketsFeedResource, handleNewTicketsFeed)
+	groupTicketsFeedTemplate := mcp.NewResourceTemplate(
+		newTicketsFeedURI+"{?group}",
+		"New Tickets Feed (Group)",
+		mcp.WithTemplateDescription("Atom feed of the tickets created in the last seven days in one group, given by name."),
+		mcp.WithTemplateMIMEType("application/atom+xml"),
+	)
+	s.AddResourceTemplate(groupTicketsFeedTemplate, handleNewTicketsFeed)
+}
+
+// handleListTickets retrieves all tickets from Zammad, or as many as fit
+// into the memory budget.
+func handleListTickets(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+	from, err := resourceContinuation(request)
+	if err != nil {
+		return nil, err
+	}
+	tickets, next, err := listTicketRecords(from, newMemoryBudget())
+	if err != nil {
+		log.Printf("Error fetching tickets from Zammad: %v", err)
+		return nil, fmt.Errorf("failed to fetch tickets: %w", err)
+	}
+
+	jsonData, err := json.MarshalIndent(tickets, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling tickets to JSON: %v", err)
+		return nil, fmt.Errorf("failed to marshal tickets: %w", err)
+	}
+
+	contents := []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}
+	return appendContinuation(contents, "zammad://tickets", "tickets", len(tickets), next), nil
+}
+
+// handleShowTicket retrieves details for a specific ticket via resource read.
+func handleShowTicket(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+
+	ticketIDStr, ok := request.Params.Arguments["ticket_id"].(string)
+	if !ok {
+		log.Printf("Error: ticket_id not found or not a string in arguments: %v", request.Params.Arguments)
+		return nil, fmt.Errorf("%w: invalid or missing ticket_id in URI", ErrResourceNotFound)
+	}
+	ticketID, err := strconv.Atoi(ticketIDStr)
+	if err != nil {
+		log.Printf("Error converting ticket_id '%s' to int: %v", ticketIDStr, err)
+		return nil, fmt.Errorf("%w: invalid ticket_id format: %w", ErrResourceNotFound, err)
+	}
+
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad: %v", ticketID, err)
+		return nil, fmt.Errorf("%w: failed to fetch ticket %d: %w", ErrResourceNotFound, ticketID, err)
+	}
+	lookupTicketVIP(&ticket)
+	jsonData, err := json.MarshalIndent(ticket, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling ticket %d to JSON: %v", ticketID, err)
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
+
+// handleListUsers retrieves all users from Zammad, or as many as fit into
+// the memory budget.
+func handleListUsers(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+	from, err := resourceContinuation(request)
+	if err != nil {
+		return nil, err
+	}
+	users, next, err := listUserRecords(from, newMemoryBudget())
+	if err != nil {
+		log.Printf("Error fetching users from Zammad: %v", err)
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	jsonData, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling users to JSON: %v", err)
+		return nil, fmt.Errorf("failed to marshal users: %w", err)
+	}
+	contents := []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}
+	return appendContinuation(contents, "zammad://users", "users", len(users), next), nil
+}
+
+// handleShowUser retrieves details for a specific user via resource read. <-- NEW HANDLER
+func handleShowUser(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("Handling request for resource: %s", request.Params.URI)
+
+	userIDStr, ok := request.Params.Arguments["user_id"].(string)
+	if !ok {
+		log.Printf("Error: user_id not found or not a string in arguments: %v", request.Params.Arguments)
+		return nil, fmt.Errorf("%w: invalid or missing user_id in URI", ErrResourceNotFound)
+	}
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		log.Printf("Error converting user_id '%s' to int: %v", userIDStr, err)
+		return nil, fmt.Errorf("%w: invalid user_id format: %w", ErrResourceNotFound, err)
+	}
+
+	user, err := fetchUserRecord(userID)
+	if err != nil {
+		log.Printf("Error fetching user %d from Zammad: %v", userID, err)
+		return nil, fmt.Errorf("%w: failed to fetch user %d: %w", ErrResourceNotFound, userID, err)
+	}
+	jsonData, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling user %d to JSON: %v", userID, err)
+		return nil, fmt.Errorf("failed to marshal user %d: %w", userID, err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
+
+// ==================================
+// MCP Tool Registration & Handlers
+// ==================================
+
+func registerTools(s *server.MCPServer) {
+	// --- Ticket Tools ---
+	createTicketTool := mcp.NewTool("create_ticket",
+		mcp.WithDescription("Creates a new Zammad ticket with the specified details, optionally pre-filled from a ticket template (see list_ticket_templates). Returns the new ticket's ID, number and web UI link, followed by the ticket."),
+		mcp.WithString("template", mcp.Description("Name or ID of a ticket template whose values pre-fill the ticket; the other arguments override them."), examples("Hardware request")),
+		mcp.WithString("title", mcp.Description("The title of the ticket. Required unless the template sets it.")),
+		mcp.WithString("group", mcp.Description("The group/department for the ticket. Required unless the template sets it.")),
+		mcp.WithString("customer", mcp.Description("The customer email or ID for the ticket. Required unless the template sets it."), examples("jane.doe@example.com", "42")),
+		mcp.WithString("body", mcp.Description("The initial message/content of the ticket. Required unless the template sets it.")),
+		mcp.WithString("type", mcp.Description("The article type (e.g., 'note', 'email'). Default: 'note'."), mcp.DefaultString("note")),
+		mcp.WithBoolean("internal", mcp.Description("Whether the article is internal. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("skip_signature", mcp.Description("For email articles, do not append the group's signature. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithObject("custom_fields", mcp.Description(customFieldsDescription), additionalProperties(true)),
+		attachmentsOption(),
+		mcp.WithString("request_id", mcp.Description("A unique key of this ticket, such as a UUID. Retrying with the same request_id and arguments, e.g. after a timeout, returns the ticket created by the first call instead of creating a duplicate."), examples("3f2b9c1e-7a4d-4e5f-9b8a-2c6d1e0f4a7b")),
+		outputProfileOption(),
+	)
+	addTool(s, createTicketTool, handleCreateTicket)
+
+	listTicketTemplatesTool := mcp.NewTool("list_ticket_templates",
+		mcp.WithDescription("Lists the ticket templates of the instance with the values each pre-fills, such as title, group, priority and text. Pass a template's name to create_ticket to create tickets consistent with it."),
+		mcp.WithBoolean("include_inactive", mcp.Description("Whether to include inactive templates, which create_ticket cannot use. Default: false."), mcp.DefaultBool(false)),
+		noCacheOption(),
+	)
+	addTool(s, listTicketTemplatesTool, handleListTicketTemplates)
+
+	createTicketFromEmailTextTool := mcp.NewTool("create_ticket_from_email_text",
+		mcp.WithDescription("Creates a ticket from a pasted raw email (headers and body, as shown by a mail client's 'show original'). Sender, subject and body are extracted server-side; the sender is looked up by email address and created as a customer if unknown."),
+		mcp.WithString("email", mcp.Required(), mcp.Description("The raw email text, including the From and Subject headers.")),
+		mcp.WithString("group", mcp.Required(), mcp.Description("The group/department for the ticket.")),
+		mcp.WithBoolean("create_customer", mcp.Description("Whether to create a customer for an unknown sender. Default: true."), mcp.DefaultBool(true)),
+		outputProfileOption(),
+	)
+	addTool(s, createTicketFromEmailTextTool, handleCreateTicketFromEmailText)
+
+	splitTicketTool := mcp.NewTool("split_ticket",
+		mcp.WithDescription("Creates a new ticket from one article of a ticket, like Zammad's split: the article's text and attachments become the new ticket's first article, and the new ticket is linked to the original. Use this when a thread mixes unrelated requests. The original ticket is not changed."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket the article belongs to.")),
+		mcp.WithNumber("article_id", mcp.Required(), mcp.Description("The ID of the article to split off.")),
+		mcp.WithString("title", mcp.Description("Title of the new ticket. Default: the article's subject, or 'Split from #<number>: <title>'.")),
+		mcp.WithString("group", mcp.Description("Group of the new ticket. Default: the original ticket's group.")),
+		mcp.WithString("customer", mcp.Description("Customer of the new ticket (email address or login). Default: the original ticket's customer.")),
+		mcp.WithString("link", mcp.Enum("child", "normal", "none"), mcp.Description("How the new ticket is linked to the original: 'child' (default) makes it a child of the original, 'normal' links them as related, 'none' does not link them.")),
+		mcp.WithBoolean("copy_attachments", mcp.Description("Copy the article's attachments to the new ticket. Default: true."), mcp.DefaultBool(true)),
+		outputProfileOption(),
+	)
+	addTool(s, splitTicketTool, handleSplitTicket)
+
+	updateTicketTool := mcp.NewTool("update_ticket",
+		mcp.WithDescription("Updates the core fields of a ticket: title, state, priority, owner and group, and custom fields, in one update. Only the fields given are changed; use clear to empty the owner or pending time. Returns the updated ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to update.")),
+		mcp.WithString("title", mcp.Description("The new title.")),
+		mcp.WithString("state", mcp.Description("The new state, by name. Common names such as 'closed', 'resolved' or 'pending' are mapped to the instance's states; see get_allowed_transitions."), examples("open", "closed", "pending reminder")),
+		mcp.WithString("pending_until", mcp.Description("Required with a pending state: when the reminder is due or the ticket is closed, as an RFC 3339 timestamp, an offset ('+3d') or a day with optional time ('Monday 9am')."), examples("+3d", "tomorrow")),
+		mcp.WithString("priority", mcp.Description("The new priority, by name or ID; 'high' matches '3 high'."), examples("3 high", "low")),
+		mcp.WithString("owner", mcp.Description("The new owner's user ID, login or email address."), examples("sam.support@example.com", "5")),
+		mcp.WithString("group", mcp.Description("Name of the group to move the ticket to.")),
+		mcp.WithObject("custom_fields", mcp.Description(customFieldsDescription+" null clears a field."), additionalProperties(true)),
+		mcp.WithArray("clear", mcp.Description("Fields to empty: 'owner' unassigns the ticket, 'pending_time' removes the pending time. A field cannot be both set and cleared."), mcp.Items(map[string]any{"type": "string", "enum": []string{"owner", "pending_time"}})),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, updateTicketTool, handleUpdateTicket)
+
+	bulkUpdateTicketsTool := mcp.NewTool("bulk_update_tickets",
+		mcp.WithDescription("Applies the same changes (state, priority, owner, group, tags) to many tickets at once, given by ID or by a search query, and reports the outcome per ticket. Without confirm it is a dry run that lists the tickets it would change. Use it instead of updating tickets one by one."),
+		mcp.WithArray("ticket_ids", mcp.Description("IDs of the tickets to update. Exclusive with query."), mcp.Items(map[string]any{"type": "number"})),
+		mcp.WithString("query", mcp.Description("Zammad search query selecting the tickets to update, e.g. state.name:new AND tags:outage. Exclusive with ticket_ids.")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of tickets the query may match; if it matches more, nothing is changed. Default: 100, at most %d.", bulkUpdateMaxTickets)), mcp.DefaultNumber(100)),
+		mcp.WithString("state", mcp.Description("The new state, by name. Common names such as 'closed', 'resolved' or 'pending' are mapped to the instance's states."), examples("open", "closed", "pending reminder")),
+		mcp.WithString("pending_until", mcp.Description("Required with a pending state: when the reminder is due or the tickets are closed, as an RFC 3339 timestamp, an offset ('+3d') or a day with optional time ('Monday 9am')."), examples("+3d", "tomorrow")),
+		mcp.WithString("priority", mcp.Description("The new priority, by name or ID; 'high' matches '3 high'."), examples("3 high", "low")),
+		mcp.WithString("owner", mcp.Description("The new owner's user ID, login or email address."), examples("sam.support@example.com", "5")),
+		mcp.WithString("group", mcp.Description("Name of the group to move the tickets to.")),
+		mcp.WithArray("add_tags", mcp.Description("Tags to add to every ticket."), mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithArray("remove_tags", mcp.Description("Tags to remove from every ticket."), mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithBoolean("confirm", mcp.Description("Set to true to actually update the tickets. Default: false (dry run).")),
+	)
+	addTool(s, bulkUpdateTicketsTool, handleBulkUpdateTickets)
+
+	changeTicketStateTool := mcp.NewTool("change_ticket_state",
+		mcp.WithDescription("Moves a ticket to another state, given by its name as shown in Zammad ('open', 'pending reminder', 'closed'); the name is resolved to the instance's state ID. Transitions the core workflow does not allow are refused. Returns the updated ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("state", mcp.Required(), mcp.Description("The new state, by name. Common names such as 'resolved' or 'geschlossen' are mapped to the instance's states; see get_allowed_transitions."), examples("open", "closed", "pending reminder")),
+		mcp.WithString("pending_until", mcp.Description("Required with a pending state: when the reminder is due or the ticket is closed, as an RFC 3339 timestamp, an offset ('+3d') or a day with optional time ('Monday 9am')."), examples("+3d", "tomorrow")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, changeTicketStateTool, handleChangeTicketState)
+
+	setTicketPriorityTool := mcp.NewTool("set_ticket_priority",
+		mcp.WithDescription("Changes the priority of a ticket, given by name or ID. An invalid priority is rejected with the list of valid ones. Returns the updated ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("priority", mcp.Required(), mcp.Description("The new priority, by name or ID; 'high' matches '3 high'."), examples("3 high", "1 low", "2")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, setTicketPriorityTool, handleSetTicketPriority)
+
+	searchTicketsTool := mcp.NewTool("search_tickets",
+		mcp.WithDescription("Searches for Zammad tickets based on a query string."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The search query string to find tickets, passed to Zammad's search index as given: search terms, optionally with the full Zammad search syntax, i.e. fields (title:printer, state.name:open), AND/OR/NOT, wildcards and ranges (created_at:[now-7d TO now]). The filter arguments are combined with it using AND."), examples("printer", "title:printer AND customer.email:*@example.com")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of results to return. Default: 50, at most %d.", searchTicketsMaxLimit)), mcp.DefaultNumber(50)),
+		mcp.WithBoolean("vip_only", mcp.Description("Only return tickets whose customer or organization is marked VIP. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithString("state", mcp.Description("Only return tickets in this state, e.g. 'open' or 'closed'. Common synonyms and translated names (e.g. 'resolved', 'offen') are mapped to the instance's states."), examples("open", "closed")),
+		mcp.WithBoolean("snippets", mcp.Description("Add to each result the fragments of its title and articles that contain the query's search terms (up to 3), so you can tell why it matched without fetching the articles. Reads the articles of every returned ticket. Default: false.")),
+		createdWithinOption(),
+		updatedWithinOption(),
+		outputProfileOption(),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, searchTicketsTool, handleSearchTickets)
+
+	addNoteTool := mcp.NewTool("add_note_to_ticket",
+		mcp.WithDescription("Adds a note/comment to an existing Zammad ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to add a note to.")),
+		mcp.WithString("body", mcp.Required(), mcp.Description("The content of the note to add. Zammad variables such as #{ticket.title} or #{customer.firstname} are expanded.")),
+		mcp.WithBoolean("internal", mcp.Description("Whether the note is internal. Default: true."), mcp.DefaultBool(true)),
+		attachmentsOption(),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+	)
+	addTool(s, addNoteTool, handleAddNoteToTicket)
+
+	setArticleVisibilityTool := mcp.NewTool("set_article_visibility",
+		mcp.WithDescription("Makes an existing article internal or public, e.g. to share an internal note with the customer. Without internal, the visibility is flipped. A public article shows up in the customer portal, but no email is sent. Returns the updated article."),
+		mcp.WithNumber("article_id", mcp.Required(), mcp.Description("The ID of the article.")),
+		mcp.WithBoolean("internal", mcp.Description("true makes the article internal, false public. Default: the opposite of its current visibility.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+	)
+	addTool(s, setArticleVisibilityTool, handleSetArticleVisibility)
+
+	replyToTicketTool := mcp.NewTool("reply_to_ticket",
+		mcp.WithDescription("Answers a ticket by email: sends the body as a public email article from the ticket's group, to the customer unless other recipients are given. The group's signature is appended. Use add_note_to_ticket for internal notes."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to reply to.")),
+		mcp.WithString("body", mcp.Required(), mcp.Description("The text of the email. Zammad variables such as #{customer.firstname} are expanded.")),
+		mcp.WithString("to", mcp.Description("Comma-separated recipient addresses. Default: the ticket's customer."), examples("anna.smith@acme.example")),
+		mcp.WithString("cc", mcp.Description("Comma-separated addresses to copy."), examples("it-lead@acme.example, Bob Jones <bob.jones@acme.example>")),
+		mcp.WithString("subject", mcp.Description("The subject of the email. Default: the ticket's title.")),
+		mcp.WithBoolean("skip_signature", mcp.Description("Send without the group's signature. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("quote_previous", mcp.Description("Quote the customer's last message below the reply, with each line prefixed with '> '. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+	)
+	addTool(s, replyToTicketTool, handleReplyToTicket)
+
+	summarizeAndNoteTool := mcp.NewTool("summarize_and_note",
+		mcp.WithDescription("Asks the client's model (via MCP sampling) to summarize a ticket thread and stores the summary as an internal note marked as AI-generated. Requires a client that supports sampling."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to summarize.")),
+		mcp.WithString("instructions", mcp.Description("Additional instructions for the summary, e.g. 'focus on the agreed next steps'.")),
+		mcp.WithNumber("max_tokens", mcp.Description("Maximum length of the summary in tokens (default: 800).")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+	)
+	addTool(s, summarizeAndNoteTool, handleSummarizeAndNote)
+
+	getTicketTool := mcp.NewTool("get_ticket",
+		mcp.WithDescription("Retrieves details for a specific Zammad ticket by its ID, including time remaining until SLA escalation."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to retrieve.")),
+		outputProfileOption(),
+		noCacheOption(),
+	)
+	addTool(s, getTicketTool, handleGetTicket)
+
+	setCurrentTicketTool := mcp.NewTool("set_current_ticket",
+		mcp.WithDescription("Makes a ticket the current ticket of this session, so later calls can pass ticket_id \"current\" instead of repeating its ID. Returns the ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to work on.")),
+		outputProfileOption(),
+	)
+	addTool(s, setCurrentTicketTool, handleSetCurrentTicket)
+
+	getCurrentTicketTool := mcp.NewTool("get_current_ticket",
+		mcp.WithDescription("Returns the current ticket of this session, as set with set_current_ticket, with its current details."),
+		outputProfileOption(),
+	)
+	addTool(s, getCurrentTicketTool, handleGetCurrentTicket)
+
+	undoLastActionTool := mcp.NewTool("undo_last_action",
+		mcp.WithDescription("Reverses the most recent write of this session if it is reversible: restores the previous owner and group after handover_ticket, auto_assign_ticket, assign_ticket or move_ticket_to_group, the previous state after snooze_ticket or set_pending_time, and the previous state and group, tags and customer status after mark_as_spam. Notes and other writes cannot be undone. Refuses if the ticket was changed since, unless force is set."),
+		mcp.WithBoolean("force", mcp.Description("Restore the previous values even if the ticket was changed after the action. Default: false."), mcp.DefaultBool(false)),
+		outputProfileOption(),
+	)
+	addTool(s, undoLastActionTool, handleUndoLastAction)
+
+	approvePendingActionTool := mcp.NewTool(approveToolName,
+		mcp.WithDescription("In approval mode, write tools only stage their change as a pending action. This tool executes a pending action, or discards it with reject. Only call it with an id after the user has reviewed that action and explicitly approved or rejected it. Without id, lists the pending actions of this session."),
+		mcp.WithNumber("id", mcp.Description("ID of the pending action, as returned when it was staged. Omit to list the pending actions."), examples(1)),
+		mcp.WithBoolean("reject", mcp.Description("Discard the pending action instead of executing it. Default: false."), mcp.DefaultBool(false)),
+	)
+	addTool(s, approvePendingActionTool, handleApprovePendingAction(s))
+
+	importTicketsTool := mcp.NewTool("import_tickets",
+		mcp.WithDescription("Bulk-creates tickets from CSV (with header line) or JSON array rows with the columns title, customer, group, body and tags. Returns a per-row result report."),
+		mcp.WithString("data", mcp.Required(), mcp.Description("The CSV text or JSON array of row objects to import.")),
+		mcp.WithString("format", mcp.Description("Format of data: 'csv' or 'json'. Detected automatically if omitted."), mcp.Enum("csv", "json")),
+		mcp.WithString("default_group", mcp.Description("Group used for rows without a group column value.")),
+		mcp.WithNumber("batch_size", mcp.Description("Number of rows processed between progress notifications. Default: 10."), mcp.DefaultNumber(10)),
+	)
+	addTool(s, importTicketsTool, handleImportTickets)
+
+	importUsersTool := mcp.NewTool("import_users",
+		mcp.WithDescription("Bulk-creates customers from CSV (with header line) or JSON array rows with the columns email, firstname, lastname, phone, mobile, organization and note, e.g. to onboard a new client company's contacts. Rows whose email address already belongs to a user, or repeats an earlier row's, are reported as duplicates and not created. Returns a per-row result report."),
+		mcp.WithString("data", mcp.Required(), mcp.Description("The CSV text or JSON array of row objects to import. email is required in every row.")),
+		mcp.WithString("format", mcp.Description("Format of data: 'csv' or 'json'. Detected automatically if omitted."), mcp.Enum("csv", "json")),
+		mcp.WithString("default_organization", mcp.Description("Organization (name or ID) for rows without an organization column value.")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only check the rows for missing email addresses and duplicates and report what would be created. Default: false.")),
+		mcp.WithNumber("batch_size", mcp.Description("Number of rows processed between progress notifications. Default: 10."), mcp.DefaultNumber(10)),
+	)
+	addTool(s, importUsersTool, handleImportUsers)
+
+	diffTicketChangesTool := mcp.NewTool("diff_ticket_changes",
+		mcp.WithDescription("Reconstructs a readable list of changes to a ticket (e.g. 'priority: 2 normal → 3 high by Anna at 14:02') from its history, with the net before/after value of each changed attribute."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("since", mcp.Description("Only include changes at or after this RFC 3339 timestamp."), examples("2024-05-14T09:30:00Z")),
+		mcp.WithString("until", mcp.Description("Only include changes at or before this RFC 3339 timestamp."), examples("2024-05-14T17:00:00Z")),
+		noCacheOption(),
+	)
+	addTool(s, diffTicketChangesTool, handleDiffTicketChanges)
+
+	whoTouchedTicketTool := mcp.NewTool("who_touched_ticket",
+		mcp.WithDescription("Extracts from a ticket's history which agents worked on it and when: each agent's first and last touch with their number of changes and articles, the ticket's first and last touch with the time to first touch, and the total number of participants. Useful for workload and QA reviews."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		noCacheOption(),
+	)
+	addTool(s, whoTouchedTicketTool, handleWhoTouchedTicket)
+
+	getTicketTimelineTool := mcp.NewTool("get_ticket_timeline",
+		mcp.WithDescription("Returns the events of a ticket as one chronological array, merged from its articles, history and escalation deadlines, ready to render as a timeline. Each event has a time, a kind (created, article, state_change, priority_change, owner_change, group_change, attribute_change, tag_added, tag_removed, merge, escalation, deadline) and a summary, plus kind-specific fields."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("since", mcp.Description("Only include events at or after this RFC 3339 timestamp."), examples("2024-05-14T09:30:00Z")),
+		mcp.WithString("until", mcp.Description("Only include events at or before this RFC 3339 timestamp."), examples("2024-05-14T17:00:00Z")),
+		mcp.WithBoolean("include_internal", mcp.Description("Whether to include internal notes. Default: true."), mcp.DefaultBool(true)),
+		noCacheOption(),
+	)
+	addTool(s, getTicketTimelineTool, handleGetTicketTimeline)
+
+	getAllowedTransitionsTool := mcp.NewTool("get_allowed_transitions",
+		mcp.WithDescription("Reports which states a ticket can be moved to, based on the instance's state definitions and core workflow rules, so invalid transitions are not attempted."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		noCacheOption(),
+	)
+	addTool(s, getAllowedTransitionsTool, handleGetAllowedTransitions)
+
+	listUnassignedTicketsTool := mcp.NewTool("list_unassigned_tickets",
+		mcp.WithDescription("Lists new and open tickets that have no owner yet, oldest first, with how long each has been waiting. Use this to dispatch incoming work."),
+		mcp.WithString("group", mcp.Description("Only list tickets of this group.")),
+		mcp.WithBoolean("vip_only", mcp.Description("Only list tickets whose customer or organization is marked VIP. Default: false."), mcp.DefaultBool(false)),
+		createdWithinOption(),
+		updatedWithinOption(),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of tickets to return. Default: 50."), mcp.DefaultNumber(50)),
+		outputProfileOption(),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, listUnassignedTicketsTool, handleListUnassignedTickets)
+
+	listAwaitingFirstResponseTool := mcp.NewTool("list_awaiting_first_response",
+		mcp.WithDescription("Lists new and open tickets no agent has replied to yet, with their first response SLA status. Use this to decide what to answer first."),
+		mcp.WithString("group", mcp.Description("Only list tickets of this group.")),
+		mcp.WithString("sort", mcp.Description("'escalation' (default): closest first response escalation first, then oldest; 'created': oldest first."), mcp.Enum("escalation", "created")),
+		mcp.WithBoolean("vip_only", mcp.Description("Only list tickets whose customer or organization is marked VIP. Default: false."), mcp.DefaultBool(false)),
+		createdWithinOption(),
+		updatedWithinOption(),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of tickets to return. Default: 50."), mcp.DefaultNumber(50)),
+		outputProfileOption(),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, listAwaitingFirstResponseTool, handleListAwaitingFirstResponse)
+
+	listWaitingOnAgentTool := mcp.NewTool("list_waiting_on_agent",
+		mcp.WithDescription("Lists open tickets where the customer wrote last, longest waiting first, with how long since the customer's message. Use this to find conversations where the ball is in the agents' court."),
+		mcp.WithString("group", mcp.Description("Only list tickets of this group.")),
+		mcp.WithBoolean("vip_only", mcp.Description("Only list tickets whose customer or organization is marked VIP. Default: false."), mcp.DefaultBool(false)),
+		createdWithinOption(),
+		updatedWithinOption(),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of tickets to return. Default: 50."), mcp.DefaultNumber(50)),
+		outputProfileOption(),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, listWaitingOnAgentTool, handleListWaitingOnAgent)
+
+	handoverTicketTool := mcp.NewTool("handover_ticket",
+		mcp.WithDescription("Hands a ticket over to another agent: reassigns the owner, optionally moves it to another group, and posts an internal handover note. If the note cannot be posted, the reassignment is rolled back."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to hand over.")),
+		mcp.WithString("owner", mcp.Required(), mcp.Description("The new owner's user ID, login or email address."), examples("sam.support@example.com", "5")),
+		mcp.WithString("note", mcp.Required(), mcp.Description("The handover note: current status, what was tried, next steps. Zammad variables such as #{ticket.title} or #{customer.firstname} are expanded.")),
+		mcp.WithString("group", mcp.Description("Name of the group to move the ticket to. The new owner must be a member.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, handoverTicketTool, handleHandoverTicket)
+
+	autoAssignTicketTool := mcp.NewTool("auto_assign_ticket",
+		mcp.WithDescription("Assigns a ticket to an agent of its group: the one with the fewest open tickets, or the next one in turn. Only active agents with full access to the group who are not out of office are considered. Returns the chosen agent and the candidates."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to assign.")),
+		mcp.WithString("strategy", mcp.Enum(assignLeastOpen, assignRoundRobin), mcp.Description("How to choose the agent: 'least_open' picks the one owning the fewest open tickets, 'round_robin' the next one after the agent picked last for the group. Defaults to the configured strategy (least_open).")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, autoAssignTicketTool, handleAutoAssignTicket)
+
+	assignTicketTool := mcp.NewTool("assign_ticket",
+		mcp.WithDescription("Assigns a ticket to the given agent. The agent must be active and have full access to the ticket's group. Returns the updated ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to assign.")),
+		mcp.WithString("owner", mcp.Required(), mcp.Description("The new owner's user ID, login or email address."), examples("sam.support@example.com", "5")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, assignTicketTool, handleAssignTicket)
+
+	moveTicketToGroupTool := mcp.NewTool("move_ticket_to_group",
+		mcp.WithDescription("Moves a ticket to another group (department), given by name. The group must exist and be active. If the current owner has no full access to the new group, the ticket is unassigned. Returns the updated ticket."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to move.")),
+		mcp.WithString("group", mcp.Required(), mcp.Description("Name of the group to move the ticket to (case-insensitive)."), examples("Support", "Billing")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, moveTicketToGroupTool, handleMoveTicketToGroup)
+
+	changeTicketCustomerTool := mcp.NewTool("change_ticket_customer",
+		mcp.WithDescription("Moves a ticket to another customer, e.g. when it was filed under the wrong requester. The ticket's organization follows the new customer's. Returns the updated ticket and the customer."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("customer", mcp.Required(), mcp.Description("The new customer's user ID or email address."), examples("bob.jones@acme.example", "3")),
+		mcp.WithBoolean("create_customer", mcp.Description("Create a customer for an email address no user has. Default: false.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, changeTicketCustomerTool, handleChangeTicketCustomer)
+
+	getTicketSeenStateTool := mcp.NewTool("get_ticket_seen_state",
+		mcp.WithDescription("Tells whether the ticket is read or unread for the API user, i.e. whether the user has unseen online notifications about it, which the web UI shows as unread markers. Lists the notifications."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		noCacheOption(),
+	)
+	addTool(s, getTicketSeenStateTool, handleGetTicketSeenState)
+
+	markTicketSeenTool := mcp.NewTool("mark_ticket_seen",
+		mcp.WithDescription("Marks a ticket as read for the API user by marking the user's online notifications about it as seen, or as unread again with seen set to false. Use it after triaging a ticket so it does not keep appearing unread, or to leave a ticket unread for a human to look at."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithBoolean("seen", mcp.Description("true (default) marks the ticket as read, false as unread.")),
+	)
+	addTool(s, markTicketSeenTool, handleMarkTicketSeen)
+
+	snoozeTicketTool := mcp.NewTool("snooze_ticket",
+		mcp.WithDescription("Snoozes a ticket: sets it to the 'pending reminder' state with the pending time computed from an absolute or relative time. Times without a time zone use the business calendar's time zone."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to snooze.")),
+		mcp.WithString("until", mcp.Required(), mcp.Description("When the reminder is due: an RFC 3339 timestamp, an offset ('+3d', 'in 2 hours'), or a day with optional time ('tomorrow', 'Monday 9am', 'next business day', '17:30'). Days without a time use the start of business hours."), examples("tomorrow", "+3d", "2024-05-20T09:00:00+02:00")),
+		mcp.WithString("note", mcp.Description("Optional internal note explaining why the ticket is snoozed. Zammad variables such as #{ticket.title} or #{customer.firstname} are expanded.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, snoozeTicketTool, handleSnoozeTicket)
+
+	setPendingTimeTool := mcp.NewTool("set_pending_time",
+		mcp.WithDescription("Sets a ticket to 'pending reminder' or 'pending close' with a pending time given as a timestamp or relative to now (e.g. '+3d' to be reminded in 3 days), or moves the pending time of a ticket already in that state. Times without a time zone use the business calendar's time zone."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket.")),
+		mcp.WithString("pending_time", mcp.Required(), mcp.Description("When the reminder is due or the ticket closes: an ISO 8601 / RFC 3339 timestamp, an offset ('+3d', 'in 2 hours'), or a day with optional time ('tomorrow', 'Monday 9am', 'next business day'). Days without a time use the start of business hours."), examples("+3d", "tomorrow 9am", "2024-05-20T09:00:00+02:00")),
+		mcp.WithString("state", mcp.Enum("pending reminder", "pending close"), mcp.Description("'pending reminder' (default) reminds the owner at the pending time; 'pending close' closes the ticket then.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+		outputProfileOption(),
+	)
+	addTool(s, setPendingTimeTool, handleSetPendingTime)
+
+	markAsSpamTool := mcp.NewTool("mark_as_spam",
+		mcp.WithDescription("Applies the configured spam workflow to a ticket: sets the spam state (default: closed), adds the spam tag (default: spam), optionally moves it to a spam group and deactivates the customer. Reports the outcome of each step."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the spam ticket.")),
+		mcp.WithBoolean("deactivate_customer", mcp.Description("Whether to deactivate the ticket's customer. Defaults to the configured workflow.")),
+		mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+	)
+	addTool(s, markAsSpamTool, handleMarkAsSpam)
+
+	if config.AllowDelete {
+		deleteTicketTool := mcp.NewTool("delete_ticket",
+			mcp.WithDescription("Permanently deletes a ticket with all its articles and attachments. This cannot be undone; prefer closing or merging tickets, and only delete after the user explicitly asked for it. Requires confirm set to true."),
+			mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to delete.")),
+			mcp.WithBoolean("confirm", mcp.Required(), mcp.Description("Must be true to delete the ticket; set it only after the user confirmed the deletion.")),
+			mcp.WithString("expected_updated_at", mcp.Description(expectedUpdatedAtDescription), examples("2024-05-14T09:30:00Z")),
+			outputProfileOption(),
+		)
+		addTool(s, deleteTicketTool, handleDeleteTicket)
+	}
+
+	suggestPriorityTool := mcp.NewTool("suggest_priority",
+		mcp.WithDescription("Suggests a ticket priority from the impact and urgency of the issue using the configured priority matrix, so triage outcomes are consistent across agents. Returns the priority with the reasoning; it does not change the ticket."),
+		mcp.WithString("impact", mcp.Required(), mcp.Description("How much is affected. "+priorityLevelsDescription(config.PriorityMatrix.Impacts))),
+		mcp.WithString("urgency", mcp.Required(), mcp.Description("How time-critical it is. "+priorityLevelsDescription(config.PriorityMatrix.Urgencies))),
+		mcp.WithNumber("ticket_id", mcp.Description("Optional ticket to compare the suggestion with its current priority.")),
+	)
+	addTool(s, suggestPriorityTool, handleSuggestPriority)
+
+	// --- Report Tools ---
+	reportTicketTrendsTool := mcp.NewTool("report_ticket_trends",
+		mcp.WithDescription("Compares ticket volume in the last period with the period before: tickets created and closed, and the backlog of unclosed tickets at the end of each period, with absolute and relative changes computed server-side."),
+		mcp.WithString("period", mcp.Description("Length of the compared periods, ending now: 'day', 'week' (default) or 'month' (30 days)."), mcp.Enum("day", "week", "month")),
+		mcp.WithString("group", mcp.Description("Only count tickets of this group.")),
+		noCacheOption(),
+	)
+	addTool(s, reportTicketTrendsTool, handleReportTicketTrends)
+
+	reportTagUsageTool := mcp.NewTool("report_tag_usage",
+		mcp.WithDescription("Reports the most used tags of the tickets created in the last period, with the number and share of tickets per tag and the trend against the period before. Use it for taxonomy cleanup and to see what customers contact support about."),
+		mcp.WithString("period", mcp.Description("Length of the compared periods, ending now: 'day', 'week' or 'month' (30 days, default)."), mcp.Enum("day", "week", "month")),
+		mcp.WithString("group", mcp.Description("Only count tickets of this group.")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of tags to return, most used first. Default: 20.")),
+		noCacheOption(),
+	)
+	addTool(s, reportTagUsageTool, handleReportTagUsage)
+
+	reportCSATTool := mcp.NewTool("report_csat",
+		mcp.WithDescription("Aggregates customer satisfaction ratings of the tickets rated in the last period: number of responses, average rating, share of satisfied customers and the distribution of ratings, per group and overall, compared with the period before. Where ratings are stored (a ticket field or tags) is set in the server configuration."),
+		mcp.WithString("period", mcp.Description("Length of the compared periods, ending now: 'day', 'week' or 'month' (30 days, default)."), mcp.Enum("day", "week", "month")),
+		mcp.WithString("group", mcp.Description("Only count tickets of this group.")),
+		mcp.WithBoolean("by_group", mcp.Description("Break the current period down by group, unless group is given. Default: true."), mcp.DefaultBool(true)),
+		noCacheOption(),
+	)
+	addTool(s, reportCSATTool, handleReportCSAT)
+
+	reportLinkedIncidentsTool := mcp.NewTool("report_linked_incidents",
+		mcp.WithDescription("Lists the incidents linked to a problem ticket as its children, with each incident's state, priority and owner, and counts of open and closed incidents and of affected customers and organizations. Use it to track a major incident handled as one problem ticket with linked incident tickets."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the problem ticket.")),
+		mcp.WithBoolean("include_related", mcp.Description("Also list tickets linked as related (normal links), not only child tickets. Default: false."), mcp.DefaultBool(false)),
+		noCacheOption(),
+	)
+	addTool(s, reportLinkedIncidentsTool, handleReportLinkedIncidents)
+
+	broadcastUpdateToLinkedTicketsTool := mcp.NewTool("broadcast_update_to_linked_tickets",
+		mcp.WithDescription("Posts the same public update to every ticket linked to a master ticket as its child, e.g. to inform all customers affected by an outage. Returns a per-ticket report of what was posted, skipped or failed. A dry run unless confirm is true."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the master (problem) ticket.")),
+		mcp.WithString("body", mcp.Required(), mcp.Description("The text of the update. Zammad variables such as #{customer.firstname} are expanded per ticket.")),
+		mcp.WithString("subject", mcp.Description("Subject of the update. Emails default to the title of each ticket.")),
+		mcp.WithString("type", mcp.Enum("email", "note"), mcp.Description("'email' (default) sends the update to each ticket's customer; 'note' adds a public note, visible in the customer portal but not emailed.")),
+		mcp.WithBoolean("include_related", mcp.Description("Also post to tickets linked as related (normal links), not only child tickets. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("include_closed", mcp.Description("Also post to closed tickets. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("skip_signature", mcp.Description("Do not append the group signature to emails. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithBoolean("confirm", mcp.Description("Post the update. Without it, only the tickets it would be posted to are listed. Default: false."), mcp.DefaultBool(false)),
+	)
+	addTool(s, broadcastUpdateToLinkedTicketsTool, handleBroadcastUpdateToLinkedTickets)
+
+	reportChannelHealthTool := mcp.NewTool("report_channel_health",
+		mcp.WithDescription("Checks whether the support inbox works: reports each email channel's fetch and delivery status with the last error messages, when mail was last fetched, and flags failing or stalled channels. Requires the admin.channel_email permission."),
+	)
+	addTool(s, reportChannelHealthTool, handleReportChannelHealth)
+
+	reportTicketAgingTool := mcp.NewTool("report_ticket_aging",
+		mcp.WithDescription("Reports queue health: counts the new, open and pending tickets of each group by age since creation (under 1 day, 1-3 days, 3-7 days, over 7 days), with totals and the oldest ticket's age."),
+		mcp.WithString("group", mcp.Description("Only count tickets of this group.")),
+		createdWithinOption(),
+		updatedWithinOption(),
+		noCacheOption(),
+	)
+	addTool(s, reportTicketAgingTool, handleReportTicketAging)
+
+	// --- User Tools ---
+	getUserTool := mcp.NewTool("get_user",
+		mcp.WithDescription("Retrieves details for a specific Zammad user by their ID."),
+		mcp.WithNumber("user_id", mcp.Required(), mcp.Description("The ID of the user to retrieve.")),
+		noCacheOption(),
+	)
+	addTool(s, getUserTool, handleGetUser)
+
+	searchUsersTool := mcp.NewTool("search_users",
+		mcp.WithDescription("Searches for Zammad users based on a query string (e.g., email, login, name)."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The search query string.")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results. Default: 50."), mcp.DefaultNumber(50)),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, searchUsersTool, handleSearchUsers)
+
+	getTicketArticlesTool := mcp.NewTool("get_ticket_articles",
+		mcp.WithDescription("Retrieves all articles (communications) for a specific Zammad ticket, each with its detected language."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket whose articles are to be retrieved.")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of articles to return, oldest first. Default: all.")),
+		listFormatOption(),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		mcp.WithBoolean("translate", mcp.Description("Also return the bodies not in the server's target language translated, as translated_body next to the original. Requires a translation endpoint in the server configuration. Default: false.")),
+		noCacheOption(),
+	)
+	addTool(s, getTicketArticlesTool, handleGetTicketArticles)
+
+	searchInTicketTool := mcp.NewTool("search_in_ticket",
+		mcp.WithDescription("Searches the articles of a ticket for a text (case-insensitive) and returns only the matching passages with their article IDs and character offsets, instead of the whole thread."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket whose articles are searched.")),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The text to search for.")),
+		mcp.WithNumber("context_chars", mcp.Description("Characters of surrounding text to include on each side of a match (default: 150).")),
+		mcp.WithNumber("max_matches", mcp.Description("Maximum number of passages to return (default: 50).")),
+		mcp.WithString("cursor", mcp.Description(cursorDescription)),
+		noCacheOption(),
+	)
+	addTool(s, searchInTicketTool, handleSearchInTicket)
+
+	getAttachmentTextTool := mcp.NewTool("get_attachment_text",
+		mcp.WithDescription("Extracts the plain text of an article attachment server-side, so attached documents can be read without their binary content. Supports plain text, HTML, PDF, Word, Excel and PowerPoint (OOXML) and OpenDocument files within the server's attachment policy; scanned PDFs have no text."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket the article belongs to.")),
+		mcp.WithNumber("article_id", mcp.Required(), mcp.Description("The ID of the article with the attachment.")),
+		mcp.WithNumber("attachment_id", mcp.Description("The ID of the attachment. May be omitted if the article has a single attachment; otherwise the article's attachments are listed.")),
+		mcp.WithNumber("max_chars", mcp.Description(fmt.Sprintf("Maximum number of characters of text to return (default: %d).", defaultAttachmentTextChars))),
+		noCacheOption(),
+	)
+	addTool(s, getAttachmentTextTool, handleGetAttachmentText)
+
+	getAttachmentImageTool := mcp.NewTool("get_attachment_image",
+		mcp.WithDescription("Returns an image attachment of an article (JPEG, PNG, GIF or WebP) as image content, e.g. a screenshot of an error message. Images larger than the maximum dimension are downscaled and re-encoded to keep the payload small."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket the article belongs to.")),
+		mcp.WithNumber("article_id", mcp.Required(), mcp.Description("The ID of the article with the attachment.")),
+		mcp.WithNumber("attachment_id", mcp.Description("The ID of the attachment. May be omitted if the article has a single attachment; otherwise the article's attachments are listed.")),
+		mcp.WithNumber("max_dimension", mcp.Description(fmt.Sprintf("Longest side in pixels to downscale the image to; 0 returns the original. Default: %d, or as configured.", defaultAttachmentPolicy.ImageMaxDimension))),
+		noCacheOption(),
+	)
+	addTool(s, getAttachmentImageTool, handleGetAttachmentImage)
+
+	// Add create_user, update_user, delete_user tools here if needed
+
+	// --- Organization Tools ---
+	getOrganizationTool := mcp.NewTool("get_organization",
+		mcp.WithDescription("Retrieves a Zammad organization by its ID, including its note and custom attributes (e.g. account manager, contract tier)."),
+		mcp.WithNumber("organization_id", mcp.Required(), mcp.Description("The ID of the organization to retrieve.")),
+		noCacheOption(),
+	)
+	addTool(s, getOrganizationTool, handleGetOrganization)
+
+	updateOrganizationTool := mcp.NewTool("update_organization",
+		mcp.WithDescription("Updates an organization's note and/or custom attributes (fields defined in Zammad's object manager, e.g. account_manager, contract_tier). Built-in attributes such as name, domain or vip cannot be changed with this tool."),
+		mcp.WithNumber("organization_id", mcp.Required(), mcp.Description("The ID of the organization to update.")),
+		mcp.WithString("note", mcp.Description("The new note. Replaces the current note; an empty string clears it.")),
+		mcp.WithObject("attributes", mcp.Description("Custom attribute names mapped to their new values, e.g. {\"contract_tier\": \"gold\"}."), additionalProperties(true)),
+	)
+	addTool(s, updateOrganizationTool, handleUpdateOrganization)
+
+	findDuplicateOrganizationsTool := mcp.NewTool("find_duplicate_organizations",
+		mcp.WithDescription("Finds organizations that are probably the same company: same domain (ignoring www.) or same name ignoring case, punctuation and legal forms such as Inc or GmbH. Returns groups with member counts and a suggested organization to keep."),
+		mcp.WithBoolean("include_inactive", mcp.Description("Also consider inactive organizations. Default: false.")),
+		noCacheOption(),
+	)
+	addTool(s, findDuplicateOrganizationsTool, handleFindDuplicateOrganizations)
+
+	reassignUsersToOrganizationTool := mcp.NewTool("reassign_users_to_organization",
+		mcp.WithDescription("Moves all members of one organization to another, e.g. to consolidate duplicates found by find_duplicate_organizations. Without confirm it is a dry run that lists the users it would move. Existing tickets keep their organization."),
+		mcp.WithNumber("from_organization_id", mcp.Required(), mcp.Description("The organization whose members are moved.")),
+		mcp.WithNumber("to_organization_id", mcp.Required(), mcp.Description("The organization the members are moved to. Must be active.")),
+		mcp.WithBoolean("confirm", mcp.Description("Set to true to actually move the users. Default: false (dry run).")),
+		mcp.WithBoolean("deactivate_source", mcp.Description("Deactivate the source organization once all of its members were moved. Default: false.")),
+	)
+	addTool(s, reassignUsersToOrganizationTool, handleReassignUsersToOrganization)
+
+	exportOrganizationHistoryTool := mcp.NewTool("export_organization_history",
+		mcp.WithDescription("Exports all tickets of an organization (optionally including their articles) as a JSON archive, e.g. for offboarding or compliance requests."),
+		mcp.WithNumber("organization_id", mcp.Required(), mcp.Description("The ID of the organization to export.")),
+		mcp.WithBoolean("include_articles", mcp.Description("Whether to include every ticket's articles. Default: false."), mcp.DefaultBool(false)),
+		mcp.WithNumber("per_page", mcp.Description("Number of tickets fetched per page while exporting. Default: 100."), mcp.DefaultNumber(100)),
+		mcp.WithString("format", mcp.Enum("json", "jsonl"), mcp.DefaultString("json"), mcp.Description("'json' (default): one JSON document with the organization, the tickets and the totals; 'jsonl': JSON Lines, one ticket per line, for streaming into other tools. The totals are then only in the result text.")),
+		mcp.WithString("cursor", mcp.Description("Cursor from a previous export that stopped at the memory budget, to export the next part. Pass the same per_page.")),
+	)
+	addTool(s, exportOrganizationHistoryTool, handleExportOrganizationHistory)
+
+	exportTicketDocumentTool := mcp.NewTool("export_ticket_document",
+		mcp.WithDescription("Renders a ticket's header (number, state, priority, group, customer, owner, tags) and its full thread as a standalone HTML or PDF document, e.g. to attach the case record to a ticket in another system. Returns the document as an embedded resource."),
+		mcp.WithNumber("ticket_id", mcp.Required(), mcp.Description("The ID of the ticket to export.")),
+		mcp.WithString("format", mcp.Description("Document format. Default: html."), mcp.Enum("html", "pdf"), mcp.DefaultString("html")),
+		mcp.WithBoolean("include_internal", mcp.Description("Whether to include internal notes. Default: false, as the document is usually shared outside the team."), mcp.DefaultBool(false)),
+		noCacheOption(),
+	)
+	addTool(s, exportTicketDocumentTool, handleExportTicketDocument)
+
+	// --- Diagnostic Tools ---
+	debugToolSchemaTool := mcp.NewTool("debug_tool_schema",
+		mcp.WithDescription("Shows the input schemas of the server's tools exactly as served by tools/list, with the findings of the server's schema check. Use this to tell whether a tool's arguments are rendered wrongly by the server or by the client."),
+		mcp.WithString("tool", mcp.Description("Only show the schema of this tool. Default: all tools."), examples("search_tickets")),
+	)
+	addTool(s, debugToolSchemaTool, handleDebugToolSchema(s))
+}
+
+// --- Ticket Tool Handlers ---
+// createTicketResult is the outcome of create_ticket. The identifiers later
+// steps need, such as the number quoted to the customer, come first regardless
+// of the output profile.
+type createTicketResult struct {
+	ID     int             `json:"id"`
+	Number string          `json:"number"`
+	WebURL string          `json:"web_url,omitempty"`
+	Ticket json.RawMessage `json:"ticket"` // ticketRecord in the requested output profile
+	// Template is the name of the template the ticket was created from.
+	Template string `json:"template,omitempty"`
+	// CustomFields are the custom field values set on creation, which
+	// ticketRecord does not include.
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// Attachments are the names of the files attached to the first article.
+	Attachments []string `json:"attachments,omitempty"`
+	// RequestID is the idempotency key of the call; Duplicate is set when an
+	// earlier call with it created the ticket.
+	RequestID string `json:"request_id,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+}
+
+func handleCreateTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	title := mcp.ParseString(request, "title", "")
+	group := mcp.ParseString(request, "group", "")
+	customer := mcp.ParseString(request, "customer", "")
+	body := mcp.ParseString(request, "body", "")
+	articleType := mcp.ParseString(request, "type", "note")
+	internal := mcp.ParseBoolean(request, "internal", false)
+	skipSignature := mcp.ParseBoolean(request, "skip_signature", false)
+	customFields := mcp.ParseStringMap(request, "custom_fields", nil)
+	requestID := strings.TrimSpace(mcp.ParseString(request, "request_id", ""))
+	if len(requestID) > maxRequestIDLength {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument request_id: at most %d characters", maxRequestIDLength)), nil
+	}
+	var fromTemplate templateDefaults
+	templateName := ""
+	if ref := mcp.ParseString(request, "template", ""); ref != "" {
+		templates, err := fetchTicketTemplates()
+		if err != nil {
+			log.Printf("Error fetching ticket templates from Zammad: %v", err)
+			return mcp.NewToolResultErrorFromErr("Failed to load ticket templates", err), nil
+		}
+		template, err := matchTicketTemplate(templates, ref)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid argument template", err), nil
+		}
+		if fromTemplate, err = ticketTemplateDefaults(template); err != nil {
+			log.Printf("Error applying ticket template %d: %v", template.ID, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to apply template %q", template.Name), err), nil
+		}
+		templateName = template.Name
+		// Explicit arguments override the template.
+		title = cmp.Or(title, fromTemplate.Title)
+		group = cmp.Or(group, fromTemplate.Group)
+		customer = cmp.Or(customer, fromTemplate.Customer)
+		body = cmp.Or(body, fromTemplate.Body)
+		if _, given := request.Params.Arguments["internal"]; !given && fromTemplate.Internal != nil {
+			internal = *fromTemplate.Internal
+		}
+	}
+	if title == "" || group == "" || customer == "" || body == "" {
+		return mcp.NewToolResultError("Missing required arguments: title, group, customer, body (unless set by the template)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	if err := validateTicketCustomFields(customFields); err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument custom_fields", err), nil
+	}
+	uploads, refused := requestAttachments(request)
+	if refused != nil {
+		return refused, nil
+	}
+	var createdTicket zammad.Ticket
+	if config.Idempotency.TTL <= 0 {
+		requestID = ""
+	}
+	if requestID != "" {
+		earlier, err := createRequests.begin(ctx, requestID, requestFingerprint(request.Params.Arguments))
+		switch {
+		case errors.Is(err, errRequestIDReused):
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid argument request_id: %q was used for a create_ticket call with other arguments; pass a new request_id for a new ticket", requestID)), nil
+		case err != nil:
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Gave up waiting for the earlier call with request_id %q", requestID), err), nil
+		case earlier != nil:
+			return replayCreatedTicket(earlier.ticketID, requestID, profile)
+		}
+		defer func() { createRequests.finish(requestID, createdTicket.ID) }()
+		if config.Idempotency.CustomField != "" {
+			ticketID, err := findTicketByRequestID(requestID)
+			if err != nil {
+				log.Printf("Error searching for a ticket with request_id %q, creating it: %v", requestID, err)
+			}
+			if ticketID != 0 {
+				createdTicket.ID = ticketID
+				return replayCreatedTicket(ticketID, requestID, profile)
+			}
+		}
+	}
+	if articleType == "email" && !skipSignature {
+		signature, err := groupSignature(group)
+		if err != nil {
+			log.Printf("Error loading signature of group %q: %v", group, err)
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to load the signature of group %q (set skip_signature to send without it)", group), err), nil
+		}
+		body = appendSignature(body, signature)
+	}
+	ticket := zammad.Ticket{Title: title, Group: group, Customer: customer, Article: zammad.TicketArticle{Subject: fromTemplate.Subject, Body: body, Type: articleType, Internal: internal}}
+	fields := customFields
+	if fromTemplate.Fields != nil {
+		fields = fromTemplate.Fields
+		maps.Copy(fields, customFields)
+	}
+	if requestID != "" && config.Idempotency.CustomField != "" {
+		fields = maps.Clone(fields)
+		if fields == nil {
+			fields = make(map[string]any)
+		}
+		fields[config.Idempotency.CustomField] = requestID
+	}
+	if len(fields) == 0 && len(uploads) == 0 {
+		createdTicket, err = zammadClient.TicketCreate(ticket)
+	} else {
+		createdTicket, err = createTicketWithFields(ticket, fields, uploads)
+	}
+	if err != nil {
+		log.Printf("Error creating ticket in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to create ticket", err), nil
+	}
+	log.Printf("Successfully created ticket ID %d", createdTicket.ID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("created ticket %d", createdTicket.ID), nil, ticketObject(createdTicket.ID))
+	record := newTicketRecord(createdTicket)
+	ticketData, err := profile.project(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", record.ID, err) // Internal server error
+	}
+	result := createTicketResult{ID: record.ID, Number: record.Number, WebURL: record.WebURL, Ticket: ticketData, Template: templateName, CustomFields: customFields, Attachments: uploadFilenames(uploads), RequestID: requestID}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal created ticket: %w", err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket #%s created (ID %d): %s\n%s", record.Number, record.ID, record.WebURL, string(jsonData))), nil
+}
+
+// searchTicketsMaxLimit caps the limit of search_tickets, so one call cannot
+// ask Zammad for an unbounded number of tickets.
+const searchTicketsMaxLimit = 500
+
+func handleSearchTickets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := mcp.ParseString(request, "query", "")
+	limit := mcp.ParseInt(request, "limit", 50)
+	vipOnly := mcp.ParseBoolean(request, "vip_only", false)
+	if query == "" {
+		return mcp.NewToolResultError("Missing required argument: query"), nil
+	}
+	if limit <= 0 || limit > searchTicketsMaxLimit {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument limit: must be between 1 and %d", searchTicketsMaxLimit)), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	// One extra result tells whether there is another chunk.
+	searchLimit := offset + limit + 1
+	if vipOnly {
+		// VIP status is filtered locally, so search a larger candidate set.
+		searchLimit = max(searchLimit, queueSearchLimit)
+	}
+	terms := searchTerms(query)
+	stateName := mcp.ParseString(request, "state", "")
+	windows, err := requestDateWindows(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid argument", err), nil
+	}
+	var filters []string
+	if stateName != "" {
+		state, err := resolveTicketState(stateName)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Invalid argument state", err), nil
+		}
+		filters = append(filters, fmt.Sprintf("state.name:%q", state.Name))
+	}
+	if windows != "" {
+		filters = append(filters, windows)
+	}
+	if len(filters) > 0 {
+		query = fmt.Sprintf("(%s) AND %s", query, strings.Join(filters, " AND "))
+	}
+	tickets, err := searchTicketRecords(query, searchLimit)
+	if err != nil {
+		log.Printf("Error searching tickets in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to search tickets", err), nil
+	}
+	if vipOnly {
+		tickets = vipTickets(tickets)
+	}
+	tickets, next := pageOf(tickets, offset, limit)
+	log.Printf("Found %d tickets matching query '%s'", len(tickets), query)
+	var results any = tickets
+	if mcp.ParseBoolean(request, "snippets", false) {
+		results = withSnippets(tickets, terms)
+	}
+	resultData, err := profile.marshalList(results, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling search results: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to format search results", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Search Results (%d found):\n%s%s", len(tickets), string(resultData), moreResultsNote(request, next))), nil
+}
+
+func handleAddNoteToTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	body := mcp.ParseString(request, "body", "")
+	internal := mcp.ParseBoolean(request, "internal", true)
+	if ticketID <= 0 || body == "" {
+		return mcp.NewToolResultError("Missing or invalid required arguments: ticket_id, body"), nil
+	}
+	uploads, refused := requestAttachments(request)
+	if refused != nil {
+		return refused, nil
+	}
+	if conflict, err := checkTicketUnchanged(ticketID, mcp.ParseString(request, "expected_updated_at", "")); conflict != nil || err != nil {
+		return conflict, err
+	}
+	body, err := expandVariables(body, ticketID)
+	if err != nil {
+		log.Printf("Error expanding variables for ticket %d: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr("Failed to expand the variables in body", err), nil
+	}
+	article := zammad.TicketArticle{TicketID: ticketID, Body: body, Type: "note", Internal: internal}
+	var createdArticle articleWithAttachments
+	if len(uploads) == 0 {
+		createdArticle.TicketArticle, err = zammadClient.TicketArticleCreate(article)
+	} else {
+		createdArticle, err = createArticleWithAttachments(article, uploads)
+	}
+	if err != nil {
+		// Notes with attachments are not queued: queued articles cannot
+		// carry files.
+		if len(uploads) == 0 {
+			if queued := queueWriteOnOutage(queuedWrite{Kind: queuedTicketArticle, TicketID: ticketID, Article: &article}, err); queued != "" {
+				return mcp.NewToolResultText(queued), nil
+			}
+		}
+		log.Printf("Error adding note to ticket %d in Zammad: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to add note to ticket %d", ticketID), err), nil
+	}
+	log.Printf("Successfully added note (Article ID %d) to ticket ID %d", createdArticle.ID, ticketID)
+	sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("added note %d to ticket %d", createdArticle.ID, ticketID), nil, ticketObject(ticketID), articleObject(ticketID, createdArticle.ID))
+	var result any = createdArticle.TicketArticle
+	if len(uploads) > 0 {
+		result = createdArticle // with the attachment IDs
+	}
+	resultData, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(fmt.Sprintf("Note added successfully to ticket %d:\n%s", ticketID, string(resultData))), nil
+}
+
+func handleGetTicket(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	profile, err := requestOutputProfile(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument profile: %v", err)), nil
+	}
+	ticket, err := fetchTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching ticket %d from Zammad via tool: %v", ticketID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get ticket %d", ticketID), err), nil
+	}
+	lookupTicketVIP(&ticket)
+	log.Printf("Successfully retrieved ticket ID %d via tool", ticketID)
+	jsonData, err := profile.marshalIndent(ticket)
+	if err != nil {
+		log.Printf("Error marshalling ticket %d to JSON (tool): %v", ticketID, err)
+		return nil, fmt.Errorf("failed to marshal ticket %d: %w", ticketID, err) // Internal server error
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ticket %d details:\n%s", ticketID, string(jsonData))), nil
+}
+
+// --- User Tool Handlers --- <-- NEW HANDLERS
+
+// handleGetUser retrieves details for a specific user by ID using the tool.
+func handleGetUser(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	userID := mcp.ParseInt(request, "user_id", 0)
+
+	if userID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: user_id (must be a positive number)"), nil
+	}
+
+	user, err := fetchUserRecord(userID)
+	if err != nil {
+		log.Printf("Error fetching user %d from Zammad via tool: %v", userID, err)
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get user %d", userID), err), nil
+	}
+
+	log.Printf("Successfully retrieved user ID %d via tool", userID)
+	jsonData, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling user %d to JSON (tool): %v", userID, err)
+		return nil, fmt.Errorf("failed to marshal user %d: %w", userID, err) // Internal server error
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("User %d details:\n%s", userID, string(jsonData))), nil
+}
+
+// handleSearchUsers searches Zammad users.
+func handleSearchUsers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := mcp.ParseString(request, "query", "")
+	limit := mcp.ParseInt(request, "limit", 50) // Default limit 50
+
+	if query == "" {
+		return mcp.NewToolResultError("Missing required argument: query"), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+
+	users, err := searchUserRecords(query, offset+limit+1)
+	if err != nil {
+		log.Printf("Error searching users in Zammad: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to search users", err), nil
+	}
+	users, next := pageOf(users, offset, limit)
+
+	log.Printf("Found %d users matching query '%s'", len(users), query)
+	resultData, err := outputProfile(nil).marshalList(users, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling user search results: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to format user search results", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("User Search Results (%d found):\n%s%s", len(users), string(resultData), moreResultsNote(request, next))), nil
+}
+
+// --- Add create/update/delete user handlers here if needed ---
+
+// handleGetTicketArticles retrieves all articles for a specific ticket by ID using the tool.
+func handleGetTicketArticles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ticketID := mcp.ParseInt(request, "ticket_id", 0)
+	limit := mcp.ParseInt(request, "limit", 0)
+	translate := mcp.ParseBoolean(request, "translate", false)
+
+	if ticketID <= 0 {
+		return mcp.NewToolResultError("Missing or invalid required argument: ticket_id (must be a positive number)"), nil
+	}
+	if translate && !config.Translation.enabled() {
+		return mcp.NewToolResultError("Invalid argument translate: no translation endpoint is configured on the server (translation in the configuration file)"), nil
+	}
+	offset, err := requestOffset(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument cursor: %v", err)), nil
+	}
+	jsonLines, err := requestJSONLines(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid argument format: %v", err)), nil
+	}
+
+	articles, err := zammadClient.TicketArticleByTicket(ticketID)
+	if err != nil {
+		log.Printf("Error fetching articles for ticket %d from Zammad via tool: %v", ticketID, err)
+		// Consider if ticket not found should be a specific error
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to get articles for ticket %d", ticketID), err), nil
+	}
+	total := len(articles)
+	articles, next := pageOf(articles, offset, limit)
+
+	log.Printf("Successfully retrieved %d articles for ticket ID %d via tool", len(articles), ticketID)
+	views, translateErr := newArticleViews(ctx, articles, translate)
+	jsonData, err := outputProfile(nil).marshalList(views, jsonLines)
+	if err != nil {
+		log.Printf("Error marshalling articles for ticket %d to JSON (tool): %v", ticketID, err)
+		return nil, fmt.Errorf("failed to marshal articles for ticket %d: %w", ticketID, err) // Internal server error
+	}
+
+	header := fmt.Sprintf("Ticket %d Articles (%d found)", ticketID, total)
+	if len(articles) < total {
+		header += fmt.Sprintf(", showing %d-%d", offset+1, offset+len(articles))
+	}
+	if translateErr != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Partial failure: %s, but the translation failed (%v), so some are untranslated:\n%s%s", header, translateErr, string(jsonData), moreResultsNote(request, next))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n%s%s", header, string(jsonData), moreResultsNote(request, next))), nil
+}
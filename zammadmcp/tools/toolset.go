@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Setup installs the Zammad client and the settings the toolset works with.
+// Like the session state of the toolset, they are kept in package variables,
+// so a process serves a single Zammad instance. A client without an HTTP
+// client gets one bounded by the settings' http_timeout. If Setup fails, the
+// client and settings installed before stay in place.
+func Setup(client *zammad.Client, settings Config) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+	var audit *auditWriter
+	if settings.AuditLog != "" {
+		a, err := openAuditLog(settings.AuditLog)
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		audit = a
+	}
+	if client.Client == nil {
+		client.Client = newZammadHTTPClient(time.Duration(settings.HTTPTimeout))
+	}
+	client.Client = gatewayErrorDoer{next: metricsDoer{m: zammadStats, next: client.Client}}
+	config, auditLog, zammadClient = settings, audit, client
+	return nil
+}
+
+// ServerOptions returns the options an MCP server needs to serve the
+// toolset: its capabilities, session hooks, instructions and the given
+// middleware chain, usually DefaultMiddleware with additions. The hooks
+// replace any set with server.WithHooks before.
+func ServerOptions(chain []Middleware) []server.ServerOption {
+	hooks := sampler.hooks()
+	hooks.AddOnUnregisterSession(sessionTickets.forget)
+	hooks.AddOnUnregisterSession(sessionActions.forget)
+	hooks.AddOnUnregisterSession(sessionPending.forget)
+	hooks.AddOnUnregisterSession(sessionRates.forget)
+	options := []server.ServerOption{
+		// Enable necessary capabilities
+		server.WithResourceCapabilities(true, true), // Read resources, support list changes
+		server.WithToolCapabilities(true),           // Expose tools, support list changes
+		server.WithLogging(),                        // Enable MCP logging notifications
+		server.WithHooks(hooks),                     // Detect client sampling support, forget ended sessions
+		server.WithInstructions(serverInstructions()),
+	}
+	// The first middleware added is the outermost.
+	for _, m := range chain {
+		options = append(options, server.WithToolHandlerMiddleware(m.Wrap))
+	}
+	return options
+}
+
+// Register adds the Zammad resources and tools to s, which must have been
+// created with ServerOptions, and checks the tool schemas.
+func Register(s *server.MCPServer) error {
+	registerResources(s)
+	registerTools(s)
+	tools, err := servedTools(s)
+	if err != nil {
+		return fmt.Errorf("failed to read tool schemas: %w", err)
+	}
+	if problems := checkToolSchemas(tools); len(problems) > 0 {
+		return fmt.Errorf("invalid tool schemas:\n%s", strings.Join(problems, "\n"))
+	}
+	if err := loadToolArgumentTypes(tools); err != nil {
+		return fmt.Errorf("failed to read tool schemas: %w", err)
+	}
+	if config.AllowDelete {
+		log.Println("Ticket deletion enabled: delete_ticket is available.")
+	}
+	if config.ApprovalMode {
+		log.Printf("Approval mode enabled: calls of %s are staged until approved with %s.", strings.Join(stagedToolNames(), ", "), approveToolName)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	zammad "github.com/AlessandroSechi/zammad-go"
+)
+
+// maxTraceBody caps the bytes of a single message or HTTP body written to the
+// trace; longer ones are cut and marked.
+const maxTraceBody = 64 << 10
+
+// wireTracer writes the MCP messages exchanged with the client and the HTTP
+// exchanges with Zammad to a file, for debugging interoperability problems.
+// Secrets are redacted: Authorization and cookie headers, and JSON fields
+// whose names contain token, password or secret. Ticket content is not.
+type wireTracer struct {
+	path string
+
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// wireTrace is the tracer enabled by ZAMMAD_MCP_TRACE=wire, or nil. Its
+// methods do nothing on nil.
+var wireTrace *wireTracer
+
+// openWireTracer opens the trace file for the ZAMMAD_MCP_TRACE mode. The file
+// is appended to; path defaults to zammad-mcp-trace.log in the temporary
+// directory.
+func openWireTracer(mode, path string) (*wireTracer, error) {
+	if mode != "wire" {
+		return nil, fmt.Errorf("unknown ZAMMAD_MCP_TRACE %q: expected wire", mode)
+	}
+	if path == "" {
+		path = filepath.Join(os.TempDir(), "zammad-mcp-trace.log")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &wireTracer{path: path, w: f}, nil
+}
+
+// EnableWireTrace traces MCP messages and the requests to Zammad of the
+// client installed by Setup to a file, for the ZAMMAD_MCP_TRACE mode and
+// file.
+func EnableWireTrace(mode, path string) error {
+	t, err := openWireTracer(mode, path)
+	if err != nil {
+		return err
+	}
+	wireTrace = t
+	zammadClient.Client = tracingDoer{t: wireTrace, next: zammadClient.Client}
+	log.Printf("Tracing MCP messages and Zammad requests to %s (contains ticket data).", wireTrace.path)
+	return nil
+}
+
+// record writes one trace entry: a timestamped title line, followed by the
+// indented body, if any.
+func (t *wireTracer) record(title string, body []byte) {
+	if t == nil {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), title)
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 {
+		b.WriteString("  ")
+		b.WriteString(strings.ReplaceAll(string(body), "\n", "\n  "))
+		b.WriteByte('\n')
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write([]byte(b.String()))
+}
+
+// mcp records an MCP message; direction is "<-" for messages from the client
+// and "->" for messages to it.
+func (t *wireTracer) mcp(direction string, message []byte) {
+	if t == nil {
+		return
+	}
+	t.record("mcp "+direction, traceBody(message, len(message)))
+}
+
+// traceBody returns a message or HTTP body as written to the trace:
+// redacted if it is JSON, and cut to maxTraceBody. total is the full size of
+// the body, which may be larger than data if only a prefix was kept.
+func traceBody(data []byte, total int) []byte {
+	data = redactJSON(data)
+	if total > len(data) || len(data) > maxTraceBody {
+		cut := min(len(data), maxTraceBody)
+		return append(data[:cut:cut], fmt.Sprintf("… [%d bytes, cut]", total)...)
+	}
+	return data
+}
+
+// redactJSON replaces the values of secret fields in a JSON document. Other
+// documents, and JSON without secrets, are returned unchanged, so the trace
+// shows messages byte for byte where possible.
+func redactJSON(data []byte) []byte {
+	var v any
+	if json.Unmarshal(data, &v) != nil || !redactValue(v) {
+		return data
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+// redactValue redacts the secret fields of a decoded JSON value in place and
+// reports whether there were any.
+func redactValue(v any) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, isString := value.(string); isString && isSecretKey(key) {
+				v[key] = "[redacted]"
+				redacted = true
+			} else if redactValue(value) {
+				redacted = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if redactValue(value) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// traceHeaders formats HTTP headers for the trace, sorted by name, with
+// secret values redacted.
+func traceHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if isSecretKey(name) {
+			value = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, value)
+	}
+	return b.String()
+}
+
+// traceWriter records each write to the client, which is one MCP message on
+// the stdio transport, before passing it on.
+type traceWriter struct {
+	t *wireTracer
+	w io.Writer
+}
+
+func (w traceWriter) Write(p []byte) (int, error) {
+	w.t.mcp("->", p)
+	return w.w.Write(p)
+}
+
+// tracingDoer records the requests sent to Zammad and the responses. Request
+// bodies are read up front; response bodies are recorded when they are
+// closed, as far as they were read, so streamed responses stay streamed.
+type tracingDoer struct {
+	t    *wireTracer
+	next zammad.Doer
+}
+
+func (d tracingDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	title := fmt.Sprintf("zammad -> %s %s", req.Method, req.URL.Redacted())
+	d.t.record(title, append([]byte(traceHeaders(req.Header)), traceBody(body, len(body))...))
+
+	start := time.Now()
+	resp, err := d.next.Do(req)
+	if err != nil {
+		d.t.record(fmt.Sprintf("zammad <- %s %s failed after %s: %v", req.Method, req.URL.Path, time.Since(start).Round(time.Millisecond), err), nil)
+		return nil, err
+	}
+	resp.Body = &tracedBody{
+		ReadCloser: resp.Body,
+		t:          d.t,
+		title:      fmt.Sprintf("zammad <- %s %s: %s after %s", req.Method, req.URL.Path, resp.Status, time.Since(start).Round(time.Millisecond)),
+	}
+	return resp, nil
+}
+
+// tracedBody keeps the first maxTraceBody bytes read from a response body
+// and records them on Close.
+type tracedBody struct {
+	io.ReadCloser
+	t      *wireTracer
+	title  string
+	kept   []byte
+	total  int
+	closed bool
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.total += n
+	if room := maxTraceBody - len(b.kept); room > 0 {
+		b.kept = append(b.kept, p[:min(n, room)]...)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.t.record(b.title, traceBody(b.kept, b.total))
+	}
+	return b.ReadCloser.Close()
+}
+
+// traceHandler records the messages of the SSE transport: the bodies of
+// client POSTs and everything written back, including the event stream.
+func (t *wireTracer) traceHandler(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Body != nil {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			t.mcp("<-", body)
+		}
+		next.ServeHTTP(traceResponseWriter{ResponseWriter: w, t: t}, r)
+	})
+}
+
+// traceResponseWriter records writes to an HTTP response. It supports
+// flushing, which the event stream needs.
+type traceResponseWriter struct {
+	http.ResponseWriter
+	t *wireTracer
+}
+
+func (w traceResponseWriter) Write(p []byte) (int, error) {
+	w.t.mcp("->", p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w traceResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// Translation providers.
+const (
+	translateDeepL          = "deepl"
+	translateLibreTranslate = "libretranslate"
+)
+
+// translationSettings configure the translation endpoint of
+// get_ticket_articles. The API key is read from
+// ZAMMAD_MCP_TRANSLATION_API_KEY, so it stays out of the configuration file.
+type translationSettings struct {
+	// Provider is deepl or libretranslate; empty disables translation.
+	Provider string `yaml:"provider"`
+	// URL is the translate endpoint, e.g.
+	// https://api-free.deepl.com/v2/translate or
+	// https://libretranslate.example.com/translate.
+	URL string `yaml:"url"`
+	// TargetLanguage is the language bodies are translated into.
+	TargetLanguage string `yaml:"target_language"`
+	// Timeout bounds each request to the endpoint; zero means http_timeout.
+	Timeout duration `yaml:"timeout"`
+}
+
+// enabled reports whether a translation endpoint is configured.
+func (t translationSettings) enabled() bool {
+	return t.Provider != ""
+}
+
+// validate checks the settings of a configured endpoint.
+func (t translationSettings) validate() error {
+	if !t.enabled() {
+		return nil
+	}
+	if t.Provider != translateDeepL && t.Provider != translateLibreTranslate {
+		return fmt.Errorf("translation.provider %q must be %s or %s", t.Provider, translateDeepL, translateLibreTranslate)
+	}
+	if t.URL == "" {
+		return fmt.Errorf("translation.url is required with translation.provider")
+	}
+	if t.TargetLanguage == "" {
+		return fmt.Errorf("translation.target_language must not be empty")
+	}
+	return nil
+}
+
+// needsTranslation reports whether text detected as language should be
+// translated: unless it already is in the target language. Texts of unknown
+// language are translated, as the endpoint detects better.
+func (t translationSettings) needsTranslation(language string) bool {
+	target := strings.ToLower(t.TargetLanguage)
+	return language == "" || language != target && !strings.HasPrefix(target, language+"-")
+}
+
+// translate returns the translations of texts, which are HTML if html is set.
+func (t translationSettings) translate(ctx context.Context, texts []string, html bool) ([]string, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	timeout := time.Duration(t.Timeout)
+	if timeout <= 0 {
+		timeout = time.Duration(config.HTTPTimeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	key := os.Getenv("ZAMMAD_MCP_TRANSLATION_API_KEY")
+	var payload map[string]any
+	if t.Provider == translateDeepL {
+		payload = map[string]any{"text": texts, "target_lang": strings.ToUpper(t.TargetLanguage)}
+		if html {
+			payload["tag_handling"] = "html"
+		}
+	} else {
+		format := "text"
+		if html {
+			format = "html"
+		}
+		payload = map[string]any{"q": texts, "source": "auto", "target": t.TargetLanguage, "format": format}
+		if key != "" {
+			payload["api_key"] = key
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.Provider == translateDeepL && key != "" {
+		req.Header.Set("Authorization", "DeepL-Auth-Key "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := strings.TrimSpace(string(data))
+		if len(message) > 200 {
+			message = message[:200] + "..."
+		}
+		return nil, fmt.Errorf("%s answered %s: %s", t.Provider, resp.Status, message)
+	}
+
+	var translated []string
+	if t.Provider == translateDeepL {
+		var result struct {
+			Translations []struct {
+				Text string `json:"text"`
+			} `json:"translations"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s response: %w", t.Provider, err)
+		}
+		for _, translation := range result.Translations {
+			translated = append(translated, translation.Text)
+		}
+	} else {
+		var result struct {
+			TranslatedText []string `json:"translatedText"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s response: %w", t.Provider, err)
+		}
+		translated = result.TranslatedText
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d translations for %d texts", t.Provider, len(translated), len(texts))
+	}
+	return translated, nil
+}
+
+// articleView is an article as returned by get_ticket_articles, with its
+// detected language and, on request, its body translated.
+type articleView struct {
+	zammad.TicketArticle
+	Language       string `json:"language,omitempty"` // detected; empty if unclear
+	TranslatedBody string `json:"translated_body,omitempty"`
+	TranslatedTo   string `json:"translated_to,omitempty"`
+}
+
+// newArticleViews detects the language of articles and, if translate is set,
+// adds the translations of the bodies not in the target language. The
+// bodies are fenced as configured, the translations like their originals.
+// If the translation fails, the articles are returned untranslated with the
+// error.
+func newArticleViews(ctx context.Context, articles []zammad.TicketArticle, translate bool) ([]articleView, error) {
+	views := make([]articleView, len(articles))
+	for i, article := range articles {
+		views[i] = articleView{TicketArticle: fenceArticle(article), Language: detectLanguage(articlePlainText(article))}
+	}
+	if !translate {
+		return views, nil
+	}
+	settings := config.Translation
+	// Plain text and HTML bodies are sent in separate requests, as both
+	// providers take the format per request.
+	for _, html := range []bool{false, true} {
+		var indexes []int
+		var texts []string
+		for i, article := range articles {
+			isHTML := strings.Contains(article.ContentType, "html")
+			if isHTML != html || strings.TrimSpace(article.Body) == "" || !settings.needsTranslation(views[i].Language) {
+				continue
+			}
+			body := article.Body
+			if config.FenceCustomerContent {
+				body = stripHiddenContent(body)
+			}
+			indexes = append(indexes, i)
+			texts = append(texts, body)
+		}
+		translated, err := settings.translate(ctx, texts, html)
+		if err != nil {
+			log.Printf("Error translating %d articles with %s: %v", len(texts), settings.Provider, err)
+			return views, err
+		}
+		for j, i := range indexes {
+			text := translated[j]
+			if config.FenceCustomerContent && isCustomerArticle(articles[i]) {
+				text = fenceUntrusted(fmt.Sprintf("article %d translation", articles[i].ID), text)
+			}
+			views[i].TranslatedBody = text
+			views[i].TranslatedTo = settings.TargetLanguage
+		}
+	}
+	return views, nil
+}
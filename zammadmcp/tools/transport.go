@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultKeepAliveInterval is the ping interval of network transports. It is
+// well below the 60s idle timeout common to proxies and load balancers.
+const defaultKeepAliveInterval = 25 * time.Second
+
+// Serve runs s on the transport selected by ZAMMAD_MCP_TRANSPORT until it
+// fails or the process is asked to terminate.
+func Serve(s *server.MCPServer) error {
+	transport := strings.ToLower(os.Getenv("ZAMMAD_MCP_TRANSPORT"))
+	switch transport {
+	case "", "stdio":
+		log.Println("Starting Zammad MCP server via stdio...")
+		return sampler.serveStdio(s)
+	case "sse":
+		return serveSSE(s)
+	case "http", "streamable-http":
+		return fmt.Errorf("transport %q is not supported by the bundled mcp-go version, use sse", transport)
+	default:
+		return fmt.Errorf("unknown ZAMMAD_MCP_TRANSPORT %q: expected stdio or sse", transport)
+	}
+}
+
+// serveSSE serves s over HTTP with Server-Sent Events. Each session is pinged
+// every ZAMMAD_MCP_KEEPALIVE_INTERVAL so that idle connections are not cut by
+// proxies; an interval of 0 disables the pings.
+func serveSSE(s *server.MCPServer) error {
+	addr := os.Getenv("ZAMMAD_MCP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	baseURL := os.Getenv("ZAMMAD_MCP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost" + addr[strings.LastIndex(addr, ":"):]
+	}
+
+	keepAlive := defaultKeepAliveInterval
+	if v := os.Getenv("ZAMMAD_MCP_KEEPALIVE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid ZAMMAD_MCP_KEEPALIVE_INTERVAL %q: expected a duration such as 25s, or 0 to disable", v)
+		}
+		keepAlive = d
+	}
+
+	toolScheduler = newFairScheduler(config.Fairness)
+	if toolScheduler != nil {
+		log.Printf("Scheduling tool calls fairly across sessions, %d at a time.", toolScheduler.slots)
+	}
+
+	httpServer := &http.Server{Addr: addr}
+	opts := []server.SSEOption{server.WithBaseURL(baseURL), server.WithHTTPServer(httpServer)}
+	if keepAlive > 0 {
+		opts = append(opts, server.WithKeepAliveInterval(keepAlive))
+	}
+	sse := server.NewSSEServer(s, opts...)
+	httpServer.Handler = wireTrace.traceHandler(sse)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigChan
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := sse.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down SSE server: %v", err)
+		}
+	}()
+
+	if keepAlive > 0 {
+		log.Printf("Starting Zammad MCP server via SSE on %s (endpoint %s, keep-alive every %s)...", addr, sse.CompleteSseEndpoint(), keepAlive)
+	} else {
+		log.Printf("Starting Zammad MCP server via SSE on %s (endpoint %s, keep-alive disabled)...", addr, sse.CompleteSseEndpoint())
+	}
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// optional is an attribute of an update: left unset, so the update does not
+// touch it, set to a value, or cleared, which Zammad receives as null. The
+// fields of update structs are tagged omitzero, so unset attributes are left
+// out of the request instead of being sent as zero values.
+type optional[T any] struct {
+	set   bool
+	value *T // nil clears the attribute
+}
+
+// setTo returns an attribute set to v.
+func setTo[T any](v T) optional[T] {
+	return optional[T]{set: true, value: &v}
+}
+
+// cleared returns an attribute set to null.
+func cleared[T any]() optional[T] {
+	return optional[T]{set: true}
+}
+
+// IsZero reports whether the attribute is unset, for omitzero.
+func (o optional[T]) IsZero() bool {
+	return !o.set
+}
+
+func (o optional[T]) MarshalJSON() ([]byte, error) {
+	if o.value == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*o.value)
+}
+
+// pendingAt sets a pending time, in whole seconds as Zammad stores it.
+func pendingAt(when time.Time) optional[time.Time] {
+	return setTo(when.UTC().Truncate(time.Second))
+}
+
+// updateArticle is an article posted together with a ticket update, such as
+// the note explaining a snooze.
+type updateArticle struct {
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	ContentType string `json:"content_type"`
+	Type        string `json:"type"`
+	Internal    bool   `json:"internal"`
+}
+
+// ticketUpdate is a change of a ticket. Only the attributes that are set are
+// sent, so an update can clear an attribute as well as leave it untouched.
+type ticketUpdate struct {
+	Title          optional[string]    `json:"title,omitzero"`
+	GroupID        optional[int]       `json:"group_id,omitzero"`
+	StateID        optional[int]       `json:"state_id,omitzero"`
+	PriorityID     optional[int]       `json:"priority_id,omitzero"`
+	OwnerID        optional[int]       `json:"owner_id,omitzero"`
+	CustomerID     optional[int]       `json:"customer_id,omitzero"`
+	OrganizationID optional[int]       `json:"organization_id,omitzero"`
+	PendingTime    optional[time.Time] `json:"pending_time,omitzero"`
+	Article        *updateArticle      `json:"article,omitempty"`
+	// CustomFields sets custom ticket attributes by name; nil clears one.
+	CustomFields map[string]any `json:"-"`
+}
+
+func (u ticketUpdate) MarshalJSON() ([]byte, error) {
+	type core ticketUpdate // without this method
+	data, err := json.Marshal(core(u))
+	if err != nil || len(u.CustomFields) == 0 {
+		return data, err
+	}
+	attributes := make(map[string]any)
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+	for name, value := range u.CustomFields {
+		attributes[name] = value
+	}
+	return json.Marshal(attributes)
+}
+
+// empty reports whether the update changes nothing.
+func (u ticketUpdate) empty() bool {
+	return !u.Title.set && !u.GroupID.set && !u.StateID.set && !u.PriorityID.set &&
+		!u.OwnerID.set && !u.CustomerID.set && !u.OrganizationID.set && !u.PendingTime.set && u.Article == nil && len(u.CustomFields) == 0
+}
+
+// describe lists the attributes the update sets with their values, sorted,
+// e.g. "owner_id 5" or "pending_time cleared".
+func (u ticketUpdate) describe() []string {
+	u.Article = nil
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil
+	}
+	var attributes map[string]any
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil
+	}
+	var described []string
+	for name, value := range attributes {
+		if value == nil {
+			value = "cleared"
+		}
+		described = append(described, fmt.Sprintf("%s %v", name, value))
+	}
+	sort.Strings(described)
+	return described
+}
+
+// updateTicket applies an update to a ticket and returns the updated ticket.
+// Unlike zammad-go's TicketUpdate, it only sends the attributes being changed.
+func updateTicket(ticketID int, u ticketUpdate) (ticketRecord, error) {
+	var ticket ticketRecord
+	if err := zammadRequest(http.MethodPut, fmt.Sprintf("/api/v1/tickets/%d", ticketID), u, &ticket); err != nil {
+		return ticket, err
+	}
+	tickets := []ticketRecord{ticket}
+	enrichTickets(tickets)
+	return tickets[0], nil
+}
+
+// userUpdate is a change of a user, sent like a ticketUpdate.
+type userUpdate struct {
+	Active         optional[bool] `json:"active,omitzero"`
+	OrganizationID optional[int]  `json:"organization_id,omitzero"`
+}
+
+// updateUser applies an update to a user.
+func updateUser(userID int, u userUpdate) error {
+	return zammadRequest(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", userID), u, nil)
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// userImportRowResult reports the outcome of importing one user row.
+type userImportRowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"` // created, would_create, duplicate or failed
+	Email  string `json:"email,omitempty"`
+	UserID int    `json:"user_id,omitempty"` // the created user, or the existing one for duplicates
+	WebURL string `json:"web_url,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// userImportColumns are the row columns sent to Zammad as user attributes.
+var userImportColumns = []string{"firstname", "lastname", "phone", "mobile", "note"}
+
+// findUserByEmail returns the user with exactly the given email address, or
+// nil if there is none.
+func findUserByEmail(email string) (*userRecord, error) {
+	users, err := searchUserRecords(fmt.Sprintf("email:%q", email), 10)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Email, email) {
+			return &u, nil
+		}
+	}
+	return nil, nil
+}
+
+// handleImportUsers creates one customer per CSV/JSON row, skipping rows
+// whose email address is already taken in Zammad or by an earlier row, and
+// returns a per-row result report.
+func handleImportUsers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format := mcp.ParseString(request, "format", "")
+	data := mcp.ParseString(request, "data", "")
+	defaultOrganization := mcp.ParseString(request, "default_organization", "")
+	dryRun := mcp.ParseBoolean(request, "dry_run", false)
+	batchSize := mcp.ParseInt(request, "batch_size", 10)
+	if data == "" {
+		return mcp.NewToolResultError("Missing required argument: data"), nil
+	}
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+
+	rows, err := parseImportRows(format, data)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to parse import data", err), nil
+	}
+	if len(rows) == 0 {
+		return mcp.NewToolResultError("Import data contains no rows"), nil
+	}
+
+	results := make([]userImportRowResult, 0, len(rows))
+	seen := make(map[string]int) // lower-cased email to row number
+	counts := make(map[string]int)
+	for start := 0; start < len(rows); start += batchSize {
+		if err := yieldTurn(ctx); err != nil {
+			log.Printf("User import cancelled after %d rows: %v", start, err)
+			break
+		}
+
+		end := min(start+batchSize, len(rows))
+		for i := start; i < end; i++ {
+			result := importUserRow(i+1, rows[i], defaultOrganization, dryRun, seen)
+			counts[result.Status]++
+			results = append(results, result)
+		}
+
+		log.Printf("Imported batch of users: %d/%d rows processed", end, len(rows))
+		sendProgress(ctx, request, float64(end), float64(len(rows)), fmt.Sprintf("%d of %d rows processed", end, len(rows)))
+	}
+
+	if counts["created"] > 0 {
+		var objects []actionObject
+		for _, result := range results {
+			if result.Status == "created" {
+				objects = append(objects, userObject(result.UserID))
+			}
+		}
+		sessionActions.record(ctx, request.Params.Name, fmt.Sprintf("imported %d users", counts["created"]), nil, objects...)
+	}
+
+	resultData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling import report: %v", err)
+		return mcp.NewToolResultErrorFromErr("Failed to format import report", err), nil
+	}
+	summary := fmt.Sprintf("User import finished: %d of %d rows created, %d duplicates, %d failed.", counts["created"], len(rows), counts["duplicate"], counts["failed"])
+	if dryRun {
+		summary = fmt.Sprintf("User import dry run: %d of %d rows would be created, %d duplicates, %d failed. Nothing was changed.", counts["would_create"], len(rows), counts["duplicate"], counts["failed"])
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(resultData))), nil
+}
+
+// importUserRow creates the customer of a single row unless its email
+// address is already in use. seen records the rows' addresses so far.
+func importUserRow(rowNumber int, row importRow, defaultOrganization string, dryRun bool, seen map[string]int) userImportRowResult {
+	email := row["email"]
+	result := userImportRowResult{Row: rowNumber, Email: email}
+	if email == "" || !strings.Contains(email, "@") {
+		result.Status = "failed"
+		result.Error = "missing or invalid email column"
+		return result
+	}
+
+	key := strings.ToLower(email)
+	if first, ok := seen[key]; ok {
+		result.Status = "duplicate"
+		result.Error = fmt.Sprintf("same email address as row %d", first)
+		return result
+	}
+	seen[key] = rowNumber
+
+	existing, err := findUserByEmail(email)
+	if err != nil {
+		log.Printf("Error looking up user row %d: %v", rowNumber, err)
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("duplicate check failed: %v", err)
+		return result
+	}
+	if existing != nil {
+		result.Status = "duplicate"
+		result.UserID = existing.ID
+		result.WebURL = existing.WebURL
+		result.Error = "a user with this email address already exists"
+		return result
+	}
+	if dryRun {
+		result.Status = "would_create"
+		return result
+	}
+
+	payload := map[string]any{"email": email, "roles": []string{"Customer"}}
+	for _, column := range userImportColumns {
+		if value := row[column]; value != "" {
+			payload[column] = value
+		}
+	}
+	organization := row["organization"]
+	if organization == "" {
+		organization = defaultOrganization
+	}
+	if id, err := strconv.Atoi(organization); err == nil {
+		payload["organization_id"] = id
+	} else if organization != "" {
+		// Zammad resolves organizations by name.
+		payload["organization"] = organization
+	}
+
+	var user userRecord
+	if err := zammadRequest(http.MethodPost, "/api/v1/users", payload, &user); err != nil {
+		log.Printf("Error importing user row %d: %v", rowNumber, err)
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "created"
+	result.UserID = user.ID
+	result.WebURL = userWebURL(user.ID)
+	return result
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// userRecord is a Zammad user as returned by this server's tools and
+// resources, with a link to the user's profile in the web UI.
+type userRecord struct {
+	zammad.User
+	// VIP is not modelled by zammad-go; it is decoded from the API response.
+	VIP    bool   `json:"vip"`
+	WebURL string `json:"web_url"`
+}
+
+// fetchUserRecord retrieves a single user.
+func fetchUserRecord(userID int) (userRecord, error) {
+	var user userRecord
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", userID), nil, &user); err != nil {
+		return user, err
+	}
+	user.WebURL = userWebURL(user.ID)
+	return user, nil
+}
+
+// searchUserRecords runs a user search.
+func searchUserRecords(query string, limit int) ([]userRecord, error) {
+	users := make([]userRecord, 0)
+	path := fmt.Sprintf("/api/v1/users/search?query=%s&limit=%d", url.QueryEscape(query), limit)
+	if err := zammadRequest(http.MethodGet, path, nil, &users); err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].WebURL = userWebURL(users[i].ID)
+	}
+	return users, nil
+}
+
+// listUserRecords pages through /api/v1/users from the given position and
+// returns the users accessible by the API token, stopping early with a
+// continuation if the memory budget is exhausted.
+func listUserRecords(from continuation, budget *memoryBudget) ([]userRecord, *continuation, error) {
+	users, next, err := listPaged[userRecord]("/api/v1/users", from, budget)
+	if err != nil {
+		return users, nil, err
+	}
+	for i := range users {
+		users[i].WebURL = userWebURL(users[i].ID)
+	}
+	return users, next, nil
+}
+
+// organizationRecord is a Zammad organization with a link to its profile in
+// the web UI.
+type organizationRecord struct {
+	zammad.Organization
+	WebURL string `json:"web_url"`
+}
+
+func newOrganizationRecord(organization zammad.Organization) organizationRecord {
+	return organizationRecord{Organization: organization, WebURL: organizationWebURL(organization.ID)}
+}
+
+// resolveUser finds a user by ID, login or email address. Other references
+// are searched for and must match exactly one user.
+func resolveUser(ref string) (zammad.User, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return zammadClient.UserShow(id)
+	}
+	users, err := zammadClient.UserSearch(ref, 10)
+	if err != nil {
+		return zammad.User{}, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Login, ref) || strings.EqualFold(u.Email, ref) {
+			return u, nil
+		}
+	}
+	switch len(users) {
+	case 0:
+		return zammad.User{}, fmt.Errorf("no user matches %q", ref)
+	case 1:
+		return users[0], nil
+	default:
+		return zammad.User{}, fmt.Errorf("%q matches %d users; use the user ID, login or email address", ref, len(users))
+	}
+}
+
+// userDisplayName returns the user's full name, or the login if it is empty.
+func userDisplayName(user zammad.User) string {
+	if name := strings.TrimSpace(user.Firstname + " " + user.Lastname); name != "" {
+		return name
+	}
+	return user.Login
+}
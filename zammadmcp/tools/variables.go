@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variablePattern matches Zammad-style variables such as #{ticket.title}.
+var variablePattern = regexp.MustCompile(`#\{\s*([a-z_]+(?:\.[a-z0-9_]+)+)\s*\}`)
+
+// variableScope resolves the objects that variables refer to, fetching each
+// one at most once.
+type variableScope struct {
+	ticketID int
+	objects  map[string]map[string]any
+}
+
+// expandVariables replaces Zammad-style variables in text the way Zammad does
+// for text modules and signatures. Supported objects are ticket, customer,
+// owner, organization (the ticket's) and user (the agent the API acts as);
+// ticket.customer.*, ticket.owner.* and ticket.organization.* are accepted as
+// aliases, and ticket.group.name, ticket.state.name and ticket.priority.name
+// give the names of the ticket's group, state and priority. Without a ticket
+// (ticketID 0) only user.* is available. Empty attributes become "-", as in
+// Zammad; unknown variables are an error, so that no half-filled text is sent.
+func expandVariables(text string, ticketID int) (string, error) {
+	if !strings.Contains(text, "#{") {
+		return text, nil
+	}
+	scope := &variableScope{ticketID: ticketID, objects: make(map[string]map[string]any)}
+	var unknown []string
+	var fetchErr error
+	expanded := variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		path := variablePattern.FindStringSubmatch(match)[1]
+		value, ok, err := scope.lookup(strings.Split(path, "."))
+		if err != nil {
+			fetchErr = err
+			return match
+		}
+		if !ok {
+			unknown = append(unknown, "#{"+path+"}")
+			return match
+		}
+		return value
+	})
+	if fetchErr != nil {
+		return "", fetchErr
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+	}
+	return expanded, nil
+}
+
+// lookup resolves a variable path such as ["ticket", "customer", "email"].
+func (s *variableScope) lookup(path []string) (string, bool, error) {
+	if path[0] == "ticket" && len(path) == 3 {
+		switch path[1] {
+		case "customer", "owner", "organization":
+			path = path[1:]
+		case "group", "state", "priority":
+			if path[2] != "name" {
+				return "", false, nil
+			}
+			path = path[:2]
+		}
+	}
+	if len(path) != 2 {
+		return "", false, nil
+	}
+	object, ok, err := s.object(path[0])
+	if err != nil || !ok {
+		return "", ok, err
+	}
+	if object == nil {
+		return "-", true, nil
+	}
+	value, ok := object[path[1]]
+	if !ok {
+		return "", false, nil
+	}
+	if value == nil || value == "" {
+		return "-", true, nil
+	}
+	return fmt.Sprint(value), true, nil
+}
+
+// object returns the named object, or nil if the ticket has none (such as a
+// customer without organization). ok is false for unknown object names.
+func (s *variableScope) object(name string) (object map[string]any, ok bool, err error) {
+	if object, cached := s.objects[name]; cached {
+		return object, true, nil
+	}
+	var path string
+	switch name {
+	case "user":
+		path = "/api/v1/users/me"
+	case "ticket":
+		if s.ticketID <= 0 {
+			return nil, false, nil
+		}
+		path = fmt.Sprintf("/api/v1/tickets/%d?expand=true", s.ticketID)
+	case "customer", "owner", "organization":
+		if s.ticketID <= 0 {
+			return nil, false, nil
+		}
+		ticket, _, err := s.object("ticket")
+		if err != nil {
+			return nil, false, err
+		}
+		id := 0
+		if n, isNumber := ticket[name+"_id"].(float64); isNumber {
+			id = int(n)
+		}
+		if id <= 0 || (name == "owner" && id == unassignedOwnerID) {
+			s.objects[name] = nil
+			return nil, true, nil
+		}
+		if name == "organization" {
+			path = fmt.Sprintf("/api/v1/organizations/%d", id)
+		} else {
+			path = fmt.Sprintf("/api/v1/users/%d", id)
+		}
+	default:
+		return nil, false, nil
+	}
+	if err := zammadRequest(http.MethodGet, path, nil, &object); err != nil {
+		return nil, false, fmt.Errorf("failed to resolve variables of %s: %w", name, err)
+	}
+	s.objects[name] = object
+	return object, true, nil
+}
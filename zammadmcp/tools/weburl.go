@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// zammadWebURL returns a deep link into the Zammad web UI for the given
+// fragment, e.g. "ticket/zoom/42".
+func zammadWebURL(fragment string) string {
+	return strings.TrimRight(zammadClient.Url, "/") + "/#" + fragment
+}
+
+func ticketWebURL(ticketID int) string {
+	return zammadWebURL(fmt.Sprintf("ticket/zoom/%d", ticketID))
+}
+
+func userWebURL(userID int) string {
+	return zammadWebURL(fmt.Sprintf("user/profile/%d", userID))
+}
+
+func organizationWebURL(organizationID int) string {
+	return zammadWebURL(fmt.Sprintf("organization/profile/%d", organizationID))
+}
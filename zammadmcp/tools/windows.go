@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dateWindowNames are the values of the created_within and updated_within
+// arguments. Calendar windows (today, this_week, ...) follow the business
+// calendar's time zone; weeks start on Monday.
+var dateWindowNames = []string{"today", "yesterday", "this_week", "last_7_days", "this_month", "last_30_days"}
+
+// dateWindow returns the time range of a named window at now. Windows end at
+// now, except yesterday, which ends at midnight.
+func dateWindow(name string, now time.Time) (reportWindow, error) {
+	// Whole minutes keep repeated calls identical for the response cache.
+	now = now.In(displayLocation()).Truncate(time.Minute)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch name {
+	case "today":
+		return reportWindow{From: midnight, To: now}, nil
+	case "yesterday":
+		return reportWindow{From: midnight.AddDate(0, 0, -1), To: midnight}, nil
+	case "this_week":
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		return reportWindow{From: midnight.AddDate(0, 0, -daysSinceMonday), To: now}, nil
+	case "last_7_days":
+		return reportWindow{From: now.AddDate(0, 0, -7), To: now}, nil
+	case "this_month":
+		return reportWindow{From: midnight.AddDate(0, 0, 1-now.Day()), To: now}, nil
+	case "last_30_days":
+		return reportWindow{From: now.AddDate(0, 0, -30), To: now}, nil
+	}
+	return reportWindow{}, fmt.Errorf("unknown window %q (one of: %s)", name, strings.Join(dateWindowNames, ", "))
+}
+
+// createdWithinOption and updatedWithinOption are the date window arguments
+// of search and report tools.
+func createdWithinOption() mcp.ToolOption {
+	return mcp.WithString("created_within", mcp.Enum(dateWindowNames...),
+		mcp.Description("Only include tickets created in this window: today, yesterday, this_week (since Monday), last_7_days, this_month or last_30_days."))
+}
+
+func updatedWithinOption() mcp.ToolOption {
+	return mcp.WithString("updated_within", mcp.Enum(dateWindowNames...),
+		mcp.Description("Only include tickets last updated in this window: today, yesterday, this_week (since Monday), last_7_days, this_month or last_30_days."))
+}
+
+// requestDateWindows returns the search clauses for the created_within and
+// updated_within arguments of a request, joined with AND, or "" if neither is
+// given.
+func requestDateWindows(request mcp.CallToolRequest) (string, error) {
+	var clauses []string
+	for _, arg := range []struct{ name, field string }{{"created_within", "created_at"}, {"updated_within", "updated_at"}} {
+		name := mcp.ParseString(request, arg.name, "")
+		if name == "" {
+			continue
+		}
+		window, err := dateWindow(name, time.Now())
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", arg.name, err)
+		}
+		clauses = append(clauses, window.rangeQuery(arg.field))
+	}
+	return strings.Join(clauses, " AND "), nil
+}
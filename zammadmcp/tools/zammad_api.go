@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlessandroSechi/zammad-go"
+)
+
+// zammadMaxIdleConns is how many idle connections to Zammad the HTTP client
+// keeps for reuse.
+const zammadMaxIdleConns = 16
+
+// newZammadHTTPClient returns the HTTP client for the Zammad API. Like any
+// net/http transport, it requests gzip-compressed responses and decodes them
+// transparently; requests must therefore not set Accept-Encoding themselves,
+// or the body is passed through undecoded.
+func newZammadHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Concurrent tool calls and paged exports reuse connections instead of
+	// paying a TLS handshake per request.
+	transport.MaxIdleConnsPerHost = zammadMaxIdleConns
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = zammadStats.countConnections(dialer.DialContext)
+	// Large list responses are read in fewer syscalls.
+	transport.ReadBufferSize = 64 << 10
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// zammadAPIError is returned by zammadRequest when Zammad answers with a
+// non-2xx status code.
+type zammadAPIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *zammadAPIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s: %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// zammadRequest calls a Zammad REST endpoint that the zammad-go client does not
+// cover (or does not decode completely) and decodes the JSON response into v.
+// path is relative to ZAMMAD_URL, e.g. "/api/v1/calendars". v may be nil if
+// the response body is not needed. Endpoints used by several tools have typed
+// wrappers in zammad_endpoints.go.
+func zammadRequest(method, path string, payload, v any) error {
+	req, err := zammadClient.NewRequest(method, zammadClient.Url+path, payload)
+	if err != nil {
+		return err
+	}
+	if zammadClient.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Token token=%s", zammadClient.Token))
+	}
+
+	resp, err := zammadClient.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newZammadAPIError(method, path, resp)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// newZammadAPIError reads the error message of a non-2xx response.
+func newZammadAPIError(method, path string, resp *http.Response) *zammadAPIError {
+	apiErr := &zammadAPIError{Method: method, Path: path, StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var errResp struct {
+		Error      string `json:"error"`
+		ErrorHuman string `json:"error_human"`
+	}
+	if json.Unmarshal(data, &errResp) == nil {
+		apiErr.Message = errResp.ErrorHuman
+		if apiErr.Message == "" {
+			apiErr.Message = errResp.Error
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if len(apiErr.Message) > 200 {
+			apiErr.Message = apiErr.Message[:200] + "..."
+		}
+	}
+	return apiErr
+}
+
+// gatewayErrorDoer turns 502, 503 and 504 responses into *zammadAPIError, so
+// that calls through zammad-go, whose ErrorResponse carries no status code,
+// can tell an outage in front of Zammad from Zammad rejecting a request.
+type gatewayErrorDoer struct {
+	next zammad.Doer
+}
+
+func (d gatewayErrorDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		defer resp.Body.Close()
+		return nil, newZammadAPIError(req.Method, req.URL.Path, resp)
+	}
+	return resp, nil
+}
+
+// zammadDownload fetches a Zammad endpoint that returns a file, such as an
+// attachment, and returns its body. A body longer than limit bytes is not
+// read but reported as an error; limit <= 0 means no limit.
+func zammadDownload(path string, limit int64) ([]byte, error) {
+	req, err := zammadClient.NewRequest(http.MethodGet, zammadClient.Url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if zammadClient.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Token token=%s", zammadClient.Token))
+	}
+
+	resp, err := zammadClient.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newZammadAPIError(http.MethodGet, path, resp)
+	}
+	if limit <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("GET %s: the response exceeds %s", path, byteSize(limit))
+	}
+	return data, nil
+}
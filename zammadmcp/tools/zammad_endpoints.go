@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// This file wraps Zammad endpoints that zammad-go does not cover, or does not
+// decode completely: tags, links, history, article updates and attachments,
+// and overviews. The calls go through zammadRequest and zammadDownload, so they
+// share the client's authentication, metrics and wire trace, and tools use
+// these wrappers instead of building the requests themselves.
+
+// --- Tags ---
+
+// addTicketTag adds a tag to a ticket. zammad-go's TagAdd does not send the
+// object/o_id/item payload the endpoint expects, so the call is made directly.
+func addTicketTag(ticketID int, tag string) error {
+	payload := map[string]any{"object": "Ticket", "o_id": ticketID, "item": tag}
+	return zammadRequest(http.MethodPost, "/api/v1/tags/add", payload, nil)
+}
+
+// removeTicketTag removes a tag from a ticket.
+func removeTicketTag(ticketID int, tag string) error {
+	payload := map[string]any{"object": "Ticket", "o_id": ticketID, "item": tag}
+	return zammadRequest(http.MethodDelete, "/api/v1/tags/remove", payload, nil)
+}
+
+// fetchTicketTags returns the tags of a ticket.
+func fetchTicketTags(ticketID int) ([]string, error) {
+	var result struct {
+		Tags []string `json:"tags"`
+	}
+	err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/tags?object=Ticket&o_id=%d", ticketID), nil, &result)
+	return result.Tags, err
+}
+
+// --- Links ---
+
+// fetchTicketLinks lists the links of a ticket.
+func fetchTicketLinks(ticketID int) (ticketLinks, error) {
+	var links ticketLinks
+	err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/links?link_object=Ticket&link_object_value=%d", ticketID), nil, &links)
+	return links, err
+}
+
+// linkTickets links the ticket with the given number (the source) to the
+// ticket with the given ID. The link type is seen from the target: "child"
+// makes the source a child of the target, "normal" links them as related.
+func linkTickets(sourceNumber string, targetID int, linkType string) error {
+	body := map[string]any{
+		"link_type":                 linkType,
+		"link_object_source":        "Ticket",
+		"link_object_source_number": sourceNumber,
+		"link_object_target":        "Ticket",
+		"link_object_target_value":  targetID,
+	}
+	return zammadRequest(http.MethodPost, "/api/v1/links/add", body, nil)
+}
+
+// unlinkTickets removes a link made by linkTickets. Unlike adding, removing
+// identifies the source by its ID.
+func unlinkTickets(sourceID, targetID int, linkType string) error {
+	body := map[string]any{
+		"link_type":                linkType,
+		"link_object_source":       "Ticket",
+		"link_object_source_value": sourceID,
+		"link_object_target":       "Ticket",
+		"link_object_target_value": targetID,
+	}
+	return zammadRequest(http.MethodDelete, "/api/v1/links/remove", body, nil)
+}
+
+// --- History ---
+
+// fetchTicketHistory returns the history of a ticket with the names of the
+// users referenced by it.
+func fetchTicketHistory(ticketID int) (ticketHistory, error) {
+	var result struct {
+		History []historyEntry `json:"history"`
+		Assets  struct {
+			User map[string]struct {
+				ID        int    `json:"id"`
+				Firstname string `json:"firstname"`
+				Lastname  string `json:"lastname"`
+				Login     string `json:"login"`
+			} `json:"User"`
+		} `json:"assets"`
+	}
+	if err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/ticket_history/%d", ticketID), nil, &result); err != nil {
+		return ticketHistory{}, err
+	}
+
+	history := ticketHistory{Entries: result.History, Users: make(map[int]string)}
+	for _, user := range result.Assets.User {
+		name := strings.TrimSpace(user.Firstname + " " + user.Lastname)
+		if name == "" {
+			name = user.Login
+		}
+		history.Users[user.ID] = name
+	}
+	return history, nil
+}
+
+// --- Articles and Attachments ---
+
+// fetchArticle returns an article with its attachments, which zammad-go's
+// TicketArticle does not decode.
+func fetchArticle(articleID int) (articleWithAttachments, error) {
+	var article articleWithAttachments
+	err := zammadRequest(http.MethodGet, fmt.Sprintf("/api/v1/ticket_articles/%d", articleID), nil, &article)
+	return article, err
+}
+
+// setArticleInternal makes an article internal or public and returns the
+// updated article.
+func setArticleInternal(articleID int, internal bool) (articleWithAttachments, error) {
+	var article articleWithAttachments
+	err := zammadRequest(http.MethodPut, fmt.Sprintf("/api/v1/ticket_articles/%d", articleID), map[string]any{"internal": internal}, &article)
+	return article, err
+}
+
+// fetchAttachmentData downloads the content of an attachment of an article.
+// Attachments larger than the attachment policy's max_size are not read.
+func fetchAttachmentData(ticketID, articleID, attachmentID int) ([]byte, error) {
+	return zammadDownload(fmt.Sprintf("/api/v1/ticket_attachment/%d/%d/%d", ticketID, articleID, attachmentID), int64(config.Attachments.MaxSize))
+}
+
+// --- Overviews ---
+
+// ticketOverview is an overview as listed for the API user, such as "My
+// Assigned Tickets", with the number of tickets it currently shows.
+type ticketOverview struct {
+	Name  string `json:"name"`
+	Link  string `json:"link"`
+	Prio  int    `json:"prio"`
+	Count int    `json:"count"`
+}
+
+// fetchTicketOverviews lists the overviews available to the API user.
+func fetchTicketOverviews() ([]ticketOverview, error) {
+	var overviews []ticketOverview
+	err := zammadRequest(http.MethodGet, "/api/v1/ticket_overviews", nil, &overviews)
+	return overviews, err
+}
+
+// fetchOverviewTicketIDs returns the IDs of the tickets an overview shows, in
+// its order, and how many tickets it matches in total; Zammad limits the
+// tickets returned to the overview's page size.
+func fetchOverviewTicketIDs(link string) ([]int, int, error) {
+	var result struct {
+		Index struct {
+			Tickets []struct {
+				ID int `json:"id"`
+			} `json:"tickets"`
+			Count int `json:"tickets_count"`
+		} `json:"index"`
+	}
+	if err := zammadRequest(http.MethodGet, "/api/v1/ticket_overviews?view="+url.QueryEscape(link), nil, &result); err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int, 0, len(result.Index.Tickets))
+	for _, t := range result.Index.Tickets {
+		ids = append(ids, t.ID)
+	}
+	return ids, result.Index.Count, nil
+}